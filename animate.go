@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"hashi/hashisolver"
+)
+
+// animateMove clears the screen and draws the board after the latest move of
+// a solve, with the bridge it changed in yellow and what it was underneath,
+// then holds the frame for delay
+func animateMove(w io.Writer, replay *hashisolver.Replay, charset hashisolver.Charset, delay time.Duration) {
+	fmt.Fprint(w, "\033[H\033[2J"+strings.Join(animationFrame(replay, charset), "\n")+"\n")
+	time.Sleep(delay)
+}
+
+// animationFrame draws the board after the moves in the replay, followed by
+// the latest move and its reason
+func animationFrame(replay *hashisolver.Replay, charset hashisolver.Charset) []string {
+	board, err := replay.Board(len(replay.Moves))
+	if err != nil {
+		return []string{err.Error()}
+	}
+	move := replay.Moves[len(replay.Moves)-1]
+	marked := onBridge(move.From, move.To)
+	if move.Backtrack {
+		marked = func(x, y int) bool { return false }
+	}
+	lines := boardLines(board, charset, marked, func(text string) string { return colored(text, colorYellow) })
+	return append(lines, "", fmt.Sprintf("%d. %s", len(replay.Moves), move), move.Reason)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"hashi/hashisolver"
+)
+
+// TestAnimate tests that a frame is drawn after every move of a solve, with
+// the latest bridge colored unless the move was a backtrack
+func TestAnimate(t *testing.T) {
+	puzzle, err := hashisolver.Parse(strings.NewReader("2..3..2.\n........\n2..4..3.\n........\n.2.4.1..\n1...1.3.\n.1......\n2..3..2.\n"), hashisolver.DefaultRuleSet)
+	if err != nil {
+		t.Fatalf("Failed to parse puzzle: %v", err)
+	}
+
+	frames := [][]string{}
+	_, replay, err := recordSolve(context.Background(), puzzle, nil, func(replay *hashisolver.Replay) {
+		frames = append(frames, animationFrame(replay, hashisolver.CharsetASCII))
+	})
+	if err != nil {
+		t.Fatalf("Failed to solve puzzle: %v", err)
+	}
+	if len(frames) != len(replay.Moves) {
+		t.Fatalf("Expected a frame for each of %d moves, got %d", len(replay.Moves), len(frames))
+	}
+
+	yellow := "\033[33m"
+	for i, frame := range frames {
+		move := replay.Moves[i]
+		board := strings.Join(frame[:puzzle.Rows], "\n")
+		if colored := strings.Contains(board, yellow); colored == move.Backtrack {
+			t.Errorf("Frame %d for %v has the latest bridge colored: %v", i+1, move, colored)
+		}
+		if want := move.String(); !strings.HasSuffix(frame[puzzle.Rows+1], want) {
+			t.Errorf("Expected frame %d to describe the move as %q, got %q", i+1, want, frame[puzzle.Rows+1])
+		}
+	}
+}
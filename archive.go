@@ -0,0 +1,153 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// member is one puzzle file's contents, read from disk or out of an archive
+// and decompressed
+type member struct {
+	// name is the file name, or archive:member for a member of an archive
+	name string
+	// base is the member's own name without directories or compression suffix,
+	// such as "s56.txt"
+	base string
+	data []byte
+}
+
+// isArchive reports whether name is an archive of puzzle files
+func isArchive(name string) bool {
+	for _, ext := range []string{".zip", ".tar", ".tar.gz", ".tgz"} {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPuzzleFile reports whether name is a puzzle file, maybe gzipped, going by
+// its extension
+func isPuzzleFile(name string) bool {
+	name = strings.TrimSuffix(name, ".gz")
+	return strings.HasSuffix(name, ".txt") || strings.HasSuffix(name, ".json")
+}
+
+// readMembers returns the puzzle files in the named file: the file itself,
+// decompressed if it ends in .gz, or each .txt and .json member of a .zip,
+// .tar, .tar.gz or .tgz archive in name order
+func readMembers(name string) ([]member, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("opening file: %v", err)
+	}
+	if strings.HasSuffix(name, ".tgz") || strings.HasSuffix(name, ".gz") {
+		if data, err = gunzip(data); err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+	}
+
+	var members []member
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		members, err = zipMembers(name, data)
+	case isArchive(name):
+		members, err = tarMembers(name, data)
+	default:
+		return []member{{name: name, base: strings.TrimSuffix(filepath.Base(name), ".gz"), data: data}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+
+	sort.Slice(members, func(i, j int) bool { return members[i].name < members[j].name })
+	return members, nil
+}
+
+// gunzip decompresses gzipped data
+func gunzip(data []byte) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// zipMembers reads the puzzle files in a zip archive
+func zipMembers(name string, data []byte) ([]member, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	members := []member{}
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() || !isPuzzleFile(file.Name) {
+			continue
+		}
+		reader, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		contents, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			return nil, err
+		}
+		if members, err = addMember(members, name, file.Name, contents); err != nil {
+			return nil, err
+		}
+	}
+	return members, nil
+}
+
+// tarMembers reads the puzzle files in a tar archive
+func tarMembers(name string, data []byte) ([]member, error) {
+	archive := tar.NewReader(bytes.NewReader(data))
+
+	members := []member{}
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return members, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg || !isPuzzleFile(header.Name) {
+			continue
+		}
+		contents, err := io.ReadAll(archive)
+		if err != nil {
+			return nil, err
+		}
+		if members, err = addMember(members, name, header.Name, contents); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// addMember adds an archive member, decompressing it if it is gzipped
+func addMember(members []member, archive, name string, contents []byte) ([]member, error) {
+	if strings.HasSuffix(name, ".gz") {
+		var err error
+		if contents, err = gunzip(contents); err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+	}
+	return append(members, member{
+		name: archive + ":" + name,
+		base: strings.TrimSuffix(path.Base(name), ".gz"),
+		data: contents,
+	}), nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestReadMembers tests reading puzzles from gzipped files and archives
+func TestReadMembers(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{"a.txt": "2.2\n", "b.json": `{"grid": ["1-1"]}`, "notes.md": "skip me\n"}
+
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	w.Write([]byte(files["a.txt"]))
+	w.Close()
+
+	var zipped bytes.Buffer
+	zw := zip.NewWriter(&zipped)
+	for _, name := range []string{"b.json", "notes.md", "a.txt"} {
+		f, _ := zw.Create("pack/" + name)
+		f.Write([]byte(files[name]))
+	}
+	zw.Close()
+
+	var tarred bytes.Buffer
+	tw := tar.NewWriter(&tarred)
+	for _, name := range []string{"a.txt", "notes.md"} {
+		tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(files[name])), Typeflag: tar.TypeReg})
+		tw.Write([]byte(files[name]))
+	}
+	tw.Close()
+
+	for name, data := range map[string][]byte{"a.txt.gz": gz.Bytes(), "pack.zip": zipped.Bytes(), "pack.tar": tarred.Bytes()} {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for name, want := range map[string][]string{
+		"a.txt.gz": {"a.txt"},
+		"pack.zip": {"a.txt", "b.json"},
+		"pack.tar": {"a.txt"},
+	} {
+		members, err := readMembers(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", name, err)
+		}
+		if len(members) != len(want) {
+			t.Fatalf("Expected %d members in %s, got %d", len(want), name, len(members))
+		}
+		for i, m := range members {
+			if m.base != want[i] || string(m.data) != files[want[i]] {
+				t.Errorf("Unexpected member %d of %s: %s %q", i, name, m.base, m.data)
+			}
+		}
+	}
+
+	// A zip of puzzles reads as a pack, with JSON members read as JSON
+	input := &inputFlags{format: "text", maxBridges: 2, strict: true}
+	puzzles, err := input.loadPuzzles(filepath.Join(dir, "pack.zip"))
+	if err != nil || len(puzzles) != 2 || puzzles[1].At(0, 0).RightBridges != 1 {
+		t.Errorf("Expected both puzzles from the zip, got %d (%v)", len(puzzles), err)
+	}
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"hashi/hashisolver"
+)
+
+// solutionMarker is part of the name of every solution file batch mode writes,
+// so they are skipped when the directory is solved again
+const solutionMarker = ".solution"
+
+// batchItem is one puzzle file to solve in batch mode, or the error from
+// reading it
+type batchItem struct {
+	name   string
+	member member
+	err    error
+	// puzzle is the puzzle read from the file, and solved its solution
+	puzzle *hashisolver.Puzzle
+	solved *hashisolver.Puzzle
+	// original is the index of the first item holding the same puzzle, turned
+	// or flipped perhaps, or -1 if there is none before this one
+	original int
+	// solution is the name of the file its solution is written to
+	solution string
+}
+
+// solveDir solves every .txt and .json puzzle in dir, up to jobs at once,
+// along with gzipped ones and those inside .zip and .tar archives. Each
+// solution is written in the output format next to its puzzle, or into
+// outDir if it is set. A puzzle that is the same as one before it, even
+// turned or flipped, isn't solved again, but gets that one's solution moved
+// to fit. It prints a table of the results in name order and returns the
+// number that failed. Once ctx is cancelled the remaining puzzles
+// fail without being solved.
+func solveDir(ctx context.Context, w io.Writer, dir, outDir string, input *inputFlags, output *outputFlags, opts []hashisolver.Option, jobs int) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, fmt.Errorf("reading directory: %v", err)
+	}
+	if outDir == "" {
+		outDir = dir
+	} else if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return 0, fmt.Errorf("creating output directory: %v", err)
+	}
+
+	names := []string{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || strings.Contains(name, solutionMarker+".") {
+			continue
+		}
+		if isPuzzleFile(name) || isArchive(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	items := []batchItem{}
+	for _, name := range names {
+		members, err := readMembers(filepath.Join(dir, name))
+		if err != nil {
+			items = append(items, batchItem{name: name, err: err})
+			continue
+		}
+		for _, m := range members {
+			item := batchItem{name: name, member: m, solution: solutionName(m.base, output.format)}
+			if isArchive(name) {
+				inside := strings.TrimPrefix(m.name, filepath.Join(dir, name)+":")
+				item.name = name + ":" + inside
+				item.solution = solutionName(memberBase(name, inside), output.format)
+			}
+			items = append(items, item)
+		}
+	}
+
+	// Members of different archives, or puzzles like a.txt and a.json, could
+	// still be named alike, and the later one fails rather than overwrite the
+	// earlier one's solution
+	written := map[string]string{}
+	for i := range items {
+		if items[i].err != nil {
+			continue
+		}
+		if other, ok := written[items[i].solution]; ok {
+			items[i].err = fmt.Errorf("its solution would overwrite %s, the solution of %s", items[i].solution, other)
+			continue
+		}
+		written[items[i].solution] = items[i].name
+	}
+
+	// Puzzles that can't be read fail when they are reached, like files that
+	// can't be
+	first := map[string]int{}
+	for i := range items {
+		items[i].original = -1
+		if items[i].err != nil {
+			continue
+		}
+		items[i].puzzle, items[i].err = input.forMember(items[i].member).parsePuzzle(bytes.NewReader(items[i].member.data))
+		if items[i].err != nil {
+			continue
+		}
+		hash := items[i].puzzle.Hash()
+		if j, ok := first[hash]; ok {
+			items[i].original = j
+		} else {
+			first[hash] = i
+		}
+	}
+
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "puzzle\tresult\ttime\terror")
+	solved, failed, duplicates := 0, 0, 0
+	start := time.Now()
+	elapsed := make([]time.Duration, len(items))
+	forEachOrdered(len(items), jobs, func(i int) {
+		if items[i].err == nil && items[i].original < 0 {
+			items[i].solved, elapsed[i], items[i].err = solveMember(ctx, items[i], outDir, output, opts)
+		}
+	}, func(i int) {
+		// The reports come in order, so the original's solve is done by
+		// the time a duplicate of it is reached
+		if j := items[i].original; j >= 0 && items[i].err == nil {
+			original := items[j]
+			if original.err != nil {
+				items[i].err = fmt.Errorf("same puzzle as %s, which failed", original.name)
+			} else {
+				items[i].solved, items[i].err = writeDuplicate(items[i], original.solved, outDir, output)
+			}
+			if items[i].err == nil {
+				fmt.Fprintf(table, "%s\tduplicate of %s\t-\t-\n", items[i].name, original.name)
+				duplicates++
+				return
+			}
+		}
+		if items[i].err != nil {
+			fmt.Fprintf(table, "%s\tfailed\t%v\t%v\n", items[i].name, elapsed[i].Round(time.Microsecond), items[i].err)
+			failed++
+			return
+		}
+		fmt.Fprintf(table, "%s\tsolved\t%v\t-\n", items[i].name, elapsed[i].Round(time.Microsecond))
+		solved++
+	})
+	table.Flush()
+
+	if duplicates > 0 {
+		fmt.Fprintf(w, "%d solved, %d duplicates not solved again, %d failed in %v\n", solved, duplicates, failed, time.Since(start).Round(time.Millisecond))
+	} else {
+		fmt.Fprintf(w, "%d solved, %d failed in %v\n", solved, failed, time.Since(start).Round(time.Millisecond))
+	}
+	return failed, nil
+}
+
+// solveMember solves the puzzle of a batch item and writes its solution into
+// outDir, returning the solved board and how long the solve took
+func solveMember(ctx context.Context, item batchItem, outDir string, output *outputFlags, opts []hashisolver.Option) (*hashisolver.Puzzle, time.Duration, error) {
+	start := time.Now()
+	solved, err := hashisolver.SolveWith(ctx, item.puzzle, opts...)
+	elapsed := time.Since(start)
+	if err != nil {
+		return nil, elapsed, err
+	}
+	return solved, elapsed, writeMemberSolution(filepath.Join(outDir, item.solution), solved, output)
+}
+
+// writeDuplicate writes the solution of a batch item holding the same puzzle
+// as an earlier one, by turning or flipping the earlier one's solved board to
+// match it, and returns the board written
+func writeDuplicate(item batchItem, solved *hashisolver.Puzzle, outDir string, output *outputFlags) (*hashisolver.Puzzle, error) {
+	for _, t := range hashisolver.Transforms {
+		moved := solved.Transform(t)
+		if sameClues(moved, item.puzzle) {
+			moved.Metadata = item.puzzle.Metadata
+			return moved, writeMemberSolution(filepath.Join(outDir, item.solution), moved, output)
+		}
+	}
+	return nil, fmt.Errorf("no rotation or reflection of the earlier puzzle matches")
+}
+
+// sameClues reports whether two boards are the same size with the same clues
+func sameClues(a, b *hashisolver.Puzzle) bool {
+	diffs, err := hashisolver.DiffPuzzles(a, b)
+	return err == nil && len(diffs) == 0
+}
+
+// writeMemberSolution writes the solved board of a puzzle file to the named
+// file in the output format
+func writeMemberSolution(name string, solved *hashisolver.Puzzle, output *outputFlags) error {
+	renderer, err := hashisolver.LookupRenderer(output.format)
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := renderer(file, solved); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// solutionName returns the name of the solution file for a puzzle file,
+// like "s56.solution.txt", with the extension the output format's renderer
+// is registered with
+func solutionName(name, format string) string {
+	return strings.TrimSuffix(name, filepath.Ext(name)) + solutionMarker + hashisolver.RendererExtension(format)
+}
+
+// memberBase returns the name a member of an archive has its solution named
+// after: the archive's name without its extension and the member's path,
+// with dots between its directories, like "pack.easy.s56.txt" for
+// easy/s56.txt in pack.tar, so that members with the same name in different
+// directories or archives are kept apart
+func memberBase(archive, name string) string {
+	for _, ext := range []string{".tar.gz", ".tgz", ".tar", ".zip"} {
+		if strings.HasSuffix(archive, ext) {
+			archive = strings.TrimSuffix(archive, ext)
+			break
+		}
+	}
+	name = strings.TrimPrefix(path.Clean("/"+strings.TrimSuffix(name, ".gz")), "/")
+	return archive + "." + strings.ReplaceAll(name, "/", ".")
+}
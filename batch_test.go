@@ -0,0 +1,188 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"hashi/hashisolver"
+)
+
+// TestSolveDir tests solving a directory of text and JSON puzzles and the summary it prints
+func TestSolveDir(t *testing.T) {
+	dir := t.TempDir()
+	for name, puzzle := range map[string]string{
+		"line.txt":  "1.2.1\n",
+		"pair.json": `{"grid": ["2 2", "   "]}`,
+		"lone.txt":  "1..\n...\n..1\n",
+		"notes.md":  "not a puzzle\n",
+		"old.out":   "1-2-1\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(puzzle), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	input := &inputFlags{format: "text", maxBridges: 2, strict: true}
+	output := &outputFlags{format: "text"}
+
+	var buf bytes.Buffer
+	failed, err := solveDir(context.Background(), &buf, dir, "", input, output, nil, 1)
+	if err != nil || failed != 1 {
+		t.Fatalf("Expected one failure, got %d (%v)", failed, err)
+	}
+	if summary := buf.String(); !strings.Contains(summary, "2 solved, 1 failed") || !strings.Contains(summary, "lone.txt") {
+		t.Errorf("Unexpected summary:\n%s", summary)
+	}
+	if header := strings.Fields(strings.SplitN(buf.String(), "\n", 2)[0]); strings.Join(header, " ") != "puzzle result time error" {
+		t.Errorf("Expected a column for the error of a failed puzzle, got %q", header)
+	}
+	for name, want := range map[string]string{
+		"line.solution.txt": "1-2-1\n",
+		"pair.solution.txt": "2=2\n   \n",
+	} {
+		if data, err := os.ReadFile(filepath.Join(dir, name)); err != nil || string(data) != want {
+			t.Errorf("Expected %s to hold %q, got %q (%v)", name, want, data, err)
+		}
+	}
+
+	// Solutions already written are not solved again, and can go elsewhere
+	out := filepath.Join(dir, "out")
+	output.format = "json"
+	if failed, err := solveDir(context.Background(), &buf, dir, out, input, output, nil, 2); err != nil || failed != 1 {
+		t.Fatalf("Expected one failure again, got %d (%v)", failed, err)
+	}
+	entries, err := os.ReadDir(out)
+	if err != nil || len(entries) != 2 || entries[0].Name() != "line.solution.json" {
+		t.Errorf("Expected two JSON solutions in the output directory, got %v (%v)", entries, err)
+	}
+	// Once interrupted, nothing more is solved
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	buf.Reset()
+	if failed, err := solveDir(ctx, &buf, dir, filepath.Join(dir, "cancelled"), input, output, nil, 1); err != nil || failed != 3 {
+		t.Errorf("Expected every puzzle to fail once cancelled, got %d (%v)", failed, err)
+	}
+}
+
+// TestSolveDirDuplicates tests that a puzzle repeated in a directory, turned
+// or not, is solved once and its solution moved to fit each copy
+func TestSolveDirDuplicates(t *testing.T) {
+	dir := t.TempDir()
+	for name, puzzle := range map[string]string{
+		"a.txt": "3.4.1\n.....\n1.2.1\n",
+		"b.txt": "1.2.1\n.....\n3.4.1\n",
+		"c.txt": "3.4.1\n.....\n1.2.1\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(puzzle), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	input := &inputFlags{format: "text", maxBridges: 2, strict: true}
+	output := &outputFlags{format: "text"}
+
+	var buf bytes.Buffer
+	if failed, err := solveDir(context.Background(), &buf, dir, "", input, output, nil, 3); err != nil || failed != 0 {
+		t.Fatalf("Expected no failures, got %d (%v)", failed, err)
+	}
+	if summary := buf.String(); !strings.Contains(summary, "b.txt   duplicate of a.txt") || !strings.Contains(summary, "1 solved, 2 duplicates not solved again, 0 failed") {
+		t.Errorf("Unexpected summary:\n%s", summary)
+	}
+	// Every row fills in all four columns, with - for a duplicate's time
+	// and for no error
+	for _, line := range strings.Split(buf.String(), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 1 && fields[1] == "duplicate" && strings.Join(fields[len(fields)-2:], " ") != "- -" {
+			t.Errorf("Expected no time or error in %q", line)
+		}
+		if len(fields) > 1 && fields[1] == "solved" && (len(fields) != 4 || fields[3] != "-") {
+			t.Errorf("Expected no error in %q", line)
+		}
+	}
+	for name, want := range map[string]string{
+		"a.solution.txt": "3=4-1\n| |  \n1 2-1\n",
+		"b.solution.txt": "1 2-1\n| |  \n3=4-1\n",
+		"c.solution.txt": "3=4-1\n| |  \n1 2-1\n",
+	} {
+		if data, err := os.ReadFile(filepath.Join(dir, name)); err != nil || string(data) != want {
+			t.Errorf("Expected %s to hold %q, got %q (%v)", name, want, data, err)
+		}
+	}
+}
+
+// TestSolveDirSolutionNames tests that archive members with the same name
+// get solutions of their own, and that a puzzle whose solution would
+// overwrite another's fails instead
+func TestSolveDirSolutionNames(t *testing.T) {
+	dir := t.TempDir()
+	var tarred bytes.Buffer
+	tw := tar.NewWriter(&tarred)
+	for _, m := range []struct{ name, puzzle string }{{"easy/a.txt", "2.2\n"}, {"hard/a.txt", "1.2.1\n"}} {
+		tw.WriteHeader(&tar.Header{Name: m.name, Mode: 0o644, Size: int64(len(m.puzzle)), Typeflag: tar.TypeReg})
+		tw.Write([]byte(m.puzzle))
+	}
+	tw.Close()
+	for name, data := range map[string]string{
+		"pack.tar": tarred.String(),
+		"a.json":   `{"grid": ["1-1"]}`,
+		"a.txt":    "1.1\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	input := &inputFlags{format: "text", maxBridges: 2, strict: true}
+	output := &outputFlags{format: "text"}
+
+	var buf bytes.Buffer
+	if failed, err := solveDir(context.Background(), &buf, dir, "", input, output, nil, 2); err != nil || failed != 1 {
+		t.Fatalf("Expected one failure, got %d (%v):\n%s", failed, err, buf.String())
+	}
+	if summary := buf.String(); !strings.Contains(summary, "would overwrite a.solution.txt, the solution of a.json") || !strings.Contains(summary, "3 solved, 1 failed") {
+		t.Errorf("Unexpected summary:\n%s", summary)
+	}
+	for name, want := range map[string]string{
+		"a.solution.txt":           "1-1\n",
+		"pack.easy.a.solution.txt": "2=2\n",
+		"pack.hard.a.solution.txt": "1-2-1\n",
+	} {
+		if data, err := os.ReadFile(filepath.Join(dir, name)); err != nil || string(data) != want {
+			t.Errorf("Expected %s to hold %q, got %q (%v)", name, want, data, err)
+		}
+	}
+}
+
+// TestForEachOrdered tests that results are reported in order however the work finishes
+func TestForEachOrdered(t *testing.T) {
+	samples := hashisolver.Samples()
+	solved := make([]*hashisolver.Puzzle, len(samples))
+	reported := []int{}
+	forEachOrdered(len(samples), 4, func(i int) {
+		puzzle, err := samples[i].Puzzle()
+		if err != nil {
+			t.Errorf("Failed to parse sample %s: %v", samples[i].Name, err)
+			return
+		}
+		solved[i], err = hashisolver.SolveWith(context.Background(), puzzle)
+		if err != nil {
+			t.Errorf("Failed to solve sample %s: %v", samples[i].Name, err)
+		}
+	}, func(i int) {
+		reported = append(reported, i)
+		if solved[i] == nil || !solved[i].IsComplete() {
+			t.Errorf("Sample %s was reported before it was solved", samples[i].Name)
+		}
+	})
+
+	for i, got := range reported {
+		if got != i {
+			t.Fatalf("Expected results in order, got %v", reported)
+		}
+	}
+	if len(reported) != len(samples) {
+		t.Errorf("Expected %d results, got %d", len(samples), len(reported))
+	}
+}
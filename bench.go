@@ -0,0 +1,306 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"hashi/hashisolver"
+)
+
+// benchPuzzle is one puzzle of a benchmark corpus
+type benchPuzzle struct {
+	name   string
+	puzzle *hashisolver.Puzzle
+}
+
+// benchConfig is one way of solving the corpus: an engine, and for the
+// heuristic engine a strategy
+type benchConfig struct {
+	engine   string
+	strategy hashisolver.Strategy
+}
+
+// name labels the configuration in reports
+func (c benchConfig) name() string {
+	if c.strategy == nil {
+		return c.engine
+	}
+	return c.engine + "/" + c.strategy.Name()
+}
+
+// benchResult is how one solve of one puzzle went
+type benchResult struct {
+	Puzzle   string `json:"puzzle"`
+	Engine   string `json:"engine"`
+	Strategy string `json:"strategy,omitempty"`
+	// Result is solved, timeout, unsolvable or the error the solve failed with
+	Result string `json:"result"`
+	// TimeNS is the median time of the runs
+	TimeNS     int64  `json:"time_ns"`
+	Nodes      int    `json:"nodes"`
+	Branches   int    `json:"branches"`
+	AllocBytes uint64 `json:"alloc_bytes"`
+}
+
+// benchSummary totals the results of one configuration
+type benchSummary struct {
+	Config     string `json:"config"`
+	Solved     int    `json:"solved"`
+	Failed     int    `json:"failed"`
+	TimeNS     int64  `json:"time_ns"`
+	Nodes      int    `json:"nodes"`
+	AllocBytes uint64 `json:"alloc_bytes"`
+}
+
+// runBench solves a corpus with each engine and strategy asked for and
+// reports how long each solve took and how much work and memory it needed
+func runBench(args []string) {
+	var input inputFlags
+	var engines, strategies string
+	var runs int
+	var timeout time.Duration
+	var jsonOutput bool
+
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s bench [flags] [puzzle files, directories or archives...]\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Without any puzzles the built in samples are used.")
+		fs.PrintDefaults()
+	}
+	input.register(fs)
+	fs.StringVar(&engines, "engines", hashisolver.EngineHeuristic, "Comma-separated engines to compare: "+strings.Join(hashisolver.Engines(), ", "))
+	fs.StringVar(&strategies, "strategies", "highest-remaining", "Comma-separated strategies to compare on the heuristic engine: "+strings.Join(hashisolver.Strategies(), ", "))
+	fs.IntVar(&runs, "runs", 1, "Solve each puzzle this many times and report the median time")
+	fs.DurationVar(&timeout, "timeout", 10*time.Second, "Give up on a puzzle after this long (0 for no limit)")
+	fs.BoolVar(&jsonOutput, "json", false, "Print the results as JSON instead of a table")
+	parseFlags(fs, args)
+
+	configs, err := benchConfigs(engines, strategies)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	corpus, err := benchCorpus(&input, fs.Args())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error %v\n", err)
+		os.Exit(1)
+	}
+	if runs < 1 {
+		runs = 1
+	}
+
+	results := bench(corpus, configs, runs, timeout)
+	if jsonOutput {
+		err = writeBenchJSON(os.Stdout, results, configs)
+	} else {
+		err = writeBenchTable(os.Stdout, results, configs)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// benchConfigs returns a configuration for each engine, and for the
+// heuristic engine one for each strategy
+func benchConfigs(engines, strategies string) ([]benchConfig, error) {
+	configs := []benchConfig{}
+	for _, engine := range strings.Split(engines, ",") {
+		engine = strings.TrimSpace(engine)
+		if _, err := hashisolver.LookupEngine(engine); err != nil {
+			return nil, err
+		}
+		if engine != hashisolver.EngineHeuristic {
+			configs = append(configs, benchConfig{engine: engine})
+			continue
+		}
+		for _, name := range strings.Split(strategies, ",") {
+			strategy, err := hashisolver.LookupStrategy(strings.TrimSpace(name))
+			if err != nil {
+				return nil, err
+			}
+			configs = append(configs, benchConfig{engine: engine, strategy: strategy})
+		}
+	}
+	return configs, nil
+}
+
+// benchCorpus reads the puzzles in each named file, directory or archive, or
+// the one -input or -sample names, or else the built in samples
+func benchCorpus(input *inputFlags, names []string) ([]benchPuzzle, error) {
+	if input.inputFile != "" || input.sample != "" {
+		name := input.inputFile
+		if name == "" {
+			name = input.sample
+		}
+		return []benchPuzzle{{name: name, puzzle: input.readPuzzle()}}, nil
+	}
+	if len(names) == 0 {
+		corpus := []benchPuzzle{}
+		for _, sample := range hashisolver.Samples() {
+			puzzle, err := sample.Puzzle()
+			if err != nil {
+				return nil, fmt.Errorf("reading sample %s: %v", sample.Name, err)
+			}
+			corpus = append(corpus, benchPuzzle{name: sample.Name, puzzle: puzzle})
+		}
+		return corpus, nil
+	}
+
+	files := []string{}
+	for _, name := range names {
+		info, err := os.Stat(name)
+		if err != nil {
+			return nil, fmt.Errorf("opening file: %v", err)
+		}
+		if !info.IsDir() {
+			files = append(files, name)
+			continue
+		}
+		entries, err := os.ReadDir(name)
+		if err != nil {
+			return nil, fmt.Errorf("reading directory: %v", err)
+		}
+		for _, entry := range entries {
+			base := entry.Name()
+			if entry.IsDir() || strings.HasPrefix(base, ".") || strings.Contains(base, solutionMarker+".") {
+				continue
+			}
+			if isPuzzleFile(base) || isArchive(base) {
+				files = append(files, filepath.Join(name, base))
+			}
+		}
+	}
+
+	corpus := []benchPuzzle{}
+	for _, file := range files {
+		members, err := readMembers(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range members {
+			puzzles, err := input.memberPuzzles(m)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", m.name, err)
+			}
+			for i, puzzle := range puzzles {
+				name := m.name
+				if len(puzzles) > 1 {
+					name = fmt.Sprintf("%s#%d", m.name, i+1)
+				}
+				corpus = append(corpus, benchPuzzle{name: name, puzzle: puzzle})
+			}
+		}
+	}
+	return corpus, nil
+}
+
+// bench solves every puzzle under every configuration, runs times each
+func bench(corpus []benchPuzzle, configs []benchConfig, runs int, timeout time.Duration) []benchResult {
+	results := []benchResult{}
+	for _, p := range corpus {
+		for _, config := range configs {
+			opts := []hashisolver.Option{hashisolver.WithEngine(config.engine), hashisolver.WithTimeout(timeout)}
+			result := benchResult{Puzzle: p.name, Engine: config.engine}
+			if config.strategy != nil {
+				opts = append(opts, hashisolver.WithStrategy(config.strategy))
+				result.Strategy = config.strategy.Name()
+			}
+
+			times := make([]time.Duration, runs)
+			for i := range times {
+				var before, after runtime.MemStats
+				runtime.ReadMemStats(&before)
+				solved, err := hashisolver.SolveWithStats(context.Background(), p.puzzle.Clone(), opts...)
+				runtime.ReadMemStats(&after)
+
+				times[i] = solved.Stats.Elapsed
+				result.Nodes, result.Branches = solved.Stats.NodesExplored, solved.Stats.Branches
+				result.AllocBytes = after.TotalAlloc - before.TotalAlloc
+				switch {
+				case err == nil:
+					result.Result = "solved"
+				case errors.Is(err, hashisolver.ErrTimeout):
+					result.Result = "timeout"
+				case errors.Is(err, hashisolver.ErrNoSolution):
+					result.Result = "unsolvable"
+				default:
+					result.Result = err.Error()
+				}
+			}
+			sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+			result.TimeNS = int64(times[len(times)/2])
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// summarize totals the results of each configuration, in the order given
+func summarize(results []benchResult, configs []benchConfig) []benchSummary {
+	summaries := make([]benchSummary, len(configs))
+	for i, config := range configs {
+		summaries[i].Config = config.name()
+		for _, result := range results {
+			if result.Engine != config.engine || config.strategy != nil && result.Strategy != config.strategy.Name() {
+				continue
+			}
+			if result.Result == "solved" {
+				summaries[i].Solved++
+			} else {
+				summaries[i].Failed++
+			}
+			summaries[i].TimeNS += result.TimeNS
+			summaries[i].Nodes += result.Nodes
+			summaries[i].AllocBytes += result.AllocBytes
+		}
+	}
+	return summaries
+}
+
+// writeBenchTable prints a row per solve and then the totals per configuration
+func writeBenchTable(w io.Writer, results []benchResult, configs []benchConfig) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "puzzle\tconfig\tresult\ttime\tnodes\tbranches\talloc")
+	for _, result := range results {
+		config := result.Engine
+		if result.Strategy != "" {
+			config += "/" + result.Strategy
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%v\t%d\t%d\t%s\n", result.Puzzle, config, result.Result,
+			time.Duration(result.TimeNS).Round(time.Microsecond), result.Nodes, result.Branches, formatBytes(result.AllocBytes))
+	}
+	if err := table.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w)
+	table = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "config\tsolved\tfailed\ttime\tnodes\talloc")
+	for _, s := range summarize(results, configs) {
+		fmt.Fprintf(table, "%s\t%d\t%d\t%v\t%d\t%s\n", s.Config, s.Solved, s.Failed,
+			time.Duration(s.TimeNS).Round(time.Microsecond), s.Nodes, formatBytes(s.AllocBytes))
+	}
+	return table.Flush()
+}
+
+// writeBenchJSON prints the results and the totals per configuration as JSON
+func writeBenchJSON(w io.Writer, results []benchResult, configs []benchConfig) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(struct {
+		Results []benchResult  `json:"results"`
+		Summary []benchSummary `json:"summary"`
+	}{results, summarize(results, configs)})
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestBench tests that bench solves the samples under each configuration and
+// totals the results for each
+func TestBench(t *testing.T) {
+	configs, err := benchConfigs("heuristic, sat", "highest-remaining,most-constrained")
+	if err != nil {
+		t.Fatalf("Failed to read configurations: %v", err)
+	}
+	if len(configs) != 3 {
+		t.Fatalf("Expected two strategies and the SAT engine, got %d configurations", len(configs))
+	}
+	corpus, err := benchCorpus(&inputFlags{format: "text", maxBridges: 2}, nil)
+	if err != nil {
+		t.Fatalf("Failed to read the samples: %v", err)
+	}
+
+	results := bench(corpus, configs, 1, time.Minute)
+	if len(results) != len(corpus)*len(configs) {
+		t.Fatalf("Expected %d results, got %d", len(corpus)*len(configs), len(results))
+	}
+	for _, result := range results {
+		if result.Result != "solved" || result.TimeNS <= 0 || result.AllocBytes == 0 {
+			t.Errorf("Expected %s to be solved with %s, got %+v", result.Puzzle, result.Engine, result)
+		}
+	}
+
+	var table bytes.Buffer
+	if err := writeBenchTable(&table, results, configs); err != nil {
+		t.Fatalf("Failed to write table: %v", err)
+	}
+	for _, config := range []string{"heuristic/highest-remaining", "heuristic/most-constrained", "sat"} {
+		if !strings.Contains(table.String(), config) {
+			t.Errorf("Expected the table to name %s, got\n%s", config, table.String())
+		}
+	}
+
+	var out bytes.Buffer
+	if err := writeBenchJSON(&out, results, configs); err != nil {
+		t.Fatalf("Failed to write JSON: %v", err)
+	}
+	var report struct {
+		Results []benchResult  `json:"results"`
+		Summary []benchSummary `json:"summary"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("Failed to read the JSON back: %v", err)
+	}
+	for _, summary := range report.Summary {
+		if summary.Solved != len(corpus) || summary.Failed != 0 {
+			t.Errorf("Expected every sample solved by %s, got %+v", summary.Config, summary)
+		}
+	}
+
+	if _, err := benchConfigs("heuristic,cp", "highest-remaining"); err == nil {
+		t.Error("Expected an error for an unknown engine")
+	}
+}
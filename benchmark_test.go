@@ -1,13 +1,37 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"math/rand"
 	"strings"
 	"testing"
 
 	"hashi/hashisolver"
 )
 
+// generatePuzzle generates a puzzle in process for the benchmarks. The seed
+// is fixed so every run times the same puzzles, and like bridgen's output
+// they may have more than one solution.
+func generatePuzzle(rows, cols int) (string, error) {
+	generated, err := hashisolver.Generate(hashisolver.GenerateOptions{
+		Rows:                   rows,
+		Cols:                   cols,
+		AllowMultipleSolutions: true,
+		Source:                 rand.NewSource(int64(rows*100 + cols)),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := hashisolver.WritePuzzle(&buf, generated.Puzzle); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
 // BenchmarkSolver benchmarks the solver with different board sizes
 func BenchmarkSolver(b *testing.B) {
 	// Test with different board sizes
@@ -24,8 +48,8 @@ func BenchmarkSolver(b *testing.B) {
 
 	for _, size := range sizes {
 		b.Run(fmt.Sprintf("%dx%d", size.rows, size.cols), func(b *testing.B) {
-			// Generate a puzzle using bridgen before starting the benchmark
-			puzzle, err := runBridgenCommand(size.rows, size.cols)
+			// Generate a puzzle before starting the benchmark
+			puzzle, err := generatePuzzle(size.rows, size.cols)
 			if err != nil {
 				b.Fatalf("Failed to generate puzzle: %v", err)
 			}
@@ -50,7 +74,7 @@ func BenchmarkSolver(b *testing.B) {
 // benchmarkHeuristicsVsNoHeuristics compares solving with and without heuristics
 func BenchmarkHeuristicsVsNoHeuristics(b *testing.B) {
 	// Use a medium-sized puzzle for the comparison
-	puzzle, err := runBridgenCommand(8, 8)
+	puzzle, err := generatePuzzle(8, 8)
 	if err != nil {
 		b.Fatalf("Failed to generate puzzle: %v", err)
 	}
@@ -93,7 +117,7 @@ func BenchmarkMemoryUsage(b *testing.B) {
 
 	for _, size := range sizes {
 		b.Run(fmt.Sprintf("%dx%d", size.rows, size.cols), func(b *testing.B) {
-			puzzle, err := runBridgenCommand(size.rows, size.cols)
+			puzzle, err := generatePuzzle(size.rows, size.cols)
 			if err != nil {
 				b.Fatalf("Failed to generate puzzle: %v", err)
 			}
@@ -111,3 +135,33 @@ func BenchmarkMemoryUsage(b *testing.B) {
 		})
 	}
 }
+
+// BenchmarkSpeculation times boards big enough to need guessing, where each
+// guess is made in place and undone from the trail of changes
+func BenchmarkSpeculation(b *testing.B) {
+	for _, size := range []int{15, 20, 25} {
+		b.Run(fmt.Sprintf("%dx%d", size, size), func(b *testing.B) {
+			puzzle, err := generatePuzzle(size, size)
+			if err != nil {
+				b.Fatalf("Failed to generate puzzle: %v", err)
+			}
+
+			b.ResetTimer()
+			b.ReportAllocs()
+
+			branches := 0
+			for i := 0; i < b.N; i++ {
+				p, err := hashisolver.Parse(strings.NewReader(puzzle), hashisolver.DefaultRuleSet)
+				if err != nil {
+					b.Fatalf("Failed to parse puzzle: %v", err)
+				}
+				result, err := hashisolver.SolveWithStats(context.Background(), p)
+				if err != nil {
+					b.Fatalf("Failed to solve puzzle: %v", err)
+				}
+				branches = result.Stats.Branches
+			}
+			b.ReportMetric(float64(branches), "branches")
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"hashi/hashisolver"
+)
+
+// runBook generates a set of puzzles and lays them out as a book with an
+// answer key at the back
+func runBook(args []string) {
+	var options bookOptions
+	var sizes, difficulty, paper, outputFile string
+	var maxBridges, answersPerPage int
+	var latex bool
+
+	fs := flag.NewFlagSet("book", flag.ExitOnError)
+	fs.IntVar(&options.count, "count", 20, "Number of puzzles in the book")
+	fs.StringVar(&sizes, "sizes", "7,10", "Comma-separated board sizes to take turns with, each N for NxN or COLSxROWS")
+	fs.StringVar(&difficulty, "difficulty", "mixed", "Difficulty band of the puzzles: easy, medium, hard, any, or mixed to take turns with the three bands as far as the generator finds them")
+	fs.IntVar(&maxBridges, "max-bridges", 2, "Maximum bridges between a pair of islands")
+	fs.Int64Var(&options.seed, "seed", 0, "Seed for the generated puzzles, so a book can be made again (0 picks one from the clock)")
+	fs.IntVar(&answersPerPage, "answers-per-page", 4, "Number of solutions on each page of the answer key")
+	fs.StringVar(&paper, "paper", "a4", "Paper size: a4 or letter")
+	fs.BoolVar(&latex, "latex", false, "Write LaTeX source instead of a PDF (the default for a -o file ending in .tex)")
+	fs.StringVar(&outputFile, "o", "", "File to write the book to (defaults to stdout)")
+	parseFlags(fs, args)
+
+	var err error
+	if options.sizes, err = parseSizes(sizes); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+	if options.bands, err = parseBands(difficulty); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+	pdfOptions, err := paperSize(paper)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+	if options.count < 1 || answersPerPage < 1 {
+		fmt.Fprintln(os.Stderr, "Error: -count and -answers-per-page must be at least 1")
+		os.Exit(2)
+	}
+	options.rules = hashisolver.RuleSetForMaxBridges(maxBridges)
+	if options.seed == 0 {
+		options.seed = time.Now().UnixNano()
+	}
+
+	puzzles, skipped, err := makeBook(options)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if skipped > 0 {
+		fmt.Fprintf(os.Stderr, "Skipped %d duplicate puzzles\n", skipped)
+	}
+	pages := bookPages(puzzles, answersPerPage)
+	write := hashisolver.WritePDF
+	if latex || strings.HasSuffix(outputFile, ".tex") {
+		write = hashisolver.WriteLaTeX
+	}
+	if err := writeFileOrStdout(outputFile, func(w io.Writer) error {
+		return write(w, pages, pdfOptions)
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "%d puzzles, seed %d\n", len(puzzles), options.seed)
+}
+
+// bookOptions is what goes into a book: how many puzzles, the sizes and
+// difficulty bands to take turns with, and the seed they are generated from
+type bookOptions struct {
+	count int
+	sizes [][2]int
+	bands []hashisolver.Difficulty
+	rules hashisolver.RuleSet
+	seed  int64
+}
+
+// parseSizes reads a list like "7,10x8", each size N for NxN or COLSxROWS
+func parseSizes(list string) ([][2]int, error) {
+	sizes := [][2]int{}
+	for _, field := range strings.Split(list, ",") {
+		cols, rows, found := strings.Cut(strings.TrimSpace(field), "x")
+		if !found {
+			rows = cols
+		}
+		c, err := strconv.Atoi(cols)
+		if err != nil {
+			return nil, fmt.Errorf("bad size %q, expected N or COLSxROWS", field)
+		}
+		r, err := strconv.Atoi(rows)
+		if err != nil {
+			return nil, fmt.Errorf("bad size %q, expected N or COLSxROWS", field)
+		}
+		sizes = append(sizes, [2]int{c, r})
+	}
+	return sizes, nil
+}
+
+// parseBands reads a difficulty band by name, or "mixed" for all three
+func parseBands(name string) ([]hashisolver.Difficulty, error) {
+	if name == "mixed" {
+		return []hashisolver.Difficulty{hashisolver.DifficultyEasy, hashisolver.DifficultyMedium, hashisolver.DifficultyHard}, nil
+	}
+	band, err := hashisolver.ParseDifficulty(name)
+	if err != nil {
+		return nil, fmt.Errorf("%v or mixed", err)
+	}
+	return []hashisolver.Difficulty{band}, nil
+}
+
+// bookPuzzle is a puzzle in a book with its solution
+type bookPuzzle struct {
+	puzzle   *hashisolver.Puzzle
+	solution *hashisolver.Puzzle
+	band     hashisolver.Difficulty
+	// score is the quick difficulty estimate, which orders puzzles in a band
+	score float64
+}
+
+// maxDuplicates is how many times in a row the generator may repeat a puzzle
+// already in the book before it is given up on
+const maxDuplicates = 10
+
+// uniquePuzzles keeps a pack free of duplicates: the puzzles already in it
+// by their canonical hash, so turned and flipped copies count too, and how
+// many repeats were turned away
+type uniquePuzzles struct {
+	seen    map[string]bool
+	skipped int
+}
+
+// add reports whether the puzzle is new to the pack, noting it if so and
+// counting it as skipped if not
+func (u *uniquePuzzles) add(puzzle *hashisolver.Puzzle) bool {
+	if u.seen == nil {
+		u.seen = map[string]bool{}
+	}
+	hash := puzzle.Hash()
+	if u.seen[hash] {
+		u.skipped++
+		return false
+	}
+	u.seen[hash] = true
+	return true
+}
+
+// makeBook generates the book's puzzles, taking turns with the sizes and
+// bands, and sorts them from easiest to hardest. Each has exactly one
+// solution, and no puzzle appears twice. When there is more than one band,
+// a puzzle the generator can't find in its band is made in any band instead.
+// It also returns how many duplicates the generator came up with, which were
+// skipped.
+func makeBook(options bookOptions) ([]bookPuzzle, int, error) {
+	rng := rand.New(rand.NewSource(options.seed))
+	var unique uniquePuzzles
+	puzzles := []bookPuzzle{}
+	for i := 0; i < options.count; i++ {
+		size := options.sizes[i%len(options.sizes)]
+		band := options.bands[i%len(options.bands)]
+		for duplicates := 0; ; duplicates++ {
+			if duplicates == maxDuplicates {
+				return nil, unique.skipped, fmt.Errorf("only %d different %dx%d %s puzzles turned up", len(puzzles), size[0], size[1], band)
+			}
+			generateOptions := hashisolver.GenerateOptions{
+				Rows:       size[1],
+				Cols:       size[0],
+				Rules:      options.rules,
+				Difficulty: band,
+				Source:     rand.NewSource(rng.Int63()),
+			}
+			generated, err := hashisolver.Generate(generateOptions)
+			if errors.Is(err, hashisolver.ErrNoPuzzle) && len(options.bands) > 1 {
+				// A mix keeps going with whatever band turns up, as hard
+				// puzzles are rare on small boards
+				generateOptions.Difficulty = hashisolver.DifficultyAny
+				generated, err = hashisolver.Generate(generateOptions)
+			}
+			if err != nil {
+				return nil, unique.skipped, fmt.Errorf("generating a %dx%d %s puzzle: %v", size[0], size[1], band, err)
+			}
+			if !unique.add(generated.Puzzle) {
+				continue
+			}
+			puzzles = append(puzzles, bookPuzzle{
+				puzzle:   generated.Puzzle,
+				solution: generated.Solution,
+				band:     generated.Difficulty,
+				score:    hashisolver.EstimateDifficulty(generated.Puzzle).Score,
+			})
+			break
+		}
+	}
+
+	sort.SliceStable(puzzles, func(i, j int) bool {
+		if puzzles[i].band != puzzles[j].band {
+			return puzzles[i].band < puzzles[j].band
+		}
+		return puzzles[i].score < puzzles[j].score
+	})
+	return puzzles, unique.skipped, nil
+}
+
+// bookPages lays out a page for each puzzle, numbered in order, followed by
+// the answer key with the given number of solutions to a page
+func bookPages(puzzles []bookPuzzle, answersPerPage int) []hashisolver.PDFPage {
+	pages := []hashisolver.PDFPage{}
+	for i, p := range puzzles {
+		caption := fmt.Sprintf("%dx%d, %s", p.puzzle.Cols, p.puzzle.Rows, p.band)
+		pages = append(pages, hashisolver.PDFPage{
+			Heading: fmt.Sprintf("Puzzle %d", i+1),
+			Boards:  []hashisolver.PDFBoard{{Caption: caption, Puzzle: p.puzzle}},
+		})
+	}
+
+	for i := 0; i < len(puzzles); i += answersPerPage {
+		page := hashisolver.PDFPage{Heading: "Answers"}
+		if i > 0 {
+			page.Heading = "Answers (continued)"
+		}
+		for j := i; j < i+answersPerPage && j < len(puzzles); j++ {
+			page.Boards = append(page.Boards, hashisolver.PDFBoard{Caption: fmt.Sprintf("Puzzle %d", j+1), Puzzle: puzzles[j].solution})
+		}
+		pages = append(pages, page)
+	}
+	return pages
+}
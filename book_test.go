@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"hashi/hashisolver"
+)
+
+// TestBook tests that a book's puzzles are different, ordered by difficulty
+// and followed by their solutions in the answer key
+func TestBook(t *testing.T) {
+	sizes, err := parseSizes("5,6x5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bands, err := parseBands("mixed")
+	if err != nil || len(bands) != 3 {
+		t.Fatalf("Expected mixed to be three bands, got %v, %v", bands, err)
+	}
+	puzzles, _, err := makeBook(bookOptions{count: 5, sizes: sizes, bands: bands, rules: hashisolver.DefaultRuleSet, seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(puzzles) != 5 {
+		t.Fatalf("Expected 5 puzzles, got %d", len(puzzles))
+	}
+	seen := map[string]bool{}
+	for i, p := range puzzles {
+		if seen[p.puzzle.Hash()] {
+			t.Errorf("Puzzle %d appears twice", i+1)
+		}
+		seen[p.puzzle.Hash()] = true
+		if i > 0 && (p.band < puzzles[i-1].band || p.band == puzzles[i-1].band && p.score < puzzles[i-1].score) {
+			t.Errorf("Puzzle %d is easier than the one before it", i+1)
+		}
+	}
+
+	pages := bookPages(puzzles, 4)
+	if len(pages) != 7 || pages[5].Heading != "Answers" || len(pages[5].Boards) != 4 || len(pages[6].Boards) != 1 {
+		t.Fatalf("Expected 5 puzzle pages and 2 answer pages, got %d pages", len(pages))
+	}
+	if answer := pages[6].Boards[0]; answer.Caption != "Puzzle 5" || !answer.Puzzle.IsComplete() {
+		t.Errorf("Expected the last answer to be puzzle 5 solved, got %q", answer.Caption)
+	}
+
+	var buf bytes.Buffer
+	if err := hashisolver.WriteLaTeX(&buf, pages, hashisolver.A4PDFOptions); err != nil {
+		t.Fatal(err)
+	}
+	latex := buf.String()
+	if strings.Count(latex, `\begin{tikzpicture}`) != 7 || !strings.Contains(latex, "Answers (continued)") || !strings.HasSuffix(latex, "\\end{document}\n") {
+		t.Errorf("Expected a LaTeX document of 7 pictures, got\n%.300s", latex)
+	}
+
+	if _, err := parseSizes("7,ten"); err == nil {
+		t.Error("Expected an error for a size that isn't a number")
+	}
+}
+
+// TestUniquePuzzles tests that a puzzle turned or flipped counts as one
+// already in the pack
+func TestUniquePuzzles(t *testing.T) {
+	puzzle, err := hashisolver.Parse(strings.NewReader("3.4.1\n.....\n1.2.1\n"), hashisolver.DefaultRuleSet)
+	if err != nil {
+		t.Fatalf("Failed to parse puzzle: %v", err)
+	}
+	var unique uniquePuzzles
+	if !unique.add(puzzle) {
+		t.Error("Expected the first puzzle to be added")
+	}
+	if unique.add(puzzle.Rotate90()) || unique.add(puzzle.MirrorV()) {
+		t.Error("Expected turned and flipped copies to be skipped")
+	}
+	if unique.skipped != 2 {
+		t.Errorf("Expected 2 skipped, got %d", unique.skipped)
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"hashi/hashisolver"
+)
+
+// TestAddRemoveBridge tests that bridges can be added and taken away one at
+// a time, and that illegal ones are refused with a reason
+func TestAddRemoveBridge(t *testing.T) {
+	puzzle, err := hashisolver.ParseText(strings.NewReader("2.3.\n....\n1.4.\n...1\n"), hashisolver.DefaultRuleSet, hashisolver.ParseOptions{})
+	if err != nil {
+		t.Fatalf("Failed to parse puzzle: %v", err)
+	}
+	at := func(x, y int) hashisolver.Position { return hashisolver.Position{X: x, Y: y} }
+
+	for _, c := range []struct{ from, to hashisolver.Position }{
+		{at(0, 0), at(2, 0)},
+		{at(2, 0), at(0, 0)},
+		{at(2, 0), at(2, 2)},
+	} {
+		if err := puzzle.AddBridge(c.from, c.to); err != nil {
+			t.Fatalf("Failed to add a bridge from %v to %v: %v", c.from, c.to, err)
+		}
+	}
+	if lines := hashisolver.MapLines(puzzle); strings.Join(lines, "\n") != "2=3 \n  | \n1 4 \n   1" {
+		t.Errorf("Expected the bridges on the board, got\n%s", strings.Join(lines, "\n"))
+	}
+
+	for _, c := range []struct {
+		from, to hashisolver.Position
+		want     string
+	}{
+		{at(0, 0), at(2, 0), "already have 2 bridges"},
+		{at(1, 0), at(2, 0), "no island at (1,0)"},
+		{at(0, 0), at(2, 2), "not in a straight line"},
+		{at(0, 2), at(0, 0), "the 2 at (0,0) already has all its bridges"},
+		{at(2, 2), at(2, 0), "the 3 at (2,0) already has all its bridges"},
+	} {
+		err := puzzle.AddBridge(c.from, c.to)
+		if !errors.Is(err, hashisolver.ErrIllegalBridge) || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("Expected adding a bridge from %v to %v to fail with %q, got %v", c.from, c.to, c.want, err)
+		}
+	}
+
+	crossing, err := hashisolver.ParseText(strings.NewReader(".1.\n1.1\n.1.\n"), hashisolver.DefaultRuleSet, hashisolver.ParseOptions{})
+	if err != nil {
+		t.Fatalf("Failed to parse puzzle: %v", err)
+	}
+	if err := crossing.AddBridge(at(0, 1), at(2, 1)); err != nil {
+		t.Fatalf("Failed to add a bridge: %v", err)
+	}
+	if err := crossing.AddBridge(at(1, 0), at(1, 2)); !errors.Is(err, hashisolver.ErrIllegalBridge) || !strings.Contains(err.Error(), "crosses") {
+		t.Errorf("Expected a crossing bridge to be refused, got %v", err)
+	}
+	if err := crossing.RemoveBridge(at(2, 1), at(0, 1)); err != nil {
+		t.Fatalf("Failed to remove a bridge: %v", err)
+	}
+	if err := crossing.AddBridge(at(1, 0), at(1, 2)); err != nil {
+		t.Errorf("Expected the bridge to fit once the crossing one is gone, got %v", err)
+	}
+
+	if err := puzzle.RemoveBridge(at(2, 2), at(2, 0)); err != nil {
+		t.Fatalf("Failed to remove a bridge: %v", err)
+	}
+	if err := puzzle.RemoveBridge(at(2, 2), at(2, 0)); err == nil || !strings.Contains(err.Error(), "no bridge") {
+		t.Errorf("Expected removing a missing bridge to fail, got %v", err)
+	}
+	if err := puzzle.RemoveBridge(at(0, 0), at(2, 0)); err != nil {
+		t.Fatalf("Failed to remove a bridge: %v", err)
+	}
+	if lines := hashisolver.MapLines(puzzle); strings.Join(lines, "\n") != "2-3 \n    \n1 4 \n   1" || puzzle.PlacedBridges() != 1 {
+		t.Errorf("Expected one bridge left, got\n%s", strings.Join(lines, "\n"))
+	}
+	if puzzle.At(2, 0).IsBlocked(hashisolver.DirectionDown) {
+		t.Error("Expected the emptied pair to be open again")
+	}
+}
+
+// TestUndoRedo tests that moves made by hand are kept in the history, can be
+// taken back and made again, and that a new move forgets the ones undone
+func TestUndoRedo(t *testing.T) {
+	puzzle, err := hashisolver.ParseText(strings.NewReader("2.3.\n....\n1.4.\n...1\n"), hashisolver.DefaultRuleSet, hashisolver.ParseOptions{})
+	if err != nil {
+		t.Fatalf("Failed to parse puzzle: %v", err)
+	}
+	at := func(x, y int) hashisolver.Position { return hashisolver.Position{X: x, Y: y} }
+	board := func() string { return strings.Join(hashisolver.MapLines(puzzle), "\n") }
+
+	if _, ok := puzzle.Undo(); ok {
+		t.Error("Expected nothing to undo on a new puzzle")
+	}
+	empty := board()
+	if err := puzzle.AddBridge(at(0, 0), at(2, 0)); err != nil {
+		t.Fatalf("Failed to add a bridge: %v", err)
+	}
+	if err := puzzle.AddBridge(at(2, 0), at(2, 2)); err != nil {
+		t.Fatalf("Failed to add a bridge: %v", err)
+	}
+	if err := puzzle.AddBridge(at(0, 0), at(0, 2)); err != nil {
+		t.Fatalf("Failed to add a bridge: %v", err)
+	}
+	if err := puzzle.AddBridge(at(0, 0), at(0, 2)); err == nil {
+		t.Fatal("Expected a second bridge to the 1 to be refused")
+	}
+	if err := puzzle.RemoveBridge(at(0, 2), at(0, 0)); err != nil {
+		t.Fatalf("Failed to remove a bridge: %v", err)
+	}
+	moves := puzzle.History().Moves()
+	if len(moves) != 4 || !moves[3].Removed || moves[3].Count != 0 || moves[1].Count != 1 || moves[0].Technique != hashisolver.TechniqueByHand {
+		t.Fatalf("Expected the four moves made in the history, got %v", moves)
+	}
+
+	if move, ok := puzzle.Undo(); !ok || !move.Removed {
+		t.Fatalf("Expected the removal to be undone, got %v", move)
+	}
+	if board() != "2-3 \n| | \n1 4 \n   1" || puzzle.PlacedBridges() != 3 {
+		t.Errorf("Expected the removed bridge back, got\n%s", board())
+	}
+	for i := 0; i < 3; i++ {
+		puzzle.Undo()
+	}
+	if board() != empty || puzzle.PlacedBridges() != 0 || len(puzzle.History().Undone()) != 4 {
+		t.Errorf("Expected every move undone, got\n%s", board())
+	}
+	if _, ok := puzzle.Undo(); ok {
+		t.Error("Expected nothing left to undo")
+	}
+
+	if move, ok := puzzle.Redo(); !ok || move.To != at(2, 0) {
+		t.Fatalf("Expected the first move redone, got %v", move)
+	}
+	if board() != "2-3 \n    \n1 4 \n   1" || len(puzzle.History().Undone()) != 3 {
+		t.Errorf("Expected the first bridge back, got\n%s", board())
+	}
+	if err := puzzle.AddBridge(at(0, 2), at(2, 2)); err != nil {
+		t.Fatalf("Failed to add a bridge: %v", err)
+	}
+	if _, ok := puzzle.Redo(); ok || len(puzzle.History().Undone()) != 0 {
+		t.Error("Expected a new move to forget the moves undone")
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"hashi/hashisolver"
+)
+
+// TestCanonicalHash tests that every rotation and reflection of a puzzle has
+// the same canonical form and hash, and that changing a clue or the rules
+// changes the hash
+func TestCanonicalHash(t *testing.T) {
+	parse := func(text string, rules hashisolver.RuleSet) *hashisolver.Puzzle {
+		puzzle, err := hashisolver.Parse(strings.NewReader(text), rules)
+		if err != nil {
+			t.Fatalf("Failed to parse puzzle: %v", err)
+		}
+		return puzzle
+	}
+
+	// The same 4x3 puzzle as drawn, turned a quarter clockwise, turned half
+	// way and mirrored left to right
+	orientations := []string{
+		"3.2.\n....\n2.1.\n",
+		"2.3\n...\n1.2\n...\n",
+		".1.2\n....\n.2.3\n",
+		".2.3\n....\n.1.2\n",
+	}
+	want := parse(orientations[0], hashisolver.DefaultRuleSet)
+	canonical := strings.Join(hashisolver.MapLines(want.Canonical()), "\n")
+	for _, text := range orientations[1:] {
+		p := parse(text, hashisolver.DefaultRuleSet)
+		if p.Hash() != want.Hash() {
+			t.Errorf("Expected %q to hash as %s, got %s", text, want.Hash(), p.Hash())
+		}
+		if got := strings.Join(hashisolver.MapLines(p.Canonical()), "\n"); got != canonical {
+			t.Errorf("Expected %q to have the canonical form\n%s\ngot\n%s", text, canonical, got)
+		}
+	}
+	if len(want.Hash()) != 16 {
+		t.Errorf("Expected a 16 digit hash, got %q", want.Hash())
+	}
+
+	if p := parse("3.2.\n....\n1.1.\n", hashisolver.DefaultRuleSet); p.Hash() == want.Hash() {
+		t.Error("Expected a changed clue to change the hash")
+	}
+	if p := parse(orientations[0], hashisolver.RuleSetForMaxBridges(3)); p.Hash() == want.Hash() {
+		t.Error("Expected the most bridges per pair to change the hash")
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// config holds default flag values read from the config file. Settings are
+// keyed by subcommand and then flag name; those under "" apply to every
+// subcommand that has the flag.
+type config struct {
+	path     string
+	sections map[string]map[string]string
+}
+
+// configPath returns the config file to read: $HASHI_CONFIG if set, otherwise
+// the first of config.toml, config.yaml and config.yml in the hashi config
+// directory that exists, or "" if there is none
+func configPath() string {
+	if path := os.Getenv("HASHI_CONFIG"); path != "" {
+		return path
+	}
+
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+
+	for _, name := range []string{"config.toml", "config.yaml", "config.yml"} {
+		path := filepath.Join(dir, "hashi", name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// loadConfig reads the config file, returning an empty config if there isn't one
+func loadConfig() (*config, error) {
+	path := configPath()
+	if path == "" {
+		return &config{}, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	ext := filepath.Ext(path)
+	cfg, err := parseConfig(file, ext == ".yaml" || ext == ".yml")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	cfg.path = path
+	return cfg, nil
+}
+
+// parseConfig reads the small subset of TOML or YAML the config file needs:
+// top-level settings followed by a section per subcommand, each setting a
+// flag name and its value.
+//
+//	output = "json"          output: json
+//	[gen]                    gen:
+//	rows = 10                  rows: 10
+func parseConfig(input io.Reader, yaml bool) (*config, error) {
+	separator := "="
+	if yaml {
+		separator = ":"
+	}
+
+	cfg := &config{sections: map[string]map[string]string{"": {}}}
+	section := ""
+
+	scanner := bufio.NewScanner(input)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		raw := scanner.Text()
+		line := strings.TrimSpace(stripComment(raw))
+		if line == "" || line == "---" {
+			continue
+		}
+
+		// A TOML table, or a YAML key with nothing after it, starts a section
+		var name string
+		switch {
+		case !yaml && strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			name = strings.TrimSpace(line[1 : len(line)-1])
+		case yaml && strings.HasSuffix(line, ":") && raw == strings.TrimLeft(raw, " \t"):
+			name = strings.TrimSpace(strings.TrimSuffix(line, ":"))
+		}
+		if name != "" {
+			section = name
+			if cfg.sections[section] == nil {
+				cfg.sections[section] = map[string]string{}
+			}
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, separator)
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("line %d: expected \"key %s value\", found %q", lineNum, separator, line)
+		}
+
+		// In YAML, an unindented setting is back at the top level
+		if yaml && raw == strings.TrimLeft(raw, " \t") {
+			section = ""
+		}
+
+		value = strings.TrimSpace(value)
+		if strings.HasPrefix(value, `"`) {
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: bad quoted value %s", lineNum, value)
+			}
+			value = unquoted
+		} else if len(value) >= 2 && strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'") {
+			value = value[1 : len(value)-1]
+		}
+		cfg.sections[section][strings.TrimSpace(key)] = value
+	}
+	return cfg, scanner.Err()
+}
+
+// stripComment removes a # comment from a line, leaving any # inside quotes
+func stripComment(line string) string {
+	quote := rune(0)
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// apply sets the flags in fs from the top-level settings and the section
+// named after the subcommand. Top-level settings the subcommand has no flag
+// for are skipped, but an unknown setting in its own section is an error.
+func (c *config) apply(fs *flag.FlagSet) error {
+	for key, value := range c.sections[""] {
+		if fs.Lookup(key) == nil {
+			continue
+		}
+		if err := fs.Set(key, value); err != nil {
+			return fmt.Errorf("%s: %s: %v", c.path, key, err)
+		}
+	}
+
+	for key, value := range c.sections[fs.Name()] {
+		if fs.Lookup(key) == nil {
+			return fmt.Errorf("%s: %s has no setting %q", c.path, fs.Name(), key)
+		}
+		if err := fs.Set(key, value); err != nil {
+			return fmt.Errorf("%s: %s.%s: %v", c.path, fs.Name(), key, err)
+		}
+	}
+	return nil
+}
+
+// parseFlags parses a subcommand's arguments on top of the defaults from the
+// config file, so flags given on the command line win
+func parseFlags(fs *flag.FlagSet, args []string) {
+	cfg, err := loadConfig()
+	if err == nil {
+		err = cfg.apply(fs)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading config: %v\n", err)
+		os.Exit(1)
+	}
+	fs.Parse(args)
+}
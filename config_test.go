@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"strings"
+	"testing"
+)
+
+// TestConfig tests that config files in either format set flag defaults that
+// the command line can override
+func TestConfig(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		text string
+		yaml bool
+	}{
+		{"toml", "# defaults\noutput = \"json\"\ncolor = true\n\n[gen]\nrows = 10 # bigger\ndifficulty = 'hard'\n", false},
+		{"yaml", "# defaults\noutput: json\ncolor: true\n\ngen:\n  rows: 10 # bigger\n  difficulty: 'hard'\n", true},
+	} {
+		cfg, err := parseConfig(strings.NewReader(tc.text), tc.yaml)
+		if err != nil {
+			t.Fatalf("Failed to parse %s config: %v", tc.name, err)
+		}
+
+		var rows, cols int
+		var difficulty string
+		var output outputFlags
+		fs := flag.NewFlagSet("gen", flag.ContinueOnError)
+		fs.IntVar(&rows, "rows", 7, "")
+		fs.IntVar(&cols, "cols", 0, "")
+		fs.StringVar(&difficulty, "difficulty", "any", "")
+		output.register(fs, "puzzle")
+
+		if err := cfg.apply(fs); err != nil {
+			t.Fatalf("Failed to apply %s config: %v", tc.name, err)
+		}
+		if err := fs.Parse([]string{"-difficulty", "easy"}); err != nil {
+			t.Fatalf("Failed to parse flags: %v", err)
+		}
+		if rows != 10 || cols != 0 || difficulty != "easy" || output.format != "json" {
+			t.Errorf("Unexpected %s settings: rows %d, cols %d, difficulty %q, output %q", tc.name, rows, cols, difficulty, output.format)
+		}
+	}
+
+	cfg, err := parseConfig(strings.NewReader("[gen]\nrow = 10\n"), false)
+	if err != nil {
+		t.Fatalf("Failed to parse config: %v", err)
+	}
+	fs := flag.NewFlagSet("gen", flag.ContinueOnError)
+	fs.Int("rows", 7, "")
+	if err := cfg.apply(fs); err == nil || !strings.Contains(err.Error(), `gen has no setting "row"`) {
+		t.Errorf("Expected an error for an unknown setting, got %v", err)
+	}
+
+	if _, err := parseConfig(strings.NewReader("rows 10\n"), false); err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("Expected a line number for a malformed setting, got %v", err)
+	}
+}
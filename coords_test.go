@@ -0,0 +1,47 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"hashi/hashisolver"
+)
+
+// TestPositionLabels tests that positions are named by column letters and
+// row numbers like a spreadsheet, and read back either way they are written
+func TestPositionLabels(t *testing.T) {
+	for label, want := range map[string]hashisolver.Position{
+		"A1": {X: 0, Y: 0}, "c4": {X: 2, Y: 3}, "Z10": {X: 25, Y: 9}, "AA2": {X: 26, Y: 1}, "AZ1": {X: 51, Y: 0}, "BA1": {X: 52, Y: 0},
+	} {
+		pos, err := hashisolver.ParsePosition(label)
+		if err != nil || pos != want {
+			t.Errorf("Expected %s to be %v, got %v (%v)", label, want, pos, err)
+		}
+		if got := want.Label(); got != strings.ToUpper(label) {
+			t.Errorf("Expected %v to be labelled %s, got %s", want, strings.ToUpper(label), got)
+		}
+	}
+	if pos, err := hashisolver.ParsePosition("3,1"); err != nil || pos != (hashisolver.Position{X: 3, Y: 1}) {
+		t.Errorf("Expected 3,1 to be read as x,y, got %v (%v)", pos, err)
+	}
+	for _, bad := range []string{"", "A", "1", "A0", "A+1", "1A", "3,1,2"} {
+		if _, err := hashisolver.ParsePosition(bad); err == nil {
+			t.Errorf("Expected %q to be refused", bad)
+		}
+	}
+}
+
+// TestLabelMap tests that the axis labels line up with the board's cells
+func TestLabelMap(t *testing.T) {
+	lines := make([]string, 10)
+	for i := range lines {
+		lines[i] = strings.Repeat(".", 28)
+	}
+	labelled := hashisolver.LabelMap(lines, 28)
+	if labelled[0] != strings.Repeat(" ", 29)+"AA" || labelled[1] != "   ABCDEFGHIJKLMNOPQRSTUVWXYZAB" {
+		t.Errorf("Expected stacked column letters, got\n%s", strings.Join(labelled[:2], "\n"))
+	}
+	if labelled[2] != " 1 "+lines[0] || labelled[11] != "10 "+lines[9] {
+		t.Errorf("Expected right-aligned row numbers, got %q and %q", labelled[2], labelled[11])
+	}
+}
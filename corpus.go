@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"hashi/hashisolver"
+)
+
+// runVerifyCorpus solves every puzzle in a directory and checks the solutions
+func runVerifyCorpus(args []string) {
+	var input inputFlags
+	var update bool
+
+	fs := flag.NewFlagSet("verify-corpus", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s verify-corpus [flags] <dir>\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	input.registerFormat(fs)
+	fs.BoolVar(&update, "update", false, "Write each solution to its .out file instead of checking it")
+	parseFlags(fs, args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	failed, err := verifyCorpus(os.Stdout, fs.Arg(0), &input, update)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error %v\n", err)
+		os.Exit(1)
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// verifyCorpus solves each puzzle in dir, comparing the solution with the
+// puzzle's .out file if it has one and checking it is complete otherwise.
+// It prints a line per puzzle and a summary, and returns the number that failed.
+func verifyCorpus(w io.Writer, dir string, input *inputFlags, update bool) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, fmt.Errorf("reading corpus: %v", err)
+	}
+
+	passed, failed := 0, 0
+	start := time.Now()
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || filepath.Ext(name) == ".out" {
+			continue
+		}
+
+		path := filepath.Join(dir, name)
+		elapsed, err := verifyPuzzle(path, input, update)
+		if err != nil {
+			fmt.Fprintf(w, "FAIL  %s: %v\n", name, err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(w, "ok    %s (%v)\n", name, elapsed.Round(time.Microsecond))
+		passed++
+	}
+
+	fmt.Fprintf(w, "%d passed, %d failed in %v\n", passed, failed, time.Since(start).Round(time.Millisecond))
+	return failed, nil
+}
+
+// verifyPuzzle solves the puzzle in path and checks the solution, returning
+// how long the solve took
+func verifyPuzzle(path string, input *inputFlags, update bool) (time.Duration, error) {
+	puzzle, err := input.loadPuzzle(path)
+	if err != nil {
+		return 0, err
+	}
+
+	start := time.Now()
+	solved, err := hashisolver.SolveWith(context.Background(), puzzle)
+	elapsed := time.Since(start)
+	if err != nil {
+		return elapsed, err
+	}
+
+	got := hashisolver.MapLines(solved)
+	expectedPath := strings.TrimSuffix(path, filepath.Ext(path)) + ".out"
+	if update {
+		return elapsed, os.WriteFile(expectedPath, []byte(strings.Join(got, "\n")+"\n"), 0o644)
+	}
+
+	data, err := os.ReadFile(expectedPath)
+	if os.IsNotExist(err) {
+		if !solved.IsComplete() {
+			return elapsed, fmt.Errorf("solution is incomplete")
+		}
+		return elapsed, nil
+	}
+	if err != nil {
+		return elapsed, err
+	}
+
+	want := normalizeLines(strings.Split(string(data), "\n"))
+	got = normalizeLines(got)
+	for i := 0; i < len(want) || i < len(got); i++ {
+		if i >= len(want) || i >= len(got) || want[i] != got[i] {
+			return elapsed, fmt.Errorf("solution differs from %s at line %d", filepath.Base(expectedPath), i+1)
+		}
+	}
+	return elapsed, nil
+}
+
+// normalizeLines drops trailing spaces and trailing blank lines so drawn
+// solutions compare the same however they were saved
+func normalizeLines(lines []string) []string {
+	normalized := make([]string, len(lines))
+	for i, line := range lines {
+		normalized[i] = strings.TrimRight(line, " \r")
+	}
+	for len(normalized) > 0 && normalized[len(normalized)-1] == "" {
+		normalized = normalized[:len(normalized)-1]
+	}
+	return normalized
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestVerifyCorpus tests checking a corpus against saved solutions and the completeness check
+func TestVerifyCorpus(t *testing.T) {
+	dir := t.TempDir()
+	for name, puzzle := range map[string]string{
+		"corner.txt": "2.2\n...\n2.2\n",
+		"line.txt":   "1.2.1\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(puzzle), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	input := &inputFlags{format: "text", maxBridges: 2, strict: true}
+
+	// Without .out files each solution only has to be complete
+	if failed, err := verifyCorpus(io.Discard, dir, input, false); err != nil || failed != 0 {
+		t.Fatalf("Expected the corpus to pass, got %d failures (%v)", failed, err)
+	}
+
+	if _, err := verifyCorpus(io.Discard, dir, input, true); err != nil {
+		t.Fatalf("Failed to update the corpus: %v", err)
+	}
+	out := filepath.Join(dir, "line.out")
+	if data, err := os.ReadFile(out); err != nil || string(data) != "1-2-1\n" {
+		t.Fatalf("Expected the solution to be saved, got %q (%v)", data, err)
+	}
+
+	if err := os.WriteFile(out, []byte("1=2.1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if failed, _ := verifyCorpus(io.Discard, dir, input, false); failed != 1 {
+		t.Errorf("Expected the changed solution to fail, got %d failures", failed)
+	}
+}
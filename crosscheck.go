@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"hashi/hashisolver"
+)
+
+// errCrossCheck is returned when two engines disagree about a puzzle
+var errCrossCheck = errors.New("cross-check failed")
+
+// crossCheckFlag is the engine to check the solving engine against. Given
+// without a value it stands for whichever of the heuristic and SAT engines
+// is not solving.
+type crossCheckFlag string
+
+// String returns the engine named
+func (c *crossCheckFlag) String() string {
+	return string(*c)
+}
+
+// Set names the engine, or the default one for true
+func (c *crossCheckFlag) Set(s string) error {
+	switch s {
+	case "true":
+		*c = "auto"
+	case "false":
+		*c = ""
+	default:
+		*c = crossCheckFlag(s)
+	}
+	return nil
+}
+
+// IsBoolFlag lets the flag be given without a value
+func (c *crossCheckFlag) IsBoolFlag() bool {
+	return true
+}
+
+// against returns the engine to check engine against, or "" for none
+func (c crossCheckFlag) against(engine string) string {
+	if c != "auto" {
+		return string(c)
+	}
+	if engine == hashisolver.EngineSAT {
+		return hashisolver.EngineHeuristic
+	}
+	return hashisolver.EngineSAT
+}
+
+// verdict is what an engine found out about a puzzle: whether it has no
+// solution, one or several, and the first solution found
+type verdict struct {
+	engine    string
+	solutions int
+	first     []string
+}
+
+func (v verdict) String() string {
+	switch v.solutions {
+	case 0:
+		return v.engine + " found no solution"
+	case 1:
+		return v.engine + " found a unique solution"
+	}
+	return v.engine + " found several solutions"
+}
+
+// judge asks the named engine for up to two solutions of the puzzle
+func judge(ctx context.Context, name string, puzzle *hashisolver.Puzzle, opts []hashisolver.Option) (verdict, error) {
+	if _, err := hashisolver.LookupEngine(name); err != nil {
+		return verdict{}, err
+	}
+	opts = append(opts[:len(opts):len(opts)], hashisolver.WithEngine(name))
+	solutions, err := hashisolver.FindSolutions(ctx, puzzle, 2, opts...)
+	if err != nil && !errors.Is(err, hashisolver.ErrNoSolution) {
+		return verdict{}, fmt.Errorf("%s: %w", name, err)
+	}
+	v := verdict{engine: name, solutions: len(solutions)}
+	if len(solutions) > 0 {
+		if v.first, err = hashisolver.SolutionLines(puzzle, solutions[0]); err != nil {
+			return verdict{}, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return v, nil
+}
+
+// crossCheck has two engines solve the puzzle and returns an error wrapping
+// errCrossCheck if they disagree on whether it has no solution, a unique one
+// or several, or on what a unique solution is
+func crossCheck(ctx context.Context, engines [2]string, puzzle *hashisolver.Puzzle, opts []hashisolver.Option) error {
+	var verdicts [2]verdict
+	for i, name := range engines {
+		v, err := judge(ctx, name, puzzle, opts)
+		if err != nil {
+			return err
+		}
+		verdicts[i] = v
+	}
+
+	a, b := verdicts[0], verdicts[1]
+	if a.solutions != b.solutions {
+		return fmt.Errorf("%w: %v but %v", errCrossCheck, a, b)
+	}
+	if a.solutions == 1 && strings.Join(a.first, "\n") != strings.Join(b.first, "\n") {
+		return fmt.Errorf("%w: %s and %s found different solutions:\n%s\n\n%s",
+			errCrossCheck, a.engine, b.engine, strings.Join(a.first, "\n"), strings.Join(b.first, "\n"))
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"hashi/hashisolver"
+)
+
+// noSolutionEngine is a broken engine that never finds a solution
+type noSolutionEngine struct{}
+
+func (noSolutionEngine) Solve(ctx context.Context, puzzle *hashisolver.Puzzle, options hashisolver.Options) (*hashisolver.SolveResult, error) {
+	return &hashisolver.SolveResult{Puzzle: puzzle}, hashisolver.ErrNoSolution
+}
+
+func (noSolutionEngine) SolveAll(ctx context.Context, puzzle *hashisolver.Puzzle, options hashisolver.Options, limit int) ([]*hashisolver.Puzzle, error) {
+	return nil, hashisolver.ErrNoSolution
+}
+
+// TestCrossCheck tests that the engines agree on the samples and that an
+// engine that disagrees is caught
+func TestCrossCheck(t *testing.T) {
+	hashisolver.RegisterEngine("never", noSolutionEngine{})
+	for _, sample := range hashisolver.Samples() {
+		puzzle, err := sample.Puzzle()
+		if err != nil {
+			t.Fatalf("Failed to parse sample %s: %v", sample.Name, err)
+		}
+		for _, engines := range [][2]string{{"heuristic", "sat"}, {"sat", "dlx"}} {
+			if err := crossCheck(context.Background(), engines, puzzle, nil); err != nil {
+				t.Errorf("Expected engines %v to agree on sample %s, got %v", engines, sample.Name, err)
+			}
+		}
+		if err := crossCheck(context.Background(), [2]string{"heuristic", "never"}, puzzle, nil); !errors.Is(err, errCrossCheck) {
+			t.Errorf("Expected the broken engine to be caught on sample %s, got %v", sample.Name, err)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"hashi/hashisolver"
+)
+
+// runDiff shows where two solutions of a puzzle differ, by default a
+// player's and the solver's
+func runDiff(args []string) {
+	var input inputFlags
+	var solutionFile, againstFile string
+
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	input.register(fs)
+	fs.StringVar(&input.inputFile, "puzzle", "", "Puzzle file the solutions are for (the same as -input)")
+	fs.StringVar(&solutionFile, "solution", "", "Solution file, as for validate: a drawn board or a bridge list")
+	fs.StringVar(&againstFile, "against", "", "Solution file to compare with, instead of the solver's solution")
+	parseFlags(fs, args)
+	input.useArgs(fs)
+
+	if solutionFile == "" {
+		fmt.Fprintln(os.Stderr, "Error: -solution is required")
+		os.Exit(2)
+	}
+
+	puzzle := input.readPuzzle()
+	bridges, err := readSolution(solutionFile, input.rules())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading solution: %v\n", err)
+		os.Exit(1)
+	}
+	first := &hashisolver.Solution{Bridges: bridges}
+
+	var second *hashisolver.Solution
+	name := "solver"
+	if againstFile != "" {
+		bridges, err := readSolution(againstFile, input.rules())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading solution: %v\n", err)
+			os.Exit(1)
+		}
+		second, name = &hashisolver.Solution{Bridges: bridges}, againstFile
+	} else {
+		result, err := hashisolver.SolveWithStats(context.Background(), puzzle.Clone())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error solving puzzle: %v\n", err)
+			os.Exit(1)
+		}
+		second = hashisolver.NewSolution(result.Puzzle)
+	}
+
+	if len(hashisolver.DiffSolutions(first.Bridges, second.Bridges)) == 0 {
+		fmt.Println("The solutions agree")
+		return
+	}
+	if err := hashisolver.WriteDiff(os.Stdout, puzzle, first, second, [2]string{solutionFile, name}); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing diff: %v\n", err)
+	}
+	os.Exit(1)
+}
+
+// runDiffPuzzle lists the clues that differ between two puzzles, and how the
+// change affects the number of solutions and the difficulty
+func runDiffPuzzle(args []string) {
+	var input inputFlags
+	var labels bool
+	var analyse bool
+
+	fs := flag.NewFlagSet("diff-puzzle", flag.ExitOnError)
+	input.registerFormat(fs)
+	fs.BoolVar(&labels, "labels", false, "Name cells by their labels, like A1, rather than as (x,y)")
+	fs.BoolVar(&analyse, "solve", true, "Also compare whether each puzzle has a unique solution and how hard it is")
+	parseFlags(fs, args)
+
+	if fs.NArg() != 2 {
+		fmt.Fprintln(os.Stderr, "Usage: hashi diff-puzzle [flags] a.txt b.txt")
+		os.Exit(2)
+	}
+	names := [2]string{fs.Arg(0), fs.Arg(1)}
+	var puzzles [2]*hashisolver.Puzzle
+	for i, name := range names {
+		puzzle, err := input.loadPuzzle(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", name, err)
+			os.Exit(1)
+		}
+		puzzles[i] = puzzle
+	}
+
+	diffs, err := hashisolver.DiffPuzzles(puzzles[0], puzzles[1])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(diffs) == 0 {
+		fmt.Println("The puzzles have the same clues")
+		return
+	}
+
+	fmt.Printf("--- %s\n+++ %s\n", names[0], names[1])
+	for _, d := range diffs {
+		if labels {
+			fmt.Printf("%s: %s\n", d.At.Label(), d.Change())
+		} else {
+			fmt.Println(d)
+		}
+	}
+	if analyse {
+		var solutions, difficulty [2]string
+		for i, puzzle := range puzzles {
+			solutions[i], difficulty[i] = describeSolutions(puzzle)
+		}
+		fmt.Printf("solutions: %s -> %s\n", solutions[0], solutions[1])
+		fmt.Printf("difficulty: %s -> %s\n", difficulty[0], difficulty[1])
+	}
+	os.Exit(1)
+}
+
+// describeSolutions says whether the puzzle has no solution, one or several,
+// and how hard it is to solve if it has any
+func describeSolutions(puzzle *hashisolver.Puzzle) (string, string) {
+	solutions := "unique"
+	switch hashisolver.CountSolutions(puzzle.Clues(), 2) {
+	case 0:
+		return "none", "-"
+	case 2:
+		solutions = "several"
+	}
+	difficulty, err := hashisolver.Rate(puzzle.Clues())
+	if err != nil {
+		return solutions, "-"
+	}
+	return solutions, difficulty.String()
+}
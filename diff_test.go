@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"hashi/hashisolver"
+)
+
+// TestDiffSolutions tests that bridges are lined up by their pair whichever
+// end they are listed from, and that only the pairs that differ are shown
+func TestDiffSolutions(t *testing.T) {
+	puzzle, err := hashisolver.Parse(strings.NewReader("3.2\n...\n1..\n"), hashisolver.DefaultRuleSet)
+	if err != nil {
+		t.Fatalf("Failed to parse puzzle: %v", err)
+	}
+	mine := &hashisolver.Solution{Bridges: []hashisolver.Bridge{
+		{From: hashisolver.Position{X: 2, Y: 0}, To: hashisolver.Position{X: 0, Y: 0}, Count: 1},
+		{From: hashisolver.Position{X: 0, Y: 0}, To: hashisolver.Position{X: 0, Y: 2}, Count: 1},
+	}}
+	solver := &hashisolver.Solution{Bridges: []hashisolver.Bridge{
+		{From: hashisolver.Position{X: 0, Y: 0}, To: hashisolver.Position{X: 2, Y: 0}, Count: 2},
+		{From: hashisolver.Position{X: 0, Y: 0}, To: hashisolver.Position{X: 0, Y: 2}, Count: 1},
+	}}
+
+	diffs := hashisolver.DiffSolutions(mine.Bridges, solver.Bridges)
+	if len(diffs) != 1 || diffs[0] != (hashisolver.BridgeDiff{To: hashisolver.Position{X: 2}, Old: 1, New: 2}) {
+		t.Errorf("Expected the top pair to differ, got %+v", diffs)
+	}
+	if diffs := hashisolver.DiffSolutions(solver.Bridges, solver.Bridges); len(diffs) != 0 {
+		t.Errorf("Expected no differences from the same solution, got %+v", diffs)
+	}
+
+	var buf bytes.Buffer
+	if err := hashisolver.WriteDiff(&buf, puzzle, mine, solver, [2]string{"mine", "solver"}); err != nil {
+		t.Fatalf("Failed to write diff: %v", err)
+	}
+	want := "--- mine\n+++ solver\n-0,0 2,0 1\n+0,0 2,0 2\n\n3*2   3*2\n|     |\n1     1\n"
+	if buf.String() != want {
+		t.Errorf("Expected\n%s\ngot\n%s", want, buf.String())
+	}
+}
+
+// TestDiffPuzzles tests that added, removed and changed clues are found, and
+// that puzzles of different sizes are refused
+func TestDiffPuzzles(t *testing.T) {
+	parse := func(text string) *hashisolver.Puzzle {
+		puzzle, err := hashisolver.Parse(strings.NewReader(text), hashisolver.DefaultRuleSet)
+		if err != nil {
+			t.Fatalf("Failed to parse puzzle: %v", err)
+		}
+		return puzzle
+	}
+
+	diffs, err := hashisolver.DiffPuzzles(parse("3.2\n...\n1..\n"), parse("3..\n...\n2.1\n"))
+	if err != nil {
+		t.Fatalf("Failed to diff puzzles: %v", err)
+	}
+	var got []string
+	for _, d := range diffs {
+		got = append(got, d.String())
+	}
+	want := "(2,0): island 2 removed; (0,2): clue changed from 1 to 2; (2,2): island 1 added"
+	if strings.Join(got, "; ") != want {
+		t.Errorf("Expected %q, got %q", want, strings.Join(got, "; "))
+	}
+
+	if _, err := hashisolver.DiffPuzzles(parse("3.2\n...\n1..\n"), parse("2.2\n")); err == nil {
+		t.Error("Expected puzzles of different sizes to be refused")
+	}
+}
+
+// TestDiffDrawnRowsOfWater tests that a drawn solution with a row of nothing
+// but water agrees with the solver's, rather than every bridge below the row
+// showing as moved
+func TestDiffDrawnRowsOfWater(t *testing.T) {
+	puzzle, err := hashisolver.Parse(strings.NewReader(".......\n2.3...2\n.......\n.......\n3.4...4\n.......\n1.....3\n"), hashisolver.DefaultRuleSet)
+	if err != nil {
+		t.Fatalf("Failed to parse puzzle: %v", err)
+	}
+	solved, err := hashisolver.SolvePuzzle(puzzle.Clone(), false)
+	if err != nil {
+		t.Fatalf("Failed to solve puzzle: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "drawn.txt")
+	if err := os.WriteFile(path, []byte(strings.Join(hashisolver.MapLines(solved), "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	bridges, err := readSolution(path, hashisolver.DefaultRuleSet)
+	if err != nil {
+		t.Fatalf("Failed to read the drawn board: %v", err)
+	}
+	if diffs := hashisolver.DiffSolutions(bridges, hashisolver.NewSolution(solved).Bridges); len(diffs) > 0 {
+		t.Errorf("Expected the drawn board to agree with the solver, got %v", diffs)
+	}
+}
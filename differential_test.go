@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"hashi/hashisolver"
+)
+
+// drawnPlanks returns the planks a drawn bridge character stands for and whether it is vertical
+func drawnPlanks(char byte) (int, bool) {
+	switch char {
+	case '-':
+		return 1, false
+	case '=':
+		return 2, false
+	case 'E':
+		return 3, false
+	case '|':
+		return 1, true
+	case '"':
+		return 2, true
+	case '#':
+		return 3, true
+	}
+	return 0, false
+}
+
+// verifyDrawn checks a drawn solution against the clue text it solves, using
+// nothing but the characters of each: every island is kept with its clue, every
+// bridge runs straight from island to island without crossing another, no pair
+// has more than maxBridges bridges, every clue is met and the islands are connected
+func verifyDrawn(clues []string, drawn []string, maxBridges int) []string {
+	problems := []string{}
+	if len(drawn) != len(clues) {
+		return append(problems, fmt.Sprintf("%d rows drawn for %d rows of clues", len(drawn), len(clues)))
+	}
+
+	rows, cols := len(clues), 0
+	for _, line := range clues {
+		if len(line) > cols {
+			cols = len(line)
+		}
+	}
+	cell := func(lines []string, r, c int) byte {
+		if r < 0 || r >= rows || c < 0 || c >= len(lines[r]) {
+			return ' '
+		}
+		return lines[r][c]
+	}
+	island := func(char byte) bool {
+		return char >= '1' && char <= '9' || char >= 'a' && char <= 'f'
+	}
+
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols; c++ {
+			clue, got := cell(clues, r, c), cell(drawn, r, c)
+			if clue == '.' {
+				clue = ' '
+			}
+			switch count, vertical := drawnPlanks(got); {
+			case island(clue) || island(got):
+				if clue != got {
+					problems = append(problems, fmt.Sprintf("(%d,%d) should be %q, drawn %q", c, r, clue, got))
+				}
+			case count > maxBridges:
+				problems = append(problems, fmt.Sprintf("(%d,%d) has %d bridges", c, r, count))
+			case count > 0:
+				// Each bridge cell must continue in its own direction or reach an island
+				dr, dc := 0, 1
+				if vertical {
+					dr, dc = 1, 0
+				}
+				for _, step := range []int{-1, 1} {
+					next := cell(drawn, r+step*dr, c+step*dc)
+					if next != got && !island(next) {
+						problems = append(problems, fmt.Sprintf("bridge at (%d,%d) is broken", c, r))
+					}
+				}
+			case got != ' ':
+				problems = append(problems, fmt.Sprintf("(%d,%d) has unexpected %q", c, r, got))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return problems
+	}
+
+	// Count the bridges reaching each island and follow them for connectivity
+	seen := map[[2]int]bool{}
+	var visit func(r, c int)
+	visit = func(r, c int) {
+		seen[[2]int{r, c}] = true
+		total := 0
+		for _, step := range [][2]int{{0, 1}, {0, -1}, {1, 0}, {-1, 0}} {
+			count, vertical := drawnPlanks(cell(drawn, r+step[0], c+step[1]))
+			if count == 0 || vertical != (step[0] != 0) {
+				continue
+			}
+			total += count
+			r1, c1 := r+step[0], c+step[1]
+			for !island(cell(drawn, r1, c1)) {
+				r1, c1 = r1+step[0], c1+step[1]
+			}
+			if !seen[[2]int{r1, c1}] {
+				visit(r1, c1)
+			}
+		}
+		char := cell(drawn, r, c)
+		value := int(char - '0')
+		if char >= 'a' {
+			value = int(char-'a') + 10
+		}
+		if total != value {
+			problems = append(problems, fmt.Sprintf("island at (%d,%d) wants %d bridges, has %d", c, r, value, total))
+		}
+	}
+
+	islands := 0
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols; c++ {
+			if island(cell(drawn, r, c)) {
+				if islands == 0 {
+					visit(r, c)
+				}
+				islands++
+			}
+		}
+	}
+	if len(seen) != islands {
+		problems = append(problems, fmt.Sprintf("only %d of %d islands are connected", len(seen), islands))
+	}
+	return problems
+}
+
+// solveAndVerify generates a puzzle from seed, solves it from its text and
+// checks the drawn solution with verifyDrawn
+func solveAndVerify(t *testing.T, seed int64, rows, cols int) {
+	t.Helper()
+
+	generated, err := hashisolver.Generate(hashisolver.GenerateOptions{
+		Rows:                   rows,
+		Cols:                   cols,
+		AllowMultipleSolutions: true,
+		MaxAttempts:            50,
+		Source:                 rand.NewSource(seed),
+	})
+	if err != nil {
+		t.Skipf("No puzzle for seed %d: %v", seed, err)
+	}
+
+	var text bytes.Buffer
+	if err := hashisolver.WritePuzzle(&text, generated.Puzzle); err != nil {
+		t.Fatalf("Failed to write puzzle: %v", err)
+	}
+	clues := strings.Split(strings.TrimSpace(strings.TrimPrefix(text.String(), "difficulty: "+generated.Difficulty.String()+"\n")), "\n")
+
+	solved, err := hashisolver.Solve(strings.NewReader(text.String()), false)
+	if err != nil {
+		t.Fatalf("Seed %d: failed to solve a generated puzzle: %v\n%s", seed, err, text.String())
+	}
+	if problems := verifyDrawn(clues, hashisolver.MapLines(solved), hashisolver.DefaultRuleSet.MaxBridgesPerPair); len(problems) > 0 {
+		t.Fatalf("Seed %d: invalid solution of\n%s\n%s\n%s", seed, text.String(),
+			strings.Join(hashisolver.MapLines(solved), "\n"), strings.Join(problems, "\n"))
+	}
+}
+
+// TestSolverAgainstVerifier checks the solver's answers to generated puzzles with an independent verifier
+func TestSolverAgainstVerifier(t *testing.T) {
+	for seed := int64(1); seed <= 60; seed++ {
+		solveAndVerify(t, seed, 4+int(seed%6), 4+int(seed/6%6))
+	}
+}
+
+// FuzzSolverAgainstVerifier does the same for any seed and board size
+func FuzzSolverAgainstVerifier(f *testing.F) {
+	f.Add(int64(2), uint8(5), uint8(5))
+	f.Add(int64(11), uint8(4), uint8(9))
+	f.Add(int64(2545), uint8(5), uint8(11))
+	f.Add(int64(1), uint8(8), uint8(8))
+	f.Add(int64(42), uint8(6), uint8(6))
+	f.Fuzz(func(t *testing.T, seed int64, rows, cols uint8) {
+		solveAndVerify(t, seed, 3+int(rows%10), 3+int(cols%10))
+	})
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"hashi/hashisolver"
+)
+
+// TestEstimateDifficulty tests that a puzzle the opening rules finish scores below one they can't
+func TestEstimateDifficulty(t *testing.T) {
+	parse := func(puzzle string) *hashisolver.Puzzle {
+		p, err := hashisolver.Parse(strings.NewReader(puzzle), hashisolver.DefaultRuleSet)
+		if err != nil {
+			t.Fatalf("Failed to parse %q: %v", puzzle, err)
+		}
+		return p
+	}
+
+	easy := hashisolver.EstimateDifficulty(parse("3.5...4\n...2.4.\n.......\n4.5..5.\n.......\n...2.4.\n3.5.3.3\n"))
+	hard := hashisolver.EstimateDifficulty(parse("2..3..2.\n........\n2..4..3.\n........\n.2.4.1..\n1...1.3.\n.1......\n2..3..2.\n"))
+
+	if easy.Islands != 14 || easy.MeanClue <= 3 || easy.OpeningFraction <= 0 {
+		t.Errorf("Unexpected estimate for the easy puzzle: %+v", easy)
+	}
+	if easy.Score >= hard.Score {
+		t.Errorf("Expected the easy puzzle to score below the hard one, got %.1f and %.1f", easy.Score, hard.Score)
+	}
+	if easy.Grade() != "easy" || hard.Grade() == "easy" {
+		t.Errorf("Unexpected grades %q and %q", easy.Grade(), hard.Grade())
+	}
+
+	// The estimate works on a copy
+	p := parse("1.3.1\n.....\n..1..\n")
+	hashisolver.EstimateDifficulty(p)
+	if p.PlacedBridges() != 0 {
+		t.Errorf("Expected the puzzle to be left untouched")
+	}
+}
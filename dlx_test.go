@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"hashi/hashisolver"
+)
+
+// TestDLXEngine tests that the DLX engine solves the samples and reports its
+// own statistics
+func TestDLXEngine(t *testing.T) {
+	for _, sample := range hashisolver.Samples() {
+		puzzle, err := sample.Puzzle()
+		if err != nil {
+			t.Fatalf("Failed to parse sample %s: %v", sample.Name, err)
+		}
+		result, err := hashisolver.SolveWithStats(context.Background(), puzzle.Clone(), hashisolver.WithEngine(hashisolver.EngineDLX))
+		if err != nil {
+			t.Fatalf("Failed to solve sample %s with the DLX engine: %v", sample.Name, err)
+		}
+		if violations := hashisolver.Verify(puzzle, result.Puzzle); len(violations) > 0 {
+			t.Errorf("Expected a valid solution of sample %s, got %v", sample.Name, violations)
+		}
+		if result.Stats.Engine != hashisolver.EngineDLX || result.Stats.NodesExplored == 0 {
+			t.Errorf("Expected the statistics of the DLX engine, got %+v", result.Stats)
+		}
+	}
+}
+
+// TestDLXAgreesWithSolveAll tests that the DLX engine finds as many solutions
+// as the heuristic engine
+func TestDLXAgreesWithSolveAll(t *testing.T) {
+	for seed := int64(1); seed <= 20; seed++ {
+		generated, err := hashisolver.Generate(hashisolver.GenerateOptions{
+			Rows:                   8,
+			Cols:                   8,
+			AllowMultipleSolutions: true,
+			Source:                 rand.NewSource(seed),
+		})
+		if err != nil {
+			t.Fatalf("Failed to generate puzzle: %v", err)
+		}
+		puzzle := generated.Puzzle
+		input := strings.Join(hashisolver.MapLines(puzzle), "\n")
+
+		want := hashisolver.CountSolutions(puzzle, 0)
+		solutions, err := hashisolver.SolveAllDLX(context.Background(), puzzle, 0)
+		if err != nil {
+			t.Fatalf("Failed to solve puzzle with the DLX engine: %v\n%s", err, input)
+		}
+		if len(solutions) != want {
+			t.Errorf("Expected %d solutions, the DLX engine found %d\n%s", want, len(solutions), input)
+		}
+		for _, solution := range solutions {
+			if violations := hashisolver.Verify(puzzle, solution); len(violations) > 0 {
+				t.Errorf("Expected a valid solution, got %v\n%s", violations, input)
+			}
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"hashi/hashisolver"
+)
+
+// TestDOT tests that every pair of facing islands is an edge, labelled with
+// the bridges placed and the most it could take
+func TestDOT(t *testing.T) {
+	puzzle, err := hashisolver.Parse(strings.NewReader("2.4\n...\n1.3\n"), hashisolver.DefaultRuleSet)
+	if err != nil {
+		t.Fatalf("Failed to parse puzzle: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := hashisolver.WriteDOT(&buf, puzzle); err != nil {
+		t.Fatal(err)
+	}
+	dot := buf.String()
+	if !strings.HasPrefix(dot, "graph hashi {\n") || strings.Count(dot, " -- ") != 4 {
+		t.Fatalf("Expected a graph with 4 edges, got\n%s", dot)
+	}
+	for _, want := range []string{`"2,0" [label="4", pos="2,0!"]`, `"0,0" -- "2,0" [label="0/2"`, `"0,2" -- "2,2" [label="0/1"`} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("Expected %s in\n%s", want, dot)
+		}
+	}
+
+	solution, err := hashisolver.FindSolution(context.Background(), puzzle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	solved, err := puzzle.Apply(solution)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	if err := hashisolver.WriteDOT(&buf, solved); err != nil {
+		t.Fatal(err)
+	}
+	if dot := buf.String(); strings.Contains(dot, "dashed") || !strings.Contains(dot, "penwidth=2") {
+		t.Errorf("Expected every edge of the solved board settled, got\n%s", dot)
+	}
+}
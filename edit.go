@@ -0,0 +1,285 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"hashi/hashisolver"
+)
+
+// editorKeys is the help line shown under the board
+const editorKeys = "arrows/hjkl move  1-9 set clue  +/- change it  space/x remove  s save  q quit"
+
+// runEdit opens a puzzle, or a blank board, in a full screen editor for
+// placing and removing islands
+func runEdit(args []string) {
+	var input inputFlags
+	var output outputFlags
+	var rows, cols int
+	var saveFile string
+
+	fs := flag.NewFlagSet("edit", flag.ExitOnError)
+	input.register(fs)
+	output.register(fs, "puzzle")
+	fs.IntVar(&rows, "rows", 7, "Number of rows on a new board")
+	fs.IntVar(&cols, "cols", 0, "Number of columns on a new board (defaults to -rows)")
+	fs.StringVar(&saveFile, "save", "", "File to save the puzzle to (defaults to the -input file)")
+	parseFlags(fs, args)
+	input.useArgs(fs)
+	renderer := output.renderer()
+
+	// Without a puzzle to open the editor starts on a blank board, rather
+	// than reading one from the terminal
+	var puzzle *hashisolver.Puzzle
+	if input.inputFile != "" || input.sample != "" || input.text != "" {
+		puzzle = input.readPuzzle().Clues()
+		if saveFile == "" && input.inputFile != "-" {
+			saveFile = input.inputFile
+		}
+	} else {
+		if cols <= 0 {
+			cols = rows
+		}
+		if rows <= 0 {
+			fmt.Fprintln(os.Stderr, "Error: -rows must be at least 1")
+			os.Exit(2)
+		}
+		values := make([][]int, rows)
+		for i := range values {
+			values[i] = make([]int, cols)
+		}
+		puzzle = hashisolver.NewPuzzle(values, input.rules())
+	}
+
+	term, err := openTerminal()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer term.restore()
+
+	e := newEditor(puzzle, saveFile, renderer)
+	events := term.events()
+	results := make(chan editorCheck)
+	cancel := func() {}
+	defer func() { cancel() }()
+
+	for {
+		// Each change to the board is checked in the background, and a check
+		// still running when the board changes again is abandoned
+		if e.unchecked {
+			cancel()
+			ctx, stop := context.WithCancel(context.Background())
+			cancel = stop
+			go func(ctx context.Context, check editorCheck, board *hashisolver.Puzzle) {
+				check.status = checkPuzzle(ctx, board)
+				select {
+				case results <- check:
+				case <-ctx.Done():
+				}
+			}(ctx, editorCheck{edit: e.edits}, e.puzzle.Clone())
+			e.unchecked = false
+		}
+		term.draw(e.lines())
+
+		select {
+		case ev, ok := <-events:
+			switch {
+			case !ok:
+				return
+			case ev.mouse != nil:
+				e.click(*ev.mouse)
+			case e.handle(ev.key):
+				return
+			}
+		case check := <-results:
+			if check.edit == e.edits {
+				e.status = check.status
+			}
+		}
+	}
+}
+
+// editorCheck is what the background check found out about the board after
+// a numbered edit
+type editorCheck struct {
+	edit   int
+	status string
+}
+
+// editor is the state of the puzzle editor: the board, the cursor on it and
+// what to show in the status bar
+type editor struct {
+	puzzle *hashisolver.Puzzle
+	x, y   int
+
+	// file and renderer are where and how the puzzle is saved
+	file     string
+	renderer hashisolver.Renderer
+
+	// edits counts the changes made, so a check of an older board can be
+	// told apart, and unchecked is set until the latest has been sent off
+	edits     int
+	unchecked bool
+	// saved is the number of edits when the puzzle was last saved, and
+	// quitting is set after q is pressed with edits unsaved
+	saved    int
+	quitting bool
+
+	// status is what the check found, and message the result of the last key
+	status  string
+	message string
+}
+
+// newEditor returns an editor for the puzzle with the cursor in the top left
+func newEditor(puzzle *hashisolver.Puzzle, file string, renderer hashisolver.Renderer) *editor {
+	return &editor{puzzle: puzzle, file: file, renderer: renderer, unchecked: true, status: "checking..."}
+}
+
+// handle acts on a key and reports whether the editor should close
+func (e *editor) handle(k key) bool {
+	quitting := e.quitting
+	e.quitting = false
+	e.message = ""
+
+	switch k {
+	case keyUp, 'k':
+		e.move(0, -1)
+	case keyDown, 'j':
+		e.move(0, 1)
+	case keyLeft, 'h':
+		e.move(-1, 0)
+	case keyRight, 'l':
+		e.move(1, 0)
+	case '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		e.setIsland(int(k - '0'))
+	case '+', '=':
+		e.setIsland(e.clue() + 1)
+	case '-':
+		if clue := e.clue(); clue > 1 {
+			e.setIsland(clue - 1)
+		}
+	case ' ', 'x', keyBackspace, keyDelete:
+		if e.clue() > 0 {
+			e.edit(e.puzzle.RemoveIsland(e.x, e.y))
+		}
+	case 's':
+		e.save()
+	case keyInterrupt:
+		return true
+	case 'q':
+		if quitting || e.saved == e.edits {
+			return true
+		}
+		e.quitting = true
+		e.message = "there are unsaved changes: press q again to quit without saving"
+	}
+	return false
+}
+
+// click moves the cursor to the cell the mouse button went down on
+func (e *editor) click(m mouseEvent) {
+	if x, y, ok := boardCell(m, e.puzzle.Rows, e.puzzle.Cols); ok && !m.release {
+		e.x, e.y = x, y
+	}
+}
+
+// move moves the cursor, keeping it on the board
+func (e *editor) move(dx, dy int) {
+	if x := e.x + dx; x >= 0 && x < e.puzzle.Cols {
+		e.x = x
+	}
+	if y := e.y + dy; y >= 0 && y < e.puzzle.Rows {
+		e.y = y
+	}
+}
+
+// clue returns the clue under the cursor, or 0 for water
+func (e *editor) clue() int {
+	return e.puzzle.At(e.x, e.y).Value()
+}
+
+// setIsland puts an island with the clue under the cursor
+func (e *editor) setIsland(value int) {
+	e.edit(e.puzzle.SetIsland(e.x, e.y, value))
+}
+
+// edit finishes a change to the islands, or shows why it was refused
+func (e *editor) edit(err error) {
+	if err != nil {
+		e.message = strings.TrimPrefix(err.Error(), hashisolver.ErrIllegalIsland.Error()+": ")
+		return
+	}
+	e.puzzle.RecomputeNeighbors()
+	e.edits++
+	e.unchecked = true
+	e.status = "checking..."
+}
+
+// save writes the puzzle to the editor's file
+func (e *editor) save() {
+	if e.file == "" {
+		e.message = "there is no file to save to: start the editor with -save"
+		return
+	}
+	var buf bytes.Buffer
+	if err := e.renderer(&buf, e.puzzle); err != nil {
+		e.message = fmt.Sprintf("saving failed: %v", err)
+		return
+	}
+	if err := os.WriteFile(e.file, buf.Bytes(), 0o644); err != nil {
+		e.message = fmt.Sprintf("saving failed: %v", err)
+		return
+	}
+	e.saved = e.edits
+	e.message = "saved to " + e.file
+}
+
+// lines draws the board with the cursor on it, then the status bar and help
+func (e *editor) lines() []string {
+	lines := []string{}
+	for y := 0; y < e.puzzle.Rows; y++ {
+		cells := make([]string, e.puzzle.Cols)
+		for x := range cells {
+			cells[x] = "."
+			if value := e.puzzle.At(x, y).Value(); value > 9 {
+				cells[x] = string(rune('a' + value - 10))
+			} else if value > 0 {
+				cells[x] = fmt.Sprint(value)
+			}
+			if x == e.x && y == e.y {
+				cells[x] = highlight(cells[x])
+			}
+		}
+		lines = append(lines, " "+strings.Join(cells, " "))
+	}
+
+	position := fmt.Sprintf("(%d,%d)", e.x, e.y)
+	if e.saved != e.edits {
+		position += " modified"
+	}
+	return append(lines, "", position+"  "+e.status, e.message, editorKeys)
+}
+
+// checkPuzzle says whether the logical rules alone solve the puzzle, which
+// proves it has exactly one solution, and otherwise why they don't
+func checkPuzzle(ctx context.Context, puzzle *hashisolver.Puzzle) string {
+	if puzzle.FullBridges == 0 {
+		return "no islands yet"
+	}
+	_, err := hashisolver.SolveWithStats(ctx, puzzle, hashisolver.WithLogicOnly(true))
+	switch {
+	case err == nil:
+		return "solvable by logic alone, with a unique solution"
+	case errors.Is(err, hashisolver.ErrGuessRequired):
+		return "logic alone stalls: it needs guessing and may have several solutions"
+	case errors.Is(err, hashisolver.ErrNoSolution):
+		return "no solution"
+	}
+	return err.Error()
+}
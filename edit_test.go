@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"hashi/hashisolver"
+)
+
+// TestDecodeEvents tests that the escape sequences for special keys and the
+// mouse are told apart from the characters typed around them
+func TestDecodeEvents(t *testing.T) {
+	got := decodeEvents([]byte("a\x1b[A\x1bOD2\x7f\x1b[3~\x1b\r\x03\x1b[<0;4;2M\x1b[<32;6;2M\x1b[<0;6;3m\x1b[<64;1;1Mq"))
+	want := []event{{key: 'a'}, {key: keyUp}, {key: keyLeft}, {key: '2'}, {key: keyBackspace}, {key: keyDelete},
+		{key: keyEscape}, {key: keyEnter}, {key: keyInterrupt},
+		{mouse: &mouseEvent{x: 3, y: 1}}, {mouse: &mouseEvent{x: 5, y: 2, release: true}}, {key: 'q'}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected events %v, got %v", want, got)
+	}
+}
+
+// TestEditor tests placing and removing islands with the editor's keys,
+// that a refused island is explained, and that saving writes the puzzle
+func TestEditor(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "edited.txt")
+	renderer, err := hashisolver.LookupRenderer("puzzle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	puzzle := hashisolver.NewPuzzle([][]int{{0, 0, 0}, {0, 0, 0}, {0, 0, 0}}, hashisolver.DefaultRuleSet)
+	e := newEditor(puzzle, file, renderer)
+
+	press := func(keys ...key) {
+		for _, k := range keys {
+			if e.handle(k) {
+				t.Fatalf("Expected %v not to close the editor", k)
+			}
+		}
+	}
+	press('1', keyRight, '1')
+	if !strings.Contains(e.message, "beside the island at (0,0)") {
+		t.Errorf("Expected the island beside another to be refused, got %q", e.message)
+	}
+	press(keyRight, 'l', '3', keyDown, 'j', '+', '+', keyLeft, 'h', '1', 'x', keyDown)
+
+	if lines := hashisolver.MapLines(e.puzzle); strings.Join(lines, "\n") != "1 3\n   \n  2" {
+		t.Errorf("Expected the islands placed, got\n%s", strings.Join(lines, "\n"))
+	}
+	if status := checkPuzzle(context.Background(), e.puzzle.Clone()); !strings.Contains(status, "unique") {
+		t.Errorf("Expected the puzzle to be solvable by logic, got %q", status)
+	}
+
+	e.click(mouseEvent{x: 3, y: 1})
+	if e.x != 1 || e.y != 1 {
+		t.Errorf("Expected a click to move the cursor to (1,1), got (%d,%d)", e.x, e.y)
+	}
+
+	if e.handle('q') || !strings.Contains(e.message, "unsaved") {
+		t.Fatalf("Expected q to warn about unsaved changes, got %q", e.message)
+	}
+	e.handle('s')
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("Expected the puzzle to be saved: %v", err)
+	}
+	if string(data) != "1.3\n...\n..2\n" {
+		t.Errorf("Expected the saved puzzle, got\n%s", data)
+	}
+	if !e.handle('q') {
+		t.Error("Expected q to close the editor once saved")
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"hashi/hashisolver"
+)
+
+// TestEditIslands tests that a puzzle can be built island by island, that
+// islands beside each other or off the board are refused, and that copies
+// made before an edit keep their clues
+func TestEditIslands(t *testing.T) {
+	puzzle := hashisolver.NewPuzzle([][]int{{0, 0, 0}, {0, 0, 0}, {0, 0, 0}}, hashisolver.DefaultRuleSet)
+	for _, island := range [][3]int{{0, 0, 2}, {2, 0, 3}, {0, 2, 1}, {2, 2, 2}} {
+		if err := puzzle.SetIsland(island[0], island[1], island[2]); err != nil {
+			t.Fatalf("Failed to set an island at (%d,%d): %v", island[0], island[1], err)
+		}
+	}
+	puzzle.RecomputeNeighbors()
+	if lines := hashisolver.MapLines(puzzle); strings.Join(lines, "\n") != "2 3\n   \n1 2" || puzzle.FullBridges != 8 {
+		t.Errorf("Expected the islands on the board, got\n%s", strings.Join(lines, "\n"))
+	}
+	before := puzzle.Clone()
+
+	if _, err := hashisolver.FindSolution(context.Background(), puzzle); err != nil {
+		t.Fatalf("Expected the puzzle built to be solvable, got %v", err)
+	}
+
+	for _, c := range []struct {
+		x, y, value int
+		want        string
+	}{
+		{1, 0, 2, "beside the island at (0,0)"},
+		{3, 0, 2, "off the 3x3 board"},
+		{1, 1, 9, "exceeds the maximum island value of 8"},
+		{1, 1, 0, "less than 1"},
+	} {
+		err := puzzle.SetIsland(c.x, c.y, c.value)
+		if !errors.Is(err, hashisolver.ErrIllegalIsland) || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("Expected an island of %d at (%d,%d) to fail with %q, got %v", c.value, c.x, c.y, c.want, err)
+		}
+	}
+	if err := puzzle.RemoveIsland(1, 1); !errors.Is(err, hashisolver.ErrIllegalIsland) {
+		t.Errorf("Expected removing water to fail, got %v", err)
+	}
+
+	if err := puzzle.RemoveIsland(2, 2); err != nil {
+		t.Fatalf("Failed to remove an island: %v", err)
+	}
+	if err := puzzle.SetIsland(0, 0, 1); err != nil {
+		t.Fatalf("Failed to change a clue: %v", err)
+	}
+	if err := puzzle.SetIsland(2, 0, 2); err != nil {
+		t.Fatalf("Failed to change a clue: %v", err)
+	}
+	puzzle.RecomputeNeighbors()
+	if err := puzzle.AddBridge(hashisolver.Position{X: 2, Y: 0}, hashisolver.Position{X: 0, Y: 0}); err != nil {
+		t.Fatalf("Failed to add a bridge: %v", err)
+	}
+	if lines := hashisolver.MapLines(puzzle); strings.Join(lines, "\n") != "1-2\n   \n1  " || puzzle.FullBridges != 4 {
+		t.Errorf("Expected the edited islands on the board, got\n%s", strings.Join(lines, "\n"))
+	}
+	if lines := hashisolver.MapLines(before); strings.Join(lines, "\n") != "2 3\n   \n1 2" {
+		t.Errorf("Expected the copy to keep its clues, got\n%s", strings.Join(lines, "\n"))
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"hashi/hashisolver"
+)
+
+// TestEngines tests that every registered engine solves a sample and lists
+// the same solutions, and that a registered test double is used in their place
+func TestEngines(t *testing.T) {
+	sample := hashisolver.Samples()[0]
+	for _, name := range []string{"", hashisolver.EngineHeuristic, hashisolver.EngineSAT, hashisolver.EngineDLX} {
+		engine, err := hashisolver.LookupEngine(name)
+		if err != nil {
+			t.Fatalf("Failed to look up engine %q: %v", name, err)
+		}
+		puzzle, err := sample.Puzzle()
+		if err != nil {
+			t.Fatalf("Failed to parse sample %s: %v", sample.Name, err)
+		}
+		result, err := engine.Solve(context.Background(), puzzle.Clone(), hashisolver.NewOptions())
+		if err != nil {
+			t.Fatalf("Failed to solve sample %s with engine %q: %v", sample.Name, name, err)
+		}
+		if violations := hashisolver.Verify(puzzle, result.Puzzle); len(violations) > 0 {
+			t.Errorf("Expected a valid solution from engine %q, got %v", name, violations)
+		}
+
+		enumerator, ok := engine.(hashisolver.Enumerator)
+		if !ok {
+			t.Fatalf("Expected engine %q to list solutions", name)
+		}
+		solutions, err := enumerator.SolveAll(context.Background(), puzzle, hashisolver.NewOptions(), 0)
+		if err != nil || len(solutions) != 1 {
+			t.Errorf("Expected the one solution of sample %s from engine %q, got %d and %v", sample.Name, name, len(solutions), err)
+		}
+	}
+
+	if _, err := hashisolver.LookupEngine("cp"); err == nil {
+		t.Error("Expected an error for an unknown engine")
+	}
+
+	errDouble := errors.New("solved by the test double")
+	hashisolver.RegisterEngine("double", hashisolver.SolverFunc(func(ctx context.Context, puzzle *hashisolver.Puzzle, options hashisolver.Options) (*hashisolver.SolveResult, error) {
+		return &hashisolver.SolveResult{Puzzle: puzzle}, errDouble
+	}))
+	puzzle, err := sample.Puzzle()
+	if err != nil {
+		t.Fatalf("Failed to parse sample %s: %v", sample.Name, err)
+	}
+	if _, err := hashisolver.SolveWithStats(context.Background(), puzzle, hashisolver.WithEngine("double")); !errors.Is(err, errDouble) {
+		t.Errorf("Expected the registered engine to solve the puzzle, got %v", err)
+	}
+}
+
+// TestEnginesListWithTimeout tests that listing solutions and counting them
+// give up with ErrTimeout once out of time, and that every engine lists
+// solutions as usual within the timeout
+func TestEnginesListWithTimeout(t *testing.T) {
+	puzzle, err := hashisolver.Samples()[0].Puzzle()
+	if err != nil {
+		t.Fatalf("Failed to parse sample: %v", err)
+	}
+	// The other engines check the time too seldom to run out on a sample
+	if _, err := hashisolver.FindSolutions(context.Background(), puzzle, 0, hashisolver.WithTimeout(time.Nanosecond)); !errors.Is(err, hashisolver.ErrTimeout) {
+		t.Errorf("Expected ErrTimeout listing solutions, got %v", err)
+	}
+	for _, name := range []string{hashisolver.EngineHeuristic, hashisolver.EngineSAT, hashisolver.EngineDLX} {
+		solutions, err := hashisolver.FindSolutions(context.Background(), puzzle, 0, hashisolver.WithEngine(name), hashisolver.WithTimeout(time.Minute))
+		if err != nil || len(solutions) != 1 {
+			t.Errorf("Expected the one solution within the timeout from engine %q, got %d and %v", name, len(solutions), err)
+		}
+	}
+
+	if _, err := hashisolver.CountSolutionsContext(context.Background(), puzzle, 0, hashisolver.WithTimeout(time.Nanosecond)); !errors.Is(err, hashisolver.ErrTimeout) {
+		t.Errorf("Expected ErrTimeout counting solutions, got %v", err)
+	}
+	if count, err := hashisolver.CountSolutionsContext(context.Background(), puzzle, 0, hashisolver.WithTimeout(time.Minute)); err != nil || count != 1 {
+		t.Errorf("Expected 1 solution counted within the timeout, got %d and %v", count, err)
+	}
+}
+
+// TestEnginesKeepCallersRules tests that solving or listing solutions under
+// another rule set leaves the caller's puzzle with its own rules
+func TestEnginesKeepCallersRules(t *testing.T) {
+	puzzle, err := hashisolver.Samples()[0].Puzzle()
+	if err != nil {
+		t.Fatalf("Failed to parse sample: %v", err)
+	}
+	rules := puzzle.Rules
+	options := hashisolver.NewOptions(hashisolver.WithRuleSet(hashisolver.TripleBridgeRuleSet))
+	for _, name := range []string{hashisolver.EngineHeuristic, hashisolver.EngineSAT, hashisolver.EngineDLX} {
+		engine, err := hashisolver.LookupEngine(name)
+		if err != nil {
+			t.Fatalf("Failed to look up engine %q: %v", name, err)
+		}
+		clone := puzzle.Clone()
+		if _, err := engine.Solve(context.Background(), clone, options); err != nil || clone.Rules != rules {
+			t.Errorf("Expected engine %q to solve under the options' rules alone, got %+v and %v", name, clone.Rules, err)
+		}
+		if _, err := engine.(hashisolver.Enumerator).SolveAll(context.Background(), puzzle, options, 0); err != nil || puzzle.Rules != rules {
+			t.Errorf("Expected engine %q to list solutions under the options' rules alone, got %+v and %v", name, puzzle.Rules, err)
+		}
+	}
+}
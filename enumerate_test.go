@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"hashi/hashisolver"
+)
+
+// TestSolveAll tests enumerating and counting the solutions of an ambiguous puzzle
+func TestSolveAll(t *testing.T) {
+	// The four islands form a cycle that can be closed on either the left or the bottom
+	p, err := hashisolver.Parse(strings.NewReader("2..3\n....\n1..2\n"), hashisolver.DefaultRuleSet)
+	if err != nil {
+		t.Fatalf("Failed to parse puzzle: %v", err)
+	}
+
+	solutions, err := hashisolver.SolveAll(p, 0, false)
+	if err != nil {
+		t.Fatalf("SolveAll failed: %v", err)
+	}
+	if len(solutions) != 2 {
+		t.Fatalf("Expected 2 solutions, got %d", len(solutions))
+	}
+	for _, solution := range solutions {
+		if !solution.IsComplete() {
+			t.Errorf("SolveAll returned an incomplete puzzle")
+		}
+	}
+	if solutions[0].At(0, 0).DownBridges == solutions[1].At(0, 0).DownBridges {
+		t.Errorf("Expected the two solutions to differ on the left edge")
+	}
+
+	if count := hashisolver.CountSolutions(p, 0); count != 2 {
+		t.Errorf("Expected CountSolutions to find 2, got %d", count)
+	}
+	if count := hashisolver.CountSolutions(p, 1); count != 1 {
+		t.Errorf("Expected CountSolutions to stop at the limit, got %d", count)
+	}
+
+	if _, err := hashisolver.SolveUnique(p, false); !errors.Is(err, hashisolver.ErrMultipleSolutions) {
+		t.Errorf("Expected ErrMultipleSolutions, got %v", err)
+	}
+
+	// The input puzzle is not modified by the search
+	if p.At(0, 0).TotalBridges != 0 {
+		t.Errorf("Expected SolveAll to leave the puzzle untouched")
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"hashi/hashisolver"
+)
+
+// TestSentinelErrors tests that failures can be told apart with errors.Is
+func TestSentinelErrors(t *testing.T) {
+	// The 1s can only pair off, which leaves two separate groups
+	_, err := hashisolver.Solve(strings.NewReader("1.1\n...\n1.1\n"), false)
+	if !errors.Is(err, hashisolver.ErrNoSolution) {
+		t.Errorf("Expected ErrNoSolution, got %v", err)
+	}
+
+	// Clues that no bridges could meet are caught before solving
+	for _, tc := range []struct {
+		input string
+		want  string
+	}{
+		{"3..\n...\n..1\n", "the 3 at (0,0) has 0 neighbor(s)"},
+		{"5.2\n...\n3..\n", "the 5 at (0,0) has 2 neighbor(s), which can take at most 4"},
+		{"2.3\n...\n2.2\n", "the clues add up to 9"},
+		{".2.\n2.8\n.2.\n", "the 8 at (2,1) needs bridges in all four directions but has 1 neighbor(s)"},
+	} {
+		_, err = hashisolver.Solve(strings.NewReader(tc.input), false)
+		if !errors.Is(err, hashisolver.ErrInvalidPuzzle) || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("Expected ErrInvalidPuzzle mentioning %q for %q, got %v", tc.want, tc.input, err)
+		}
+	}
+
+	_, err = hashisolver.Solve(strings.NewReader(""), false)
+	if !errors.Is(err, hashisolver.ErrInvalidPuzzle) {
+		t.Errorf("Expected ErrInvalidPuzzle for empty input, got %v", err)
+	}
+
+	_, err = hashisolver.ParseText(strings.NewReader("2.2\n.?.\n"), hashisolver.DefaultRuleSet,
+		hashisolver.ParseOptions{Strict: true})
+	if !errors.Is(err, hashisolver.ErrInvalidPuzzle) {
+		t.Errorf("Expected ErrInvalidPuzzle for a parse error, got %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	"hashi/hashisolver"
+)
+
+// runEstimate prints a quick difficulty estimate for each puzzle file, easiest first
+func runEstimate(args []string) {
+	var input inputFlags
+
+	fs := flag.NewFlagSet("estimate", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s estimate [flags] [puzzle files...]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	input.register(fs)
+	parseFlags(fs, args)
+
+	files := fs.Args()
+	if len(files) == 0 {
+		files = []string{input.inputFile}
+	}
+
+	type rated struct {
+		name     string
+		estimate hashisolver.DifficultyEstimate
+	}
+	results := []rated{}
+	failed := false
+	for _, name := range files {
+		puzzle, err := input.loadPuzzle(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+			failed = true
+			continue
+		}
+		results = append(results, rated{name, hashisolver.EstimateDifficulty(puzzle)})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].estimate.Score < results[j].estimate.Score
+	})
+	for _, r := range results {
+		name := r.name
+		if name == "" {
+			name = "-"
+		}
+		fmt.Printf("%5.1f  %-6s  %s\n", r.estimate.Score, r.estimate.Grade(), name)
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
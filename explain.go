@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"hashi/hashisolver"
+)
+
+// runExplain says why there must or can't be a bridge between two islands
+func runExplain(args []string) {
+	var input inputFlags
+	var from, to string
+
+	fs := flag.NewFlagSet("explain", flag.ExitOnError)
+	input.register(fs)
+	fs.StringVar(&from, "from", "", "Island at one end of the bridge, as x,y or a label like A1")
+	fs.StringVar(&to, "to", "", "Island at the other end of the bridge, as x,y or a label like C1")
+	parseFlags(fs, args)
+	input.useArgs(fs)
+
+	fromPos, err := hashisolver.ParsePosition(from)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: -from: %v\n", err)
+		os.Exit(1)
+	}
+	toPos, err := hashisolver.ParsePosition(to)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: -to: %v\n", err)
+		os.Exit(1)
+	}
+
+	puzzle := input.readPuzzle()
+
+	explanation, err := puzzle.ExplainBridge(fromPos, toPos)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error explaining bridge: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Print(explanation)
+}
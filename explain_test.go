@@ -0,0 +1,68 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"hashi/hashisolver"
+)
+
+// TestExplainBridge tests each way a bridge can be explained
+func TestExplainBridge(t *testing.T) {
+	pos := func(x, y int) hashisolver.Position {
+		return hashisolver.Position{X: x, Y: y}
+	}
+
+	p, err := hashisolver.Parse(strings.NewReader("3.5...4\n...2.4.\n.......\n4.5..5.\n.......\n...2.4.\n3.5.3.3\n"), hashisolver.DefaultRuleSet)
+	if err != nil {
+		t.Fatalf("Failed to parse puzzle: %v", err)
+	}
+
+	// The 3 in the corner has to send bridges both ways
+	e, err := p.ExplainBridge(pos(2, 0), pos(0, 0))
+	if err != nil {
+		t.Fatalf("Failed to explain bridge: %v", err)
+	}
+	if e.Verdict != hashisolver.VerdictRequired || len(e.Steps) != 2 || !strings.Contains(e.Steps[1].Reason, "at least 1 must go to (2,0)") {
+		t.Errorf("Unexpected explanation:\n%s", e)
+	}
+
+	// The bridge from the 2 down to the other 2 would cross the middle row
+	e, err = p.ExplainBridge(pos(3, 1), pos(3, 5))
+	if err != nil {
+		t.Fatalf("Failed to explain bridge: %v", err)
+	}
+	if e.Verdict != hashisolver.VerdictImpossible || !strings.Contains(e.Summary, "would cross the bridge between (2,3) and (5,3)") {
+		t.Errorf("Unexpected explanation:\n%s", e)
+	}
+
+	// Here the rules only settle these bridges once the opposite is assumed
+	p, err = hashisolver.Parse(strings.NewReader("......1\n1...1..\n.......\n3...5.3\n..1....\n.......\n1.3.3.2\n"), hashisolver.DefaultRuleSet)
+	if err != nil {
+		t.Fatalf("Failed to parse puzzle: %v", err)
+	}
+	e, err = p.ExplainBridge(pos(0, 6), pos(2, 6))
+	if err != nil {
+		t.Fatalf("Failed to explain bridge: %v", err)
+	}
+	if e.Verdict != hashisolver.VerdictRequired || !strings.HasPrefix(e.Steps[0].Reason, "Assume there is no bridge") {
+		t.Errorf("Unexpected explanation:\n%s", e)
+	}
+	e, err = p.ExplainBridge(pos(0, 3), pos(0, 6))
+	if err != nil {
+		t.Fatalf("Failed to explain bridge: %v", err)
+	}
+	if e.Verdict != hashisolver.VerdictImpossible || !strings.Contains(e.Summary, "contradiction") {
+		t.Errorf("Unexpected explanation:\n%s", e)
+	}
+
+	// The cycle can be closed either way
+	p, _ = hashisolver.Parse(strings.NewReader("2..3\n....\n1..2\n"), hashisolver.DefaultRuleSet)
+	if e, err := p.ExplainBridge(pos(0, 2), pos(3, 2)); err != nil || e.Verdict != hashisolver.VerdictUndecided {
+		t.Errorf("Expected an undecided bridge, got %v (%v)", e, err)
+	}
+
+	if _, err := p.ExplainBridge(pos(0, 2), pos(3, 0)); err == nil {
+		t.Errorf("Expected an error for islands that aren't neighbors")
+	}
+}
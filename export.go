@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"hashi/hashisolver"
+)
+
+// runExport writes puzzles as a document for printing
+func runExport(args []string) {
+	var input inputFlags
+	var pdf, html, solutions bool
+	var paper, outputFile string
+
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	input.register(fs)
+	fs.BoolVar(&pdf, "pdf", false, "Write a PDF with a page for each puzzle")
+	fs.BoolVar(&html, "html", false, "Write a web page for playing the puzzle in a browser, checked against its solution")
+	fs.BoolVar(&solutions, "solutions", false, "Follow each puzzle with a page showing its solution")
+	fs.StringVar(&paper, "paper", "a4", "Paper size: a4 or letter")
+	fs.StringVar(&outputFile, "o", "", "File to write the document to (defaults to stdout)")
+	parseFlags(fs, args)
+	input.useArgs(fs)
+
+	if pdf == html {
+		fmt.Fprintln(os.Stderr, "Error: export needs one format to write: -pdf or -html")
+		os.Exit(2)
+	}
+	if html {
+		if solutions {
+			fmt.Fprintln(os.Stderr, "Error: -solutions only goes with -pdf")
+			os.Exit(2)
+		}
+		exportHTML(context.Background(), input.readPuzzles(), outputFile)
+		return
+	}
+	options, err := paperSize(paper)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+
+	pages, err := exportPages(context.Background(), input.readPuzzles(), solutions)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := writeFileOrStdout(outputFile, func(w io.Writer) error {
+		return hashisolver.WritePDF(w, pages, options)
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// exportHTML writes the one puzzle given as a web page, with a hash of its
+// solution for checking the board
+func exportHTML(ctx context.Context, puzzles []*hashisolver.Puzzle, outputFile string) {
+	if len(puzzles) != 1 {
+		fmt.Fprintf(os.Stderr, "Error: -html writes a single puzzle, got %d\n", len(puzzles))
+		os.Exit(2)
+	}
+	solution, err := hashisolver.FindSolution(ctx, puzzles[0].Clues())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := writeFileOrStdout(outputFile, func(w io.Writer) error {
+		return hashisolver.WriteHTML(w, puzzles[0], solution)
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// paperSize returns the PDF options for a paper size by name
+func paperSize(name string) (hashisolver.PDFOptions, error) {
+	switch name {
+	case "a4":
+		return hashisolver.A4PDFOptions, nil
+	case "letter":
+		return hashisolver.LetterPDFOptions, nil
+	}
+	return hashisolver.PDFOptions{}, fmt.Errorf("unknown paper size %q (expected a4 or letter)", name)
+}
+
+// exportPages lays out a page for each puzzle, headed with its title or its
+// number, followed by a page with its solution if solutions is set
+func exportPages(ctx context.Context, puzzles []*hashisolver.Puzzle, solutions bool) ([]hashisolver.PDFPage, error) {
+	pages := []hashisolver.PDFPage{}
+	for i, puzzle := range puzzles {
+		heading := puzzle.Metadata.Title
+		if heading == "" {
+			heading = fmt.Sprintf("Puzzle %d", i+1)
+		}
+		caption := fmt.Sprintf("%dx%d", puzzle.Cols, puzzle.Rows)
+		if puzzle.Metadata.Difficulty != "" {
+			caption += ", " + puzzle.Metadata.Difficulty
+		}
+		clues := puzzle.Clues()
+		pages = append(pages, hashisolver.PDFPage{Heading: heading, Boards: []hashisolver.PDFBoard{{Caption: caption, Puzzle: clues}}})
+		if !solutions {
+			continue
+		}
+
+		solution, err := hashisolver.FindSolution(ctx, clues)
+		if err != nil {
+			return nil, fmt.Errorf("solving %s: %v", heading, err)
+		}
+		solved, err := clues.Apply(solution)
+		if err != nil {
+			return nil, err
+		}
+		pages = append(pages, hashisolver.PDFPage{Heading: "Solution: " + heading, Boards: []hashisolver.PDFBoard{{Caption: caption, Puzzle: solved}}})
+	}
+	return pages, nil
+}
+
+// writeFileOrStdout calls write with the named file, created afresh, or with
+// stdout if the name is empty
+func writeFileOrStdout(name string, write func(w io.Writer) error) error {
+	if name == "" {
+		return write(os.Stdout)
+	}
+	file, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"hashi/hashisolver"
+)
+
+// TestExportPDF tests that puzzles and their solutions are laid out a page
+// each, and that the PDF's cross-reference table points at its objects
+func TestExportPDF(t *testing.T) {
+	puzzles := []*hashisolver.Puzzle{}
+	for _, text := range []string{"title: Pair\n2.2\n...\n", "1.3\n...\n..2\n"} {
+		puzzle, err := hashisolver.Parse(strings.NewReader(text), hashisolver.DefaultRuleSet)
+		if err != nil {
+			t.Fatalf("Failed to parse puzzle: %v", err)
+		}
+		puzzles = append(puzzles, puzzle)
+	}
+	pages, err := exportPages(context.Background(), puzzles, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	headings := []string{}
+	for _, page := range pages {
+		headings = append(headings, page.Heading)
+	}
+	if got := strings.Join(headings, "|"); got != "Pair|Solution: Pair|Puzzle 2|Solution: Puzzle 2" {
+		t.Errorf("Unexpected page headings %q", got)
+	}
+	if solved := pages[3].Boards[0].Puzzle; !solved.IsComplete() || pages[2].Boards[0].Puzzle.BuiltBridges != 0 {
+		t.Error("Expected the puzzle pages without bridges and the solution pages solved")
+	}
+
+	var buf bytes.Buffer
+	if err := hashisolver.WritePDF(&buf, pages, hashisolver.A4PDFOptions); err != nil {
+		t.Fatal(err)
+	}
+	pdf := buf.String()
+	if !strings.HasPrefix(pdf, "%PDF-1.4\n") || !strings.HasSuffix(pdf, "%%EOF\n") || !strings.Contains(pdf, "/Count 4") {
+		t.Fatalf("Expected a PDF of 4 pages, got\n%.300s", pdf)
+	}
+
+	xref := regexp.MustCompile(`(\d{10}) 00000 n`).FindAllStringSubmatch(pdf, -1)
+	if len(xref) != 3+2*len(pages) {
+		t.Fatalf("Expected %d objects in the cross-reference table, found %d", 3+2*len(pages), len(xref))
+	}
+	for i, entry := range xref {
+		offset, _ := strconv.Atoi(entry[1])
+		if want := fmt.Sprintf("%d 0 obj\n", i+1); !strings.HasPrefix(pdf[offset:], want) {
+			t.Errorf("Expected object %d at offset %d, found %q", i+1, offset, pdf[offset:offset+10])
+		}
+	}
+	start := strings.LastIndex(pdf, "startxref\n") + len("startxref\n")
+	if offset, _ := strconv.Atoi(strings.Fields(pdf[start:])[0]); !strings.HasPrefix(pdf[offset:], "xref\n") {
+		t.Errorf("Expected startxref to point at the table, found %q", pdf[offset:offset+10])
+	}
+
+	if _, err := paperSize("a5"); err == nil {
+		t.Error("Expected an unknown paper size to be refused")
+	}
+}
+
+// TestExportHTML tests that the web page carries the islands and a hash of
+// the solution's bridges rather than the bridges themselves
+func TestExportHTML(t *testing.T) {
+	puzzle, err := hashisolver.Parse(strings.NewReader("title: <Pair>\n2.3\n...\n..1\n"), hashisolver.DefaultRuleSet)
+	if err != nil {
+		t.Fatalf("Failed to parse puzzle: %v", err)
+	}
+	solution, err := hashisolver.FindSolution(context.Background(), puzzle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := hashisolver.WriteHTML(&buf, puzzle, solution); err != nil {
+		t.Fatal(err)
+	}
+	page := buf.String()
+
+	hash := fmt.Sprintf("%x", sha256.Sum256([]byte("0,0-2,0:2;2,0-2,2:1")))
+	for _, want := range []string{`"islands":[{"x":0,"y":0,"value":2},{"x":2,"y":0,"value":3},{"x":2,"y":2,"value":1}]`, `"hash":"` + hash + `"`, "<h1>&lt;Pair&gt;</h1>"} {
+		if !strings.Contains(page, want) {
+			t.Errorf("Expected the page to contain %s", want)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"time"
+
+	"hashi/hashisolver"
+)
+
+// runGen generates random puzzles and prints them
+func runGen(args []string) {
+	var rows, cols int
+	var maxBridges int
+	var difficulty string
+	var attempts int
+	var allowMultiple bool
+	var logicOnly bool
+	var symmetry string
+	var seed int64
+	var density, highClues, lowClues float64
+	var cycles int
+	var solutionFile string
+	var count int
+	var output outputFlags
+
+	fs := flag.NewFlagSet("gen", flag.ExitOnError)
+	fs.IntVar(&rows, "rows", 7, "Number of rows on the board")
+	fs.IntVar(&cols, "cols", 0, "Number of columns on the board (defaults to -rows)")
+	fs.IntVar(&maxBridges, "max-bridges", 2, "Maximum bridges between a pair of islands")
+	fs.StringVar(&difficulty, "difficulty", "any", "Difficulty band to generate: easy, medium, hard or any")
+	fs.Float64Var(&density, "density", 0, "Fraction of cells that are islands (0 grows until no more bridges fit)")
+	fs.Float64Var(&highClues, "high-clues", 0, "Fraction of clues that are 6 or more (0 leaves it free)")
+	fs.Float64Var(&lowClues, "low-clues", 0, "Fraction of clues that are 1 or 2 (0 leaves it free)")
+	fs.IntVar(&cycles, "cycles", -1, "Number of cycles in the solution, 0 for a spanning tree (-1 allows any)")
+	fs.StringVar(&symmetry, "symmetry", "none", "Symmetry of the island layout: 180, 90, horizontal, vertical or none")
+	fs.BoolVar(&logicOnly, "logic-only", false, "Only generate puzzles the logical rules solve without guessing")
+	fs.BoolVar(&allowMultiple, "allow-multiple", false, "Skip the check that the puzzle has exactly one solution")
+	fs.Int64Var(&seed, "seed", 0, "Seed for the random choices, so a puzzle can be reproduced (0 picks one from the clock)")
+	fs.StringVar(&solutionFile, "solution", "", "Also write the solution as a bridge list to this file")
+	fs.IntVar(&attempts, "attempts", 1000, "Give up after trying this many candidate puzzles")
+	fs.IntVar(&count, "count", 1, "Generate this many different puzzles, separated by --- lines, skipping any that repeat one already printed, turned or flipped")
+	output.register(fs, "puzzle")
+	parseFlags(fs, args)
+	output.renderer()
+
+	if cols == 0 {
+		cols = rows
+	}
+	if count > 1 && (solutionFile != "" || output.format == "png" || output.format == "gif") {
+		fmt.Fprintln(os.Stderr, "Error: -count prints several puzzles, so it can't go with -solution or an image format")
+		os.Exit(2)
+	}
+	band, err := hashisolver.ParseDifficulty(difficulty)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	sym, err := hashisolver.ParseSymmetry(symmetry)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	topology := hashisolver.TopologyAny
+	switch {
+	case cycles == 0:
+		topology = hashisolver.TopologyTree
+	case cycles > 0:
+		topology = hashisolver.TopologyCycles
+	}
+
+	// Each puzzle gets a seed of its own, written in its metadata, so any one
+	// of them can be made again with -seed
+	var unique uniquePuzzles
+	var generated *hashisolver.Generated
+	for printed, repeats := 0, 0; printed < count; seed++ {
+		if repeats == maxDuplicates {
+			fmt.Fprintf(os.Stderr, "Error: only %d different puzzles turned up, with %d duplicates skipped\n", printed, unique.skipped)
+			os.Exit(1)
+		}
+		generated, err = hashisolver.Generate(hashisolver.GenerateOptions{
+			Rows:                   rows,
+			Cols:                   cols,
+			Rules:                  hashisolver.RuleSetForMaxBridges(maxBridges),
+			Difficulty:             band,
+			Density:                density,
+			HighClues:              highClues,
+			LowClues:               lowClues,
+			Topology:               topology,
+			Cycles:                 cycles,
+			Symmetry:               sym,
+			LogicOnly:              logicOnly,
+			AllowMultipleSolutions: allowMultiple,
+			Source:                 rand.NewSource(seed),
+			MaxAttempts:            attempts,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error generating puzzle: %v\n", err)
+			os.Exit(1)
+		}
+		if !unique.add(generated.Puzzle) {
+			repeats++
+			continue
+		}
+		repeats = 0
+
+		puzzle := generated.Puzzle
+		puzzle.Metadata.Difficulty = generated.Difficulty.String()
+		puzzle.Metadata.Extra = map[string]string{
+			"seed":     strconv.FormatInt(seed, 10),
+			"topology": "tree",
+		}
+		switch {
+		case generated.Cycles == 1:
+			puzzle.Metadata.Extra["topology"] = "1 cycle"
+		case generated.Cycles > 1:
+			puzzle.Metadata.Extra["topology"] = fmt.Sprintf("%d cycles", generated.Cycles)
+		}
+		if printed > 0 && output.format != "json" {
+			fmt.Println("---")
+		}
+		output.write(os.Stdout, puzzle, nil)
+		printed++
+	}
+	if unique.skipped > 0 {
+		fmt.Fprintf(os.Stderr, "Skipped %d duplicate puzzles\n", unique.skipped)
+	}
+
+	if solutionFile != "" {
+		if err := writeSolution(solutionFile, generated.Solution); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing solution: %v\n", err)
+			os.Exit(1)
+		}
+	}
+}
+
+// writeSolution writes the solution's bridge list to the named file
+func writeSolution(name string, solution *hashisolver.Puzzle) error {
+	file, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := hashisolver.WriteBridges(file, solution); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
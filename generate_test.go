@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"hashi/hashisolver"
+)
+
+// TestGenerate tests that generated puzzles are unique, land in the requested band and round trip through the writer
+func TestGenerate(t *testing.T) {
+	g, err := hashisolver.Generate(hashisolver.GenerateOptions{
+		Rows:       8,
+		Cols:       8,
+		Rules:      hashisolver.DefaultRuleSet,
+		Difficulty: hashisolver.DifficultyEasy,
+	})
+	if err != nil {
+		t.Fatalf("Failed to generate puzzle: %v", err)
+	}
+
+	if g.Puzzle.Rows != 8 || g.Puzzle.Cols != 8 {
+		t.Fatalf("Expected an 8x8 board, got %dx%d", g.Puzzle.Rows, g.Puzzle.Cols)
+	}
+	if rating, err := hashisolver.Rate(g.Puzzle); err != nil || rating != hashisolver.DifficultyEasy {
+		t.Errorf("Expected an easy puzzle, got %v (%v)", rating, err)
+	}
+	if n := hashisolver.CountSolutions(g.Puzzle, 2); n != 1 {
+		t.Errorf("Expected a unique solution, found %d", n)
+	}
+	if !g.Solution.IsComplete() {
+		t.Errorf("Expected the generated solution to be complete")
+	}
+
+	var buf bytes.Buffer
+	if err := hashisolver.WritePuzzle(&buf, g.Puzzle); err != nil {
+		t.Fatalf("Failed to write puzzle: %v", err)
+	}
+	p, err := hashisolver.Parse(&buf, hashisolver.DefaultRuleSet)
+	if err != nil {
+		t.Fatalf("Failed to parse written puzzle: %v", err)
+	}
+	for r := 0; r < p.Rows; r++ {
+		for c := 0; c < p.Cols; c++ {
+			if p.At(c, r).Value() != g.Puzzle.At(c, r).Value() {
+				t.Fatalf("Written puzzle differs at (%d,%d)", c, r)
+			}
+		}
+	}
+}
+
+// TestGenerateLogicOnly tests that logic only puzzles need no guessing and can't be asked to be hard
+func TestGenerateLogicOnly(t *testing.T) {
+	g, err := hashisolver.Generate(hashisolver.GenerateOptions{Rows: 7, Cols: 9, LogicOnly: true})
+	if err != nil {
+		t.Fatalf("Failed to generate puzzle: %v", err)
+	}
+	if _, err := hashisolver.SolveLogicOnly(g.Puzzle, false); err != nil {
+		t.Errorf("Expected logic alone to solve the puzzle, got %v", err)
+	}
+
+	_, err = hashisolver.Generate(hashisolver.GenerateOptions{Rows: 7, Cols: 7, LogicOnly: true, Difficulty: hashisolver.DifficultyHard})
+	if err == nil {
+		t.Errorf("Expected a hard logic only puzzle to be rejected")
+	}
+}
+
+// TestGenerateSymmetry tests that islands are placed symmetrically
+func TestGenerateSymmetry(t *testing.T) {
+	g, err := hashisolver.Generate(hashisolver.GenerateOptions{Rows: 9, Cols: 9, Symmetry: hashisolver.SymmetryRotate90})
+	if err != nil {
+		t.Fatalf("Failed to generate puzzle: %v", err)
+	}
+
+	p := g.Puzzle
+	for r := 0; r < p.Rows; r++ {
+		for c := 0; c < p.Cols; c++ {
+			island := p.At(c, r).Value() > 0
+			if turned := p.At(p.Rows-1-r, c).Value() > 0; island != turned {
+				t.Fatalf("Island layout is not symmetric at (%d,%d)", c, r)
+			}
+		}
+	}
+
+	if _, err := hashisolver.Generate(hashisolver.GenerateOptions{Rows: 7, Cols: 9, Symmetry: hashisolver.SymmetryRotate90}); err == nil {
+		t.Errorf("Expected 90 degree symmetry to need a square board")
+	}
+}
+
+// TestGenerateSeed tests that the same seed reproduces the same puzzle
+func TestGenerateSeed(t *testing.T) {
+	var written [2]bytes.Buffer
+	for i := range written {
+		g, err := hashisolver.Generate(hashisolver.GenerateOptions{
+			Rows:     9,
+			Cols:     9,
+			Symmetry: hashisolver.SymmetryRotate180,
+			Source:   rand.NewSource(2536),
+		})
+		if err != nil {
+			t.Fatalf("Failed to generate puzzle: %v", err)
+		}
+		hashisolver.WritePuzzle(&written[i], g.Puzzle)
+	}
+
+	if written[0].String() != written[1].String() {
+		t.Errorf("Expected the same puzzle from the same seed, got\n%s\nand\n%s", written[0].String(), written[1].String())
+	}
+}
+
+// TestGenerateTexture tests the island density and clue mix controls
+func TestGenerateTexture(t *testing.T) {
+	g, err := hashisolver.Generate(hashisolver.GenerateOptions{
+		Rows:     10,
+		Cols:     10,
+		Density:  0.2,
+		LowClues: 0.4,
+		Source:   rand.NewSource(2537),
+	})
+	if err != nil {
+		t.Fatalf("Failed to generate puzzle: %v", err)
+	}
+
+	islands, low := 0, 0
+	for i := range g.Puzzle.Cells {
+		node := &g.Puzzle.Cells[i]
+		if node.Value() > 0 {
+			islands++
+		}
+		if node.Value() == 1 || node.Value() == 2 {
+			low++
+		}
+	}
+	if islands < 10 || islands > 30 {
+		t.Errorf("Expected about 20 islands, got %d", islands)
+	}
+	if fraction := float64(low) / float64(islands); fraction < 0.3 || fraction > 0.5 {
+		t.Errorf("Expected about 40%% of clues to be 1 or 2, got %.0f%%", 100*fraction)
+	}
+}
+
+// TestGenerateTopology tests that the solution has the requested number of cycles
+func TestGenerateTopology(t *testing.T) {
+	for _, c := range []struct {
+		topology hashisolver.Topology
+		cycles   int
+	}{
+		{hashisolver.TopologyTree, 0},
+		{hashisolver.TopologyCycles, 2},
+	} {
+		g, err := hashisolver.Generate(hashisolver.GenerateOptions{
+			Rows:     8,
+			Cols:     8,
+			Topology: c.topology,
+			Cycles:   c.cycles,
+			Source:   rand.NewSource(2538),
+		})
+		if err != nil {
+			t.Fatalf("Failed to generate puzzle: %v", err)
+		}
+		if g.Cycles != c.cycles {
+			t.Errorf("Expected %d cycles to be reported, got %d", c.cycles, g.Cycles)
+		}
+
+		// The solution is connected, so it has one cycle per edge beyond a spanning tree
+		islands, edges := 0, 0
+		for i := range g.Solution.Cells {
+			node := &g.Solution.Cells[i]
+			if node.Value() > 0 {
+				islands++
+			}
+			if node.RightBridges > 0 {
+				edges++
+			}
+			if node.DownBridges > 0 {
+				edges++
+			}
+		}
+		if got := edges - islands + 1; got != c.cycles {
+			t.Errorf("Expected a solution with %d cycles, got %d", c.cycles, got)
+		}
+	}
+}
+
+// TestWriteBridges tests that the bridge list of a generated solution accounts for every clue
+func TestWriteBridges(t *testing.T) {
+	g, err := hashisolver.Generate(hashisolver.GenerateOptions{Rows: 7, Cols: 7, Source: rand.NewSource(2539)})
+	if err != nil {
+		t.Fatalf("Failed to generate puzzle: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := hashisolver.WriteBridges(&buf, g.Solution); err != nil {
+		t.Fatalf("Failed to write bridges: %v", err)
+	}
+
+	placed := map[[2]int]int{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var x1, y1, x2, y2, count int
+		if _, err := fmt.Sscanf(line, "%d,%d %d,%d %d", &x1, &y1, &x2, &y2, &count); err != nil {
+			t.Fatalf("Malformed bridge line %q: %v", line, err)
+		}
+		placed[[2]int{x1, y1}] += count
+		placed[[2]int{x2, y2}] += count
+	}
+
+	for i := range g.Puzzle.Cells {
+		node := &g.Puzzle.Cells[i]
+		if node.Value() > 0 && placed[[2]int{node.XPos(), node.YPos()}] != node.Value() {
+			t.Errorf("Island at (%d,%d) wants %d bridges, the list has %d",
+				node.XPos(), node.YPos(), node.Value(), placed[[2]int{node.XPos(), node.YPos()}])
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"hashi/hashisolver"
+)
+
+// TestGraphML tests that a solved board is written as well formed GraphML
+// with a node for each island and an edge for each pair joined by bridges
+func TestGraphML(t *testing.T) {
+	puzzle, err := hashisolver.Parse(strings.NewReader("2.4\n...\n1.3\n"), hashisolver.DefaultRuleSet)
+	if err != nil {
+		t.Fatalf("Failed to parse puzzle: %v", err)
+	}
+	solution, err := hashisolver.FindSolution(context.Background(), puzzle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	solved, err := puzzle.Apply(solution)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := hashisolver.WriteGraphML(&buf, solved); err != nil {
+		t.Fatal(err)
+	}
+
+	type data struct {
+		Key   string `xml:"key,attr"`
+		Value int    `xml:",chardata"`
+	}
+	var graphml struct {
+		Nodes []struct {
+			ID   string `xml:"id,attr"`
+			Data []data `xml:"data"`
+		} `xml:"graph>node"`
+		Edges []struct {
+			Source string `xml:"source,attr"`
+			Target string `xml:"target,attr"`
+			Data   []data `xml:"data"`
+		} `xml:"graph>edge"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &graphml); err != nil {
+		t.Fatalf("The GraphML isn't well formed: %v\n%s", err, buf.String())
+	}
+	if len(graphml.Nodes) != 4 || graphml.Nodes[1].ID != "2,0" || graphml.Nodes[1].Data[2] != (data{"clue", 4}) {
+		t.Errorf("Expected 4 islands with the 4 second, got %+v", graphml.Nodes)
+	}
+	total := 0
+	for _, edge := range graphml.Edges {
+		total += edge.Data[0].Value
+	}
+	if len(graphml.Edges) != len(solution.Bridges) || total != 5 {
+		t.Errorf("Expected the solution's %d bridges, 5 in all, got %+v", len(solution.Bridges), graphml.Edges)
+	}
+}
@@ -0,0 +1,78 @@
+// hashisolver/accessible.go
+package hashisolver
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// bridgeNames are the words for one, two and three bridges between a pair
+var bridgeNames = []string{"a single bridge", "a double bridge", "a triple bridge"}
+
+// WriteAccessible writes the board as sentences rather than a drawing, for
+// screen readers: its size, then each island in reading order with its row,
+// column and value, the bridges leaving it each way and the island at their
+// far end, and how many more bridges it needs if it isn't yet satisfied.
+// Rows and columns are counted from 1.
+func WriteAccessible(w io.Writer, puzzle *Puzzle) error {
+	out := bufio.NewWriter(w)
+	islands, bridges := 0, 0
+	for i := range puzzle.Cells {
+		if puzzle.Cells[i].Value() > 0 {
+			islands++
+		}
+	}
+	for _, b := range puzzle.Bridges() {
+		bridges += b.Count
+	}
+	fmt.Fprintf(out, "Board of %d columns and %d rows with %s and %s.\n",
+		puzzle.Cols, puzzle.Rows, plural(islands, "island"), plural(bridges, "bridge"))
+
+	for y := 0; y < puzzle.Rows; y++ {
+		for x := 0; x < puzzle.Cols; x++ {
+			node := puzzle.At(x, y)
+			value := node.Value()
+			if value <= 0 {
+				continue
+			}
+			links := []string{}
+			placed := 0
+			for dir := DirectionUp; dir <= DirectionRight; dir++ {
+				count := node.BridgesInDirection(dir)
+				if count <= 0 {
+					continue
+				}
+				placed += count
+				name := fmt.Sprintf("%d bridges", count)
+				if count <= len(bridgeNames) {
+					name = bridgeNames[count-1]
+				}
+				neighbor := node.GetNeighbor(dir)
+				links = append(links, fmt.Sprintf("%s with %s to the island at row %d column %d",
+					directionNames[dir], name, neighbor.YPos()+1, neighbor.XPos()+1))
+			}
+
+			fmt.Fprintf(out, "Island at row %d column %d, value %d, ", y+1, x+1, value)
+			if len(links) == 0 {
+				out.WriteString("not connected")
+			} else {
+				out.WriteString("connected " + strings.Join(links, ", and "))
+			}
+			if placed < value {
+				fmt.Fprintf(out, ", still needs %s", plural(value-placed, "bridge"))
+			}
+			out.WriteString(".\n")
+		}
+	}
+	return out.Flush()
+}
+
+// plural writes n and the noun, adding an s unless n is 1
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,61 @@
+// hashisolver/anytime.go
+package hashisolver
+
+import "sync"
+
+// Anytime keeps the best board a solve has reached, the one with the most
+// bridges placed without a contradiction being found, so it can be read at
+// any moment while the search carries on. The board may include guesses that
+// a later contradiction would undo.
+type Anytime struct {
+	mu   sync.Mutex
+	best *Puzzle
+	// placed is the number of bridges on best
+	placed int
+}
+
+// WithAnytime records the best board reached in anytime as the solve runs.
+// When the solve is stopped early by a timeout or its context, the result
+// holds that board instead of the one the logical rules reached.
+func WithAnytime(anytime *Anytime) Option {
+	return func(o *Options) {
+		o.Anytime = anytime
+	}
+}
+
+// Best returns a copy of the best board reached so far, or nil if the solve
+// hasn't started. It is safe to call from any goroutine.
+func (a *Anytime) Best() *Puzzle {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.best == nil {
+		return nil
+	}
+	return a.best.Clone()
+}
+
+// offer keeps a copy of puzzle, which has placed bridges on it, if that is
+// more than the best so far
+func (a *Anytime) offer(puzzle *Puzzle, placed int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.best == nil || placed > a.placed {
+		a.best = puzzle.Clone()
+		a.placed = placed
+	}
+}
+
+// observe offers the board to the anytime record, if one is kept. The solver
+// remembers the best count itself so most calls don't take the lock.
+func (s *solver) observe(puzzle *Puzzle) {
+	if s.anytime == nil {
+		return
+	}
+	placed := puzzle.PlacedBridges()
+	if s.observed && placed <= s.bestBridges {
+		return
+	}
+	s.observed = true
+	s.bestBridges = placed
+	s.anytime.offer(puzzle, placed)
+}
@@ -0,0 +1,17 @@
+// hashisolver/arena.go
+package hashisolver
+
+// newCells allocates the cells of the puzzle as one block of nodes, rather
+// than one allocation per cell, each knowing its place on the board
+func newCells(puzzle *Puzzle) []Node {
+	cells := make([]Node, puzzle.Rows*puzzle.Cols)
+	for i := range cells {
+		cells[i].index, cells[i].puzzle = int32(i), puzzle
+	}
+	return cells
+}
+
+// link makes the cell at (x,y) the node's neighbor in the given direction
+func (p *Puzzle) link(node *Node, direction, x, y int) {
+	p.grid.neighbors[node.index][direction] = int32(y*p.Cols+x) + 1
+}
@@ -0,0 +1,115 @@
+// hashisolver/bridges.go
+package hashisolver
+
+import "fmt"
+
+// planks returns how many bridges a board marker stands for, and whether it is vertical
+func planks(marker int) (count int, vertical bool) {
+	switch marker {
+	case -1:
+		return 1, true
+	case -2:
+		return 2, true
+	case -5:
+		return 3, true
+	case -3:
+		return 1, false
+	case -4:
+		return 2, false
+	case -6:
+		return 3, false
+	}
+	return 0, false
+}
+
+// placeBridges builds the bridges drawn between islands in values onto a newly
+// created puzzle. On error it returns the row and column of the offending cell.
+func placeBridges(puzzle *Puzzle, values [][]int) (int, int, error) {
+	at := func(i, j int) int {
+		if j < len(values[i]) {
+			return values[i][j]
+		}
+		return 0
+	}
+
+	// Remember which marker cells belong to a bridge, so stray ones can be reported
+	covered := make([][]bool, puzzle.Rows)
+	for i := range covered {
+		covered[i] = make([]bool, puzzle.Cols)
+	}
+
+	for i := 0; i < puzzle.Rows; i++ {
+		for j := 0; j < puzzle.Cols; j++ {
+			node := puzzle.At(j, i)
+			if node.Value() <= 0 {
+				continue
+			}
+
+			// Follow a bridge to the right
+			if j+1 < puzzle.Cols {
+				if count, vertical := planks(at(i, j+1)); count > 0 && !vertical {
+					marker := at(i, j+1)
+					k := j + 1
+					for k < puzzle.Cols && at(i, k) == marker {
+						covered[i][k] = true
+						k++
+					}
+					if k == puzzle.Cols || at(i, k) <= 0 {
+						return i, j + 1, fmt.Errorf("bridge does not reach an island")
+					}
+					if err := buildBridge(puzzle, node, DirectionRight, count); err != nil {
+						return i, j + 1, err
+					}
+				}
+			}
+
+			// Follow a bridge downwards
+			if i+1 < puzzle.Rows {
+				if count, vertical := planks(at(i+1, j)); count > 0 && vertical {
+					marker := at(i+1, j)
+					k := i + 1
+					for k < puzzle.Rows && at(k, j) == marker {
+						covered[k][j] = true
+						k++
+					}
+					if k == puzzle.Rows || at(k, j) <= 0 {
+						return i + 1, j, fmt.Errorf("bridge does not reach an island")
+					}
+					if err := buildBridge(puzzle, node, DirectionDown, count); err != nil {
+						return i + 1, j, err
+					}
+				}
+			}
+		}
+	}
+
+	for i := 0; i < puzzle.Rows; i++ {
+		for j := 0; j < puzzle.Cols; j++ {
+			if at(i, j) < 0 && !covered[i][j] {
+				return i, j, fmt.Errorf("bridge does not start at an island")
+			}
+		}
+	}
+
+	return 0, 0, nil
+}
+
+// buildBridge places count bridges from node in direction, checking that the
+// rules and both islands allow them
+func buildBridge(puzzle *Puzzle, node *Node, direction, count int) error {
+	neighbor := node.GetNeighbor(direction)
+
+	if count > puzzle.maxBridges() {
+		return fmt.Errorf("%d bridges exceed the limit of %d per pair", count, puzzle.maxBridges())
+	}
+	if node.TotalBridges+count > node.Value() || neighbor.TotalBridges+count > neighbor.Value() {
+		return fmt.Errorf("bridge gives an island more bridges than its value")
+	}
+
+	for k := 0; k < count; k++ {
+		if err := ConnectNodes(puzzle, node, neighbor, direction, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+// hashisolver/canonical.go
+package hashisolver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Canonical returns the puzzle's clues, without bridges, turned and flipped
+// into the one of its eight rotations and reflections whose key comes first,
+// so that every orientation of a puzzle has the same canonical form. The
+// rules and metadata are kept.
+func (p *Puzzle) Canonical() *Puzzle {
+	values, _ := canonicalClues(p)
+	canonical := NewPuzzle(values, p.Rules)
+	canonical.Metadata = p.Metadata
+	return canonical
+}
+
+// Hash returns a stable ID for the puzzle: the first 16 hex digits of the
+// SHA-256 of its canonical form's key, so that the same puzzle in any
+// orientation has the same hash and any change to its clues or its most
+// bridges per pair gives another. Bridges on the board and metadata are left
+// out.
+func (p *Puzzle) Hash() string {
+	_, key := canonicalClues(p)
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:8])
+}
+
+// canonicalClues returns the clues of the puzzle's canonical form, row by
+// row, and its key: the most bridges per pair, the size as columns x rows and
+// the clues in reading order, as in "2/3x2:2,0,2,0,0,0"
+func canonicalClues(p *Puzzle) ([][]int, string) {
+	var best [][]int
+	bestKey := ""
+	for _, t := range Transforms {
+		values := transformedClues(p, t)
+		key := cluesKey(p.maxBridges(), values)
+		if best == nil || key < bestKey {
+			best, bestKey = values, key
+		}
+	}
+	return best, bestKey
+}
+
+// cluesKey writes the key of a board of clues
+func cluesKey(maxBridges int, values [][]int) string {
+	var b strings.Builder
+	cols := 0
+	if len(values) > 0 {
+		cols = len(values[0])
+	}
+	fmt.Fprintf(&b, "%d/%dx%d:", maxBridges, cols, len(values))
+	for i, row := range values {
+		for j, value := range row {
+			if i > 0 || j > 0 {
+				b.WriteByte(',')
+			}
+			fmt.Fprint(&b, value)
+		}
+	}
+	return b.String()
+}
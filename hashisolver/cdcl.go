@@ -0,0 +1,343 @@
+// hashisolver/cdcl.go
+package hashisolver
+
+import "context"
+
+// satLit is a literal of the SAT solver: variable v is 2v, and its negation 2v+1
+type satLit int32
+
+// positive returns the literal that is true when variable v is
+func positive(v int) satLit {
+	return satLit(2 * v)
+}
+
+// not returns the negation of the literal
+func (l satLit) not() satLit {
+	return l ^ 1
+}
+
+// variable returns the variable the literal is of
+func (l satLit) variable() int {
+	return int(l >> 1)
+}
+
+// satSolver is a small conflict-driven clause learning SAT solver, with two
+// watched literals per clause, branching on the most active variable and
+// restarts. Clauses can be added between calls to solve, so a model can be
+// refuted and the solver asked again.
+type satSolver struct {
+	clauses [][]satLit
+	// watches holds, for each literal, the clauses that have it first or second
+	watches [][]int
+
+	// assign is 1 for a true variable, -1 for a false one and 0 if unassigned
+	assign []int8
+	level  []int
+	// reason is the clause that implied the variable, or -1 for a decision
+	reason []int
+	trail  []satLit
+	// limits is where each decision level starts in trail
+	limits []int
+	head   int
+
+	activity []float64
+	bump     float64
+	// phase is the value each variable last had, which decisions reuse
+	phase []bool
+	seen  []bool
+
+	// unsat is set once the clauses are known to have no model
+	unsat bool
+
+	decisions int
+	conflicts int
+	maxLevel  int
+}
+
+// newVar adds a variable, returning its number
+func (s *satSolver) newVar() int {
+	s.assign = append(s.assign, 0)
+	s.level = append(s.level, 0)
+	s.reason = append(s.reason, -1)
+	s.activity = append(s.activity, 0)
+	s.phase = append(s.phase, false)
+	s.seen = append(s.seen, false)
+	s.watches = append(s.watches, nil, nil)
+	return len(s.assign) - 1
+}
+
+// value returns 1 if the literal is true, -1 if false and 0 if unassigned
+func (s *satSolver) value(l satLit) int8 {
+	a := s.assign[l.variable()]
+	if l&1 == 1 {
+		return -a
+	}
+	return a
+}
+
+// isTrue reports whether variable v is true in the model last found
+func (s *satSolver) isTrue(v int) bool {
+	return s.assign[v] == 1
+}
+
+// enqueue makes the literal true at the current level
+func (s *satSolver) enqueue(l satLit, reason int) {
+	v := l.variable()
+	s.assign[v] = 1
+	if l&1 == 1 {
+		s.assign[v] = -1
+	}
+	s.level[v] = len(s.limits)
+	s.reason[v] = reason
+	s.trail = append(s.trail, l)
+}
+
+// addClause adds the clause that at least one of lits holds, reporting false
+// once the clauses are known to have no model
+func (s *satSolver) addClause(lits ...satLit) bool {
+	if s.unsat {
+		return false
+	}
+	s.backtrack(0)
+
+	clause := make([]satLit, 0, len(lits))
+	for _, l := range lits {
+		switch s.value(l) {
+		case 1:
+			return true
+		case -1:
+			continue
+		}
+		duplicate := false
+		for _, c := range clause {
+			if c == l.not() {
+				return true
+			}
+			duplicate = duplicate || c == l
+		}
+		if !duplicate {
+			clause = append(clause, l)
+		}
+	}
+
+	switch len(clause) {
+	case 0:
+		s.unsat = true
+	case 1:
+		s.enqueue(clause[0], -1)
+		s.unsat = s.propagate() >= 0
+	default:
+		s.attach(clause)
+	}
+	return !s.unsat
+}
+
+// attach adds a clause of at least two literals, watching the first two
+func (s *satSolver) attach(clause []satLit) int {
+	i := len(s.clauses)
+	s.clauses = append(s.clauses, clause)
+	s.watches[clause[0]] = append(s.watches[clause[0]], i)
+	s.watches[clause[1]] = append(s.watches[clause[1]], i)
+	return i
+}
+
+// propagate makes every literal true that a clause forces, returning the
+// clause left with every literal false, or -1 if there is none
+func (s *satSolver) propagate() int {
+	for s.head < len(s.trail) {
+		falsified := s.trail[s.head].not()
+		s.head++
+
+		watching := s.watches[falsified]
+		kept := watching[:0]
+		for k := 0; k < len(watching); k++ {
+			ci := watching[k]
+			c := s.clauses[ci]
+			if c[0] == falsified {
+				c[0], c[1] = c[1], c[0]
+			}
+			if s.value(c[0]) == 1 {
+				kept = append(kept, ci)
+				continue
+			}
+
+			// Watch another literal that is not yet false, if there is one
+			moved := false
+			for j := 2; j < len(c); j++ {
+				if s.value(c[j]) != -1 {
+					c[1], c[j] = c[j], c[1]
+					s.watches[c[1]] = append(s.watches[c[1]], ci)
+					moved = true
+					break
+				}
+			}
+			if moved {
+				continue
+			}
+
+			kept = append(kept, ci)
+			if s.value(c[0]) == -1 {
+				s.watches[falsified] = append(kept, watching[k+1:]...)
+				s.head = len(s.trail)
+				return ci
+			}
+			s.enqueue(c[0], ci)
+		}
+		s.watches[falsified] = kept
+	}
+	return -1
+}
+
+// analyze learns a clause from a conflict, returning it with the literal it
+// asserts first and the level to go back to
+func (s *satSolver) analyze(conflict int) ([]satLit, int) {
+	learnt := []satLit{0}
+	pending := 0
+	index := len(s.trail) - 1
+	var p satLit
+	first := true
+
+	// Resolve the conflict against the reasons of the current level's
+	// literals, latest first, until only one of them is left
+	for ci := conflict; ; ci = s.reason[p.variable()] {
+		for _, q := range s.clauses[ci] {
+			if !first && q == p {
+				continue
+			}
+			v := q.variable()
+			if s.seen[v] || s.level[v] == 0 {
+				continue
+			}
+			s.seen[v] = true
+			s.bumpActivity(v)
+			if s.level[v] == len(s.limits) {
+				pending++
+			} else {
+				learnt = append(learnt, q)
+			}
+		}
+		first = false
+
+		for !s.seen[s.trail[index].variable()] {
+			index--
+		}
+		p = s.trail[index]
+		index--
+		s.seen[p.variable()] = false
+		if pending--; pending == 0 {
+			break
+		}
+	}
+	learnt[0] = p.not()
+
+	// Go back to the latest level among the rest, which is watched second
+	back := 0
+	for i := 1; i < len(learnt); i++ {
+		s.seen[learnt[i].variable()] = false
+		if level := s.level[learnt[i].variable()]; level > back {
+			back = level
+			learnt[1], learnt[i] = learnt[i], learnt[1]
+		}
+	}
+	return learnt, back
+}
+
+// bumpActivity makes variable v more likely to be branched on
+func (s *satSolver) bumpActivity(v int) {
+	s.activity[v] += s.bump
+	if s.activity[v] > 1e100 {
+		for i := range s.activity {
+			s.activity[i] *= 1e-100
+		}
+		s.bump *= 1e-100
+	}
+}
+
+// backtrack undoes every assignment above level
+func (s *satSolver) backtrack(level int) {
+	if len(s.limits) <= level {
+		return
+	}
+	for i := len(s.trail) - 1; i >= s.limits[level]; i-- {
+		v := s.trail[i].variable()
+		s.phase[v] = s.assign[v] == 1
+		s.assign[v] = 0
+		s.reason[v] = -1
+	}
+	s.trail = s.trail[:s.limits[level]]
+	s.limits = s.limits[:level]
+	s.head = len(s.trail)
+}
+
+// pick returns the unassigned variable with the highest activity, or -1 if
+// every variable is assigned
+func (s *satSolver) pick() int {
+	best := -1
+	for v, a := range s.assign {
+		if a == 0 && (best < 0 || s.activity[v] > s.activity[best]) {
+			best = v
+		}
+	}
+	return best
+}
+
+// solve searches for a model of the clauses, reporting whether there is one.
+// It gives up with ctx.Err() once the context is done.
+func (s *satSolver) solve(ctx context.Context) (bool, error) {
+	if s.unsat {
+		return false, nil
+	}
+	if s.bump == 0 {
+		s.bump = 1
+	}
+
+	restart, sinceRestart := 100, 0
+	for steps := 1; ; steps++ {
+		if ctx != nil && steps%1024 == 0 {
+			if err := ctx.Err(); err != nil {
+				s.backtrack(0)
+				return false, err
+			}
+		}
+
+		if conflict := s.propagate(); conflict >= 0 {
+			s.conflicts++
+			if len(s.limits) == 0 {
+				s.unsat = true
+				return false, nil
+			}
+			learnt, back := s.analyze(conflict)
+			s.backtrack(back)
+			if len(learnt) == 1 {
+				s.enqueue(learnt[0], -1)
+			} else {
+				s.enqueue(learnt[0], s.attach(learnt))
+			}
+			s.bump /= 0.95
+			sinceRestart++
+			continue
+		}
+
+		if sinceRestart >= restart {
+			restart += restart / 2
+			sinceRestart = 0
+			s.backtrack(0)
+			continue
+		}
+
+		v := s.pick()
+		if v < 0 {
+			return true, nil
+		}
+		s.decisions++
+		s.limits = append(s.limits, len(s.trail))
+		if len(s.limits) > s.maxLevel {
+			s.maxLevel = len(s.limits)
+		}
+		l := positive(v)
+		if !s.phase[v] {
+			l = l.not()
+		}
+		s.enqueue(l, -1)
+	}
+}
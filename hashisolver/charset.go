@@ -0,0 +1,155 @@
+// hashisolver/charset.go
+package hashisolver
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Charset is the set of characters bridges are drawn with on a text board
+type Charset int
+
+const (
+	// CharsetASCII draws bridges as | " # and - = E, which Parse reads back
+	CharsetASCII Charset = iota
+	// CharsetUnicode draws bridges with box-drawing characters, │ ║ ┃ and
+	// ─ ═ ≡, for terminals that show them
+	CharsetUnicode
+)
+
+func (c Charset) String() string {
+	if c == CharsetUnicode {
+		return "unicode"
+	}
+	return "ascii"
+}
+
+// ParseCharset reads a charset by name
+func ParseCharset(name string) (Charset, error) {
+	for c := CharsetASCII; c <= CharsetUnicode; c++ {
+		if c.String() == name {
+			return c, nil
+		}
+	}
+	return CharsetASCII, fmt.Errorf("unknown charset %q, expected ascii or unicode", name)
+}
+
+// horizontalSymbols and verticalSymbols are the characters bridges running
+// each way are drawn with, in every charset
+const (
+	horizontalSymbols = "-=E─═≡"
+	verticalSymbols   = "|\"#│║┃"
+)
+
+// unicodeBridges are the box-drawing characters for the bridge markers -1
+// to -6, in the same order as cellSymbol's
+var unicodeBridges = []string{"│", "║", "─", "═", "┃", "≡"}
+
+// symbol returns the character drawn for a board value
+func (c Charset) symbol(value int) string {
+	if c == CharsetUnicode && value < 0 && -value <= len(unicodeBridges) {
+		return unicodeBridges[-value-1]
+	}
+	return cellSymbol(value)
+}
+
+// DrawMap renders each row of the board like MapLines, with bridges drawn
+// in the charset
+func DrawMap(puzzle *Puzzle, charset Charset) []string {
+	lines := make([]string, puzzle.Rows)
+	for i := 0; i < puzzle.Rows; i++ {
+		var line strings.Builder
+		for j := 0; j < puzzle.Cols; j++ {
+			line.WriteString(charset.symbol(puzzle.marker(j, i)))
+		}
+		lines[i] = line.String()
+	}
+	return lines
+}
+
+// MapRenderer returns a renderer writing the board like WriteMap, with
+// bridges drawn in the charset
+func MapRenderer(charset Charset) Renderer {
+	return func(w io.Writer, puzzle *Puzzle) error {
+		out := bufio.NewWriter(w)
+		for _, line := range DrawMap(puzzle, charset) {
+			out.WriteString(line)
+			out.WriteByte('\n')
+		}
+		return out.Flush()
+	}
+}
+
+// largeCellWidth is the number of characters across each cell of a board
+// drawn by LargeMap
+const largeCellWidth = 3
+
+// LargeMap redraws a board drawn a character to a cell, as by DrawMap, with
+// each cell three characters wide and a line between rows, so that islands
+// stand apart and bridges stretch across the gaps between them. If paint
+// isn't nil, each piece of the drawing is passed through it along with the
+// cell whose character it repeats, for coloring.
+func LargeMap(lines []string, paint func(x, y int, text string) string) []string {
+	cells := [][]rune{}
+	for _, line := range lines {
+		cells = append(cells, []rune(line))
+	}
+	at := func(x, y int) rune {
+		if y < 0 || y >= len(cells) || x < 0 || x >= len(cells[y]) {
+			return ' '
+		}
+		return cells[y][x]
+	}
+	// piece repeats the cell's character n times, painted
+	piece := func(x, y, n int) string {
+		text := strings.Repeat(string(at(x, y)), n)
+		if paint != nil {
+			text = paint(x, y, text)
+		}
+		return text
+	}
+	horizontal := func(r rune) bool { return strings.ContainsRune(horizontalSymbols, r) }
+	vertical := func(r rune) bool { return strings.ContainsRune(verticalSymbols, r) }
+
+	large := []string{}
+	for y, row := range cells {
+		var line, gap strings.Builder
+		for x, symbol := range row {
+			switch {
+			case symbol == ' ':
+				line.WriteString("   ")
+			case horizontal(symbol):
+				line.WriteString(piece(x, y, largeCellWidth))
+			case vertical(symbol):
+				line.WriteString(" " + piece(x, y, 1) + " ")
+			default:
+				// An island, with the bridges either side running up to it
+				for _, side := range []int{x - 1, x, x + 1} {
+					if side == x || horizontal(at(side, y)) {
+						line.WriteString(piece(side, y, 1))
+					} else {
+						line.WriteByte(' ')
+					}
+				}
+			}
+
+			// Vertical bridges carry on through the line below, up to the
+			// island at their end
+			switch below := at(x, y+1); {
+			case vertical(symbol):
+				gap.WriteString(" " + piece(x, y, 1) + " ")
+			case symbol != ' ' && !horizontal(symbol) && vertical(below):
+				gap.WriteString(" " + piece(x, y+1, 1) + " ")
+			default:
+				gap.WriteString("   ")
+			}
+		}
+		large = append(large, strings.TrimRight(line.String(), " "))
+		if y+1 < len(cells) {
+			large = append(large, strings.TrimRight(gap.String(), " "))
+		}
+	}
+	return large
+}
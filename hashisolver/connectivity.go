@@ -0,0 +1,189 @@
+// hashisolver/connectivity.go
+package hashisolver
+
+// unionFind groups island indexes into sets, for finding connected parts of
+// the board
+type unionFind struct {
+	parent []int
+	rank   []int
+}
+
+// newUnionFind returns n singleton sets
+func newUnionFind(n int) *unionFind {
+	u := &unionFind{parent: make([]int, n), rank: make([]int, n)}
+	for i := range u.parent {
+		u.parent[i] = i
+	}
+	return u
+}
+
+// find returns the representative of i's set, flattening the path to it
+func (u *unionFind) find(i int) int {
+	for u.parent[i] != i {
+		u.parent[i] = u.parent[u.parent[i]]
+		i = u.parent[i]
+	}
+	return i
+}
+
+// union merges the sets holding i and j, reporting whether they were apart
+func (u *unionFind) union(i, j int) bool {
+	i, j = u.find(i), u.find(j)
+	if i == j {
+		return false
+	}
+	if u.rank[i] < u.rank[j] {
+		i, j = j, i
+	}
+	u.parent[j] = i
+	if u.rank[i] == u.rank[j] {
+		u.rank[i]++
+	}
+	return true
+}
+
+// links describes the graph of islands that are joined or could still be
+// joined: a link runs to each neighbor with a bridge already or an open
+// direction. It is never changed once worked out, so copies of a board can
+// share it; a link it reports as the only one holding the board together
+// stays so while directions are blocked and bridges placed.
+type links struct {
+	// number numbers the islands, by cell index, and -1 for water
+	number []int32
+	// connected is set when every island can still reach every other
+	connected bool
+	// cut marks, for each island and direction, a link whose loss would split
+	// the board in two
+	cut [][4]bool
+}
+
+// linked reports whether node has a bridge or an open direction toward its
+// neighbor in direction
+func linked(node *Node, direction int) bool {
+	return node.GetNeighbor(direction) != nil && (node.BridgesInDirection(direction) > 0 || !node.IsBlocked(direction))
+}
+
+// newLinks works out which islands can still be joined and which links the
+// board can't do without
+func newLinks(puzzle *Puzzle) *links {
+	l := &links{number: puzzle.grid.number}
+	islands := make([]*Node, len(puzzle.grid.islands))
+	for k, i := range puzzle.grid.islands {
+		islands[k] = &puzzle.Cells[i]
+	}
+	l.cut = make([][4]bool, len(islands))
+
+	// Each link is seen from both ends, so only those going right and down are joined
+	sets := newUnionFind(len(islands))
+	parts := len(islands)
+	for i, node := range islands {
+		for _, dir := range []int{DirectionRight, DirectionDown} {
+			if linked(node, dir) && sets.union(i, l.id(node.GetNeighbor(dir))) {
+				parts--
+			}
+		}
+	}
+	l.connected = parts <= 1
+	if l.connected && len(islands) > 1 {
+		l.findCuts(islands)
+	}
+	return l
+}
+
+// currentLinks returns the board's links, working them out again only if
+// they were dropped since
+func (p *Puzzle) currentLinks() *links {
+	if p.links == nil {
+		p.links = newLinks(p)
+	}
+	return p.links
+}
+
+// unlink notes that node is about to lose its link in direction, if it has
+// one without a bridge. Losing a cut link splits the board, which is known
+// at once. Losing any other leaves the board joined but can turn other links
+// into cuts, so the links are dropped to be worked out when next needed.
+func (n *Node) unlink(direction int) {
+	p := n.puzzle
+	if p == nil || p.links == nil || !p.links.connected {
+		return
+	}
+	if n.GetNeighbor(direction) == nil || n.BridgesInDirection(direction) > 0 {
+		return
+	}
+	if p.links.isCut(n, direction) {
+		p.links = &links{number: p.links.number}
+	} else {
+		p.links = nil
+	}
+}
+
+// id returns the index of an island
+func (l *links) id(node *Node) int {
+	return int(l.number[node.index])
+}
+
+// isCut reports whether the link from node in direction is the only one
+// holding the board together
+func (l *links) isCut(node *Node, direction int) bool {
+	return l.cut[l.id(node)][direction]
+}
+
+// findCuts marks the links whose loss would split the board, by Tarjan's
+// bridge-finding depth-first search. The search keeps its own stack, as a
+// path across a big board can be too long to recurse along comfortably.
+func (l *links) findCuts(islands []*Node) {
+	order := make([]int, len(islands))
+	low := make([]int, len(islands))
+	for i := range order {
+		order[i] = -1
+	}
+
+	// frame is an island on the search path, the direction it was reached
+	// from and the next direction to look in
+	type frame struct {
+		island, from, next int
+	}
+
+	visited := 0
+	order[0], low[0] = visited, visited
+	visited++
+	stack := []frame{{island: 0, from: -1}}
+	for len(stack) > 0 {
+		top := &stack[len(stack)-1]
+		node := islands[top.island]
+
+		if top.next > DirectionRight {
+			stack = stack[:len(stack)-1]
+			if len(stack) == 0 {
+				break
+			}
+			parent := stack[len(stack)-1].island
+			if low[top.island] < low[parent] {
+				low[parent] = low[top.island]
+			}
+			if low[top.island] > order[parent] {
+				l.cut[top.island][top.from] = true
+				l.cut[parent][opposite(top.from)] = true
+			}
+			continue
+		}
+
+		dir := top.next
+		top.next++
+		if !linked(node, dir) || dir == top.from {
+			continue
+		}
+
+		next := l.id(node.GetNeighbor(dir))
+		if order[next] >= 0 {
+			if order[next] < low[top.island] {
+				low[top.island] = order[next]
+			}
+			continue
+		}
+		order[next], low[next] = visited, visited
+		visited++
+		stack = append(stack, frame{island: next, from: opposite(dir)})
+	}
+}
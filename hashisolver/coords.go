@@ -0,0 +1,94 @@
+// hashisolver/coords.go
+package hashisolver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Label writes the position the way a board with axis labels names it, a
+// column letter and a row number counted from 1, as in A1 for (0,0). The
+// columns after Z are AA, AB and so on, like a spreadsheet.
+func (p Position) Label() string {
+	return columnLabel(p.X) + strconv.Itoa(p.Y+1)
+}
+
+// columnLabel returns the letters naming column x
+func columnLabel(x int) string {
+	label := ""
+	for x++; x > 0; x = (x - 1) / 26 {
+		label = string(rune('A'+(x-1)%26)) + label
+	}
+	return label
+}
+
+// ParsePosition reads a position written either as x,y counting from 0 or
+// as a label like A1 or c4
+func ParsePosition(s string) (Position, error) {
+	var pos Position
+	if strings.Contains(s, ",") {
+		var rest string
+		if n, _ := fmt.Sscanf(s+" .", "%d,%d %s", &pos.X, &pos.Y, &rest); n != 3 || rest != "." {
+			return pos, fmt.Errorf("invalid position %q, expected x,y or a label like A1", s)
+		}
+		return pos, nil
+	}
+
+	letters := 0
+	for letters < len(s) && (s[letters] >= 'A' && s[letters] <= 'Z' || s[letters] >= 'a' && s[letters] <= 'z') {
+		letters++
+	}
+	digits := s[letters:]
+	row, err := strconv.Atoi(digits)
+	if letters == 0 || letters > 3 || err != nil || row < 1 || strings.Trim(digits, "0123456789") != "" {
+		return pos, fmt.Errorf("invalid position %q, expected x,y or a label like A1", s)
+	}
+	for _, r := range strings.ToUpper(s[:letters]) {
+		pos.X = pos.X*26 + int(r-'A') + 1
+	}
+	pos.X--
+	pos.Y = row - 1
+	return pos, nil
+}
+
+// LabelMap adds axis labels to a board drawn a character to a cell, as by
+// DrawMap: the column letters above it, stacked when they run to more than
+// one letter, and the row numbers down its left side
+func LabelMap(lines []string, cols int) []string {
+	return labelMap(lines, cols, 1, 1)
+}
+
+// LabelLargeMap adds axis labels like LabelMap to a board drawn by LargeMap
+func LabelLargeMap(lines []string, cols int) []string {
+	return labelMap(lines, cols, largeCellWidth, 2)
+}
+
+// labelMap adds axis labels to a board drawn with cells cellWidth characters
+// wide, a row of cells every rowLines lines
+func labelMap(lines []string, cols, cellWidth, rowLines int) []string {
+	rows := (len(lines) + rowLines - 1) / rowLines
+	width := len(strconv.Itoa(rows))
+	height := len(columnLabel(cols - 1))
+	labelled := []string{}
+	for i := 0; i < height; i++ {
+		header := []byte(strings.Repeat(" ", width+1+cols*cellWidth))
+		for x := 0; x < cols; x++ {
+			// Shorter labels sit at the bottom, next to the board, and each
+			// letter over the middle of its cell
+			label := columnLabel(x)
+			if j := i - (height - len(label)); j >= 0 {
+				header[width+1+x*cellWidth+cellWidth/2] = label[j]
+			}
+		}
+		labelled = append(labelled, strings.TrimRight(string(header), " "))
+	}
+	for i, line := range lines {
+		if i%rowLines == 0 {
+			labelled = append(labelled, fmt.Sprintf("%*d %s", width, i/rowLines+1, line))
+		} else {
+			labelled = append(labelled, strings.Repeat(" ", width+1)+line)
+		}
+	}
+	return labelled
+}
@@ -0,0 +1,104 @@
+// hashisolver/crossing.go
+package hashisolver
+
+import "fmt"
+
+// pathCells returns the board cells strictly between node and its neighbor
+// in direction
+func pathCells(puzzle *Puzzle, node *Node, direction int) []*Node {
+	neighbor := node.GetNeighbor(direction)
+	if neighbor == nil {
+		return nil
+	}
+
+	dx, dy := 0, 0
+	switch direction {
+	case DirectionUp:
+		dy = -1
+	case DirectionDown:
+		dy = 1
+	case DirectionLeft:
+		dx = -1
+	case DirectionRight:
+		dx = 1
+	}
+
+	cells := []*Node{}
+	for x, y := node.XPos()+dx, node.YPos()+dy; x != neighbor.XPos() || y != neighbor.YPos(); x, y = x+dx, y+dy {
+		cells = append(cells, puzzle.At(x, y))
+	}
+	return cells
+}
+
+// vertical reports whether direction runs up or down the board
+func vertical(direction int) bool {
+	return direction == DirectionUp || direction == DirectionDown
+}
+
+// islandsAcross returns the nearest islands on either side of cell, looking
+// across a bridge that runs vertically or not
+func islandsAcross(puzzle *Puzzle, cell *Node, vertically bool) (*Node, *Node) {
+	dx, dy := 0, 1
+	if vertically {
+		dx, dy = 1, 0
+	}
+
+	var before, after *Node
+	for x, y := cell.XPos()-dx, cell.YPos()-dy; x >= 0 && y >= 0; x, y = x-dx, y-dy {
+		if puzzle.At(x, y).Value() > 0 {
+			before = puzzle.At(x, y)
+			break
+		}
+	}
+	for x, y := cell.XPos()+dx, cell.YPos()+dy; x < puzzle.Cols && y < puzzle.Rows; x, y = x+dx, y+dy {
+		if puzzle.At(x, y).Value() > 0 {
+			after = puzzle.At(x, y)
+			break
+		}
+	}
+	return before, after
+}
+
+// crossedBridge returns the islands at the ends of a bridge already on the
+// board that a bridge from node in direction would cross, or nils if none
+func crossedBridge(puzzle *Puzzle, node *Node, direction int) (*Node, *Node) {
+	for _, cell := range pathCells(puzzle, node, direction) {
+		if end, dir := puzzle.spanning(cell); end != nil && vertical(dir) != vertical(direction) {
+			return end, end.GetNeighbor(dir)
+		}
+	}
+	return nil, nil
+}
+
+// checkPath returns an error if a bridge from node in direction would pass
+// through an island or cross another bridge
+func checkPath(puzzle *Puzzle, node *Node, direction int) error {
+	neighbor := node.GetNeighbor(direction)
+	for _, cell := range pathCells(puzzle, node, direction) {
+		if cell.Value() > 0 {
+			return fmt.Errorf("%w: bridge from (%d,%d) to (%d,%d) passes through the island at (%d,%d)",
+				ErrIllegalBridge, node.XPos(), node.YPos(), neighbor.XPos(), neighbor.YPos(), cell.XPos(), cell.YPos())
+		}
+		if end, dir := puzzle.spanning(cell); end != nil && vertical(dir) != vertical(direction) {
+			return fmt.Errorf("%w: bridge from (%d,%d) to (%d,%d) crosses another bridge at (%d,%d)",
+				ErrIllegalBridge, node.XPos(), node.YPos(), neighbor.XPos(), neighbor.YPos(), cell.XPos(), cell.YPos())
+		}
+	}
+	return nil
+}
+
+// blockCrossings blocks every pair of islands whose bridge would cross the
+// bridge from node in direction, so the rules never count on them
+func blockCrossings(puzzle *Puzzle, node *Node, direction int) {
+	for _, cell := range pathCells(puzzle, node, direction) {
+		before, after := islandsAcross(puzzle, cell, vertical(direction))
+		if before == nil || after == nil {
+			continue
+		}
+		if vertical(direction) {
+			before.DirectionBlocked(DirectionRight)
+		} else {
+			before.DirectionBlocked(DirectionDown)
+		}
+	}
+}
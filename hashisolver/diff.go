@@ -0,0 +1,121 @@
+// hashisolver/diff.go
+package hashisolver
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// BridgeDiff is a pair of islands two solutions join with different numbers
+// of bridges
+type BridgeDiff struct {
+	// From is the island at the left or top end, and To the other
+	From, To Position
+	// Old and New are the bridges between the pair in the first solution and
+	// in the second, 0 where a solution has none
+	Old, New int
+}
+
+// DiffSolutions lines up two lists of bridges on the same puzzle by the pair
+// of islands each joins, whichever end it is listed from, and returns the
+// pairs whose bridges differ in reading order of their left or top ends
+func DiffSolutions(first, second []Bridge) []BridgeDiff {
+	pairs := map[[2]Position]*BridgeDiff{}
+	diff := func(b Bridge) *BridgeDiff {
+		from, to := b.From, b.To
+		if to.Y < from.Y || to.X < from.X {
+			from, to = to, from
+		}
+		key := [2]Position{from, to}
+		if pairs[key] == nil {
+			pairs[key] = &BridgeDiff{From: from, To: to}
+		}
+		return pairs[key]
+	}
+	for _, b := range first {
+		diff(b).Old += b.Count
+	}
+	for _, b := range second {
+		diff(b).New += b.Count
+	}
+
+	diffs := []BridgeDiff{}
+	for _, d := range pairs {
+		if d.Old != d.New {
+			diffs = append(diffs, *d)
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		a, b := diffs[i], diffs[j]
+		if a.From != b.From {
+			return a.From.Y < b.From.Y || a.From.Y == b.From.Y && a.From.X < b.From.X
+		}
+		return a.To.Y < b.To.Y || a.To.Y == b.To.Y && a.To.X < b.To.X
+	})
+	return diffs
+}
+
+// WriteDiff writes how the second solution of the puzzle differs from the
+// first, like a unified diff of their bridge lists: a - line for each pair
+// as the first has it and a + line as the second does, under headers naming
+// them. The two boards follow side by side, with the cells of the pairs that
+// differ drawn as * in each, unless either solution can't be built on the
+// puzzle. Nothing is written if the solutions agree.
+func WriteDiff(w io.Writer, puzzle *Puzzle, first, second *Solution, names [2]string) error {
+	diffs := DiffSolutions(first.Bridges, second.Bridges)
+	if len(diffs) == 0 {
+		return nil
+	}
+	out := bufio.NewWriter(w)
+	fmt.Fprintf(out, "--- %s\n+++ %s\n", names[0], names[1])
+	for _, d := range diffs {
+		if d.Old > 0 {
+			fmt.Fprintf(out, "-%d,%d %d,%d %d\n", d.From.X, d.From.Y, d.To.X, d.To.Y, d.Old)
+		}
+		if d.New > 0 {
+			fmt.Fprintf(out, "+%d,%d %d,%d %d\n", d.From.X, d.From.Y, d.To.X, d.To.Y, d.New)
+		}
+	}
+
+	firstBoard, err := puzzle.Apply(first)
+	if err != nil {
+		return out.Flush()
+	}
+	secondBoard, err := puzzle.Apply(second)
+	if err != nil {
+		return out.Flush()
+	}
+	left, right := diffMap(firstBoard, diffs), diffMap(secondBoard, diffs)
+	out.WriteByte('\n')
+	for i := range left {
+		out.WriteString(strings.TrimRight(left[i]+"   "+right[i], " "))
+		out.WriteByte('\n')
+	}
+	return out.Flush()
+}
+
+// diffMap draws the board like MapLines with the cells between the ends of
+// each differing pair drawn as *
+func diffMap(board *Puzzle, diffs []BridgeDiff) []string {
+	cells := [][]byte{}
+	for _, line := range MapLines(board) {
+		cells = append(cells, []byte(line))
+	}
+	for _, d := range diffs {
+		for y := d.From.Y; y <= d.To.Y; y++ {
+			for x := d.From.X; x <= d.To.X; x++ {
+				if (x != d.From.X || y != d.From.Y) && (x != d.To.X || y != d.To.Y) {
+					cells[y][x] = '*'
+				}
+			}
+		}
+	}
+	lines := make([]string, len(cells))
+	for i, row := range cells {
+		lines[i] = string(row)
+	}
+	return lines
+}
@@ -0,0 +1,83 @@
+// hashisolver/difficulty.go
+package hashisolver
+
+import "context"
+
+// DifficultyEstimate is a quick guess at how hard a puzzle is, made without solving it.
+// It is meant for sorting large batches of puzzles before rating them properly.
+type DifficultyEstimate struct {
+	// Islands is the number of islands on the board
+	Islands int
+	// Density is the fraction of cells that are islands
+	Density float64
+	// MeanClue is the average island value
+	MeanClue float64
+	// LowClueFraction is the fraction of islands with a clue of 1 or 2, which
+	// leave the most choice about where their bridges go
+	LowClueFraction float64
+	// OpeningFraction is the fraction of the solution's bridges that a single
+	// pass of the logical rules places
+	OpeningFraction float64
+	// Score runs from 0 for trivial puzzles to 100 for the hardest
+	Score float64
+}
+
+// Grade names the band the score falls in: easy, medium or hard
+func (e DifficultyEstimate) Grade() string {
+	switch {
+	case e.Score < 35:
+		return "easy"
+	case e.Score < 65:
+		return "medium"
+	}
+	return "hard"
+}
+
+// EstimateDifficulty scores a puzzle from its island density, its clues and how
+// much of it the opening pass of the logical rules settles. It costs about one
+// pass over the board, however hard the puzzle is. The puzzle is left untouched.
+func EstimateDifficulty(puzzle *Puzzle) DifficultyEstimate {
+	var estimate DifficultyEstimate
+
+	clues, low := 0, 0
+	for i := range puzzle.Cells {
+		node := &puzzle.Cells[i]
+		if node.Value() <= 0 {
+			continue
+		}
+		estimate.Islands++
+		clues += node.Value()
+		if node.Value() <= 2 {
+			low++
+		}
+	}
+	if estimate.Islands == 0 {
+		return estimate
+	}
+
+	estimate.Density = float64(estimate.Islands) / float64(puzzle.Rows*puzzle.Cols)
+	estimate.MeanClue = float64(clues) / float64(estimate.Islands)
+	estimate.LowClueFraction = float64(low) / float64(estimate.Islands)
+
+	// Run one pass of the rules on a copy, without guessing
+	opening := puzzle.Clone()
+	s := newSolver(context.Background(), NewOptions(WithLogicOnly(true)))
+	s.maxPasses = 1
+	s.search(opening, 0)
+
+	estimate.OpeningFraction = 1
+	if remaining := puzzle.FullBridges/2 - puzzle.PlacedBridges(); remaining > 0 {
+		placed := opening.PlacedBridges() - puzzle.PlacedBridges()
+		estimate.OpeningFraction = float64(placed) / float64(remaining)
+	}
+
+	// What the opening pass leaves undone counts most. Low clues and crowded
+	// boards give more choice about where the remaining bridges go.
+	density := estimate.Density / 0.5
+	if density > 1 {
+		density = 1
+	}
+	estimate.Score = 100 * (0.6*(1-estimate.OpeningFraction) + 0.25*estimate.LowClueFraction + 0.15*density)
+
+	return estimate
+}
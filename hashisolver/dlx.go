@@ -0,0 +1,368 @@
+// hashisolver/dlx.go
+package hashisolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// dancingLinks is an exact cover problem held as Knuth's dancing links: a
+// sparse matrix of circular doubly linked lists, whose columns can be taken
+// out and put back in constant time per cell. Node 0 is the root and nodes
+// 1 to the number of columns are the column headers. Primary columns must be
+// covered exactly once and secondary columns at most once.
+type dancingLinks struct {
+	left, right, up, down []int
+	// col is the header of each node's column, and row the row it belongs to
+	col, row []int
+	// size is the number of rows left in each column, indexed by header
+	size []int
+}
+
+// newDancingLinks returns an empty matrix with the given numbers of columns
+func newDancingLinks(primary, secondary int) *dancingLinks {
+	n := primary + secondary + 1
+	d := &dancingLinks{
+		left: make([]int, n), right: make([]int, n), up: make([]int, n), down: make([]int, n),
+		col: make([]int, n), row: make([]int, n), size: make([]int, n),
+	}
+	for c := 0; c < n; c++ {
+		d.up[c], d.down[c], d.col[c], d.row[c] = c, c, c, -1
+		d.left[c], d.right[c] = c, c
+	}
+
+	// Only the primary columns hang off the root, so a secondary column never
+	// has to be covered
+	for c := 0; c <= primary; c++ {
+		d.left[c], d.right[c] = (c+primary)%(primary+1), (c+1)%(primary+1)
+	}
+	return d
+}
+
+// addRow adds a row covering the given columns, numbered from 0
+func (d *dancingLinks) addRow(row int, columns []int) {
+	first := -1
+	for _, c := range columns {
+		h, x := c+1, len(d.left)
+		d.up, d.down = append(d.up, d.up[h]), append(d.down, h)
+		d.col, d.row = append(d.col, h), append(d.row, row)
+		d.down[d.up[h]], d.up[h] = x, x
+		d.size[h]++
+
+		if first < 0 {
+			first = x
+			d.left, d.right = append(d.left, x), append(d.right, x)
+		} else {
+			d.left, d.right = append(d.left, d.left[first]), append(d.right, first)
+			d.right[d.left[first]], d.left[first] = x, x
+		}
+	}
+}
+
+// cover takes column c out of the matrix, along with every row in it
+func (d *dancingLinks) cover(c int) {
+	d.right[d.left[c]], d.left[d.right[c]] = d.right[c], d.left[c]
+	for i := d.down[c]; i != c; i = d.down[i] {
+		for j := d.right[i]; j != i; j = d.right[j] {
+			d.up[d.down[j]], d.down[d.up[j]] = d.up[j], d.down[j]
+			d.size[d.col[j]]--
+		}
+	}
+}
+
+// uncover puts column c back, undoing cover
+func (d *dancingLinks) uncover(c int) {
+	for i := d.up[c]; i != c; i = d.up[i] {
+		for j := d.left[i]; j != i; j = d.left[j] {
+			d.size[d.col[j]]++
+			d.up[d.down[j]], d.down[d.up[j]] = j, j
+		}
+	}
+	d.right[d.left[c]], d.left[d.right[c]] = c, c
+}
+
+// dlxLink is a pair of islands a bridge could join
+type dlxLink struct {
+	from, to int
+	dir      int
+	// placed is how many bridges the pair had before solving
+	placed int
+	// crossings are the secondary columns shared with the links it crosses
+	crossings []int
+}
+
+// dlxChoice is a way of meeting one island's clue: a number of bridges on
+// each of its links
+type dlxChoice struct {
+	island int
+	counts []int
+}
+
+// dlxSearch finds the solutions of a puzzle as exact covers. Each island has
+// a column that one of its choices must cover. Each link has a column per
+// number of bridges it could have: the island at its left or top end covers
+// every one but its own count and the other island covers its count alone,
+// so the two can only be chosen together if they agree. Links that cross
+// share a secondary column that each covers when it has a bridge.
+type dlxSearch struct {
+	ctx    context.Context
+	puzzle *Puzzle
+	matrix *dancingLinks
+	links  []dlxLink
+	// incident holds the links of each island, by cell index
+	incident [][]int
+	islands  []int
+	choices  []dlxChoice
+	// chosen is the choice made for each island so far, by cell index, or -1
+	chosen []int
+
+	limit     int
+	solutions []*Puzzle
+	stats     Stats
+}
+
+// errEnoughSolutions stops the search once the limit is reached
+var errEnoughSolutions = errors.New("enough solutions")
+
+// newDLXSearch sets up the exact cover problem for the puzzle
+func newDLXSearch(ctx context.Context, puzzle *Puzzle, limit int) *dlxSearch {
+	x := &dlxSearch{ctx: ctx, puzzle: puzzle, limit: limit, stats: Stats{Engine: EngineDLX}}
+	maxBridges := puzzle.maxBridges()
+
+	x.incident = make([][]int, len(puzzle.Cells))
+	x.chosen = make([]int, len(puzzle.Cells))
+	for i := range puzzle.Cells {
+		x.chosen[i] = -1
+		node := &puzzle.Cells[i]
+		if node.Value() <= 0 {
+			continue
+		}
+		x.islands = append(x.islands, i)
+		for _, dir := range []int{DirectionRight, DirectionDown} {
+			neighbor := node.GetNeighbor(dir)
+			if neighbor == nil || neighbor.Value() <= 0 {
+				continue
+			}
+			to := neighbor.YPos()*puzzle.Cols + neighbor.XPos()
+			x.incident[i] = append(x.incident[i], len(x.links))
+			x.incident[to] = append(x.incident[to], len(x.links))
+			x.links = append(x.links, dlxLink{from: i, to: to, dir: dir, placed: node.BridgesInDirection(dir)})
+		}
+	}
+
+	// Columns are numbered islands first, then each link's counts, then the
+	// crossings
+	island := make([]int, len(puzzle.Cells))
+	for k, i := range x.islands {
+		island[i] = k
+	}
+	primary := len(x.islands) + len(x.links)*(maxBridges+1)
+	secondary := 0
+	for a := range x.links {
+		across := &x.links[a]
+		if across.dir != DirectionRight {
+			continue
+		}
+		for b := range x.links {
+			down := &x.links[b]
+			if down.dir != DirectionDown {
+				continue
+			}
+			cx, cy := down.from%puzzle.Cols, across.from/puzzle.Cols
+			if across.from%puzzle.Cols < cx && cx < across.to%puzzle.Cols && down.from/puzzle.Cols < cy && cy < down.to/puzzle.Cols {
+				across.crossings = append(across.crossings, primary+secondary)
+				down.crossings = append(down.crossings, primary+secondary)
+				secondary++
+			}
+		}
+	}
+	x.matrix = newDancingLinks(primary, secondary)
+
+	for _, i := range x.islands {
+		links := x.incident[i]
+		counts := make([]int, len(links))
+		for {
+			sum, possible := 0, true
+			for j, c := range counts {
+				sum += c
+				possible = possible && c >= x.links[links[j]].placed
+			}
+			if sum == puzzle.Cells[i].Value() && possible {
+				columns := []int{island[i]}
+				for j, c := range counts {
+					e := links[j]
+					base := len(x.islands) + e*(maxBridges+1)
+					if x.links[e].from == i {
+						for k := 0; k <= maxBridges; k++ {
+							if k != c {
+								columns = append(columns, base+k)
+							}
+						}
+						if c > 0 {
+							columns = append(columns, x.links[e].crossings...)
+						}
+					} else {
+						columns = append(columns, base+c)
+					}
+				}
+				x.matrix.addRow(len(x.choices), columns)
+				x.choices = append(x.choices, dlxChoice{island: i, counts: append([]int(nil), counts...)})
+			}
+
+			j := 0
+			for j < len(counts) && counts[j] == maxBridges {
+				counts[j] = 0
+				j++
+			}
+			if j == len(counts) {
+				break
+			}
+			counts[j]++
+		}
+	}
+	return x
+}
+
+// count returns the bridges on link e as chosen so far, or -1 if neither end
+// has been chosen
+func (x *dlxSearch) count(e int) int {
+	link := x.links[e]
+	for _, i := range []int{link.from, link.to} {
+		if c := x.chosen[i]; c >= 0 {
+			for j, f := range x.incident[i] {
+				if f == e {
+					return x.choices[c].counts[j]
+				}
+			}
+		}
+	}
+	return -1
+}
+
+// closedOff reports whether the islands joined to island i are cut off from
+// the rest: every one of them has been chosen, so no more bridges can reach
+// them, and they are not all the islands
+func (x *dlxSearch) closedOff(i int) bool {
+	seen := map[int]bool{i: true}
+	queue := []int{i}
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		if x.chosen[next] < 0 {
+			return false
+		}
+		for _, e := range x.incident[next] {
+			other := x.links[e].from
+			if other == next {
+				other = x.links[e].to
+			}
+			if !seen[other] && x.count(e) > 0 {
+				seen[other] = true
+				queue = append(queue, other)
+			}
+		}
+	}
+	return len(seen) < len(x.islands)
+}
+
+// search covers the columns left, taking the one with the fewest rows first
+func (x *dlxSearch) search(depth int) error {
+	x.stats.NodesExplored++
+	if depth > x.stats.MaxDepth {
+		x.stats.MaxDepth = depth
+	}
+	if x.ctx != nil && x.stats.NodesExplored%1024 == 0 {
+		if err := x.ctx.Err(); err != nil {
+			return err
+		}
+	}
+
+	d := x.matrix
+	if d.right[0] == 0 {
+		solved, err := x.solution()
+		if err != nil {
+			return err
+		}
+		x.solutions = append(x.solutions, solved)
+		if x.limit > 0 && len(x.solutions) >= x.limit {
+			return errEnoughSolutions
+		}
+		return nil
+	}
+
+	c := d.right[0]
+	for h := d.right[c]; h != 0; h = d.right[h] {
+		if d.size[h] < d.size[c] {
+			c = h
+		}
+	}
+	if d.size[c] == 0 {
+		return nil
+	}
+
+	d.cover(c)
+	defer d.uncover(c)
+	for r := d.down[c]; r != c; r = d.down[r] {
+		x.stats.Branches++
+		for j := d.right[r]; j != r; j = d.right[j] {
+			d.cover(d.col[j])
+		}
+		choice := x.choices[d.row[r]]
+		x.chosen[choice.island] = d.row[r]
+
+		var err error
+		if x.closedOff(choice.island) {
+			x.stats.Cuts++
+		} else {
+			err = x.search(depth + 1)
+		}
+
+		x.chosen[choice.island] = -1
+		for j := d.left[r]; j != r; j = d.left[j] {
+			d.uncover(d.col[j])
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// solution builds the bridges of the islands' choices onto a copy of the puzzle
+func (x *dlxSearch) solution() (*Puzzle, error) {
+	x.stats.Clones++
+	solved := x.puzzle.Clone()
+	for e, link := range x.links {
+		node := &solved.Cells[link.from]
+		for k := link.placed; k < x.count(e); k++ {
+			if err := ConnectNodes(solved, node, node.GetNeighbor(link.dir), link.dir, false); err != nil {
+				return nil, fmt.Errorf("%w: the DLX engine's bridges do not fit the board: %v", ErrInvariant, err)
+			}
+		}
+	}
+	return solved, nil
+}
+
+// solveDLX finds up to limit solutions of the puzzle with the DLX engine, or
+// all of them if limit is 0
+func solveDLX(ctx context.Context, puzzle *Puzzle, limit int) ([]*Puzzle, Stats, error) {
+	start := time.Now()
+	x := newDLXSearch(ctx, puzzle, limit)
+	err := x.search(0)
+	if errors.Is(err, errEnoughSolutions) {
+		err = nil
+	}
+	x.stats.Elapsed = time.Since(start)
+	if err == nil && len(x.solutions) == 0 {
+		err = fmt.Errorf("%w: no way of meeting every clue joins every island", ErrNoSolution)
+	}
+	return x.solutions, x.stats, err
+}
+
+// SolveAllDLX finds up to limit solutions of a parsed puzzle with the DLX
+// engine, or all of them if limit is 0
+func SolveAllDLX(ctx context.Context, puzzle *Puzzle, limit int) ([]*Puzzle, error) {
+	return backend{solveDLX}.SolveAll(ctx, puzzle, Options{}, limit)
+}
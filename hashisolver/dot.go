@@ -0,0 +1,69 @@
+// hashisolver/dot.go
+package hashisolver
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// WriteDOT writes the puzzle as a Graphviz graph of its islands, each node
+// labelled with its clue and pinned to its place on the board for neato or
+// fdp. Every pair of islands that face each other is an edge, labelled with
+// the bridges placed between them and the most the pair could still take,
+// as "placed/most", so a partly solved board shows what the solver has
+// settled. Edges without bridges are dashed, or dotted and grey once no
+// bridge can go there.
+func WriteDOT(w io.Writer, puzzle *Puzzle) error {
+	out := bufio.NewWriter(w)
+	out.WriteString("graph hashi {\n")
+	out.WriteString("\tnode [shape=circle, fixedsize=true, width=0.5];\n")
+	for y := 0; y < puzzle.Rows; y++ {
+		for x := 0; x < puzzle.Cols; x++ {
+			if value := puzzle.At(x, y).Value(); value > 0 {
+				fmt.Fprintf(out, "\t\"%d,%d\" [label=\"%d\", pos=\"%d,%d!\"];\n", x, y, value, x, -y)
+			}
+		}
+	}
+
+	maxBridges := puzzle.maxBridges()
+	for y := 0; y < puzzle.Rows; y++ {
+		for x := 0; x < puzzle.Cols; x++ {
+			node := puzzle.At(x, y)
+			if node.Value() <= 0 {
+				continue
+			}
+			for _, dir := range []int{DirectionRight, DirectionDown} {
+				neighbor := node.GetNeighbor(dir)
+				if neighbor == nil {
+					continue
+				}
+				placed := node.BridgesInDirection(dir)
+				most := placed + pairCapacity(node, dir, maxBridges)
+				var style string
+				switch {
+				case placed > 0:
+					style = fmt.Sprintf("solid, penwidth=%d", placed)
+				case most > 0:
+					style = "dashed"
+				default:
+					style = "dotted, color=gray"
+				}
+				fmt.Fprintf(out, "\t\"%d,%d\" -- \"%d,%d\" [label=\"%d/%d\", bridges=%d, allowed=%d, style=%s];\n",
+					x, y, neighbor.XPos(), neighbor.YPos(), placed, most, placed, most, style)
+			}
+		}
+	}
+	out.WriteString("}\n")
+	return out.Flush()
+}
+
+// pairCapacity returns how many more bridges could join node and its
+// neighbor in direction, allowing for what both ends still need
+func pairCapacity(node *Node, direction, maxBridges int) int {
+	capacity := node.DirectionCapacity(direction, maxBridges)
+	if back := node.GetNeighbor(direction).DirectionCapacity(opposite(direction), maxBridges); back < capacity {
+		capacity = back
+	}
+	return capacity
+}
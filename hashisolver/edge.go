@@ -0,0 +1,252 @@
+// hashisolver/edge.go
+package hashisolver
+
+import "fmt"
+
+// Bridge is the bridges between one pair of islands. A pair's bridges are
+// kept as counts on the islands at both ends, and the water cells they pass
+// over only note which pair they belong to, so the markers drawn for them are
+// worked out when the board is drawn.
+type Bridge struct {
+	// From is the island at the left or top end, and To the other
+	From  Position `json:"from"`
+	To    Position `json:"to"`
+	Count int      `json:"count"`
+}
+
+// Bridges returns every pair of islands with bridges between them, in
+// reading order of their left or top ends
+func (p *Puzzle) Bridges() []Bridge {
+	bridges := []Bridge{}
+	for i := range p.Cells {
+		node := &p.Cells[i]
+		if node.Value() <= 0 {
+			continue
+		}
+		for _, dir := range []int{DirectionRight, DirectionDown} {
+			if count := node.BridgesInDirection(dir); count > 0 {
+				to := node.GetNeighbor(dir)
+				bridges = append(bridges, Bridge{
+					From:  Position{X: node.XPos(), Y: node.YPos()},
+					To:    Position{X: to.XPos(), Y: to.YPos()},
+					Count: count,
+				})
+			}
+		}
+	}
+	return bridges
+}
+
+// spanning returns the island at the left or top end of the bridge passing
+// over cell and the direction the bridge runs from it, or nil if none does
+func (p *Puzzle) spanning(cell *Node) (*Node, int) {
+	if cell.span == 0 {
+		return nil, 0
+	}
+	end := &p.Cells[cell.span-1]
+	if end.YPos() == cell.YPos() {
+		return end, DirectionRight
+	}
+	return end, DirectionDown
+}
+
+// BridgeOver returns the bridge passing over the water cell at (x,y), if any
+func (p *Puzzle) BridgeOver(x, y int) (Bridge, bool) {
+	end, dir := p.spanning(p.At(x, y))
+	if end == nil {
+		return Bridge{}, false
+	}
+	to := end.GetNeighbor(dir)
+	return Bridge{
+		From:  Position{X: end.XPos(), Y: end.YPos()},
+		To:    Position{X: to.XPos(), Y: to.YPos()},
+		Count: end.BridgesInDirection(dir),
+	}, true
+}
+
+// marker returns the board value drawn at (x,y): the clue of an island, the
+// marker of the bridge passing over water, or 0 for open water
+func (p *Puzzle) marker(x, y int) int {
+	cell := p.At(x, y)
+	end, dir := p.spanning(cell)
+	if cell.Value() > 0 || end == nil {
+		return cell.Value()
+	}
+	if dir == DirectionDown {
+		return verticalMarker(end.DownBridges)
+	}
+	return horizontalMarker(end.RightBridges)
+}
+
+// AddBridge places one more bridge between the islands at from and to, which
+// must face each other across open water, and adds the move to the puzzle's
+// history. It returns an error wrapping ErrIllegalBridge, and leaves the
+// puzzle as it was, if the bridge would pass through an island or cross
+// another bridge, give the pair more bridges than the rules allow or give
+// either island more than its clue.
+func (p *Puzzle) AddBridge(from, to Position) error {
+	return p.edit(from, to, false, false)
+}
+
+// RemoveBridge takes one bridge away from between the islands at from and
+// to, and adds the move to the puzzle's history. Every island's blocked
+// directions are then worked out afresh from the clues and the bridges left,
+// so any the logical rules or a guess had blocked are open again.
+func (p *Puzzle) RemoveBridge(from, to Position) error {
+	return p.edit(from, to, true, false)
+}
+
+// edit adds or removes a bridge by hand and keeps the move in the history,
+// with its changes on the history's trail. Unless the move is being redone,
+// the moves undone before it can no longer be redone.
+func (p *Puzzle) edit(from, to Position, removed, redo bool) error {
+	if p.history == nil {
+		p.history = &History{}
+	}
+	h := p.history
+	saved := p.trail
+	attach(p, &h.trail)
+	defer attach(p, saved)
+
+	n := h.push(p, Move{From: from, To: to, Technique: TechniqueByHand, Removed: removed})
+	var err error
+	if removed {
+		err = p.removeBridge(from, to)
+	} else {
+		err = p.addBridge(from, to)
+	}
+	if err != nil {
+		h.rewind(p, n)
+		return err
+	}
+
+	node, dir, _ := p.pair(from, to)
+	h.done[n].move.Count = node.BridgesInDirection(dir)
+	if !redo {
+		h.undone = h.undone[:0]
+	}
+	return nil
+}
+
+// addBridge places one more bridge between the islands at from and to, if
+// the rules allow it
+func (p *Puzzle) addBridge(from, to Position) error {
+	node, dir, err := p.pair(from, to)
+	if err != nil {
+		return err
+	}
+	neighbor := node.GetNeighbor(dir)
+
+	if count := node.BridgesInDirection(dir); count >= p.maxBridges() {
+		return fmt.Errorf("%w: (%d,%d) and (%d,%d) already have %d bridges, the most the rules allow",
+			ErrIllegalBridge, from.X, from.Y, to.X, to.Y, count)
+	}
+	for _, island := range []*Node{node, neighbor} {
+		if island.TotalBridges >= island.Value() {
+			return fmt.Errorf("%w: the %d at (%d,%d) already has all its bridges",
+				ErrIllegalBridge, island.Value(), island.XPos(), island.YPos())
+		}
+	}
+	return ConnectNodes(p, node, neighbor, dir, false)
+}
+
+// removeBridge takes one bridge away from between the islands at from and to
+func (p *Puzzle) removeBridge(from, to Position) error {
+	node, dir, err := p.pair(from, to)
+	if err != nil {
+		return err
+	}
+	neighbor := node.GetNeighbor(dir)
+	if node.BridgesInDirection(dir) == 0 {
+		return fmt.Errorf("%w: there is no bridge between (%d,%d) and (%d,%d)", ErrIllegalBridge, from.X, from.Y, to.X, to.Y)
+	}
+
+	node.save()
+	neighbor.save()
+	node.TotalBridges--
+	neighbor.TotalBridges--
+	switch dir {
+	case DirectionUp:
+		node.UpBridges--
+		neighbor.DownBridges--
+	case DirectionDown:
+		node.DownBridges--
+		neighbor.UpBridges--
+	case DirectionLeft:
+		node.LeftBridges--
+		neighbor.RightBridges--
+	case DirectionRight:
+		node.RightBridges--
+		neighbor.LeftBridges--
+	}
+	if p.BuiltBridges > 0 {
+		p.BuiltBridges--
+	}
+
+	// The water under the last bridge of a pair is open again
+	if node.BridgesInDirection(dir) == 0 {
+		for _, cell := range pathCells(p, node, dir) {
+			cell.save()
+			cell.span = 0
+		}
+	}
+	p.reblock()
+	return nil
+}
+
+// pair returns the island at from and the direction of the island at to,
+// or an error wrapping ErrIllegalBridge if they aren't islands facing each
+// other
+func (p *Puzzle) pair(from, to Position) (*Node, int, error) {
+	for _, pos := range []Position{from, to} {
+		if pos.X < 0 || pos.X >= p.Cols || pos.Y < 0 || pos.Y >= p.Rows || p.At(pos.X, pos.Y).Value() <= 0 {
+			return nil, 0, fmt.Errorf("%w: there is no island at (%d,%d)", ErrIllegalBridge, pos.X, pos.Y)
+		}
+	}
+
+	var dir int
+	switch {
+	case from.Y == to.Y && to.X > from.X:
+		dir = DirectionRight
+	case from.Y == to.Y && to.X < from.X:
+		dir = DirectionLeft
+	case from.X == to.X && to.Y > from.Y:
+		dir = DirectionDown
+	case from.X == to.X && to.Y < from.Y:
+		dir = DirectionUp
+	default:
+		return nil, 0, fmt.Errorf("%w: (%d,%d) and (%d,%d) are not in a straight line", ErrIllegalBridge, from.X, from.Y, to.X, to.Y)
+	}
+
+	node := p.At(from.X, from.Y)
+	if neighbor := node.GetNeighbor(dir); neighbor.XPos() != to.X || neighbor.YPos() != to.Y {
+		return nil, 0, fmt.Errorf("%w: the island at (%d,%d) lies between (%d,%d) and (%d,%d)",
+			ErrIllegalBridge, neighbor.XPos(), neighbor.YPos(), from.X, from.Y, to.X, to.Y)
+	}
+	return node, dir, nil
+}
+
+// reblock works out every island's blocked directions afresh from the clues
+// and the bridges on the board
+func (p *Puzzle) reblock() {
+	p.links = nil
+	for _, i := range p.grid.islands {
+		node := &p.Cells[i]
+		node.save()
+		node.UpBlocked, node.DownBlocked, node.LeftBlocked, node.RightBlocked = false, false, false, false
+		node.NumBlocked = 0
+	}
+
+	blockObvious(p)
+	for _, i := range p.grid.islands {
+		node := &p.Cells[i]
+		for _, dir := range []int{DirectionRight, DirectionDown} {
+			if node.BridgesInDirection(dir) > 0 {
+				blockCrossings(p, node, dir)
+			}
+		}
+	}
+	for _, i := range p.grid.islands {
+		p.Cells[i].BlockCheck(p.maxBridges())
+	}
+}
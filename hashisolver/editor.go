@@ -0,0 +1,135 @@
+// hashisolver/editor.go
+package hashisolver
+
+import "fmt"
+
+// SetIsland puts an island with the given clue at (x,y), or changes the clue
+// of the island already there. An island may not be placed beside another,
+// as every format draws bridges on the water between islands. Editing the
+// clues clears the board's bridges and history, and the islands are not
+// linked to each other until RecomputeNeighbors is called, so a batch of
+// edits can be made before it.
+func (p *Puzzle) SetIsland(x, y, value int) error {
+	if err := p.onBoard(x, y); err != nil {
+		return err
+	}
+	if value < 1 {
+		return fmt.Errorf("%w: a clue of %d at (%d,%d) is less than 1", ErrIllegalIsland, value, x, y)
+	}
+	if p.Rules.MaxIslandValue > 0 && value > p.Rules.MaxIslandValue {
+		return fmt.Errorf("%w: a clue of %d at (%d,%d) exceeds the maximum island value of %d",
+			ErrIllegalIsland, value, x, y, p.Rules.MaxIslandValue)
+	}
+	for _, d := range [][2]int{{0, -1}, {0, 1}, {-1, 0}, {1, 0}} {
+		nx, ny := x+d[0], y+d[1]
+		if nx >= 0 && nx < p.Cols && ny >= 0 && ny < p.Rows && p.At(nx, ny).Value() > 0 {
+			return fmt.Errorf("%w: (%d,%d) is beside the island at (%d,%d)", ErrIllegalIsland, x, y, nx, ny)
+		}
+	}
+	p.setClue(x, y, value)
+	return nil
+}
+
+// RemoveIsland turns the island at (x,y) back into water. Like SetIsland, it
+// clears the board's bridges and leaves the islands to be linked again by
+// RecomputeNeighbors.
+func (p *Puzzle) RemoveIsland(x, y int) error {
+	if err := p.onBoard(x, y); err != nil {
+		return err
+	}
+	if p.At(x, y).Value() <= 0 {
+		return fmt.Errorf("%w: there is no island at (%d,%d)", ErrIllegalIsland, x, y)
+	}
+	p.setClue(x, y, 0)
+	return nil
+}
+
+// onBoard returns an error wrapping ErrIllegalIsland if (x,y) is off the board
+func (p *Puzzle) onBoard(x, y int) error {
+	if x < 0 || x >= p.Cols || y < 0 || y >= p.Rows {
+		return fmt.Errorf("%w: (%d,%d) is off the %dx%d board", ErrIllegalIsland, x, y, p.Cols, p.Rows)
+	}
+	return nil
+}
+
+// setClue changes one clue on a copy of the clues, as they may be shared
+// with other copies of the puzzle, and clears the bridges built on the old
+// ones
+func (p *Puzzle) setClue(x, y, value int) {
+	clues := make([]int, len(p.grid.clues))
+	copy(clues, p.grid.clues)
+	clues[y*p.Cols+x] = value
+	p.grid.clues = clues
+
+	p.Cells = newCells(p)
+	p.BuiltBridges = 0
+	p.history = nil
+	p.links = nil
+}
+
+// RecomputeNeighbors works out from the clues which islands face each other,
+// and so which directions are blocked from the start, leaving the board with
+// no bridges. It must be called after SetIsland and RemoveIsland before the
+// puzzle is solved or its bridges changed.
+func (p *Puzzle) RecomputeNeighbors() {
+	cells := p.Rows * p.Cols
+	p.grid.neighbors = make([][4]int32, cells)
+	p.grid.number = make([]int32, cells)
+	p.grid.islands = nil
+	p.FullBridges = 0
+	for i, value := range p.grid.clues {
+		p.grid.number[i] = -1
+		if value > 0 {
+			p.FullBridges += value
+			p.grid.number[i] = int32(len(p.grid.islands))
+			p.grid.islands = append(p.grid.islands, int32(i))
+		}
+	}
+	p.Cells = newCells(p)
+	p.BuiltBridges = 0
+	p.history = nil
+	p.links = nil
+
+	// Find neighbors for each node
+	for i := 0; i < p.Rows; i++ {
+		for j := 0; j < p.Cols; j++ {
+			if p.At(j, i).Value() <= 0 {
+				continue
+			}
+
+			// Find right neighbor
+			for k := j + 1; k < p.Cols; k++ {
+				if p.At(k, i).Value() > 0 {
+					p.link(p.At(j, i), DirectionRight, k, i)
+					break
+				}
+			}
+
+			// Find left neighbor
+			for k := j - 1; k >= 0; k-- {
+				if p.At(k, i).Value() > 0 {
+					p.link(p.At(j, i), DirectionLeft, k, i)
+					break
+				}
+			}
+
+			// Find down neighbor
+			for k := i + 1; k < p.Rows; k++ {
+				if p.At(j, k).Value() > 0 {
+					p.link(p.At(j, i), DirectionDown, j, k)
+					break
+				}
+			}
+
+			// Find up neighbor
+			for k := i - 1; k >= 0; k-- {
+				if p.At(j, k).Value() > 0 {
+					p.link(p.At(j, i), DirectionUp, j, k)
+					break
+				}
+			}
+		}
+	}
+
+	blockObvious(p)
+}
@@ -0,0 +1,191 @@
+// hashisolver/engine.go
+package hashisolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Engines that can solve a puzzle
+const (
+	// EngineHeuristic applies the logical rules and guesses when they stall
+	EngineHeuristic = "heuristic"
+	// EngineSAT encodes the puzzle as clauses for a SAT solver, and shares no
+	// code with the heuristic engine beyond reading and drawing the board
+	EngineSAT = "sat"
+	// EngineDLX searches the ways of meeting each island's clue as an exact
+	// cover problem with dancing links
+	EngineDLX = "dlx"
+)
+
+// Solver is an engine that solves puzzles. An engine is free to ignore the
+// options that make no sense for how it works, but should honour the rule set
+// and timeout.
+type Solver interface {
+	// Solve solves a parsed puzzle, reporting how the solve went even if it fails
+	Solve(ctx context.Context, puzzle *Puzzle, options Options) (*SolveResult, error)
+}
+
+// Enumerator is a Solver that can also list a puzzle's solutions
+type Enumerator interface {
+	Solver
+	// SolveAll finds up to limit solutions of a parsed puzzle, or all of them
+	// if limit is 0, leaving the puzzle itself as it was. A search stopped by
+	// the timeout or ctx returns the solutions found so far with the error.
+	SolveAll(ctx context.Context, puzzle *Puzzle, options Options, limit int) ([]*Puzzle, error)
+}
+
+// SolverFunc lets an ordinary function be used as a Solver
+type SolverFunc func(ctx context.Context, puzzle *Puzzle, options Options) (*SolveResult, error)
+
+// Solve calls f
+func (f SolverFunc) Solve(ctx context.Context, puzzle *Puzzle, options Options) (*SolveResult, error) {
+	return f(ctx, puzzle, options)
+}
+
+// engines maps each engine name to the engine
+var engines = map[string]Solver{
+	EngineHeuristic: heuristicEngine{},
+	EngineSAT:       backend{solveSAT},
+	EngineDLX:       backend{solveDLX},
+}
+
+// RegisterEngine adds an engine, replacing any engine already registered
+// under the name. It is not safe to call while puzzles are being solved, so
+// register engines before starting any work.
+func RegisterEngine(name string, engine Solver) {
+	engines[name] = engine
+}
+
+// Engines returns the names of the registered engines in order
+func Engines() []string {
+	names := make([]string, 0, len(engines))
+	for name := range engines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupEngine returns the named engine, or the heuristic engine for an empty name
+func LookupEngine(name string) (Solver, error) {
+	if name == "" {
+		name = EngineHeuristic
+	}
+	engine, ok := engines[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown engine %q (want one of %s)", name, strings.Join(Engines(), ", "))
+	}
+	return engine, nil
+}
+
+// WithEngine picks the engine that solves the puzzle, by name. The SAT and
+// DLX engines honour only the timeout and rule set among the other options.
+func WithEngine(engine string) Option {
+	return func(o *Options) {
+		o.Engine = engine
+	}
+}
+
+// heuristicEngine applies the logical rules and guesses when they stall
+type heuristicEngine struct{}
+
+func (heuristicEngine) Solve(ctx context.Context, puzzle *Puzzle, options Options) (*SolveResult, error) {
+	return newSolver(ctx, options).run(puzzle, options)
+}
+
+func (heuristicEngine) SolveAll(ctx context.Context, puzzle *Puzzle, options Options, limit int) ([]*Puzzle, error) {
+	puzzle = underRules(puzzle, options)
+	ctx, stop := timeoutContext(ctx, options)
+	s := newSolver(ctx, options)
+	s.limit = limit
+
+	_, err := s.search(puzzle.Clone(), 0)
+	return found(s.solutions, stop(err))
+}
+
+// backend is an engine that finds solutions without the logical rules, by
+// finding up to a number of them at once
+type backend struct {
+	solve func(ctx context.Context, puzzle *Puzzle, limit int) ([]*Puzzle, Stats, error)
+}
+
+func (b backend) Solve(ctx context.Context, puzzle *Puzzle, options Options) (*SolveResult, error) {
+	return runEngine(ctx, puzzle, options, b.solve)
+}
+
+func (b backend) SolveAll(ctx context.Context, puzzle *Puzzle, options Options, limit int) ([]*Puzzle, error) {
+	puzzle = underRules(puzzle, options)
+	ctx, stop := timeoutContext(ctx, options)
+	solutions, _, err := b.solve(ctx, puzzle, limit)
+	return found(solutions, stop(err))
+}
+
+// found returns what SolveAll does for the solutions a search found and the
+// error it ended with: the error alone if there are none, and the solutions
+// alone unless the search was stopped before it finished
+func found(solutions []*Puzzle, err error) ([]*Puzzle, error) {
+	if len(solutions) == 0 {
+		return nil, err
+	}
+	if stopped(err) {
+		return solutions, err
+	}
+	return solutions, nil
+}
+
+// stopped reports whether err ended a search that ran out of time or was
+// given up on by its caller
+func stopped(err error) bool {
+	return errors.Is(err, ErrTimeout) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// timeoutContext bounds ctx by the options' timeout, if there is one. The
+// function returned releases the context and turns an error from running
+// out of that time into ErrTimeout, while the caller's own deadline or
+// cancellation is left as it is.
+func timeoutContext(ctx context.Context, options Options) (context.Context, func(error) error) {
+	if options.Timeout <= 0 {
+		return ctx, func(err error) error { return err }
+	}
+	parent := ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(parent, options.Timeout)
+	return ctx, func(err error) error {
+		cancel()
+		if errors.Is(err, context.DeadlineExceeded) && parent.Err() == nil {
+			return fmt.Errorf("%w after %v", ErrTimeout, options.Timeout)
+		}
+		return err
+	}
+}
+
+// underRules returns the puzzle to solve under the options' rule set: the
+// puzzle itself if it has them already, or else a copy with them, so the
+// caller's puzzle keeps its own
+func underRules(puzzle *Puzzle, options Options) *Puzzle {
+	if options.Rules.MaxBridgesPerPair == 0 || puzzle.Rules == options.Rules {
+		return puzzle
+	}
+	puzzle = puzzle.Clone()
+	puzzle.Rules = options.Rules
+	return puzzle
+}
+
+// runEngine solves the puzzle under the options with solve, which finds up to
+// a number of solutions with one of the engines other than the heuristic one
+func runEngine(ctx context.Context, puzzle *Puzzle, options Options, solve func(context.Context, *Puzzle, int) ([]*Puzzle, Stats, error)) (*SolveResult, error) {
+	puzzle = underRules(puzzle, options)
+	ctx, stop := timeoutContext(ctx, options)
+	solutions, stats, err := solve(ctx, puzzle, 1)
+	err = stop(err)
+	if err != nil {
+		return &SolveResult{Puzzle: puzzle, Stats: stats}, err
+	}
+	return &SolveResult{Puzzle: solutions[0], Stats: stats}, nil
+}
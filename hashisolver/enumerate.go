@@ -0,0 +1,148 @@
+// hashisolver/enumerate.go
+package hashisolver
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// errKeepSearching is returned by search when a solution was recorded but more are wanted
+var errKeepSearching = errors.New("keep searching")
+
+// solver holds the configuration and results of one search
+type solver struct {
+	// ctx is checked between deduction passes and speculative branches, if set
+	ctx context.Context
+	// log receives debug and info messages, and is never nil
+	log Logger
+	// noGuess stops the search where the logical rules stall instead of speculating
+	noGuess bool
+	// maxDepth is the most nested speculative guesses allowed, or 0 for no limit
+	maxDepth int
+	// strategy picks the islands to guess at, and is never nil
+	strategy Strategy
+	// probing tries both ways of undecided links once the other rules stall
+	probing bool
+	// maxPasses stops deduction after this many passes over the board, or 0 for no limit
+	maxPasses int
+	// maxMemory is the most bytes the trail of changes may take, or 0 for no limit
+	maxMemory int64
+	// history holds the guesses being explored, with the changes made since
+	// each, so a failed one can be backtracked
+	history *History
+	// stack holds the guesses being explored, outermost first
+	stack []*decision
+	// slots holds a token for each extra goroutine exploring guesses, if
+	// branches may be explored at once
+	slots chan struct{}
+
+	// progress is called at most every progressInterval while searching
+	progress         ProgressFunc
+	progressInterval time.Duration
+	lastProgress     time.Time
+
+	// moves, if set, receives every bridge placed
+	moves chan<- Move
+	// tracing keeps every move in trace as well
+	tracing bool
+	trace   []Move
+	// verify checks the invariants after every move
+	verify bool
+	// anytime, if set, is offered each board with more bridges than any before
+	anytime     *Anytime
+	observed    bool
+	bestBridges int
+
+	// limit is how many solutions to find before stopping, or 0 for all of them
+	limit int
+	// keep stores the solutions found, rather than only counting them
+	keep bool
+
+	solutions []*Puzzle
+	count     int
+
+	// stats counts the work done by the search
+	stats Stats
+	// depthLimited is set once a branch has been abandoned because of maxDepth
+	depthLimited bool
+}
+
+// cancelled returns the context's error once the caller has given up on the search
+func (s *solver) cancelled() error {
+	if s.ctx == nil {
+		return nil
+	}
+	return s.ctx.Err()
+}
+
+// record notes a complete puzzle and reports whether the search can stop
+func (s *solver) record(puzzle *Puzzle) (*Puzzle, error) {
+	s.count++
+	// The search goes on in place after this, so a solution to keep is copied
+	if s.keep {
+		s.solutions = append(s.solutions, puzzle.Clone())
+		s.stats.Clones++
+	}
+
+	if s.limit > 0 && s.count >= s.limit {
+		return puzzle, nil
+	}
+	return puzzle, errKeepSearching
+}
+
+// SolveAll returns up to limit solutions of the puzzle, or all of them if limit is 0.
+// The puzzle itself is left untouched.
+func SolveAll(puzzle *Puzzle, limit int, debug bool) ([]*Puzzle, error) {
+	s := &solver{log: debugLogger(debug), strategy: HighestRemainingValue(), limit: limit, keep: true}
+
+	_, err := s.search(puzzle.Clone(), 0)
+	if len(s.solutions) == 0 {
+		return nil, err
+	}
+	return s.solutions, nil
+}
+
+// CountSolutions counts the solutions of the puzzle without keeping them,
+// stopping early once limit is reached if limit is above 0
+func CountSolutions(puzzle *Puzzle, limit int) int {
+	s := &solver{log: nopLogger{}, strategy: HighestRemainingValue(), limit: limit}
+	s.search(puzzle.Clone(), 0)
+	return s.count
+}
+
+// CountSolutionsContext counts up to limit solutions of the puzzle, or all of
+// them if limit is 0, like CountSolutions but under the options' rules,
+// strategy and timeout. A count stopped by the timeout or ctx returns the
+// solutions counted so far with the error.
+func CountSolutionsContext(ctx context.Context, puzzle *Puzzle, limit int, opts ...Option) (int, error) {
+	options := NewOptions(opts...)
+	puzzle = puzzle.Clone()
+	if options.Rules.MaxBridgesPerPair > 0 {
+		puzzle.Rules = options.Rules
+	}
+	ctx, stop := timeoutContext(ctx, options)
+	s := newSolver(ctx, options)
+	s.limit = limit
+	s.keep = false
+
+	_, err := s.search(puzzle, 0)
+	if err = stop(err); stopped(err) {
+		return s.count, err
+	}
+	return s.count, nil
+}
+
+// SolveUnique solves the puzzle and checks that the solution is the only one.
+// If there are several, the first is returned along with ErrMultipleSolutions.
+func SolveUnique(puzzle *Puzzle, debug bool) (*Puzzle, error) {
+	solutions, err := SolveAll(puzzle, 2, debug)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(solutions) > 1 {
+		return solutions[0], ErrMultipleSolutions
+	}
+	return solutions[0], nil
+}
@@ -0,0 +1,31 @@
+// hashisolver/errors.go
+package hashisolver
+
+import "errors"
+
+// Errors returned by the solver, which callers can check for with errors.Is
+var (
+	// ErrNoSolution means the puzzle was read but no arrangement of bridges satisfies it
+	ErrNoSolution = errors.New("no solution found")
+	// ErrInvalidPuzzle means the input could not be read as a puzzle
+	ErrInvalidPuzzle = errors.New("invalid puzzle")
+	// ErrMultipleSolutions means the puzzle has more than one solution where exactly one was required
+	ErrMultipleSolutions = errors.New("multiple solutions")
+	// ErrGuessRequired means the logical rules stalled and only speculation could go further
+	ErrGuessRequired = errors.New("logic alone cannot solve the puzzle")
+	// ErrDepthLimit means branches were abandoned at the speculation depth limit before a solution was found
+	ErrDepthLimit = errors.New("speculation depth limit reached")
+	// ErrAlreadySolved means a hint was asked for on a puzzle with every bridge in place
+	ErrAlreadySolved = errors.New("puzzle is already solved")
+	// ErrIllegalBridge means a bridge would pass through an island or cross another bridge
+	ErrIllegalBridge = errors.New("illegal bridge")
+	// ErrIllegalIsland means an island would be off the board, beside another or have a clue out of range
+	ErrIllegalIsland = errors.New("illegal island")
+	// ErrInvariant means the solver's own bookkeeping disagreed with itself,
+	// which is a bug in the solver rather than in the puzzle
+	ErrInvariant = errors.New("solver invariant broken")
+	// ErrTimeout means the solver gave up before finishing
+	ErrTimeout = errors.New("solver timed out")
+	// ErrMemoryLimit means the boards held for speculation would have taken more memory than allowed
+	ErrMemoryLimit = errors.New("solver memory limit reached")
+)
@@ -0,0 +1,182 @@
+// hashisolver/explain.go
+package hashisolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Verdict is what the logical rules can say about a bridge
+type Verdict int
+
+const (
+	// VerdictUndecided means logic alone can neither prove nor rule out the bridge
+	VerdictUndecided Verdict = iota
+	// VerdictRequired means every solution has a bridge between the islands
+	VerdictRequired
+	// VerdictImpossible means no solution has a bridge between the islands
+	VerdictImpossible
+)
+
+func (v Verdict) String() string {
+	switch v {
+	case VerdictRequired:
+		return "required"
+	case VerdictImpossible:
+		return "impossible"
+	}
+	return "undecided"
+}
+
+// BridgeExplanation is the answer to why there must or can't be a bridge between two islands
+type BridgeExplanation struct {
+	From, To Position
+	Verdict  Verdict
+	// Summary is a one line account of how the verdict was reached
+	Summary string
+	// Steps are the deductions behind the verdict, in order. When the verdict
+	// comes from a contradiction, they start with the assumption that was made.
+	Steps []Move
+}
+
+// String describes the explanation, one step per line
+func (e *BridgeExplanation) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "A bridge between (%d,%d) and (%d,%d) is %s: %s\n",
+		e.From.X, e.From.Y, e.To.X, e.To.Y, e.Verdict, e.Summary)
+	for i, step := range e.Steps {
+		if step.Reason != "" {
+			fmt.Fprintf(&b, "%d. %s\n", i+1, step.Reason)
+		} else {
+			fmt.Fprintf(&b, "%d. %s\n", i+1, step)
+		}
+	}
+	return b.String()
+}
+
+// logicTrace applies the logical rules to the puzzle in place and returns the moves they made
+func logicTrace(puzzle *Puzzle) ([]Move, error) {
+	s := newSolver(context.Background(), NewOptions(WithLogicOnly(true), WithTrace(true)))
+	_, err := s.search(puzzle, 0)
+	if errors.Is(err, ErrGuessRequired) {
+		err = nil
+	}
+	return s.trace, err
+}
+
+// ExplainBridge works out whether the logical rules require or rule out a
+// bridge between two neighboring islands, given the bridges already placed.
+// If logic alone can't decide, it tries assuming each answer in turn and
+// looks for a contradiction. The puzzle itself is left untouched.
+func (p *Puzzle) ExplainBridge(from, to Position) (*BridgeExplanation, error) {
+	node, _, direction, err := p.edge(from, to)
+	if err != nil {
+		return nil, err
+	}
+	explanation := &BridgeExplanation{From: from, To: to}
+
+	if node.BridgesInDirection(direction) > 0 {
+		explanation.Verdict = VerdictRequired
+		explanation.Summary = "the bridge is already built."
+		return explanation, nil
+	}
+	if node.IsBlocked(direction) {
+		explanation.Verdict = VerdictImpossible
+		explanation.Summary = blockedReason(p, node, direction)
+		return explanation, nil
+	}
+
+	// See whether the rules place the bridge, or block it, from the board as it stands
+	clone := p.Clone()
+	trace, err := logicTrace(clone)
+	if err != nil {
+		return nil, fmt.Errorf("%w: the bridges placed so far cannot be completed", ErrNoSolution)
+	}
+	for i, move := range trace {
+		if (move.From == from && move.To == to) || (move.From == to && move.To == from) {
+			explanation.Verdict = VerdictRequired
+			explanation.Summary = "the logical rules place it."
+			explanation.Steps = trace[:i+1]
+			return explanation, nil
+		}
+	}
+	cloneNode := clone.At(from.X, from.Y)
+	if cloneNode.BridgesInDirection(direction) == 0 && cloneNode.IsBlocked(direction) {
+		explanation.Verdict = VerdictImpossible
+		explanation.Summary = "after the logical rules, " + blockedReason(clone, cloneNode, direction)
+		explanation.Steps = trace
+		return explanation, nil
+	}
+
+	// Assume the bridge is there and look for a contradiction
+	clone = p.Clone()
+	assumption := Move{From: from, To: to, Count: 1, Technique: TechniqueSpeculation,
+		Reason: fmt.Sprintf("Assume there is a bridge between (%d,%d) and (%d,%d).", from.X, from.Y, to.X, to.Y)}
+	if err := ConnectNodes(clone, clone.At(from.X, from.Y), clone.At(to.X, to.Y), direction, true); err != nil {
+		explanation.Verdict = VerdictImpossible
+		explanation.Summary = err.Error() + "."
+		return explanation, nil
+	}
+	if trace, err := logicTrace(clone); err != nil {
+		explanation.Verdict = VerdictImpossible
+		explanation.Summary = "assuming it leads to a contradiction, " + err.Error() + "."
+		explanation.Steps = append([]Move{assumption}, trace...)
+		return explanation, nil
+	}
+
+	// Assume there is no bridge and look for a contradiction
+	clone = p.Clone()
+	assumption = Move{From: from, To: to, Technique: TechniqueSpeculation,
+		Reason: fmt.Sprintf("Assume there is no bridge between (%d,%d) and (%d,%d).", from.X, from.Y, to.X, to.Y)}
+	clone.At(from.X, from.Y).DirectionBlocked(direction)
+	if trace, err := logicTrace(clone); err != nil {
+		explanation.Verdict = VerdictRequired
+		explanation.Summary = "assuming there is none leads to a contradiction, " + err.Error() + "."
+		explanation.Steps = append([]Move{assumption}, trace...)
+		return explanation, nil
+	}
+
+	explanation.Summary = "logic alone can neither place nor rule out the bridge."
+	return explanation, nil
+}
+
+// edge finds the islands at from and to, which must be neighbors, and the direction between them
+func (p *Puzzle) edge(from, to Position) (*Node, *Node, int, error) {
+	island := func(pos Position) *Node {
+		if pos.Y < 0 || pos.Y >= p.Rows || pos.X < 0 || pos.X >= p.Cols || p.At(pos.X, pos.Y).Value() <= 0 {
+			return nil
+		}
+		return p.At(pos.X, pos.Y)
+	}
+
+	node, neighbor := island(from), island(to)
+	if node == nil || neighbor == nil {
+		return nil, nil, 0, fmt.Errorf("(%d,%d) and (%d,%d) must both be islands", from.X, from.Y, to.X, to.Y)
+	}
+
+	direction := directionTo(from, to)
+	if (from.X != to.X && from.Y != to.Y) || node.GetNeighbor(direction) != neighbor {
+		return nil, nil, 0, fmt.Errorf("(%d,%d) and (%d,%d) are not neighboring islands", from.X, from.Y, to.X, to.Y)
+	}
+	return node, neighbor, direction, nil
+}
+
+// blockedReason says why no more bridges can go from node in direction
+func blockedReason(puzzle *Puzzle, node *Node, direction int) string {
+	neighbor := node.GetNeighbor(direction)
+	if a, b := crossedBridge(puzzle, node, direction); a != nil && b != nil {
+		return fmt.Sprintf("it would cross the bridge between (%d,%d) and (%d,%d).", a.XPos(), a.YPos(), b.XPos(), b.YPos())
+	}
+
+	switch {
+	case node.TotalBridges == node.Value():
+		return fmt.Sprintf("the %d at (%d,%d) already has all its bridges.", node.Value(), node.XPos(), node.YPos())
+	case neighbor.TotalBridges == neighbor.Value():
+		return fmt.Sprintf("the %d at (%d,%d) already has all its bridges.", neighbor.Value(), neighbor.XPos(), neighbor.YPos())
+	case node.Value() == 1 && neighbor.Value() == 1:
+		return "joining two 1s would cut them off from the rest of the board."
+	}
+	return "the rules have ruled it out."
+}
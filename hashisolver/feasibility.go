@@ -0,0 +1,46 @@
+// hashisolver/feasibility.go
+package hashisolver
+
+import (
+	"fmt"
+	"strings"
+)
+
+// checkFeasible looks for clues that no arrangement of bridges could satisfy,
+// so the search isn't spent proving it the slow way. It returns an error
+// wrapping ErrInvalidPuzzle that names every offending island.
+func checkFeasible(puzzle *Puzzle) error {
+	maxBridges := puzzle.maxBridges()
+	problems := []string{}
+	sum := 0
+
+	for i := 0; i < puzzle.Rows; i++ {
+		for j := 0; j < puzzle.Cols; j++ {
+			node := puzzle.At(j, i)
+			if node.Value() <= 0 {
+				continue
+			}
+			sum += node.Value()
+
+			neighbors := node.NumNeighbors()
+			switch {
+			case node.Value() == 4*maxBridges && neighbors < 4:
+				problems = append(problems, fmt.Sprintf("the %d at (%d,%d) needs bridges in all four directions but has %d neighbor(s)",
+					node.Value(), node.XPos(), node.YPos(), neighbors))
+			case node.Value() > maxBridges*neighbors:
+				problems = append(problems, fmt.Sprintf("the %d at (%d,%d) has %d neighbor(s), which can take at most %d bridge(s)",
+					node.Value(), node.XPos(), node.YPos(), neighbors, maxBridges*neighbors))
+			}
+		}
+	}
+
+	// Every bridge counts towards the islands at both of its ends
+	if sum%2 != 0 {
+		problems = append(problems, fmt.Sprintf("the clues add up to %d, but every bridge counts twice so the total must be even", sum))
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidPuzzle, strings.Join(problems, "; "))
+	}
+	return nil
+}
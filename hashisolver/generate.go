@@ -0,0 +1,568 @@
+// hashisolver/generate.go
+package hashisolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// ErrNoPuzzle means the generator ran out of attempts before producing a puzzle
+// that met every requirement
+var ErrNoPuzzle = errors.New("no puzzle generated")
+
+// Difficulty is a band of puzzle difficulty
+type Difficulty int
+
+const (
+	// DifficultyAny accepts a puzzle of any difficulty
+	DifficultyAny Difficulty = iota
+	// DifficultyEasy puzzles are solved by the logical rules alone
+	DifficultyEasy
+	// DifficultyMedium puzzles need at most two nested guesses
+	DifficultyMedium
+	// DifficultyHard puzzles need deeper guessing
+	DifficultyHard
+)
+
+func (d Difficulty) String() string {
+	switch d {
+	case DifficultyEasy:
+		return "easy"
+	case DifficultyMedium:
+		return "medium"
+	case DifficultyHard:
+		return "hard"
+	}
+	return "any"
+}
+
+// ParseDifficulty reads a difficulty band by name
+func ParseDifficulty(name string) (Difficulty, error) {
+	for d := DifficultyAny; d <= DifficultyHard; d++ {
+		if d.String() == name {
+			return d, nil
+		}
+	}
+	return DifficultyAny, fmt.Errorf("unknown difficulty %q, expected easy, medium, hard or any", name)
+}
+
+// Rate solves the puzzle to place it in a difficulty band. It returns
+// ErrNoSolution if the puzzle can't be solved at all.
+func Rate(puzzle *Puzzle) (Difficulty, error) {
+	result, err := SolveWithStats(context.Background(), puzzle.Clone())
+	if err != nil {
+		return DifficultyAny, err
+	}
+
+	switch depth := result.Stats.MaxDepth; {
+	case depth == 0:
+		return DifficultyEasy, nil
+	case depth <= 2:
+		return DifficultyMedium, nil
+	}
+	return DifficultyHard, nil
+}
+
+// GenerateOptions controls the puzzles Generate produces
+type GenerateOptions struct {
+	// Rows and Cols are the size of the board, at least 3 each
+	Rows, Cols int
+	// Rules sets how many bridges may join a pair of islands. The zero value
+	// means DefaultRuleSet.
+	Rules RuleSet
+	// Difficulty is the band the puzzle must be rated in
+	Difficulty Difficulty
+	// Density is the fraction of cells that should be islands. The layout
+	// stops growing once it is reached. 0 lets the layout grow until no more
+	// bridges fit.
+	Density float64
+	// HighClues is the fraction of clues that should be 6 or more, and
+	// LowClues the fraction that should be 1 or 2. 0 leaves either free.
+	HighClues, LowClues float64
+	// Topology is the shape of the hidden solution, with Cycles giving the
+	// number of independent cycles for TopologyCycles
+	Topology Topology
+	Cycles   int
+	// Symmetry is the symmetry of the island layout
+	Symmetry Symmetry
+	// LogicOnly only accepts puzzles the logical rules solve without speculating
+	LogicOnly bool
+	// AllowMultipleSolutions skips the check that the puzzle has exactly one
+	// solution
+	AllowMultipleSolutions bool
+	// Source drives every random choice the generator makes, so the same
+	// source and options always give the same puzzle. If nil, a source
+	// seeded from the clock is used.
+	Source rand.Source
+	// MaxAttempts is how many candidate puzzles to try before giving up, or 0 for 1000
+	MaxAttempts int
+}
+
+// Generated is a generated puzzle along with the solution it was built from
+type Generated struct {
+	Puzzle   *Puzzle
+	Solution *Puzzle
+	// Difficulty is the band the puzzle was rated in
+	Difficulty Difficulty
+	// Cycles is the number of independent cycles in the solution, 0 for a
+	// spanning tree
+	Cycles int
+	// Attempts is the number of candidates tried, including the one returned
+	Attempts int
+}
+
+// Generate builds random puzzles the way c_src/bridgen.c does, by growing a
+// network of bridges from a single bridge until no more fit, and keeps going
+// until one is rated in the requested difficulty band and has a unique solution
+func Generate(opts GenerateOptions) (*Generated, error) {
+	if opts.Rows < 3 || opts.Cols < 3 {
+		return nil, fmt.Errorf("board must be at least 3x3, got %dx%d", opts.Rows, opts.Cols)
+	}
+	rules := opts.Rules
+	if rules.MaxBridgesPerPair <= 0 {
+		rules = DefaultRuleSet
+	}
+	attempts := opts.MaxAttempts
+	if attempts <= 0 {
+		attempts = 1000
+	}
+	if opts.Symmetry == SymmetryRotate90 && opts.Rows != opts.Cols {
+		return nil, fmt.Errorf("90° symmetry needs a square board, got %dx%d", opts.Rows, opts.Cols)
+	}
+	if opts.Topology == TopologyCycles && opts.Cycles < 1 {
+		return nil, fmt.Errorf("a solution needs at least 1 cycle, got %d", opts.Cycles)
+	}
+	if opts.LogicOnly && opts.Difficulty > DifficultyEasy {
+		return nil, fmt.Errorf("a %s puzzle needs guessing, so it can't be logic only", opts.Difficulty)
+	}
+	source := opts.Source
+	if source == nil {
+		source = rand.NewSource(time.Now().UnixNano())
+	}
+	rng := rand.New(source)
+
+	g := opts.growth(rules)
+	var l *layout
+	for attempt := 1; attempt <= attempts; attempt++ {
+		// Mutate the last layout a few times before starting a fresh one
+		if l == nil || attempt%mutationsPerLayout == 1 || !l.mutate(rng, rules.MaxBridgesPerPair, opts.steer(l.texture())) {
+			l = newLayout(opts.Rows, opts.Cols)
+			if !l.grow(rng, g) {
+				l = nil
+				continue
+			}
+		}
+		if !opts.fits(l.texture()) {
+			continue
+		}
+		cycles := l.cycles()
+		if g.maxCycles >= 0 && cycles != g.maxCycles {
+			continue
+		}
+
+		solution := NewPuzzle(l.values(true), rules)
+		if _, _, err := placeBridges(solution, l.values(true)); err != nil {
+			return nil, fmt.Errorf("generated an inconsistent layout: %v", err)
+		}
+		puzzle := NewPuzzle(l.values(false), rules)
+
+		difficulty, err := Rate(puzzle)
+		if err != nil {
+			continue
+		}
+		if opts.Difficulty != DifficultyAny && difficulty != opts.Difficulty {
+			continue
+		}
+		if opts.LogicOnly {
+			if _, err := SolveLogicOnly(puzzle.Clone(), false); err != nil {
+				continue
+			}
+		}
+		// An ambiguous candidate is repaired by mutating it on the next attempt
+		if !opts.AllowMultipleSolutions && CountSolutions(puzzle, 2) != 1 {
+			continue
+		}
+
+		return &Generated{Puzzle: puzzle, Solution: solution, Difficulty: difficulty, Cycles: cycles, Attempts: attempt}, nil
+	}
+
+	band := ""
+	if opts.Difficulty != DifficultyAny {
+		band = opts.Difficulty.String() + " "
+	}
+	return nil, fmt.Errorf("%w: no %s%dx%d puzzle in %d attempts", ErrNoPuzzle, band, opts.Rows, opts.Cols, attempts)
+}
+
+// mutationsPerLayout is how many candidates are made from one layout by
+// mutating it before a fresh layout is grown
+const mutationsPerLayout = 20
+
+// Bridge directions on a generator layout
+const (
+	layoutNone = iota
+	layoutHorizontal
+	layoutVertical
+)
+
+// layout is a board being grown by the generator. islands holds each island's
+// value and count the number of islands, and every bridge cell records its
+// direction and number of planks.
+type layout struct {
+	rows, cols int
+	count      int
+	islands    [][]int
+	dirn       [][]int
+	planks     [][]int
+}
+
+// span is a straight bridge between the cells (r0,c0) and (r1,c1), with
+// (r0,c0) the top or left end
+type span struct {
+	r0, c0, r1, c1 int
+}
+
+// newLayout returns an empty rows x cols layout
+func newLayout(rows, cols int) *layout {
+	l := &layout{rows: rows, cols: cols}
+	l.islands = make([][]int, rows)
+	l.dirn = make([][]int, rows)
+	l.planks = make([][]int, rows)
+	for r := 0; r < rows; r++ {
+		l.islands[r] = make([]int, cols)
+		l.dirn[r] = make([]int, cols)
+		l.planks[r] = make([]int, cols)
+	}
+	return l
+}
+
+// growth limits how a layout grows
+type growth struct {
+	// maxPlanks is the most planks on one bridge
+	maxPlanks int
+	// symmetry is kept by laying each bridge with its images
+	symmetry Symmetry
+	// maxIslands stops growth once reached, if above 0
+	maxIslands int
+	// maxCycles rejects bridges that would close more cycles, if not negative
+	maxCycles int
+}
+
+// growth returns the growth limits for the options
+func (opts GenerateOptions) growth(rules RuleSet) growth {
+	g := growth{
+		maxPlanks:  rules.MaxBridgesPerPair,
+		symmetry:   opts.Symmetry,
+		maxIslands: opts.islandTarget(),
+		maxCycles:  -1,
+	}
+	switch opts.Topology {
+	case TopologyTree:
+		g.maxCycles = 0
+	case TopologyCycles:
+		g.maxCycles = opts.Cycles
+	}
+	return g
+}
+
+// grow adds a first bridge, then keeps adding bridges until 200 attempts in a
+// row fail or the island limit is reached. It reports false if no first
+// bridge could be placed.
+func (l *layout) grow(rng *rand.Rand, g growth) bool {
+	placed := false
+	for tries := 0; tries < 1000 && !placed; tries++ {
+		placed = l.addBridge(rng, true, g)
+	}
+	if !placed {
+		return false
+	}
+
+	for fails := 0; fails < 200 && (g.maxIslands <= 0 || l.count < g.maxIslands); {
+		fails = 0
+		for fails < 200 && !l.addBridge(rng, false, g) {
+			fails++
+		}
+	}
+	return true
+}
+
+// mutate changes the number of planks on one random bridge and updates the
+// islands at either end, so the layout stays a solution of its clues and
+// the islands don't move. A positive steer only adds planks and a negative
+// one only removes them. It reports false if there was no bridge to change.
+func (l *layout) mutate(rng *rand.Rand, maxPlanks int, steer int) bool {
+	if maxPlanks < 2 {
+		return false
+	}
+
+	cells := [][2]int{}
+	for r := 0; r < l.rows; r++ {
+		for c := 0; c < l.cols; c++ {
+			planks := l.planks[r][c]
+			if l.dirn[r][c] != layoutNone && (steer == 0 || (steer > 0 && planks < maxPlanks) || (steer < 0 && planks > 1)) {
+				cells = append(cells, [2]int{r, c})
+			}
+		}
+	}
+	if len(cells) == 0 {
+		return false
+	}
+
+	// Walk from the chosen cell to the islands at both ends of its bridge
+	start := cells[rng.Intn(len(cells))]
+	dirn := l.dirn[start[0]][start[1]]
+	dr, dc := 0, 1
+	if dirn == layoutVertical {
+		dr, dc = 1, 0
+	}
+	r0, c0 := start[0], start[1]
+	for l.dirn[r0][c0] == dirn {
+		r0, c0 = r0-dr, c0-dc
+	}
+	r1, c1 := start[0], start[1]
+	for l.dirn[r1][c1] == dirn {
+		r1, c1 = r1+dr, c1+dc
+	}
+
+	planks := l.planks[start[0]][start[1]] + steer
+	if steer == 0 {
+		planks = 1 + rng.Intn(maxPlanks-1)
+		if planks >= l.planks[start[0]][start[1]] {
+			planks++
+		}
+	}
+	for r, c := r0+dr, c0+dc; r != r1 || c != c1; r, c = r+dr, c+dc {
+		l.planks[r][c] = planks
+	}
+
+	l.settle(r0, c0)
+	l.settle(r1, c1)
+	return true
+}
+
+// nextToIsland reports whether any cell beside (r,c) is an island
+func (l *layout) nextToIsland(r, c int) bool {
+	return (c < l.cols-1 && l.islands[r][c+1] > 0) ||
+		(r > 0 && l.islands[r-1][c] > 0) ||
+		(c > 0 && l.islands[r][c-1] > 0) ||
+		(r < l.rows-1 && l.islands[r+1][c] > 0)
+}
+
+// addBridge tries to add one bridge starting from a random island or bridge cell
+// (or anywhere, for the first bridge), along with its images under the
+// symmetry, reporting whether it succeeded
+func (l *layout) addBridge(rng *rand.Rand, first bool, g growth) bool {
+	var r, c int
+	if first {
+		r, c = rng.Intn(l.rows), rng.Intn(l.cols)
+	} else {
+		// Start from an existing island, or split an existing bridge
+		for {
+			r, c = rng.Intn(l.rows), rng.Intn(l.cols)
+			if l.dirn[r][c] != layoutNone || l.islands[r][c] > 0 {
+				break
+			}
+		}
+		if l.nextToIsland(r, c) {
+			return false
+		}
+	}
+
+	planks := 1 + rng.Intn(g.maxPlanks)
+	var r0, c0, r1, c1 int
+
+	switch rng.Intn(4) {
+	case 0: // East
+		if c > l.cols-3 {
+			return false // no room for a bridge
+		}
+		r0, c0, r1 = r, c, r
+		c = c0 + 1
+		for c < l.cols-1 && l.dirn[r][c] == layoutNone && l.islands[r][c] == 0 {
+			c++
+		}
+		if l.dirn[r][c] != layoutNone {
+			c--
+		}
+		if c < c0+2 {
+			return false
+		}
+		c1 = c0 + 2 + rng.Intn(c-c0-1)
+		if l.nextToIsland(r1, c1) {
+			return false
+		}
+
+	case 1: // North
+		if r < 2 {
+			return false
+		}
+		c0, c1, r1 = c, c, r
+		r = r1 - 1
+		for r > 0 && l.dirn[r][c] == layoutNone && l.islands[r][c] == 0 {
+			r--
+		}
+		if l.dirn[r][c] != layoutNone {
+			r++
+		}
+		if r > r1-2 {
+			return false
+		}
+		r0 = r1 - 2 - rng.Intn(r1-r-1)
+		if l.nextToIsland(r0, c0) {
+			return false
+		}
+
+	case 2: // West
+		if c < 2 {
+			return false
+		}
+		c1, r0, r1 = c, r, r
+		c = c1 - 1
+		for c > 0 && l.dirn[r][c] == layoutNone && l.islands[r][c] == 0 {
+			c--
+		}
+		if l.dirn[r][c] != layoutNone {
+			c++
+		}
+		if c > c1-2 {
+			return false
+		}
+		c0 = c1 - 2 - rng.Intn(c1-c-1)
+		if l.nextToIsland(r0, c0) {
+			return false
+		}
+
+	default: // South
+		if r > l.rows-3 {
+			return false
+		}
+		c0, c1, r0 = c, c, r
+		r = r0 + 1
+		for r < l.rows-1 && l.dirn[r][c] == layoutNone && l.islands[r][c] == 0 {
+			r++
+		}
+		if l.dirn[r][c] != layoutNone {
+			r--
+		}
+		if r < r0+2 {
+			return false
+		}
+		r1 = r0 + 2 + rng.Intn(r-r0-1)
+		if l.nextToIsland(r1, c1) {
+			return false
+		}
+	}
+
+	return l.build(span{r0, c0, r1, c1}, planks, g)
+}
+
+// build lays a bridge of planks along sp along with its images under the
+// symmetry, reporting false and leaving the layout untouched if any of them
+// doesn't fit or they close too many cycles
+func (l *layout) build(sp span, planks int, g growth) bool {
+	trial := l.clone()
+	for _, image := range g.symmetry.images(sp, l.rows, l.cols) {
+		if !trial.fits(image) {
+			return false
+		}
+		trial.lay(image, planks)
+	}
+	if g.maxCycles >= 0 && trial.cycles() > g.maxCycles {
+		return false
+	}
+	*l = *trial
+	return true
+}
+
+// fits reports whether a bridge can be laid along sp: the cells between its
+// ends must be empty, and an end that isn't already an island can't be next
+// to one
+func (l *layout) fits(sp span) bool {
+	for r := sp.r0; r <= sp.r1; r++ {
+		for c := sp.c0; c <= sp.c1; c++ {
+			end := (r == sp.r0 && c == sp.c0) || (r == sp.r1 && c == sp.c1)
+			switch {
+			case end && l.islands[r][c] > 0:
+			case end:
+				if l.nextToIsland(r, c) {
+					return false
+				}
+			case l.islands[r][c] > 0 || l.dirn[r][c] != layoutNone:
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// lay draws a bridge of planks along sp and settles the islands at its ends
+func (l *layout) lay(sp span, planks int) {
+	dirn := layoutHorizontal
+	if sp.c0 == sp.c1 {
+		dirn = layoutVertical
+	}
+	for r := sp.r0; r <= sp.r1; r++ {
+		for c := sp.c0; c <= sp.c1; c++ {
+			l.dirn[r][c], l.planks[r][c] = dirn, planks
+		}
+	}
+	l.settle(sp.r0, sp.c0)
+	l.settle(sp.r1, sp.c1)
+}
+
+// clone returns a deep copy of the layout
+func (l *layout) clone() *layout {
+	copied := newLayout(l.rows, l.cols)
+	copied.count = l.count
+	for r := 0; r < l.rows; r++ {
+		copy(copied.islands[r], l.islands[r])
+		copy(copied.dirn[r], l.dirn[r])
+		copy(copied.planks[r], l.planks[r])
+	}
+	return copied
+}
+
+// settle makes (r,c) an island whose value is the planks of the bridges touching it
+func (l *layout) settle(r, c int) {
+	if l.islands[r][c] == 0 {
+		l.count++
+	}
+	l.dirn[r][c], l.planks[r][c] = layoutNone, 0
+
+	value := 0
+	if c < l.cols-1 && l.dirn[r][c+1] == layoutHorizontal {
+		value += l.planks[r][c+1]
+	}
+	if r > 0 && l.dirn[r-1][c] == layoutVertical {
+		value += l.planks[r-1][c]
+	}
+	if c > 0 && l.dirn[r][c-1] == layoutHorizontal {
+		value += l.planks[r][c-1]
+	}
+	if r < l.rows-1 && l.dirn[r+1][c] == layoutVertical {
+		value += l.planks[r+1][c]
+	}
+	l.islands[r][c] = value
+}
+
+// values returns the layout as board values, with the bridges drawn as markers
+// if withBridges is set
+func (l *layout) values(withBridges bool) [][]int {
+	values := make([][]int, l.rows)
+	for r := 0; r < l.rows; r++ {
+		values[r] = make([]int, l.cols)
+		for c := 0; c < l.cols; c++ {
+			switch {
+			case l.islands[r][c] > 0:
+				values[r][c] = l.islands[r][c]
+			case withBridges && l.dirn[r][c] == layoutHorizontal:
+				values[r][c] = horizontalMarker(l.planks[r][c])
+			case withBridges && l.dirn[r][c] == layoutVertical:
+				values[r][c] = verticalMarker(l.planks[r][c])
+			}
+		}
+	}
+	return values
+}
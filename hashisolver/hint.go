@@ -0,0 +1,125 @@
+// hashisolver/hint.go
+package hashisolver
+
+import "fmt"
+
+// Hint is a suggested next move for a partly solved puzzle
+type Hint struct {
+	// Move is the bridge to add. Count is the number of bridges between the
+	// islands once it is added.
+	Move Move
+	// Forced is set when the move follows by logic from the bridges already placed.
+	// Otherwise logic has stalled and the move is taken from a solution.
+	Forced bool
+	// Explanation says why the move was suggested
+	Explanation string
+}
+
+// Hint suggests a single next bridge for the puzzle as it stands, preferring one
+// the logical rules can prove is forced. The bridges may have been placed in
+// any order, by hand or otherwise, and if they can't all be part of a
+// solution the error wraps ErrNoSolution. The puzzle itself is left untouched.
+func (p *Puzzle) Hint() (*Hint, error) {
+	if p.IsComplete() {
+		return nil, ErrAlreadySolved
+	}
+
+	// The first move the logical rules make is forced by the bridges already
+	// placed, unless they go on to reach a contradiction, when some of those
+	// bridges must be wrong
+	trace, err := logicTrace(p.Clone())
+	if err != nil {
+		return nil, fmt.Errorf("%w: the bridges placed so far cannot be completed", ErrNoSolution)
+	}
+	if len(trace) > 0 {
+		move := trace[0]
+		return &Hint{Move: move, Forced: true, Explanation: move.Reason}, nil
+	}
+
+	// Nothing is forced, so suggest a bridge from a solution
+	solutions, err := SolveAll(p, 2, false)
+	if err != nil {
+		return nil, fmt.Errorf("%w: the bridges placed so far cannot be completed", ErrNoSolution)
+	}
+	solution := solutions[0]
+
+	for i := 0; i < p.Rows; i++ {
+		for j := 0; j < p.Cols; j++ {
+			node := p.At(j, i)
+			if node.Value() <= 0 {
+				continue
+			}
+
+			for _, dir := range []int{DirectionRight, DirectionDown} {
+				count := node.BridgesInDirection(dir)
+				if solution.At(j, i).BridgesInDirection(dir) <= count {
+					continue
+				}
+
+				neighbor := node.GetNeighbor(dir)
+				move := Move{
+					From:      Position{X: node.XPos(), Y: node.YPos()},
+					To:        Position{X: neighbor.XPos(), Y: neighbor.YPos()},
+					Count:     count + 1,
+					Technique: TechniqueSpeculation,
+				}
+
+				explanation := "No bridge is forced yet. This one is part of a solution, but others exist."
+				if len(solutions) == 1 {
+					explanation = "No bridge is forced yet, but this one is part of the only solution."
+				}
+				return &Hint{Move: move, Explanation: explanation}, nil
+			}
+		}
+	}
+
+	// Every bridge of the solution is already built, so the puzzle was complete
+	return nil, ErrAlreadySolved
+}
+
+// explainMove says why technique adds a bridge from node to neighbor, from the
+// state of the board just before it is added
+func explainMove(node, neighbor *Node, direction int, technique Technique, maxBridges int) string {
+	remaining := node.Value() - node.TotalBridges
+	island := fmt.Sprintf("The %d at (%d,%d)", node.Value(), node.XPos(), node.YPos())
+	to := fmt.Sprintf("(%d,%d)", neighbor.XPos(), neighbor.YPos())
+
+	switch technique {
+	case TechniqueOneDirection:
+		return fmt.Sprintf("%s needs %d more bridge(s) and %s is the only island it can still reach.",
+			island, remaining, to)
+	case TechniqueValueEqualsCapacity:
+		return fmt.Sprintf("%s needs %d more bridge(s), exactly as many as its neighbors can still take, so it connects to all of them.",
+			island, remaining)
+	case TechniqueForcedShare:
+		others := node.TotalPossibleMoves(maxBridges) - node.DirectionCapacity(direction, maxBridges)
+		return fmt.Sprintf("%s needs %d more bridge(s), but its other neighbors can take at most %d, so at least %d must go to %s.",
+			island, remaining, others, remaining-others, to)
+	case TechniqueIsolation:
+		return fmt.Sprintf("Without a bridge between (%d,%d) and %s, some islands would be cut off from the rest.",
+			node.XPos(), node.YPos(), to)
+	case TechniqueTwoDirections:
+		return fmt.Sprintf("%s needs %d more bridge(s) from its two open directions, and one neighbor can only take a single bridge, so %s must take one.",
+			island, remaining, to)
+	case TechniqueProbing:
+		return fmt.Sprintf("Trying both ways of a link still in doubt, the rules end up joining (%d,%d) and %s either way.",
+			node.XPos(), node.YPos(), to)
+	case TechniqueSpeculation:
+		return fmt.Sprintf("Nothing is forced, so guess a bridge between (%d,%d) and %s.", node.XPos(), node.YPos(), to)
+	}
+	return fmt.Sprintf("%s connects to %s by %s.", island, to, technique)
+}
+
+// directionTo returns the direction from one island to another in the same row or column
+func directionTo(from, to Position) int {
+	switch {
+	case to.Y < from.Y:
+		return DirectionUp
+	case to.Y > from.Y:
+		return DirectionDown
+	case to.X < from.X:
+		return DirectionLeft
+	default:
+		return DirectionRight
+	}
+}
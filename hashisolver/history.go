@@ -0,0 +1,97 @@
+// hashisolver/history.go
+package hashisolver
+
+// History is a list of moves made on a board, each kept with the changes it
+// made to the cells on a trail so that it can be taken back. The search
+// keeps one of the guesses it is exploring and backtracks through it, and a
+// board changed with AddBridge and RemoveBridge keeps one of those moves for
+// Undo and Redo.
+type History struct {
+	trail
+	done []step
+	// undone holds the moves taken back that can be made again, the next to
+	// make again last
+	undone []Move
+}
+
+// step is a move in a history, with the trail mark and bridge count to take
+// it back to
+type step struct {
+	move  Move
+	mark  int
+	built int
+}
+
+// push starts a move on the puzzle, returning the number of moves before it
+// to rewind to. Every change to the cells until the next move is part of it.
+func (h *History) push(puzzle *Puzzle, move Move) int {
+	h.done = append(h.done, step{move: move, mark: h.mark(), built: puzzle.BuiltBridges})
+	return len(h.done) - 1
+}
+
+// rewind takes back every move after the first n, latest first
+func (h *History) rewind(puzzle *Puzzle, n int) {
+	if n >= len(h.done) {
+		return
+	}
+	h.undo(h.done[n].mark)
+	puzzle.BuiltBridges = h.done[n].built
+	h.done = h.done[:n]
+}
+
+// Moves returns the moves made, oldest first
+func (h *History) Moves() []Move {
+	moves := make([]Move, len(h.done))
+	for i, s := range h.done {
+		moves[i] = s.move
+	}
+	return moves
+}
+
+// Undone returns the moves taken back that Redo would make again, the next
+// one first
+func (h *History) Undone() []Move {
+	moves := make([]Move, len(h.undone))
+	for i, move := range h.undone {
+		moves[len(moves)-1-i] = move
+	}
+	return moves
+}
+
+// History returns the moves made on the puzzle with AddBridge and
+// RemoveBridge, or nil if there have been none. A copy of the puzzle starts
+// without one.
+func (p *Puzzle) History() *History {
+	return p.history
+}
+
+// Undo takes back the last move made with AddBridge or RemoveBridge and
+// returns it, or returns false if there is none. The board is put back
+// exactly as it was, so it should only have changed through moves in its
+// history since.
+func (p *Puzzle) Undo() (Move, bool) {
+	h := p.history
+	if h == nil || len(h.done) == 0 {
+		return Move{}, false
+	}
+	move := h.done[len(h.done)-1].move
+	h.rewind(p, len(h.done)-1)
+	h.undone = append(h.undone, move)
+	return move, true
+}
+
+// Redo makes the last move taken back with Undo again and returns it, or
+// returns false if there is none. Making any other move forgets the moves
+// that could be redone.
+func (p *Puzzle) Redo() (Move, bool) {
+	h := p.history
+	if h == nil || len(h.undone) == 0 {
+		return Move{}, false
+	}
+	move := h.undone[len(h.undone)-1]
+	if err := p.edit(move.From, move.To, move.Removed, true); err != nil {
+		return Move{}, false
+	}
+	h.undone = h.undone[:len(h.undone)-1]
+	return move, true
+}
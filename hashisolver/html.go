@@ -0,0 +1,252 @@
+// hashisolver/html.go
+package hashisolver
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+)
+
+// htmlIsland is an island as the page's script sees it
+type htmlIsland struct {
+	X     int `json:"x"`
+	Y     int `json:"y"`
+	Value int `json:"value"`
+}
+
+// htmlBoard is the puzzle as the page's script sees it. Hash is the
+// SHA-256 of the solution's bridges written by bridgeListKey, so the page
+// can check a board without giving the solution away.
+type htmlBoard struct {
+	Cols       int          `json:"cols"`
+	Rows       int          `json:"rows"`
+	MaxBridges int          `json:"maxBridges"`
+	Islands    []htmlIsland `json:"islands"`
+	Hash       string       `json:"hash"`
+}
+
+// WriteHTML writes the puzzle as a web page that can be played in a
+// browser, with nothing else to download. Clicking an island and then one
+// it faces adds a bridge between them, cycling through the counts like the
+// play command, and clicking a bridge does the same. The solution is kept
+// only as a hash, which the page's Check button compares the board with.
+func WriteHTML(w io.Writer, puzzle *Puzzle, solution *Solution) error {
+	clues := puzzle.Clues()
+	solved, err := clues.Apply(solution)
+	if err != nil {
+		return err
+	}
+	board := htmlBoard{
+		Cols:       clues.Cols,
+		Rows:       clues.Rows,
+		MaxBridges: clues.Rules.MaxBridgesPerPair,
+		Islands:    []htmlIsland{},
+		Hash:       fmt.Sprintf("%x", sha256.Sum256([]byte(bridgeListKey(solved.Bridges())))),
+	}
+	for y := 0; y < clues.Rows; y++ {
+		for x := 0; x < clues.Cols; x++ {
+			if value := clues.At(x, y).Value(); value > 0 {
+				board.Islands = append(board.Islands, htmlIsland{X: x, Y: y, Value: value})
+			}
+		}
+	}
+	title := puzzle.Metadata.Title
+	if title == "" {
+		title = fmt.Sprintf("Hashi %dx%d", clues.Cols, clues.Rows)
+	}
+	return htmlPage.Execute(w, struct {
+		Title string
+		Board htmlBoard
+	}{title, board})
+}
+
+// bridgeListKey writes the bridges as "x,y-x,y:count" for each, sorted and
+// joined with ";". The page's script writes its board the same way.
+func bridgeListKey(bridges []Bridge) string {
+	parts := []string{}
+	for _, b := range bridges {
+		parts = append(parts, fmt.Sprintf("%d,%d-%d,%d:%d", b.From.X, b.From.Y, b.To.X, b.To.Y, b.Count))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ";")
+}
+
+var htmlPage = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+svg { max-width: 100%; height: auto; user-select: none; }
+.island circle { fill: white; stroke: black; stroke-width: 2; cursor: pointer; }
+.island text { font-size: 18px; text-anchor: middle; dominant-baseline: central; pointer-events: none; }
+.island.done circle { fill: #ddd; }
+.island.over text { fill: #c00; }
+.island.selected circle { stroke: #c00; stroke-width: 3; }
+.bridge line { stroke: black; stroke-width: 2; }
+.bridge line.hit { stroke: transparent; stroke-width: 14; cursor: pointer; }
+#message { min-height: 1.5em; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>Click an island and then one it faces to add a bridge between them. Clicking again, or clicking the bridge, adds another, until it goes back to none.</p>
+<svg id="board" xmlns="http://www.w3.org/2000/svg"></svg>
+<p><button id="check">Check</button> <button id="reset">Start again</button></p>
+<p id="message"></p>
+<script>
+"use strict";
+const board = {{.Board}};
+const cell = 40, radius = cell * 0.4;
+const svgNS = "http://www.w3.org/2000/svg";
+const svg = document.getElementById("board");
+const message = document.getElementById("message");
+svg.setAttribute("width", board.cols * cell);
+svg.setAttribute("height", board.rows * cell);
+svg.setAttribute("viewBox", "0 0 " + board.cols * cell + " " + board.rows * cell);
+
+const islands = new Map();
+for (const island of board.islands) {
+  islands.set(island.x + "," + island.y, island);
+}
+// bridges maps "x,y-x,y", from the left or top end, to its count
+let bridges = new Map();
+let selected = null;
+
+// facing returns the islands a and b in order if they face each other with
+// nothing between them, or null
+function facing(a, b) {
+  if (a.x !== b.x && a.y !== b.y) return null;
+  if (a.x > b.x || a.y > b.y) [a, b] = [b, a];
+  const dx = Math.sign(b.x - a.x), dy = Math.sign(b.y - a.y);
+  for (let x = a.x + dx, y = a.y + dy; x !== b.x || y !== b.y; x += dx, y += dy) {
+    if (islands.has(x + "," + y)) return null;
+  }
+  return [a, b];
+}
+
+function key(a, b) {
+  return a.x + "," + a.y + "-" + b.x + "," + b.y;
+}
+
+function ends(k) {
+  const [from, to] = k.split("-").map((p) => p.split(",").map(Number));
+  return [{x: from[0], y: from[1]}, {x: to[0], y: to[1]}];
+}
+
+// crosses reports whether a bridge between a and b would cross one already
+// on the board
+function crosses(a, b) {
+  for (const k of bridges.keys()) {
+    const [c, d] = ends(k);
+    if (a.y === b.y && c.x === d.x && a.x < c.x && c.x < b.x && c.y < a.y && a.y < d.y) return true;
+    if (a.x === b.x && c.y === d.y && c.x < a.x && a.x < d.x && a.y < c.y && c.y < b.y) return true;
+  }
+  return false;
+}
+
+// cycle adds a bridge between a and b, or clears them once no more fit
+function cycle(a, b) {
+  const k = key(a, b);
+  const count = bridges.get(k) || 0;
+  if (count === 0 && crosses(a, b)) {
+    message.textContent = "That bridge would cross another.";
+    return;
+  }
+  if (count >= board.maxBridges) {
+    bridges.delete(k);
+  } else {
+    bridges.set(k, count + 1);
+  }
+  message.textContent = "";
+}
+
+function clickIsland(island) {
+  if (selected === null || selected === island) {
+    selected = selected === island ? null : island;
+  } else {
+    const pair = facing(selected, island);
+    if (pair) {
+      cycle(pair[0], pair[1]);
+      selected = null;
+    } else {
+      selected = island;
+    }
+  }
+  draw();
+}
+
+function element(name, attributes, parent) {
+  const e = document.createElementNS(svgNS, name);
+  for (const [k, v] of Object.entries(attributes)) e.setAttribute(k, v);
+  parent.appendChild(e);
+  return e;
+}
+
+function center(island) {
+  return [(island.x + 0.5) * cell, (island.y + 0.5) * cell];
+}
+
+function draw() {
+  svg.replaceChildren();
+  const counts = new Map();
+  for (const [k, count] of bridges) {
+    const [a, b] = ends(k);
+    const [x0, y0] = center(a), [x1, y1] = center(b);
+    const g = element("g", {class: "bridge"}, svg);
+    for (let i = 0; i < count; i++) {
+      // The lines are spread evenly about the centre line
+      const offset = (i - (count - 1) / 2) * 6;
+      if (y0 === y1) {
+        element("line", {x1: x0 + radius, y1: y0 + offset, x2: x1 - radius, y2: y1 + offset}, g);
+      } else {
+        element("line", {x1: x0 + offset, y1: y0 + radius, x2: x1 + offset, y2: y1 - radius}, g);
+      }
+    }
+    const hit = element("line", {class: "hit", x1: x0, y1: y0, x2: x1, y2: y1}, g);
+    hit.addEventListener("click", () => { cycle(a, b); selected = null; draw(); });
+    for (const p of [a, b]) counts.set(p.x + "," + p.y, (counts.get(p.x + "," + p.y) || 0) + count);
+  }
+  for (const island of board.islands) {
+    const count = counts.get(island.x + "," + island.y) || 0;
+    let c = "island";
+    if (count === island.value) c += " done";
+    if (count > island.value) c += " over";
+    if (island === selected) c += " selected";
+    const g = element("g", {class: c}, svg);
+    const [cx, cy] = center(island);
+    element("circle", {cx: cx, cy: cy, r: radius}, g).addEventListener("click", () => clickIsland(island));
+    element("text", {x: cx, y: cy}, g).textContent = island.value;
+  }
+}
+
+async function check() {
+  const parts = [];
+  for (const [k, count] of bridges) parts.push(k + ":" + count);
+  parts.sort();
+  if (!window.crypto || !crypto.subtle) {
+    message.textContent = "This browser can't check the board here.";
+    return;
+  }
+  const digest = await crypto.subtle.digest("SHA-256", new TextEncoder().encode(parts.join(";")));
+  const hash = Array.from(new Uint8Array(digest), (b) => b.toString(16).padStart(2, "0")).join("");
+  message.textContent = hash === board.hash ? "Solved!" : "Not solved yet.";
+}
+
+document.getElementById("check").addEventListener("click", check);
+document.getElementById("reset").addEventListener("click", () => {
+  bridges = new Map();
+  selected = null;
+  message.textContent = "";
+  draw();
+});
+draw();
+</script>
+</body>
+</html>
+`))
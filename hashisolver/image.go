@@ -0,0 +1,193 @@
+// hashisolver/image.go
+package hashisolver
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"io"
+	"time"
+)
+
+// ImageStyle sets the sizes, in pixels, of a board drawn as an image
+type ImageStyle struct {
+	// CellSize is the width and height of each cell of the board
+	CellSize int
+	// StrokeWidth is the thickness of the bridges and island outlines
+	StrokeWidth int
+}
+
+// DefaultImageStyle is the style the image output formats use
+var DefaultImageStyle = ImageStyle{CellSize: 32, StrokeWidth: 2}
+
+// Colors of a board drawn as an image, by their index in boardPalette
+const (
+	imageBackground = iota
+	imageInk
+	imageHighlight
+)
+
+// boardPalette is white paper, black ink and red for a highlighted bridge
+var boardPalette = color.Palette{
+	color.RGBA{0xff, 0xff, 0xff, 0xff},
+	color.RGBA{0x00, 0x00, 0x00, 0xff},
+	color.RGBA{0xd0, 0x20, 0x20, 0xff},
+}
+
+// digitFont is a 5x7 pixel glyph for each digit, drawn scaled up, so clues
+// can be written without a font file
+var digitFont = [10][7]string{
+	{"01110", "10001", "10011", "10101", "11001", "10001", "01110"},
+	{"00100", "01100", "00100", "00100", "00100", "00100", "01110"},
+	{"01110", "10001", "00001", "00010", "00100", "01000", "11111"},
+	{"11111", "00010", "00100", "00010", "00001", "10001", "01110"},
+	{"00010", "00110", "01010", "10010", "11111", "00010", "00010"},
+	{"11111", "10000", "11110", "00001", "00001", "10001", "01110"},
+	{"00110", "01000", "10000", "11110", "10001", "10001", "01110"},
+	{"11111", "00001", "00010", "00100", "01000", "01000", "01000"},
+	{"01110", "10001", "10001", "01110", "10001", "10001", "01110"},
+	{"01110", "10001", "10001", "01111", "00001", "00010", "01100"},
+}
+
+// DrawBoard draws the puzzle with whatever bridges are on it as an image,
+// each island a circle with its clue in it. The bridges between the islands
+// of highlight, if it isn't nil, are drawn in red.
+func DrawBoard(puzzle *Puzzle, style ImageStyle, highlight *Bridge) *image.Paletted {
+	if style.CellSize < 8 {
+		style.CellSize = 8
+	}
+	if style.StrokeWidth < 1 {
+		style.StrokeWidth = 1
+	}
+	cell := style.CellSize
+	img := image.NewPaletted(image.Rect(0, 0, puzzle.Cols*cell, puzzle.Rows*cell), boardPalette)
+	radius := cell * 2 / 5
+
+	for _, bridge := range puzzle.Bridges() {
+		ink := uint8(imageInk)
+		if highlight != nil && bridge.From == highlight.From && bridge.To == highlight.To {
+			ink = imageHighlight
+		}
+		drawBridge(img, style, bridge, radius, ink)
+	}
+
+	for y := 0; y < puzzle.Rows; y++ {
+		for x := 0; x < puzzle.Cols; x++ {
+			if value := puzzle.At(x, y).Value(); value > 0 {
+				cx, cy := x*cell+cell/2, y*cell+cell/2
+				drawRing(img, cx, cy, radius, style.StrokeWidth)
+				drawNumber(img, cx, cy, radius, value)
+			}
+		}
+	}
+	return img
+}
+
+// drawBridge draws count parallel lines between the edges of the circles
+// of a bridge's islands
+func drawBridge(img *image.Paletted, style ImageStyle, bridge Bridge, radius int, ink uint8) {
+	cell := style.CellSize
+	x0, y0 := bridge.From.X*cell+cell/2, bridge.From.Y*cell+cell/2
+	x1, y1 := bridge.To.X*cell+cell/2, bridge.To.Y*cell+cell/2
+	gap := style.StrokeWidth * 2
+	for i := 0; i < bridge.Count; i++ {
+		// The lines are spread evenly about the centre line
+		offset := (2*i-(bridge.Count-1))*gap/2 - style.StrokeWidth/2
+		if y0 == y1 {
+			fill(img, image.Rect(x0+radius, y0+offset, x1-radius, y0+offset+style.StrokeWidth), ink)
+		} else {
+			fill(img, image.Rect(x0+offset, y0+radius, x0+offset+style.StrokeWidth, y1-radius), ink)
+		}
+	}
+}
+
+// drawRing draws a circle outline of the given radius and thickness
+func drawRing(img *image.Paletted, cx, cy, radius, width int) {
+	outer, inner := radius*radius, (radius-width)*(radius-width)
+	for dy := -radius; dy <= radius; dy++ {
+		for dx := -radius; dx <= radius; dx++ {
+			if d := dx*dx + dy*dy; d < outer && d >= inner {
+				img.SetColorIndex(cx+dx, cy+dy, imageInk)
+			}
+		}
+	}
+}
+
+// drawNumber writes a number centred on (cx,cy) in the digit font, scaled
+// to fit in a circle of the given radius
+func drawNumber(img *image.Paletted, cx, cy, radius, value int) {
+	digits := fmt.Sprint(value)
+	// Each glyph pixel is drawn as a square, with a column of space between
+	// digits
+	columns := 6*len(digits) - 1
+	size := radius * 3 / 2 / columns
+	if height := radius * 6 / 5 / 7; height < size {
+		size = height
+	}
+	if size < 1 {
+		size = 1
+	}
+
+	left, top := cx-columns*size/2, cy-7*size/2
+	for i, digit := range digits {
+		for row, bits := range digitFont[digit-'0'] {
+			for col, bit := range bits {
+				if bit == '1' {
+					x, y := left+(6*i+col)*size, top+row*size
+					fill(img, image.Rect(x, y, x+size, y+size), imageInk)
+				}
+			}
+		}
+	}
+}
+
+// fill paints a rectangle of the image
+func fill(img *image.Paletted, r image.Rectangle, ink uint8) {
+	draw.Draw(img, r, image.NewUniform(img.Palette[ink]), image.Point{}, draw.Src)
+}
+
+// WriteGIF writes the puzzle with whatever bridges are on it as a still GIF
+// image in the default style
+func WriteGIF(w io.Writer, puzzle *Puzzle) error {
+	return gif.Encode(w, DrawBoard(puzzle, DefaultImageStyle, nil), nil)
+}
+
+// WritePNG writes the puzzle with whatever bridges are on it as a PNG image
+// in the default style
+func WritePNG(w io.Writer, puzzle *Puzzle) error {
+	return png.Encode(w, DrawBoard(puzzle, DefaultImageStyle, nil))
+}
+
+// WriteReplayGIF writes the replay as an animated GIF with a frame for the
+// board at the start and after each move, the bridge the move changed in
+// red. Each frame is shown for delay, and the last for a few seconds more
+// before the animation starts again.
+func WriteReplayGIF(w io.Writer, replay *Replay, style ImageStyle, delay time.Duration) error {
+	hundredths := int(delay / (10 * time.Millisecond))
+	if hundredths < 1 {
+		hundredths = 1
+	}
+
+	anim := &gif.GIF{}
+	for n := 0; n <= len(replay.Moves); n++ {
+		board, err := replay.Board(n)
+		if err != nil {
+			return err
+		}
+		var highlight *Bridge
+		if n > 0 && !replay.Moves[n-1].Backtrack {
+			move := replay.Moves[n-1].Move
+			highlight = &Bridge{From: move.From, To: move.To}
+			if move.To.Y < move.From.Y || move.To.X < move.From.X {
+				highlight.From, highlight.To = move.To, move.From
+			}
+		}
+		anim.Image = append(anim.Image, DrawBoard(board, style, highlight))
+		anim.Delay = append(anim.Delay, hundredths)
+	}
+	anim.Delay[len(anim.Delay)-1] += 300
+	return gif.EncodeAll(w, anim)
+}
@@ -0,0 +1,118 @@
+// hashisolver/invariants.go
+package hashisolver
+
+import (
+	"fmt"
+	"strings"
+)
+
+// checkInvariants looks for bookkeeping that disagrees with itself: bridge
+// totals, blocked flags and the markers drawn on the board. It returns a
+// description of the first problem found, or "" if there is none.
+func checkInvariants(puzzle *Puzzle) string {
+	for i := 0; i < puzzle.Rows; i++ {
+		for j := 0; j < puzzle.Cols; j++ {
+			node := puzzle.At(j, i)
+			if node.Value() <= 0 {
+				continue
+			}
+			if problem := checkNode(puzzle, node); problem != "" {
+				return fmt.Sprintf("%s\n%s", problem, describeNode(node))
+			}
+		}
+	}
+	return ""
+}
+
+// checkNode checks one island's counts against its neighbors and the board
+func checkNode(puzzle *Puzzle, node *Node) string {
+	at := fmt.Sprintf("island at (%d,%d)", node.XPos(), node.YPos())
+
+	sum := node.UpBridges + node.DownBridges + node.LeftBridges + node.RightBridges
+	if node.TotalBridges != sum {
+		return fmt.Sprintf("%s has TotalBridges %d but %d bridges by direction", at, node.TotalBridges, sum)
+	}
+
+	blocked := 0
+	for _, direction := range []int{DirectionUp, DirectionDown, DirectionLeft, DirectionRight} {
+		if node.IsBlocked(direction) {
+			blocked++
+		}
+
+		neighbor := node.GetNeighbor(direction)
+		count := node.BridgesInDirection(direction)
+		if neighbor == nil {
+			if count != 0 {
+				return fmt.Sprintf("%s has %d bridge(s) in direction %d with no neighbor", at, count, direction)
+			}
+			continue
+		}
+
+		back := opposite(direction)
+		if neighbor.BridgesInDirection(back) != count {
+			return fmt.Sprintf("%s has %d bridge(s) to (%d,%d), which has %d back",
+				at, count, neighbor.XPos(), neighbor.YPos(), neighbor.BridgesInDirection(back))
+		}
+		if node.IsBlocked(direction) != neighbor.IsBlocked(back) {
+			return fmt.Sprintf("%s and (%d,%d) disagree on whether the way between them is blocked",
+				at, neighbor.XPos(), neighbor.YPos())
+		}
+
+		// Each pair's cells are checked once, from the island above or to the left
+		if direction == DirectionDown || direction == DirectionRight {
+			for _, cell := range pathCells(puzzle, node, direction) {
+				end, dir := puzzle.spanning(cell)
+				if spanned := end == node && dir == direction; spanned != (count > 0) {
+					return fmt.Sprintf("%s has %d bridge(s) to (%d,%d), but the cell at (%d,%d) is marked as under them: %v",
+						at, count, neighbor.XPos(), neighbor.YPos(), cell.XPos(), cell.YPos(), spanned)
+				}
+			}
+		}
+	}
+
+	if node.NumBlocked != blocked {
+		return fmt.Sprintf("%s has NumBlocked %d but %d directions blocked", at, node.NumBlocked, blocked)
+	}
+	return ""
+}
+
+// opposite returns the direction pointing back the other way
+func opposite(direction int) int {
+	switch direction {
+	case DirectionUp:
+		return DirectionDown
+	case DirectionDown:
+		return DirectionUp
+	case DirectionLeft:
+		return DirectionRight
+	default:
+		return DirectionLeft
+	}
+}
+
+// describeNode dumps an island's counts and flags for a failure report
+func describeNode(node *Node) string {
+	blocked := []string{}
+	for direction, name := range directionNames {
+		if node.IsBlocked(direction) {
+			blocked = append(blocked, name)
+		}
+	}
+	return fmt.Sprintf("(%d,%d): value %d, bridges up %d down %d left %d right %d, total %d, blocked [%s], NumBlocked %d",
+		node.XPos(), node.YPos(), node.Value(), node.UpBridges, node.DownBridges, node.LeftBridges, node.RightBridges,
+		node.TotalBridges, strings.Join(blocked, " "), node.NumBlocked)
+}
+
+// verifyAfter checks the invariants, if the solver was asked to, once what has
+// been done to the puzzle. A failure wraps ErrInvariant and carries the board
+// so the broken state can be reproduced.
+func (s *solver) verifyAfter(puzzle *Puzzle, what string) error {
+	if !s.verify {
+		return nil
+	}
+	problem := checkInvariants(puzzle)
+	if problem == "" {
+		return nil
+	}
+	return fmt.Errorf("%w after %s: %s\n%s", ErrInvariant, what, problem, strings.Join(MapLines(puzzle), "\n"))
+}
@@ -0,0 +1,86 @@
+// hashisolver/json.go
+package hashisolver
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Position is a cell on the board
+type Position struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+// String writes the position as (x,y)
+func (p Position) String() string {
+	return fmt.Sprintf("(%d,%d)", p.X, p.Y)
+}
+
+// BridgeJSON is the name Bridge had when bridges were only listed in JSON
+type BridgeJSON = Bridge
+
+// PuzzleJSON is the JSON form of a puzzle and the bridges placed on it
+type PuzzleJSON struct {
+	Metadata Metadata     `json:"metadata"`
+	Rows     int          `json:"rows"`
+	Cols     int          `json:"cols"`
+	Solved   bool         `json:"solved"`
+	Grid     []string     `json:"grid"`
+	Bridges  []BridgeJSON `json:"bridges"`
+}
+
+// ToJSON converts the puzzle into its JSON form
+func ToJSON(puzzle *Puzzle) PuzzleJSON {
+	result := PuzzleJSON{
+		Metadata: puzzle.Metadata,
+		Rows:     puzzle.Rows,
+		Cols:     puzzle.Cols,
+		Solved:   puzzle.IsComplete(),
+		Grid:     MapLines(puzzle),
+		Bridges:  puzzle.Bridges(),
+	}
+
+	return result
+}
+
+// ParseJSON reads a puzzle written by WriteJSON. The grid is read as a drawn
+// board, so any bridges already on it are placed, and the metadata comes from
+// the JSON rather than a header.
+func ParseJSON(input io.Reader, rules RuleSet) (*Puzzle, error) {
+	var data PuzzleJSON
+	if err := json.NewDecoder(input).Decode(&data); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPuzzle, err)
+	}
+	return fromJSON(data, rules)
+}
+
+// fromJSON builds the puzzle a decoded PuzzleJSON describes
+func fromJSON(data PuzzleJSON, rules RuleSet) (*Puzzle, error) {
+	if len(data.Grid) == 0 {
+		return nil, fmt.Errorf("%w: no grid in JSON", ErrInvalidPuzzle)
+	}
+
+	// Water is drawn as spaces, which the text format would trim from the ends of rows
+	var text strings.Builder
+	for _, row := range data.Grid {
+		text.WriteString(strings.ReplaceAll(row, " ", "."))
+		text.WriteByte('\n')
+	}
+
+	puzzle, err := ParseText(strings.NewReader(text.String()), rules, ParseOptions{Layout: LayoutCompact, Strict: true})
+	if err != nil {
+		return nil, err
+	}
+	puzzle.Metadata = data.Metadata
+	return puzzle, nil
+}
+
+// WriteJSON writes the puzzle, its metadata and its bridges as indented JSON
+func WriteJSON(w io.Writer, puzzle *Puzzle) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(ToJSON(puzzle))
+}
@@ -0,0 +1,83 @@
+// hashisolver/latex.go
+package hashisolver
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteLaTeX writes the pages as a LaTeX document laid out like WritePDF,
+// each page a TikZ picture filling the space inside the margins, for
+// typesetting with pdflatex or editing before it is printed
+func WriteLaTeX(w io.Writer, pages []PDFPage, options PDFOptions) error {
+	out := bufio.NewWriter(w)
+	fmt.Fprintf(out, `\documentclass{article}
+\usepackage[paperwidth=%[1]spt,paperheight=%[2]spt,margin=%[3]spt]{geometry}
+\usepackage[scaled]{helvet}
+\usepackage{tikz}
+\renewcommand{\familydefault}{\sfdefault}
+\pagestyle{empty}
+\setlength{\parindent}{0pt}
+\setlength{\topskip}{0pt}
+\begin{document}
+`, pdfNumber(options.PageWidth), pdfNumber(options.PageHeight), pdfNumber(options.Margin))
+
+	for i, page := range pages {
+		if i > 0 {
+			out.WriteString("\\newpage\n")
+		}
+		// The picture is the size of the space inside the margins, so its
+		// coordinates are the page's
+		canvas := &tikzCanvas{}
+		drawPage(canvas, page, options)
+		fmt.Fprintf(out, "\\begin{tikzpicture}[x=1pt,y=1pt]\n\\useasboundingbox (%s,%s) rectangle (%s,%s);\n%s\\end{tikzpicture}\n",
+			pdfNumber(options.Margin), pdfNumber(options.Margin),
+			pdfNumber(options.PageWidth-options.Margin), pdfNumber(options.PageHeight-options.Margin), canvas.b.String())
+	}
+	out.WriteString("\\end{document}\n")
+	return out.Flush()
+}
+
+// tikzCanvas draws on a page as TikZ commands
+type tikzCanvas struct {
+	b strings.Builder
+}
+
+func (c *tikzCanvas) line(x0, y0, x1, y1, width float64) {
+	n := pdfNumber
+	fmt.Fprintf(&c.b, "\\draw[line width=%spt] (%s,%s) -- (%s,%s);\n", n(width), n(x0), n(y0), n(x1), n(y1))
+}
+
+func (c *tikzCanvas) circle(cx, cy, r, width float64) {
+	n := pdfNumber
+	fmt.Fprintf(&c.b, "\\filldraw[fill=white,line width=%spt] (%s,%s) circle (%spt);\n", n(width), n(cx), n(cy), n(r))
+}
+
+func (c *tikzCanvas) text(x, y, size float64, text string) {
+	n := pdfNumber
+	fmt.Fprintf(&c.b, "\\node[anchor=base west,inner sep=0] at (%s,%s) {\\fontsize{%s}{%s}\\selectfont %s};\n",
+		n(x), n(y), n(size), n(size), latexEscape(text))
+}
+
+// latexEscape escapes the characters LaTeX gives a meaning to
+func latexEscape(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch r {
+		case '\\':
+			b.WriteString(`\textbackslash{}`)
+		case '~':
+			b.WriteString(`\textasciitilde{}`)
+		case '^':
+			b.WriteString(`\textasciicircum{}`)
+		case '&', '%', '$', '#', '_', '{', '}':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,77 @@
+// hashisolver/legend.go
+package hashisolver
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Summary is what the footer under a printed solution says about its solve
+type Summary struct {
+	// Islands is the number of islands on the board
+	Islands int
+	// Bridges is the number of bridges placed, and Needed the number the
+	// clues call for
+	Bridges, Needed int
+	// Techniques are the rules that placed the bridges on the board, in the
+	// order they were first used, or nil if the solve wasn't recorded
+	Techniques []Technique
+	// Elapsed is how long the solve took, or 0 if it wasn't timed
+	Elapsed time.Duration
+}
+
+// Summarize gathers the summary of a board, from the moves that left it as
+// it is, as given by Replay.Made, and the time its solve took. moves may be
+// nil and elapsed 0 when they aren't known.
+func Summarize(puzzle *Puzzle, moves []Move, elapsed time.Duration) Summary {
+	summary := Summary{Needed: puzzle.FullBridges / 2, Elapsed: elapsed}
+	for i := range puzzle.Cells {
+		if puzzle.Cells[i].Value() > 0 {
+			summary.Islands++
+		}
+	}
+	for _, b := range puzzle.Bridges() {
+		summary.Bridges += b.Count
+	}
+	seen := map[Technique]bool{}
+	for _, move := range moves {
+		if !move.Removed && !seen[move.Technique] {
+			seen[move.Technique] = true
+			summary.Techniques = append(summary.Techniques, move.Technique)
+		}
+	}
+	return summary
+}
+
+// WriteLegend writes the footer that goes under a board drawn in the
+// charset: a line naming what each bridge symbol means, up to the most
+// bridges a pair may take under the puzzle's rules, and a line with the
+// summary, so that a pasted solution explains itself
+func WriteLegend(w io.Writer, puzzle *Puzzle, charset Charset, summary Summary) error {
+	out := bufio.NewWriter(w)
+	across, down := []string{}, []string{}
+	for count := 1; count <= puzzle.maxBridges() && count <= len(bridgeNames); count++ {
+		name := strings.TrimPrefix(bridgeNames[count-1], "a ")
+		across = append(across, charset.symbol(horizontalMarker(count))+" "+name)
+		down = append(down, charset.symbol(verticalMarker(count))+" "+name)
+	}
+	fmt.Fprintf(out, "Legend: numbers and letters are islands and their clues; across %s; down %s\n",
+		strings.Join(across, ", "), strings.Join(down, ", "))
+
+	fmt.Fprintf(out, "Summary: %s, %d of %d bridges placed", plural(summary.Islands, "island"), summary.Bridges, summary.Needed)
+	if len(summary.Techniques) > 0 {
+		names := make([]string, len(summary.Techniques))
+		for i, technique := range summary.Techniques {
+			names[i] = string(technique)
+		}
+		fmt.Fprintf(out, ", techniques used: %s", strings.Join(names, ", "))
+	}
+	if summary.Elapsed > 0 {
+		fmt.Fprintf(out, ", solved in %v", summary.Elapsed.Round(time.Microsecond))
+	}
+	out.WriteString("\n")
+	return out.Flush()
+}
@@ -0,0 +1,99 @@
+// hashisolver/logger.go
+package hashisolver
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Field is a named value attached to a log message, such as a coordinate,
+// direction or technique
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Logger receives the solver's diagnostic output. Debug is for the detail of
+// every deduction and guess, Info for the milestones of a solve.
+type Logger interface {
+	Debug(msg string, fields ...Field)
+	Info(msg string, fields ...Field)
+}
+
+// Level is the least important message a TextLogger writes
+type Level int
+
+const (
+	// LevelDebug writes every message
+	LevelDebug Level = iota
+	// LevelInfo writes only the milestones of a solve
+	LevelInfo
+)
+
+// TextLogger writes one line per message, as the level and message followed
+// by key=value fields, quoting values with spaces. It is safe for concurrent use.
+type TextLogger struct {
+	mu    sync.Mutex
+	w     io.Writer
+	level Level
+}
+
+// NewTextLogger returns a logger writing messages at level or above to w
+func NewTextLogger(w io.Writer, level Level) *TextLogger {
+	return &TextLogger{w: w, level: level}
+}
+
+// Debug writes a detail message if the logger's level includes it
+func (l *TextLogger) Debug(msg string, fields ...Field) {
+	l.write(LevelDebug, "debug", msg, fields)
+}
+
+// Info writes a milestone message
+func (l *TextLogger) Info(msg string, fields ...Field) {
+	l.write(LevelInfo, "info", msg, fields)
+}
+
+// write formats a message and its fields onto one line
+func (l *TextLogger) write(level Level, name, msg string, fields []Field) {
+	if level < l.level {
+		return
+	}
+
+	var line strings.Builder
+	fmt.Fprintf(&line, "level=%s msg=%q", name, msg)
+	for _, field := range fields {
+		value := fmt.Sprint(field.Value)
+		if value == "" || strings.ContainsAny(value, " \"=") {
+			value = fmt.Sprintf("%q", value)
+		}
+		fmt.Fprintf(&line, " %s=%s", field.Key, value)
+	}
+	line.WriteByte('\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	io.WriteString(l.w, line.String())
+}
+
+// nopLogger discards everything, for solves nobody is listening to
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...Field) {}
+func (nopLogger) Info(string, ...Field)  {}
+
+// debugLogger returns the logger the old debug flag stands for: everything to
+// stdout if set, and nothing otherwise
+func debugLogger(debug bool) Logger {
+	if debug {
+		return NewTextLogger(os.Stdout, LevelDebug)
+	}
+	return nopLogger{}
+}
+
+// posFields returns the fields for a position on the board
+func posFields(x, y int) []Field {
+	return []Field{{"x", x}, {"y", y}}
+}
@@ -0,0 +1,30 @@
+// hashisolver/memory.go
+package hashisolver
+
+import "fmt"
+
+// WithMaxMemory gives up on the solve with ErrMemoryLimit once the changes
+// recorded to undo guesses would take more than maxBytes, or never if it is 0
+func WithMaxMemory(maxBytes int64) Option {
+	return func(o *Options) {
+		o.MaxMemory = maxBytes
+	}
+}
+
+// checkMemory notes how much the trail of changes takes, failing with
+// ErrMemoryLimit once it is past the budget
+func (s *solver) checkMemory() error {
+	if s.history == nil {
+		return nil
+	}
+	held := int64(len(s.history.changes)) * changeBytes
+	if held > s.stats.PeakMemory {
+		s.stats.PeakMemory = held
+	}
+	if s.maxMemory > 0 && held > s.maxMemory {
+		s.log.Info("memory limit reached", Field{"held", held}, Field{"limit", s.maxMemory})
+		return fmt.Errorf("%w: %d changes of about %d bytes each are held to undo guesses, and the limit is %d bytes",
+			ErrMemoryLimit, len(s.history.changes), changeBytes, s.maxMemory)
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+// hashisolver/moves.go
+package hashisolver
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// Technique names the rule that placed a bridge
+type Technique string
+
+const (
+	// TechniqueOneDirection places bridges on an island with only one direction open
+	TechniqueOneDirection Technique = "only one direction open"
+	// TechniqueValueEqualsCapacity fills every direction of an island whose
+	// remaining value equals what its neighbors can take
+	TechniqueValueEqualsCapacity Technique = "value equals capacity"
+	// TechniqueForcedShare places the bridges the other directions cannot take
+	TechniqueForcedShare Technique = "forced share"
+	// TechniqueIsolation places a bridge that keeps part of the board from being cut off
+	TechniqueIsolation Technique = "isolation prevention"
+	// TechniqueTwoDirections places a bridge on an island with two directions
+	// open when one neighbor can only take a single bridge
+	TechniqueTwoDirections Technique = "two directions open"
+	// TechniqueProbing places a bridge that applying the rules both with and
+	// without another bridge on some undecided link agrees on
+	TechniqueProbing Technique = "probing"
+	// TechniqueSpeculation places a bridge as a guess that may be backtracked
+	TechniqueSpeculation Technique = "speculation"
+	// TechniqueByHand is a bridge added or removed with AddBridge or RemoveBridge
+	TechniqueByHand Technique = "by hand"
+)
+
+// Techniques lists the techniques the solver places bridges with, the
+// logical rules first and then probing and speculation
+var Techniques = []Technique{
+	TechniqueOneDirection, TechniqueValueEqualsCapacity, TechniqueForcedShare,
+	TechniqueIsolation, TechniqueTwoDirections, TechniqueProbing, TechniqueSpeculation,
+}
+
+// Move is one step of a solve, either a bridge being placed or a failed guess
+// being abandoned
+type Move struct {
+	// From and To are the islands the bridge joins
+	From, To Position
+	// Count is the number of bridges between the islands after this move
+	Count int
+	// Technique is the rule that placed the bridge
+	Technique Technique
+	// Reason explains why the technique applied, from the board as it was
+	Reason string
+	// Speculative is set for bridges placed while a guess is being explored,
+	// which are undone if the guess fails
+	Speculative bool
+	// Depth is the level of nested speculation the move was made at
+	Depth int
+	// Backtrack marks a failed guess. The moves at the end of the stream with
+	// a Depth at or above this one are undone.
+	Backtrack bool
+	// Removed marks a bridge taken away rather than placed
+	Removed bool
+}
+
+// String describes the move for a human reader, with islands given as (x,y)
+func (m Move) String() string {
+	if m.Backtrack {
+		return fmt.Sprintf("backtrack: the guess at depth %d failed", m.Depth)
+	}
+
+	verb := ""
+	if m.Removed {
+		verb = "removed "
+	}
+	desc := fmt.Sprintf("%s: %s(%d,%d) to (%d,%d), now %d bridge(s)",
+		m.Technique, verb, m.From.X, m.From.Y, m.To.X, m.To.Y, m.Count)
+	if m.Speculative {
+		desc += fmt.Sprintf(" at depth %d", m.Depth)
+	}
+	return desc
+}
+
+// SolveStream solves a parsed puzzle like SolveWithStats, sending each move on
+// moves as it is made. moves is closed when the solve finishes. If ctx is
+// cancelled while a send is blocked, the solve gives up with ctx.Err().
+func SolveStream(ctx context.Context, puzzle *Puzzle, moves chan<- Move, opts ...Option) (*SolveResult, error) {
+	defer close(moves)
+
+	options := NewOptions(opts...)
+	s := newSolver(ctx, options)
+	s.moves = moves
+	return s.run(puzzle, options)
+}
+
+// connect places a bridge found by technique and reports it
+func (s *solver) connect(puzzle *Puzzle, node, neighbor *Node, direction int, technique Technique, depth int) error {
+	// The reason has to be worked out before the bridge changes the board
+	reason := ""
+	if s.moves != nil || s.tracing {
+		reason = explainMove(node, neighbor, direction, technique, puzzle.maxBridges())
+	}
+
+	if err := ConnectNodes(puzzle, node, neighbor, direction, technique == TechniqueSpeculation); err != nil {
+		return fmt.Errorf("%w: %v", ErrNoSolution, err)
+	}
+	s.log.Debug("placed bridge", Field{"from", Position{X: node.XPos(), Y: node.YPos()}},
+		Field{"to", Position{X: neighbor.XPos(), Y: neighbor.YPos()}}, Field{"direction", directionNames[direction]},
+		Field{"technique", technique}, Field{"count", node.BridgesInDirection(direction)}, Field{"depth", depth})
+	s.placed(node, direction, technique, depth, reason)
+	if err := s.checkMemory(); err != nil {
+		return err
+	}
+	return s.verifyAfter(puzzle, fmt.Sprintf("%s from (%d,%d) to (%d,%d)",
+		technique, node.XPos(), node.YPos(), neighbor.XPos(), neighbor.YPos()))
+}
+
+// placed reports a bridge that has just been added from node in direction
+func (s *solver) placed(node *Node, direction int, technique Technique, depth int, reason string) {
+	if s.moves == nil && !s.tracing {
+		return
+	}
+
+	neighbor := node.GetNeighbor(direction)
+	s.emit(Move{
+		From:        Position{X: node.XPos(), Y: node.YPos()},
+		To:          Position{X: neighbor.XPos(), Y: neighbor.YPos()},
+		Count:       node.BridgesInDirection(direction),
+		Technique:   technique,
+		Reason:      reason,
+		Speculative: depth > 0,
+		Depth:       depth,
+	})
+}
+
+// backtracked reports that the guess made at depth has failed
+func (s *solver) backtracked(depth int) {
+	s.emit(Move{Depth: depth, Backtrack: true})
+}
+
+// emit adds a move to the trace, if one is being kept, and sends it on the
+// moves channel unless the caller gives up first
+func (s *solver) emit(move Move) {
+	if s.tracing {
+		s.trace = append(s.trace, move)
+	}
+	if s.moves == nil {
+		return
+	}
+
+	if s.ctx == nil {
+		s.moves <- move
+		return
+	}
+
+	select {
+	case s.moves <- move:
+	case <-s.ctx.Done():
+	}
+}
+
+// WriteTrace prints the moves one per line, numbered from 1
+func WriteTrace(w io.Writer, trace []Move) error {
+	for i, move := range trace {
+		if _, err := fmt.Fprintf(w, "%d. %s\n", i+1, move); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+// hashisolver/options.go
+package hashisolver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Options configures how a puzzle is solved. New capabilities are added here
+// rather than as extra parameters on the Solve functions.
+type Options struct {
+	// Debug writes the solver's progress to stdout, unless Logger is set
+	Debug bool
+	// Logger, if set, receives the solver's debug and info messages
+	Logger Logger
+	// Rules is the rule set used to read input. If set when solving an already
+	// parsed puzzle, it replaces the puzzle's own rule set.
+	Rules RuleSet
+	// MaxDepth is the most nested speculative guesses allowed, or 0 for no limit
+	MaxDepth int
+	// LogicOnly stops where the logical rules stall instead of speculating
+	LogicOnly bool
+	// Progress, if set, is called periodically with the state of the solve
+	Progress ProgressFunc
+	// ProgressInterval is how often Progress is called, or 0 for DefaultProgressInterval
+	ProgressInterval time.Duration
+	// Trace records every move, with the technique that made it, in SolveResult.Trace
+	Trace bool
+	// Verify checks the solver's internal bookkeeping after every move and
+	// aborts with ErrInvariant at the first inconsistency
+	Verify bool
+	// Timeout gives up on the solve with ErrTimeout after this long, or never if 0
+	Timeout time.Duration
+	// Anytime, if set, records the best board reached during the solve
+	Anytime *Anytime
+	// MaxMemory gives up with ErrMemoryLimit once the changes recorded to
+	// undo guesses would take more than this many bytes, or never if 0
+	MaxMemory int64
+	// Strategy picks the islands to guess at, or HighestRemainingValue if nil
+	Strategy Strategy
+	// Probing tries both ways of undecided links once the other rules stall
+	Probing bool
+	// Parallelism is the most goroutines exploring guesses at once, or 0 or
+	// 1 for the calling goroutine alone
+	Parallelism int
+	// Engine names the engine that solves the puzzle, or EngineHeuristic if empty
+	Engine string
+}
+
+// Option sets one field of Options
+type Option func(*Options)
+
+// NewOptions returns the default options with opts applied in order
+func NewOptions(opts ...Option) Options {
+	var options Options
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// WithDebug turns the solver's debug output on or off
+func WithDebug(debug bool) Option {
+	return func(o *Options) {
+		o.Debug = debug
+	}
+}
+
+// WithLogger sends the solver's messages to logger, taking the place of Debug
+func WithLogger(logger Logger) Option {
+	return func(o *Options) {
+		o.Logger = logger
+	}
+}
+
+// WithRuleSet solves under the given rule set
+func WithRuleSet(rules RuleSet) Option {
+	return func(o *Options) {
+		o.Rules = rules
+	}
+}
+
+// WithMaxDepth limits speculation to maxDepth nested guesses, or none if 0
+func WithMaxDepth(maxDepth int) Option {
+	return func(o *Options) {
+		o.MaxDepth = maxDepth
+	}
+}
+
+// WithLogicOnly disables speculation, so only the logical rules are applied
+func WithLogicOnly(logicOnly bool) Option {
+	return func(o *Options) {
+		o.LogicOnly = logicOnly
+	}
+}
+
+// WithTrace turns recording of the technique trace on or off
+func WithTrace(trace bool) Option {
+	return func(o *Options) {
+		o.Trace = trace
+	}
+}
+
+// WithVerify turns the invariant checks after every move on or off
+func WithVerify(verify bool) Option {
+	return func(o *Options) {
+		o.Verify = verify
+	}
+}
+
+// WithTimeout gives up on the solve after timeout, leaving the partial board
+// in the result, or never if timeout is 0
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.Timeout = timeout
+	}
+}
+
+// newSolver returns a solver looking for a single solution under the options
+func newSolver(ctx context.Context, options Options) *solver {
+	interval := options.ProgressInterval
+	if interval <= 0 {
+		interval = DefaultProgressInterval
+	}
+
+	logger := options.Logger
+	if logger == nil {
+		logger = debugLogger(options.Debug)
+	}
+
+	strategy := options.Strategy
+	if strategy == nil {
+		strategy = HighestRemainingValue()
+	}
+
+	// The calling goroutine is one of those allowed
+	var slots chan struct{}
+	if options.Parallelism > 1 {
+		slots = make(chan struct{}, options.Parallelism-1)
+	}
+
+	return &solver{
+		ctx:              ctx,
+		log:              logger,
+		noGuess:          options.LogicOnly,
+		maxDepth:         options.MaxDepth,
+		progress:         options.Progress,
+		progressInterval: interval,
+		lastProgress:     time.Now(),
+		tracing:          options.Trace,
+		verify:           options.Verify,
+		anytime:          options.Anytime,
+		maxMemory:        options.MaxMemory,
+		probing:          options.Probing,
+		strategy:         strategy,
+		slots:            slots,
+		limit:            1,
+		keep:             true,
+	}
+}
+
+// solve runs the search from the top, reporting ErrDepthLimit when a solution
+// may have been cut off by maxDepth
+func (s *solver) solve(puzzle *Puzzle) (*Puzzle, error) {
+	result, err := s.search(puzzle, 0)
+	if err != nil && s.depthLimited {
+		err = fmt.Errorf("%w (max depth %d)", ErrDepthLimit, s.maxDepth)
+	}
+	return result, err
+}
+
+// SolveWith solves a parsed puzzle under the given options, giving up with
+// ctx.Err() once the context is cancelled or its deadline passes
+func SolveWith(ctx context.Context, puzzle *Puzzle, opts ...Option) (*Puzzle, error) {
+	result, err := SolveWithStats(ctx, puzzle, opts...)
+	return result.Puzzle, err
+}
+
+// SolveReader reads a puzzle under the options' rule set, or the default rules
+// if none is given, and solves it
+func SolveReader(ctx context.Context, input io.Reader, opts ...Option) (*Puzzle, error) {
+	options := NewOptions(opts...)
+
+	rules := options.Rules
+	if rules.MaxBridgesPerPair <= 0 {
+		rules = DefaultRuleSet
+	}
+
+	puzzle, err := Parse(input, rules)
+	if err != nil {
+		return nil, err
+	}
+	return SolveWith(ctx, puzzle, opts...)
+}
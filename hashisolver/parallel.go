@@ -0,0 +1,144 @@
+// hashisolver/parallel.go
+package hashisolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// WithParallelism explores the branches of a guess in up to n goroutines at
+// once, each on its own copy of the board, stopping the others as soon as one
+// finds a solution. 0 or 1 explores one branch at a time. Only a search for a
+// single solution, without a trace or a move stream, is spread out, and when
+// there are several solutions it may find a different one than it would alone.
+// The logger is called from every goroutine, and each goroutine holds its own
+// history of guesses to the memory budget.
+func WithParallelism(n int) Option {
+	return func(o *Options) {
+		o.Parallelism = n
+	}
+}
+
+// parallel reports whether branches guessing this many ways can be explored
+// at once
+func (s *solver) parallel(branches int) bool {
+	return s.slots != nil && branches > 1 && s.limit == 1 && s.moves == nil && !s.tracing
+}
+
+// fork returns a solver for one branch explored on its own goroutine. It
+// shares the configuration and the free goroutines, but keeps its own
+// history and statistics and doesn't report progress.
+func (s *solver) fork(ctx context.Context) *solver {
+	return &solver{
+		ctx:       ctx,
+		log:       s.log,
+		noGuess:   s.noGuess,
+		maxDepth:  s.maxDepth,
+		maxPasses: s.maxPasses,
+		probing:   s.probing,
+		strategy:  s.strategy,
+		maxMemory: s.maxMemory,
+		history:   &History{},
+		slots:     s.slots,
+		verify:    s.verify,
+		anytime:   s.anytime,
+		limit:     s.limit,
+		keep:      s.keep,
+	}
+}
+
+// join adds the work done by a forked solver to this one's
+func (s *solver) join(f *solver) {
+	s.stats.NodesExplored += f.stats.NodesExplored
+	s.stats.Branches += f.stats.Branches
+	s.stats.Iterations += f.stats.Iterations
+	s.stats.Probes += f.stats.Probes
+	s.stats.Clones += f.stats.Clones
+	s.stats.PeakMemory += f.stats.PeakMemory
+	if f.stats.MaxDepth > s.stats.MaxDepth {
+		s.stats.MaxDepth = f.stats.MaxDepth
+	}
+	s.depthLimited = s.depthLimited || f.depthLimited
+	s.count += f.count
+	s.solutions = append(s.solutions, f.solutions...)
+}
+
+// exploreParallel tries each branch of the guess made at depth on a copy of
+// the board, in a goroutine of its own while there are any free and on this
+// one otherwise. The puzzle itself is left as it was.
+func (s *solver) exploreParallel(puzzle *Puzzle, guess *decision, depth int) (*Puzzle, error) {
+	node, dir, counts := guess.node, guess.dir, guess.counts
+	parent := s.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	forks := make([]*solver, len(counts))
+	solved := make([]*Puzzle, len(counts))
+	errs := make([]error, len(counts))
+
+	// explore runs one branch on its own copy. The others are stopped once
+	// it succeeds, or fails in a way the sequential search wouldn't go on from.
+	explore := func(i int) {
+		f := forks[i]
+		if errs[i] = f.cancelled(); errs[i] != nil {
+			return
+		}
+		board := puzzle.Clone()
+		f.stats.Clones++
+		attach(board, &f.history.trail)
+		if errs[i] = f.guess(board, board.At(node.XPos(), node.YPos()), dir, counts[i], depth); errs[i] == nil {
+			solved[i], errs[i] = f.search(board, depth+1)
+		}
+		attach(board, nil)
+		if errs[i] == nil || errors.Is(errs[i], ErrInvariant) || errors.Is(errs[i], ErrMemoryLimit) {
+			cancel()
+		}
+	}
+
+	// Hand out branches while there are goroutines free, then take the rest
+	var wg sync.WaitGroup
+	inline := []int{}
+	for i := range counts {
+		forks[i] = s.fork(ctx)
+		select {
+		case s.slots <- struct{}{}:
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				defer func() { <-s.slots }()
+				explore(i)
+			}(i)
+		default:
+			inline = append(inline, i)
+		}
+	}
+	for _, i := range inline {
+		explore(i)
+	}
+	wg.Wait()
+
+	for _, f := range forks {
+		s.join(f)
+	}
+	for i := range counts {
+		if errs[i] == nil {
+			return solved[i], nil
+		}
+	}
+
+	if err := s.cancelled(); err != nil {
+		return puzzle, err
+	}
+	for _, err := range errs {
+		if errors.Is(err, ErrInvariant) || errors.Is(err, ErrMemoryLimit) {
+			return puzzle, err
+		}
+	}
+	return puzzle, fmt.Errorf("%w: every number of bridges going %s from the %d at (%d,%d) leads to a contradiction",
+		ErrNoSolution, directionNames[dir], node.Value(), node.XPos(), node.YPos())
+}
@@ -0,0 +1,581 @@
+// hashisolver/parse.go
+package hashisolver
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// TextLayout selects how cells are laid out on each line of the text format
+type TextLayout int
+
+const (
+	// LayoutAuto uses LayoutSpaced if any line has whitespace between cells, otherwise LayoutCompact
+	LayoutAuto TextLayout = iota
+	// LayoutCompact has one character (or bracketed clue) per cell, e.g. "2.3"
+	LayoutCompact
+	// LayoutSpaced has cells separated by spaces or tabs, e.g. "2 . 12"
+	LayoutSpaced
+)
+
+// ParseOptions controls how puzzle input is read
+type ParseOptions struct {
+	// Layout selects how cells are separated in the text format
+	Layout TextLayout
+	// Strict rejects ragged rows, unknown characters and boards without islands,
+	// which are otherwise padded or treated as water
+	Strict bool
+}
+
+// ParseError reports a problem with the input, with the position when it is known
+type ParseError struct {
+	Line   int
+	Column int
+	Msg    string
+}
+
+// Is makes every ParseError match ErrInvalidPuzzle
+func (e *ParseError) Is(target error) bool {
+	return target == ErrInvalidPuzzle
+}
+
+func (e *ParseError) Error() string {
+	if e.Line == 0 {
+		return e.Msg
+	}
+	if e.Column == 0 {
+		return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+	}
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Msg)
+}
+
+// boardLine is a line of the board along with where it came from in the input
+type boardLine struct {
+	text   string
+	number int
+	indent int
+	// blank is set for a line of nothing but whitespace, which is a row of
+	// water on a drawn board and skipped on any other
+	blank bool
+}
+
+// cell is a parsed board value and the column (1-based, within the trimmed line) it started at
+type cell struct {
+	value  int
+	column int
+}
+
+// Parse reads a puzzle in the text format, one line per row of the board,
+// detecting whether cells are separated by whitespace
+func Parse(input io.Reader, rules RuleSet) (*Puzzle, error) {
+	return ParseText(input, rules, ParseOptions{})
+}
+
+// ParseText reads a puzzle in the text format using the given options
+func ParseText(input io.Reader, rules RuleSet, opts ParseOptions) (*Puzzle, error) {
+	scanner := bufio.NewScanner(input)
+
+	// Read the puzzle from the input, skipping comments and collecting the
+	// header. Blank lines are held back until the next row, as they are rows
+	// of water if the board turns out to be drawn; so are lines of spaces
+	// before the first row or after the last, as a drawn board writes them.
+	lines := []boardLine{}
+	blanks := []boardLine{}
+	metadata := Metadata{}
+	number := 0
+	for scanner.Scan() {
+		number++
+		raw := strings.TrimRight(scanner.Text(), "\r")
+		line := strings.TrimSpace(raw)
+		if len(line) == 0 {
+			if raw != "" || len(lines) > 0 {
+				blanks = append(blanks, boardLine{number: number, indent: len(raw), blank: true})
+			}
+			continue
+		}
+		if comment(line, len(lines) > 0, rules) {
+			continue
+		}
+
+		// key: value lines are only allowed before the first row of the board
+		if len(lines) == 0 {
+			if key, value, ok := parseHeader(line); ok {
+				metadata.set(key, value)
+				blanks = blanks[:0]
+				continue
+			}
+		}
+
+		indent := len(raw) - len(strings.TrimLeft(raw, " \t"))
+		lines = append(append(lines, blanks...), boardLine{text: line, number: number, indent: indent})
+		blanks = blanks[:0]
+	}
+	for _, line := range blanks {
+		if line.indent > 0 && len(lines) > 0 {
+			lines = append(lines, line)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading input: %v", err)
+	}
+
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("%w: no input provided", ErrInvalidPuzzle)
+	}
+
+	layout := opts.Layout
+	if layout == LayoutAuto {
+		layout = detectLayout(lines, rules)
+	}
+
+	// Leading spaces are water on a board with bridges drawn on it, and its rows
+	// may be ragged where trailing spaces were trimmed. Blank lines are rows of
+	// water there, and skipped on any other board.
+	drawn := layout == LayoutCompact && drawnBoard(lines, rules)
+	if !drawn {
+		kept := lines[:0]
+		for _, line := range lines {
+			if !line.blank {
+				kept = append(kept, line)
+			}
+		}
+		lines = kept
+	}
+
+	// Parse each line of the puzzle
+	values := make([][]int, len(lines))
+	columns := make([][]int, len(lines))
+	width := -1
+	for i, line := range lines {
+		var cells []cell
+		var err error
+		if layout == LayoutSpaced {
+			cells, err = parseSpacedRow(line.text, rules, opts.Strict)
+		} else if drawn {
+			// Parse the indent as water, so columns are counted from the start of the line
+			indent := line.indent
+			lines[i].indent = 0
+			line = lines[i]
+			cells, err = parseRow(strings.Repeat(" ", indent)+line.text, rules, opts.Strict)
+		} else {
+			cells, err = parseRow(line.text, rules, opts.Strict)
+		}
+		if err != nil {
+			return nil, line.locate(err)
+		}
+
+		if opts.Strict && !drawn {
+			if width < 0 {
+				width = len(cells)
+			} else if len(cells) != width {
+				column := len(line.text) + 1
+				if len(cells) > width {
+					column = cells[width].column
+				}
+				return nil, line.locate(&ParseError{
+					Column: column,
+					Msg:    fmt.Sprintf("expected %d cells, found %d", width, len(cells)),
+				})
+			}
+		}
+
+		values[i] = make([]int, len(cells))
+		columns[i] = make([]int, len(cells))
+		for j, c := range cells {
+			values[i][j] = c.value
+			columns[i][j] = c.column
+		}
+	}
+
+	puzzle := NewPuzzle(values, rules)
+	puzzle.Metadata = metadata
+
+	if row, col, err := placeBridges(puzzle, values); err != nil {
+		return nil, lines[row].locate(&ParseError{Column: columns[row][col], Msg: err.Error()})
+	}
+
+	if opts.Strict && puzzle.FullBridges == 0 {
+		return nil, &ParseError{Msg: "puzzle has no islands"}
+	}
+
+	return puzzle, nil
+}
+
+// locate fills in the input position of an error raised while parsing this line
+func (l boardLine) locate(err error) error {
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		return &ParseError{Line: l.number, Msg: err.Error()}
+	}
+
+	located := *parseErr
+	located.Line = l.number
+	if located.Column > 0 {
+		located.Column += l.indent
+	}
+	return &located
+}
+
+// Metadata is the provenance recorded in a puzzle file's header
+type Metadata struct {
+	Title      string            `json:"title,omitempty"`
+	Author     string            `json:"author,omitempty"`
+	Difficulty string            `json:"difficulty,omitempty"`
+	Source     string            `json:"source,omitempty"`
+	Extra      map[string]string `json:"extra,omitempty"`
+}
+
+// set stores a header value, keeping unrecognised keys in Extra
+func (m *Metadata) set(key, value string) {
+	switch key {
+	case "title":
+		m.Title = value
+	case "author":
+		m.Author = value
+	case "difficulty":
+		m.Difficulty = value
+	case "source":
+		m.Source = value
+	default:
+		if m.Extra == nil {
+			m.Extra = map[string]string{}
+		}
+		m.Extra[key] = value
+	}
+}
+
+// parseHeader splits a "key: value" header line, where the key is a single word
+func parseHeader(line string) (string, string, bool) {
+	colon := strings.Index(line, ":")
+	if colon <= 0 {
+		return "", "", false
+	}
+
+	key := strings.ToLower(strings.TrimSpace(line[:colon]))
+	for _, char := range key {
+		if (char < 'a' || char > 'z') && char != '_' && char != '-' {
+			return "", "", false
+		}
+	}
+
+	return key, strings.TrimSpace(line[colon+1:]), true
+}
+
+// parseRow splits one line of the text format into cells.
+// Clues are a single digit, a hex letter (a=10, b=11, ...) or a bracketed
+// number such as [12]. '.', '0' and spaces are water; other characters are
+// water too unless strict is set, in which case they are an error.
+func parseRow(line string, rules RuleSet, strict bool) ([]cell, error) {
+	cells := []cell{}
+
+	for i := 0; i < len(line); i++ {
+		char := line[i]
+		start := i
+
+		value := 0
+		switch {
+		case char >= '1' && char <= '9':
+			value = int(char - '0')
+		case char == '|':
+			value = verticalMarker(1)
+		case char == '"':
+			value = verticalMarker(2)
+		case char == '#':
+			value = verticalMarker(3)
+		case char == '-':
+			value = horizontalMarker(1)
+		case char == '=':
+			value = horizontalMarker(2)
+		case char == 'E' && rules.MaxIslandValue > 0 && rules.MaxIslandValue < 14:
+			// E is a triple bridge unless the rules allow a clue of 14
+			value = horizontalMarker(3)
+		case char >= 'a' && char <= 'f':
+			value = int(char-'a') + 10
+		case char >= 'A' && char <= 'F':
+			value = int(char-'A') + 10
+		case char == '[':
+			end := i + 1
+			for end < len(line) && line[end] != ']' {
+				end++
+			}
+			if end == len(line) {
+				return nil, &ParseError{Column: i + 1, Msg: fmt.Sprintf("unterminated clue %q", line[i:])}
+			}
+			n, err := strconv.Atoi(line[i+1 : end])
+			if err != nil || n <= 0 {
+				return nil, &ParseError{Column: i + 1, Msg: fmt.Sprintf("invalid clue %q", line[i:end+1])}
+			}
+			value = n
+			i = end
+		case char == '.' || char == '0' || char == ' ' || char == '\t':
+			value = 0
+		default:
+			if strict {
+				return nil, &ParseError{Column: i + 1, Msg: fmt.Sprintf("unexpected character %q", char)}
+			}
+		}
+
+		if rules.MaxIslandValue > 0 && value > rules.MaxIslandValue {
+			return nil, &ParseError{
+				Column: start + 1,
+				Msg:    fmt.Sprintf("clue %d exceeds the maximum island value of %d", value, rules.MaxIslandValue),
+			}
+		}
+
+		cells = append(cells, cell{value: value, column: start + 1})
+	}
+
+	return cells, nil
+}
+
+// detectLayout picks LayoutSpaced when any line has whitespace between its cells.
+// Boards with bridges drawn on them, as printed by PrintMap, use spaces for water
+// and are always compact.
+func detectLayout(lines []boardLine, rules RuleSet) TextLayout {
+	if drawnBoard(lines, rules) {
+		return LayoutCompact
+	}
+
+	for _, line := range lines {
+		if strings.ContainsAny(line.text, " \t") {
+			return LayoutSpaced
+		}
+	}
+	return LayoutCompact
+}
+
+// drawnBoard reports whether any line has bridges drawn on it. Triple
+// bridges only count under rules that allow them, as # and E are otherwise
+// a comment and a clue of 14.
+func drawnBoard(lines []boardLine, rules RuleSet) bool {
+	symbols := "|\"-="
+	if rules.MaxBridgesPerPair >= 3 {
+		symbols += "#"
+		if rules.MaxIslandValue > 0 && rules.MaxIslandValue < 14 {
+			symbols += "E"
+		}
+	}
+	for _, line := range lines {
+		if strings.ContainsAny(line.text, symbols) {
+			return true
+		}
+	}
+	return false
+}
+
+// drawnRowChars are the characters a row of a drawn board is made of: water,
+// bridges and clues
+const drawnRowChars = " \t.0123456789abcdefABCDEF[]|\"#-=E"
+
+// comment reports whether a trimmed line is a comment, starting with #. Under
+// rules that allow triple bridges, a line after the first row of the board
+// that starts with # and holds nothing but a drawn row's characters is a row
+// with a triple bridge passing down its first cell instead.
+func comment(line string, inBoard bool, rules RuleSet) bool {
+	if !strings.HasPrefix(line, "#") {
+		return false
+	}
+	return !inBoard || rules.MaxBridgesPerPair < 3 || strings.Trim(line, drawnRowChars) != ""
+}
+
+// parseSpacedRow splits one whitespace-separated line into cells.
+// Each field is '.', a decimal number of any length, a hex letter or a bracketed clue.
+func parseSpacedRow(line string, rules RuleSet, strict bool) ([]cell, error) {
+	cells := []cell{}
+
+	for i := 0; i < len(line); {
+		// Skip to the start of the next field
+		if line[i] == ' ' || line[i] == '\t' {
+			i++
+			continue
+		}
+		start := i
+		for i < len(line) && line[i] != ' ' && line[i] != '\t' {
+			i++
+		}
+		field := line[start:i]
+
+		value := 0
+		if field != "." {
+			if n, err := strconv.Atoi(field); err == nil && n >= 0 {
+				value = n
+			} else {
+				row, err := parseRow(field, rules, strict)
+				if err != nil {
+					var parseErr *ParseError
+					if errors.As(err, &parseErr) {
+						parseErr.Column += start
+					}
+					return nil, err
+				}
+				if len(row) != 1 || row[0].value == 0 {
+					return nil, &ParseError{Column: start + 1, Msg: fmt.Sprintf("invalid cell %q", field)}
+				}
+				value = row[0].value
+			}
+		}
+
+		if rules.MaxIslandValue > 0 && value > rules.MaxIslandValue {
+			return nil, &ParseError{
+				Column: start + 1,
+				Msg:    fmt.Sprintf("clue %d exceeds the maximum island value of %d", value, rules.MaxIslandValue),
+			}
+		}
+
+		cells = append(cells, cell{value: value, column: start + 1})
+	}
+
+	return cells, nil
+}
+
+// ParseCSV reads a puzzle from CSV, where empty cells are water and numeric cells are islands.
+// The board is as tall as the number of records and as wide as the longest one,
+// unless opts.Strict is set, in which case every record must be the same width.
+func ParseCSV(input io.Reader, rules RuleSet, opts ParseOptions) (*Puzzle, error) {
+	reader := csv.NewReader(input)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: error reading CSV: %v", ErrInvalidPuzzle, err)
+	}
+
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%w: no input provided", ErrInvalidPuzzle)
+	}
+
+	values := make([][]int, len(records))
+	for i, record := range records {
+		if opts.Strict && len(record) != len(records[0]) {
+			return nil, &ParseError{
+				Line: i + 1,
+				Msg:  fmt.Sprintf("expected %d cells, found %d", len(records[0]), len(record)),
+			}
+		}
+
+		values[i] = make([]int, len(record))
+
+		for j, cell := range record {
+			cell = strings.TrimSpace(cell)
+			if cell == "" || cell == "." {
+				continue
+			}
+
+			value, err := strconv.Atoi(cell)
+			if err != nil || value < 0 {
+				return nil, &ParseError{Line: i + 1, Column: j + 1, Msg: fmt.Sprintf("invalid cell %q", cell)}
+			}
+			if rules.MaxIslandValue > 0 && value > rules.MaxIslandValue {
+				return nil, &ParseError{
+					Line:   i + 1,
+					Column: j + 1,
+					Msg:    fmt.Sprintf("clue %d exceeds the maximum island value of %d", value, rules.MaxIslandValue),
+				}
+			}
+
+			values[i][j] = value
+		}
+	}
+
+	puzzle := NewPuzzle(values, rules)
+	if opts.Strict && puzzle.FullBridges == 0 {
+		return nil, &ParseError{Msg: "puzzle has no islands"}
+	}
+
+	return puzzle, nil
+}
+
+// NewPuzzle builds a puzzle from a grid of clue values, where 0 is water.
+// Rows shorter than the longest one are padded with water.
+func NewPuzzle(values [][]int, rules RuleSet) *Puzzle {
+	cols := 0
+	for _, row := range values {
+		if len(row) > cols {
+			cols = len(row)
+		}
+	}
+
+	// Initialize the puzzle
+	puzzle := &Puzzle{
+		Rows:  len(values),
+		Cols:  cols,
+		Rules: rules,
+		grid:  grid{clues: make([]int, len(values)*cols)},
+	}
+	for i, row := range values {
+		for j, value := range row {
+			if value > 0 {
+				puzzle.grid.clues[i*cols+j] = value
+			}
+		}
+	}
+
+	puzzle.RecomputeNeighbors()
+	return puzzle
+}
+
+// blockObvious sets up the initial blockages: directions with no island to
+// connect to, and a 1 connecting to a 1
+func blockObvious(puzzle *Puzzle) {
+	for _, i := range puzzle.grid.islands {
+		node := &puzzle.Cells[i]
+		for dir := DirectionUp; dir <= DirectionRight; dir++ {
+			if neighbor := node.GetNeighbor(dir); neighbor == nil || (node.Value() == 1 && neighbor.Value() == 1) {
+				node.block(dir)
+			}
+		}
+	}
+}
+
+// SplitPuzzles splits a stream holding several puzzles in the text format
+// into the text of each. Puzzles are separated by an empty line or a "---"
+// line; separators before a puzzle's first board row are skipped, so a
+// header or comments can be set off from the board. Each puzzle's text keeps
+// the lines before it as empty lines, so errors from parsing it give line
+// numbers in the whole stream.
+func SplitPuzzles(input io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(input)
+
+	puzzles := []string{}
+	var current strings.Builder
+	hasBoard := false
+	for number := 0; scanner.Scan(); number++ {
+		raw := strings.TrimRight(scanner.Text(), "\r")
+		line := strings.TrimSpace(raw)
+
+		// Lines of spaces are left alone, as they can be water on a drawn board
+		if (raw == "" || line == "---") && hasBoard {
+			puzzles = append(puzzles, current.String())
+			current.Reset()
+			current.WriteString(strings.Repeat("\n", number+1))
+			hasBoard = false
+			continue
+		}
+
+		if line != "" && line != "---" && !strings.HasPrefix(line, "#") {
+			if _, _, ok := parseHeader(line); !ok {
+				hasBoard = true
+			}
+		}
+		if line == "---" {
+			raw = ""
+		}
+		current.WriteString(raw)
+		current.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading input: %v", err)
+	}
+
+	if hasBoard || len(puzzles) == 0 {
+		puzzles = append(puzzles, current.String())
+	}
+	return puzzles, nil
+}
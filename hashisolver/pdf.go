@@ -0,0 +1,258 @@
+// hashisolver/pdf.go
+package hashisolver
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// PDFPage is one page of a PDF: a heading and the boards laid out under it,
+// in a grid if there is more than one
+type PDFPage struct {
+	Heading string
+	Boards  []PDFBoard
+}
+
+// PDFBoard is a board drawn on a PDF page with whatever bridges are on it,
+// under a caption
+type PDFBoard struct {
+	Caption string
+	Puzzle  *Puzzle
+}
+
+// PDFOptions sets the paper size and margins of a PDF, in points
+type PDFOptions struct {
+	PageWidth, PageHeight float64
+	Margin                float64
+}
+
+// A4PDFOptions and LetterPDFOptions are the usual paper sizes with margins of
+// about 2cm
+var (
+	A4PDFOptions     = PDFOptions{PageWidth: 595.28, PageHeight: 841.89, Margin: 56}
+	LetterPDFOptions = PDFOptions{PageWidth: 612, PageHeight: 792, Margin: 56}
+)
+
+// Sizes of the text on a PDF page, in points
+const (
+	pdfHeadingSize = 18
+	pdfCaptionSize = 11
+)
+
+// WritePDF writes the pages as a PDF document. Each board is scaled to fill
+// the room it has inside the margins, keeping its cells square. The text is
+// set in Helvetica, which every PDF reader has, so no font is embedded.
+func WritePDF(w io.Writer, pages []PDFPage, options PDFOptions) error {
+	if len(pages) == 0 {
+		return fmt.Errorf("a PDF needs at least one page")
+	}
+
+	// Objects 1 to 3 are the catalog, the page tree and the font, and each
+	// page is then a page object followed by its contents
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+	}
+	kids := []string{}
+	for _, page := range pages {
+		canvas := &pdfCanvas{}
+		drawPage(canvas, page, options)
+		var contents bytes.Buffer
+		z := zlib.NewWriter(&contents)
+		if _, err := io.WriteString(z, canvas.b.String()); err != nil {
+			return err
+		}
+		if err := z.Close(); err != nil {
+			return err
+		}
+
+		pageObject := len(objects) + 1
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageObject))
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+				pdfNumber(options.PageWidth), pdfNumber(options.PageHeight), pageObject+1),
+			fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", contents.Len(), contents.String()))
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages))
+
+	out := &countingWriter{w: bufio.NewWriter(w)}
+	fmt.Fprint(out, "%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = out.n
+		fmt.Fprintf(out, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := out.n
+	fmt.Fprintf(out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	if out.err != nil {
+		return out.err
+	}
+	return out.w.Flush()
+}
+
+// countingWriter counts the bytes written through it, for the offsets of
+// the objects in a PDF, and keeps the first error
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int
+	err error
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += n
+	c.err = err
+	return n, err
+}
+
+// pageCanvas is what drawPage draws on, measuring in points up and right
+// from the bottom left corner of the page
+type pageCanvas interface {
+	// line draws a straight black line
+	line(x0, y0, x1, y1, width float64)
+	// circle draws a circle filled white with a black outline
+	circle(cx, cy, r, width float64)
+	// text writes black Helvetica, or the nearest sans serif, with its
+	// baseline starting at (x,y)
+	text(x, y, size float64, text string)
+}
+
+// drawPage draws a page: the heading at the top left, then the boards in a
+// grid filling the rest of the space inside the margins
+func drawPage(c pageCanvas, page PDFPage, options PDFOptions) {
+	top := options.PageHeight - options.Margin
+	if page.Heading != "" {
+		c.text(options.Margin, top-pdfHeadingSize, pdfHeadingSize, page.Heading)
+		top -= 2 * pdfHeadingSize
+	}
+	if len(page.Boards) == 0 {
+		return
+	}
+
+	columns := int(math.Ceil(math.Sqrt(float64(len(page.Boards)))))
+	rows := (len(page.Boards) + columns - 1) / columns
+	slotWidth := (options.PageWidth - 2*options.Margin) / float64(columns)
+	slotHeight := (top - options.Margin) / float64(rows)
+	for i, board := range page.Boards {
+		left := options.Margin + float64(i%columns)*slotWidth
+		slotTop := top - float64(i/columns)*slotHeight
+
+		// A gap is left between the boards of a grid
+		width, height := slotWidth, slotHeight
+		if len(page.Boards) > 1 {
+			width -= pdfCaptionSize
+			height -= pdfCaptionSize
+		}
+		if board.Caption != "" {
+			height -= 2 * pdfCaptionSize
+		}
+		puzzle := board.Puzzle
+		cell := math.Min(width/float64(puzzle.Cols), height/float64(puzzle.Rows))
+		left += (width - cell*float64(puzzle.Cols)) / 2
+
+		if board.Caption != "" {
+			c.text(left, slotTop-pdfCaptionSize, pdfCaptionSize, board.Caption)
+			slotTop -= 2 * pdfCaptionSize
+		}
+		drawPageBoard(c, puzzle, left, slotTop, cell)
+	}
+}
+
+// drawPageBoard draws a board with its top left corner at (left,top) and
+// cells of the given size
+func drawPageBoard(c pageCanvas, puzzle *Puzzle, left, top, cell float64) {
+	radius := cell * 0.4
+	center := func(p Position) (float64, float64) {
+		return left + (float64(p.X)+0.5)*cell, top - (float64(p.Y)+0.5)*cell
+	}
+
+	width := math.Max(cell/20, 0.5)
+	for _, bridge := range puzzle.Bridges() {
+		x0, y0 := center(bridge.From)
+		x1, y1 := center(bridge.To)
+		for i := 0; i < bridge.Count; i++ {
+			// The lines are spread evenly about the centre line
+			offset := (float64(i) - float64(bridge.Count-1)/2) * 3 * width
+			if y0 == y1 {
+				c.line(x0+radius, y0-offset, x1-radius, y1-offset, width)
+			} else {
+				c.line(x0+offset, y0-radius, x1+offset, y1+radius, width)
+			}
+		}
+	}
+
+	size := cell * 0.45
+	for y := 0; y < puzzle.Rows; y++ {
+		for x := 0; x < puzzle.Cols; x++ {
+			value := puzzle.At(x, y).Value()
+			if value <= 0 {
+				continue
+			}
+			cx, cy := center(Position{X: x, Y: y})
+			c.circle(cx, cy, radius, width)
+			// Every digit in Helvetica is 0.556 of the size wide, and its
+			// height is about 0.7 of it
+			digits := fmt.Sprint(value)
+			c.text(cx-0.278*size*float64(len(digits)), cy-0.35*size, size, digits)
+		}
+	}
+}
+
+// pdfCanvas draws on a page as PDF content stream operators
+type pdfCanvas struct {
+	b strings.Builder
+}
+
+func (c *pdfCanvas) line(x0, y0, x1, y1, width float64) {
+	n := pdfNumber
+	fmt.Fprintf(&c.b, "%s w %s %s m %s %s l S\n", n(width), n(x0), n(y0), n(x1), n(y1))
+}
+
+// circle draws the circle as four Bézier curves
+func (c *pdfCanvas) circle(cx, cy, r, width float64) {
+	k := r * 0.5523
+	n := pdfNumber
+	fmt.Fprintf(&c.b, "%s w 1 g %s %s m\n", n(width), n(cx+r), n(cy))
+	fmt.Fprintf(&c.b, "%s %s %s %s %s %s c\n", n(cx+r), n(cy+k), n(cx+k), n(cy+r), n(cx), n(cy+r))
+	fmt.Fprintf(&c.b, "%s %s %s %s %s %s c\n", n(cx-k), n(cy+r), n(cx-r), n(cy+k), n(cx-r), n(cy))
+	fmt.Fprintf(&c.b, "%s %s %s %s %s %s c\n", n(cx-r), n(cy-k), n(cx-k), n(cy-r), n(cx), n(cy-r))
+	fmt.Fprintf(&c.b, "%s %s %s %s %s %s c\n", n(cx+k), n(cy-r), n(cx+r), n(cy-k), n(cx+r), n(cy))
+	c.b.WriteString("b 0 g\n")
+}
+
+// text writes characters outside ASCII as '?'
+func (c *pdfCanvas) text(x, y, size float64, text string) {
+	var escaped strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			escaped.WriteByte('\\')
+			escaped.WriteRune(r)
+		case r < 32 || r > 126:
+			escaped.WriteByte('?')
+		default:
+			escaped.WriteRune(r)
+		}
+	}
+	fmt.Fprintf(&c.b, "BT /F1 %s Tf %s %s Td (%s) Tj ET\n", pdfNumber(size), pdfNumber(x), pdfNumber(y), escaped.String())
+}
+
+// pdfNumber writes a number with at most two decimal places, as PDF doesn't
+// accept exponents
+func pdfNumber(v float64) string {
+	s := strings.TrimRight(fmt.Sprintf("%.2f", v), "0")
+	return strings.TrimSuffix(s, ".")
+}
@@ -0,0 +1,127 @@
+// hashisolver/probe.go
+package hashisolver
+
+import (
+	"errors"
+	"fmt"
+)
+
+// WithProbing turns probing on or off. Once the other rules stall, probing
+// takes each undecided link in turn and applies the rules twice, once after
+// adding a bridge there and once after ruling more bridges out. A bridge
+// placed either way is placed for real, and if one way ends in a
+// contradiction the other is taken. It costs two rounds of the rules per
+// link tried but often saves a guess.
+func WithProbing(probing bool) Option {
+	return func(o *Options) {
+		o.Probing = probing
+	}
+}
+
+// probe tries both ways of each undecided link until one tells it something,
+// reporting whether it placed a bridge or blocked a direction
+func (s *solver) probe(puzzle *Puzzle, depth int) (bool, error) {
+	// The trials are made on the board and taken back through the history,
+	// by a solver that keeps no record of them
+	if s.history == nil {
+		s.history = &History{}
+	}
+	if puzzle.trail == nil {
+		attach(puzzle, &s.history.trail)
+		defer attach(puzzle, nil)
+	}
+	trial := &solver{ctx: s.ctx, log: nopLogger{}, maxMemory: s.maxMemory, history: s.history}
+	defer func() {
+		if trial.stats.PeakMemory > s.stats.PeakMemory {
+			s.stats.PeakMemory = trial.stats.PeakMemory
+		}
+	}()
+
+	maxBridges := puzzle.maxBridges()
+	for i := range puzzle.Cells {
+		node := &puzzle.Cells[i]
+		if node.Value() <= 0 {
+			continue
+		}
+		for _, dir := range []int{DirectionRight, DirectionDown} {
+			if node.DirectionCapacity(dir, maxBridges) == 0 {
+				continue
+			}
+			s.stats.Probes++
+			neighbor := node.GetNeighbor(dir)
+
+			with, errWith := trial.outcome(puzzle, node, dir, true, depth)
+			without, errWithout := trial.outcome(puzzle, node, dir, false, depth)
+			for _, err := range []error{errWith, errWithout} {
+				if err != nil && !errors.Is(err, ErrNoSolution) {
+					return false, err
+				}
+			}
+
+			switch {
+			case errWith != nil && errWithout != nil:
+				return false, fmt.Errorf("%w: both another bridge and no more bridges going %s from the %d at (%d,%d) lead to a contradiction",
+					ErrNoSolution, directionNames[dir], node.Value(), node.XPos(), node.YPos())
+			case errWith != nil:
+				s.log.Debug("probing rules out another bridge", append(posFields(node.XPos(), node.YPos()), Field{"direction", directionNames[dir]})...)
+				node.DirectionBlocked(dir)
+				return true, nil
+			case errWithout != nil:
+				return true, s.connect(puzzle, node, neighbor, dir, TechniqueProbing, depth)
+			}
+
+			// Place whatever bridges both trials placed
+			moved := false
+			for j := range puzzle.Cells {
+				cell := &puzzle.Cells[j]
+				for k, d := range []int{DirectionRight, DirectionDown} {
+					agreed := with[2*j+k]
+					if without[2*j+k] < agreed {
+						agreed = without[2*j+k]
+					}
+					for cell.BridgesInDirection(d) < agreed {
+						if err := s.connect(puzzle, cell, cell.GetNeighbor(d), d, TechniqueProbing, depth); err != nil {
+							return moved, err
+						}
+						moved = true
+					}
+				}
+			}
+			if moved {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// outcome applies the rules after adding a bridge from node in direction dir,
+// or after ruling out any more, and returns the bridges going right and down
+// from every cell that they reach. The board is then put back as it was.
+func (s *solver) outcome(puzzle *Puzzle, node *Node, dir int, bridge bool, depth int) ([]int, error) {
+	neighbor, count := node.GetNeighbor(dir), node.BridgesInDirection(dir)
+	if bridge {
+		count++
+	}
+	n := s.history.push(puzzle, Move{From: Position{X: node.XPos(), Y: node.YPos()}, To: Position{X: neighbor.XPos(), Y: neighbor.YPos()},
+		Count: count, Technique: TechniqueProbing, Speculative: true, Depth: depth})
+	defer s.history.rewind(puzzle, n)
+
+	if bridge {
+		if err := s.connect(puzzle, node, neighbor, dir, TechniqueProbing, depth); err != nil {
+			return nil, err
+		}
+	} else {
+		node.DirectionBlocked(dir)
+	}
+	if err := s.propagate(puzzle, depth); err != nil {
+		return nil, err
+	}
+
+	bridges := make([]int, 2*len(puzzle.Cells))
+	for i := range puzzle.Cells {
+		bridges[2*i] = puzzle.Cells[i].RightBridges
+		bridges[2*i+1] = puzzle.Cells[i].DownBridges
+	}
+	return bridges, nil
+}
@@ -0,0 +1,53 @@
+// hashisolver/progress.go
+package hashisolver
+
+import "time"
+
+// DefaultProgressInterval is how often a ProgressFunc is called during a solve
+const DefaultProgressInterval = 100 * time.Millisecond
+
+// Progress is a snapshot of a solve that is still running
+type Progress struct {
+	// BuiltBridges is the number of bridges on the board being searched
+	BuiltBridges int
+	// TotalBridges is the number of bridges in a complete solution
+	TotalBridges int
+	// Depth is the current level of nested speculation
+	Depth int
+	// Guesses are the guesses being explored, outermost first, with the
+	// branch of each being tried
+	Guesses []Guess
+}
+
+// ProgressFunc is called periodically with the state of a solve
+type ProgressFunc func(Progress)
+
+// WithProgress calls fn about every interval while solving, and once more when a
+// solution is found. An interval of 0 uses DefaultProgressInterval.
+func WithProgress(fn ProgressFunc, interval time.Duration) Option {
+	return func(o *Options) {
+		o.Progress = fn
+		o.ProgressInterval = interval
+	}
+}
+
+// reportProgress calls the progress callback if the interval has passed since
+// the last call, or always if final is set
+func (s *solver) reportProgress(puzzle *Puzzle, depth int, final bool) {
+	if s.progress == nil {
+		return
+	}
+
+	now := time.Now()
+	if !final && now.Sub(s.lastProgress) < s.progressInterval {
+		return
+	}
+	s.lastProgress = now
+
+	s.progress(Progress{
+		BuiltBridges: puzzle.PlacedBridges(),
+		TotalBridges: puzzle.FullBridges / 2,
+		Depth:        depth,
+		Guesses:      s.guesses(),
+	})
+}
@@ -0,0 +1,47 @@
+// hashisolver/puzzlediff.go
+package hashisolver
+
+import "fmt"
+
+// ClueDiff is a cell whose clue differs between two puzzles
+type ClueDiff struct {
+	At Position
+	// Old and New are the clues in the first puzzle and the second, 0 where
+	// the cell is water
+	Old, New int
+}
+
+// String describes the change for a human reader, with the cell given as (x,y)
+func (d ClueDiff) String() string {
+	return fmt.Sprintf("(%d,%d): %s", d.At.X, d.At.Y, d.Change())
+}
+
+// Change describes the change without saying where it is
+func (d ClueDiff) Change() string {
+	switch {
+	case d.Old == 0:
+		return fmt.Sprintf("island %d added", d.New)
+	case d.New == 0:
+		return fmt.Sprintf("island %d removed", d.Old)
+	}
+	return fmt.Sprintf("clue changed from %d to %d", d.Old, d.New)
+}
+
+// DiffPuzzles returns the cells whose clues differ between two puzzles of
+// the same size, in reading order. Bridges on either board are ignored.
+func DiffPuzzles(first, second *Puzzle) ([]ClueDiff, error) {
+	if first.Rows != second.Rows || first.Cols != second.Cols {
+		return nil, fmt.Errorf("the puzzles are different sizes, %dx%d and %dx%d",
+			first.Cols, first.Rows, second.Cols, second.Rows)
+	}
+	diffs := []ClueDiff{}
+	for y := 0; y < first.Rows; y++ {
+		for x := 0; x < first.Cols; x++ {
+			a, b := first.At(x, y).Value(), second.At(x, y).Value()
+			if a != b {
+				diffs = append(diffs, ClueDiff{At: Position{X: x, Y: y}, Old: a, New: b})
+			}
+		}
+	}
+	return diffs, nil
+}
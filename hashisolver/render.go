@@ -0,0 +1,87 @@
+// hashisolver/render.go
+package hashisolver
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Renderer writes a puzzle, with whatever bridges are on it, in one output format
+type Renderer func(w io.Writer, puzzle *Puzzle) error
+
+// renderers maps each output format name to the renderer for it
+var renderers = map[string]Renderer{
+	"text":       WriteMap,
+	"puzzle":     WritePuzzle,
+	"json":       WriteJSON,
+	"bridges":    WriteBridges,
+	"gif":        WriteGIF,
+	"png":        WritePNG,
+	"svg":        WriteSVG,
+	"dot":        WriteDOT,
+	"graphml":    WriteGraphML,
+	"accessible": WriteAccessible,
+
+	"terminal-image": TerminalImageRenderer(ImageSixel),
+}
+
+// extensions maps each output format name that isn't plain text to the file
+// extension its output is saved with
+var extensions = map[string]string{
+	"json":    ".json",
+	"gif":     ".gif",
+	"png":     ".png",
+	"svg":     ".svg",
+	"dot":     ".dot",
+	"graphml": ".graphml",
+}
+
+// RendererExtension returns the file extension for output in the named
+// format, like ".png", or ".txt" for text and any format registered without
+// one
+func RendererExtension(name string) string {
+	if ext, ok := extensions[strings.ToLower(name)]; ok {
+		return ext
+	}
+	return ".txt"
+}
+
+// RegisterRendererExtension sets the file extension output in a registered
+// format is saved with, like ".html". It is no safer to call while work is
+// going on than RegisterRenderer.
+func RegisterRendererExtension(name, ext string) {
+	extensions[strings.ToLower(name)] = ext
+}
+
+// RegisterRenderer adds an output format, replacing any renderer already
+// registered under the name. It is not safe to call while puzzles are being
+// rendered, so register formats before starting any work.
+func RegisterRenderer(name string, renderer Renderer) {
+	renderers[name] = renderer
+}
+
+// Renderers returns the names of the registered output formats in order
+func Renderers() []string {
+	names := make([]string, 0, len(renderers))
+	for name := range renderers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupRenderer returns the renderer for the named output format
+func LookupRenderer(name string) (Renderer, error) {
+	renderer, ok := renderers[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown output format %q (expected %s)", name, strings.Join(Renderers(), ", "))
+	}
+	return renderer, nil
+}
+
+// WriteMap writes the board as PrintMap draws it, with bridges in place
+func WriteMap(w io.Writer, puzzle *Puzzle) error {
+	return MapRenderer(CharsetASCII)(w, puzzle)
+}
@@ -0,0 +1,150 @@
+// hashisolver/replay.go
+package hashisolver
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Replay is a recording of a puzzle being solved: its clues and every move
+// made on them in order, each with when it was made
+type Replay struct {
+	// Source says what made the moves, like "solver" or "play"
+	Source string
+	// Puzzle is the board the moves start from, without bridges
+	Puzzle *Puzzle
+	Moves  []TimedMove
+}
+
+// TimedMove is a move in a replay
+type TimedMove struct {
+	Move
+	// At is how long after the start the move was made
+	At time.Duration
+}
+
+// replayJSON is the file format of a replay
+type replayJSON struct {
+	Source string     `json:"source"`
+	Puzzle PuzzleJSON `json:"puzzle"`
+	Moves  []moveJSON `json:"moves"`
+}
+
+// moveJSON is a timed move in a replay file, with its time in milliseconds
+type moveJSON struct {
+	AtMillis    float64   `json:"at_ms"`
+	From        Position  `json:"from"`
+	To          Position  `json:"to"`
+	Count       int       `json:"count"`
+	Technique   Technique `json:"technique,omitempty"`
+	Reason      string    `json:"reason,omitempty"`
+	Depth       int       `json:"depth,omitempty"`
+	Speculative bool      `json:"speculative,omitempty"`
+	Backtrack   bool      `json:"backtrack,omitempty"`
+	Removed     bool      `json:"removed,omitempty"`
+}
+
+// Board returns the board after the first n moves of the replay, with the
+// bridges of any guesses backtracked by then taken away again
+func (r *Replay) Board(n int) (*Puzzle, error) {
+	if n < 0 || n > len(r.Moves) {
+		return nil, fmt.Errorf("move %d is outside the replay's %d moves", n, len(r.Moves))
+	}
+
+	// Each move gives the count between its islands, so the last one on a
+	// pair is what it is left with
+	counts := map[[2]Position]int{}
+	pairs := [][2]Position{}
+	for _, move := range r.Made(n) {
+		pair := [2]Position{move.From, move.To}
+		if move.To.Y < move.From.Y || move.To.X < move.From.X {
+			pair = [2]Position{move.To, move.From}
+		}
+		if _, ok := counts[pair]; !ok {
+			pairs = append(pairs, pair)
+		}
+		counts[pair] = move.Count
+	}
+	solution := &Solution{Partial: true}
+	for _, pair := range pairs {
+		if counts[pair] > 0 {
+			solution.Bridges = append(solution.Bridges, Bridge{From: pair[0], To: pair[1], Count: counts[pair]})
+		}
+	}
+	return r.Puzzle.Apply(solution)
+}
+
+// Made returns the moves among the first n of the replay that still stand,
+// leaving out those a backtrack has taken back. n must be within the replay.
+func (r *Replay) Made(n int) []Move {
+	made := []Move{}
+	for _, move := range r.Moves[:n] {
+		if move.Backtrack {
+			for len(made) > 0 && made[len(made)-1].Depth >= move.Depth {
+				made = made[:len(made)-1]
+			}
+			continue
+		}
+		made = append(made, move.Move)
+	}
+	return made
+}
+
+// WriteReplay writes the replay as indented JSON
+func WriteReplay(w io.Writer, replay *Replay) error {
+	data := replayJSON{Source: replay.Source, Puzzle: ToJSON(replay.Puzzle.Clues()), Moves: []moveJSON{}}
+	for _, move := range replay.Moves {
+		data.Moves = append(data.Moves, moveJSON{
+			AtMillis:    float64(move.At) / float64(time.Millisecond),
+			From:        move.From,
+			To:          move.To,
+			Count:       move.Count,
+			Technique:   move.Technique,
+			Reason:      move.Reason,
+			Depth:       move.Depth,
+			Speculative: move.Speculative,
+			Backtrack:   move.Backtrack,
+			Removed:     move.Removed,
+		})
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(data)
+}
+
+// ReadReplay reads a replay written by WriteReplay, checking that its moves
+// end on a board they fit
+func ReadReplay(input io.Reader, rules RuleSet) (*Replay, error) {
+	var data replayJSON
+	if err := json.NewDecoder(input).Decode(&data); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPuzzle, err)
+	}
+	puzzle, err := fromJSON(data.Puzzle, rules)
+	if err != nil {
+		return nil, err
+	}
+
+	replay := &Replay{Source: data.Source, Puzzle: puzzle.Clues()}
+	for _, move := range data.Moves {
+		replay.Moves = append(replay.Moves, TimedMove{
+			Move: Move{
+				From:        move.From,
+				To:          move.To,
+				Count:       move.Count,
+				Technique:   move.Technique,
+				Reason:      move.Reason,
+				Depth:       move.Depth,
+				Speculative: move.Speculative,
+				Backtrack:   move.Backtrack,
+				Removed:     move.Removed,
+			},
+			At: time.Duration(move.AtMillis * float64(time.Millisecond)),
+		})
+	}
+	if _, err := replay.Board(len(replay.Moves)); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPuzzle, err)
+	}
+	return replay, nil
+}
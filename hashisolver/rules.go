@@ -0,0 +1,55 @@
+// hashisolver/rules.go
+package hashisolver
+
+// RuleSet describes the variant of hashiwokakero being solved
+type RuleSet struct {
+	// MaxBridgesPerPair is the most bridges allowed between two islands
+	MaxBridgesPerPair int
+	// MaxIslandValue is the largest clue accepted when parsing
+	MaxIslandValue int
+}
+
+// DefaultRuleSet is the classic game: at most 2 bridges per pair and clues up to 8
+var DefaultRuleSet = RuleSet{MaxBridgesPerPair: 2, MaxIslandValue: 8}
+
+// TripleBridgeRuleSet allows up to 3 bridges per pair and clues up to 12,
+// which is what c_src/bridgen.c generates
+var TripleBridgeRuleSet = RuleSet{MaxBridgesPerPair: 3, MaxIslandValue: 12}
+
+// RuleSetForMaxBridges returns the rule set allowing maxBridges bridges per pair
+func RuleSetForMaxBridges(maxBridges int) RuleSet {
+	return RuleSet{MaxBridgesPerPair: maxBridges, MaxIslandValue: 4 * maxBridges}
+}
+
+// maxBridges returns the per-pair bridge limit, falling back to the default rules
+// for puzzles that were built without a rule set
+func (p *Puzzle) maxBridges() int {
+	if p.Rules.MaxBridgesPerPair <= 0 {
+		return DefaultRuleSet.MaxBridgesPerPair
+	}
+	return p.Rules.MaxBridgesPerPair
+}
+
+// verticalMarker returns the board value drawn for a vertical bridge of count planks
+func verticalMarker(count int) int {
+	switch count {
+	case 1:
+		return -1 // Vertical single bridge
+	case 2:
+		return -2 // Vertical double bridge
+	default:
+		return -5 // Vertical triple bridge
+	}
+}
+
+// horizontalMarker returns the board value drawn for a horizontal bridge of count planks
+func horizontalMarker(count int) int {
+	switch count {
+	case 1:
+		return -3 // Horizontal single bridge
+	case 2:
+		return -4 // Horizontal double bridge
+	default:
+		return -6 // Horizontal triple bridge
+	}
+}
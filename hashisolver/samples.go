@@ -0,0 +1,77 @@
+// hashisolver/samples.go
+package hashisolver
+
+import (
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+//go:embed samples/*.txt
+var sampleFiles embed.FS
+
+// Sample is one of the curated puzzles shipped with the package
+type Sample struct {
+	// Name is the sample's file name without its extension, such as "medium-8x8"
+	Name string
+	// Difficulty is the band the sample was generated and rated in
+	Difficulty Difficulty
+	// Rows and Cols are the size of the board
+	Rows, Cols int
+	// Text is the puzzle in the compact text format, with its metadata header
+	Text string
+}
+
+// Puzzle parses the sample under the classic rules
+func (s Sample) Puzzle() (*Puzzle, error) {
+	return Parse(strings.NewReader(s.Text), DefaultRuleSet)
+}
+
+// Samples returns the curated sample puzzles, easiest and smallest first
+func Samples() []Sample {
+	entries, err := sampleFiles.ReadDir("samples")
+	if err != nil {
+		panic(err) // the directory is embedded at build time
+	}
+
+	samples := make([]Sample, 0, len(entries))
+	for _, entry := range entries {
+		data, err := sampleFiles.ReadFile(path.Join("samples", entry.Name()))
+		if err != nil {
+			panic(err)
+		}
+
+		// Names are <difficulty>-<rows>x<cols>
+		s := Sample{Name: strings.TrimSuffix(entry.Name(), ".txt"), Text: string(data)}
+		var band string
+		if dash := strings.IndexByte(s.Name, '-'); dash > 0 {
+			band = s.Name[:dash]
+			fmt.Sscanf(s.Name[dash+1:], "%dx%d", &s.Rows, &s.Cols)
+		}
+		s.Difficulty, _ = ParseDifficulty(band)
+		samples = append(samples, s)
+	}
+
+	sort.Slice(samples, func(i, j int) bool {
+		if samples[i].Difficulty != samples[j].Difficulty {
+			return samples[i].Difficulty < samples[j].Difficulty
+		}
+		return samples[i].Rows*samples[i].Cols < samples[j].Rows*samples[j].Cols
+	})
+	return samples
+}
+
+// FindSample returns the sample with the given name, or the smallest sample
+// in the difficulty band if name is easy, medium or hard
+func FindSample(name string) (Sample, error) {
+	names := []string{}
+	for _, s := range Samples() {
+		if s.Name == name || s.Difficulty.String() == name {
+			return s, nil
+		}
+		names = append(names, s.Name)
+	}
+	return Sample{}, fmt.Errorf("no sample named %q, expected easy, medium, hard or one of %s", name, strings.Join(names, ", "))
+}
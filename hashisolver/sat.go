@@ -0,0 +1,251 @@
+// hashisolver/sat.go
+package hashisolver
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// satLink is a pair of islands a bridge could join. Its variables are in
+// order, so atLeast[k] is true if the pair has more than k bridges.
+type satLink struct {
+	from, to int
+	dir      int
+	atLeast  []int
+	// placed is how many bridges the pair had before solving
+	placed int
+}
+
+// satEncoding is a puzzle written as clauses. The clauses cover how many
+// bridges each island has and which bridges cross; that every island is
+// joined is added as cuts, only once a model leaves some apart.
+type satEncoding struct {
+	puzzle *Puzzle
+	sat    *satSolver
+	links  []satLink
+	// islands holds the cell index of every island
+	islands []int
+	runs    int
+	cuts    int
+}
+
+// encodeSAT writes the rules of the puzzle as clauses
+func encodeSAT(puzzle *Puzzle) *satEncoding {
+	e := &satEncoding{puzzle: puzzle, sat: &satSolver{}}
+	maxBridges := puzzle.maxBridges()
+
+	incident := make([][]int, len(puzzle.Cells))
+	for i := range puzzle.Cells {
+		node := &puzzle.Cells[i]
+		if node.Value() <= 0 {
+			continue
+		}
+		e.islands = append(e.islands, i)
+		for _, dir := range []int{DirectionRight, DirectionDown} {
+			neighbor := node.GetNeighbor(dir)
+			if neighbor == nil || neighbor.Value() <= 0 {
+				continue
+			}
+			link := satLink{from: i, to: neighbor.YPos()*puzzle.Cols + neighbor.XPos(), dir: dir, placed: node.BridgesInDirection(dir)}
+			for k := 0; k < maxBridges; k++ {
+				link.atLeast = append(link.atLeast, e.sat.newVar())
+				if k > 0 {
+					e.sat.addClause(positive(link.atLeast[k]).not(), positive(link.atLeast[k-1]))
+				}
+				if k < link.placed {
+					e.sat.addClause(positive(link.atLeast[k]))
+				}
+			}
+			incident[link.from] = append(incident[link.from], len(e.links))
+			incident[link.to] = append(incident[link.to], len(e.links))
+			e.links = append(e.links, link)
+		}
+	}
+
+	// Every way of counting an island's bridges that misses its value is ruled out
+	for _, i := range e.islands {
+		links := incident[i]
+		counts := make([]int, len(links))
+		for {
+			sum := 0
+			for _, c := range counts {
+				sum += c
+			}
+			if sum != puzzle.Cells[i].Value() {
+				clause := []satLit{}
+				for j, c := range counts {
+					clause = append(clause, e.other(e.links[links[j]], c)...)
+				}
+				e.sat.addClause(clause...)
+			}
+
+			j := 0
+			for j < len(counts) && counts[j] == maxBridges {
+				counts[j] = 0
+				j++
+			}
+			if j == len(counts) {
+				break
+			}
+			counts[j]++
+		}
+	}
+
+	// No two bridges cross
+	for a := range e.links {
+		across := &e.links[a]
+		if across.dir != DirectionRight {
+			continue
+		}
+		for b := range e.links {
+			down := &e.links[b]
+			if down.dir != DirectionDown {
+				continue
+			}
+			x, y := down.from%puzzle.Cols, across.from/puzzle.Cols
+			if across.from%puzzle.Cols < x && x < across.to%puzzle.Cols && down.from/puzzle.Cols < y && y < down.to/puzzle.Cols {
+				e.sat.addClause(positive(across.atLeast[0]).not(), positive(down.atLeast[0]).not())
+			}
+		}
+	}
+	return e
+}
+
+// other returns the literals saying that the link has some number of bridges
+// other than count
+func (e *satEncoding) other(link satLink, count int) []satLit {
+	lits := []satLit{}
+	if count > 0 {
+		lits = append(lits, positive(link.atLeast[count-1]).not())
+	}
+	if count < len(link.atLeast) {
+		lits = append(lits, positive(link.atLeast[count]))
+	}
+	return lits
+}
+
+// count returns how many bridges the link has in the model last found
+func (e *satEncoding) count(link satLink) int {
+	n := 0
+	for n < len(link.atLeast) && e.sat.isTrue(link.atLeast[n]) {
+		n++
+	}
+	return n
+}
+
+// next finds another model whose bridges join every island, reporting false
+// once there are none. Each model that leaves islands apart is cut off by
+// requiring a bridge out of every group of joined islands.
+func (e *satEncoding) next(ctx context.Context) (bool, error) {
+	for {
+		e.runs++
+		ok, err := e.sat.solve(ctx)
+		if err != nil || !ok {
+			return false, err
+		}
+
+		groups := newUnionFind(len(e.puzzle.Cells))
+		for _, link := range e.links {
+			if e.count(link) > 0 {
+				groups.union(link.from, link.to)
+			}
+		}
+		roots := map[int]bool{}
+		for _, i := range e.islands {
+			roots[groups.find(i)] = true
+		}
+		if len(roots) <= 1 {
+			return true, nil
+		}
+
+		for root := range roots {
+			cut := []satLit{}
+			for _, link := range e.links {
+				if (groups.find(link.from) == root) != (groups.find(link.to) == root) {
+					cut = append(cut, positive(link.atLeast[0]))
+				}
+			}
+			e.cuts++
+			e.sat.addClause(cut...)
+		}
+	}
+}
+
+// exclude rules out the model last found, so next finds a different one
+func (e *satEncoding) exclude() {
+	clause := []satLit{}
+	for _, link := range e.links {
+		clause = append(clause, e.other(link, e.count(link))...)
+	}
+	e.sat.addClause(clause...)
+}
+
+// solution builds the bridges of the model last found onto a copy of the puzzle
+func (e *satEncoding) solution() (*Puzzle, error) {
+	solved := e.puzzle.Clone()
+	for _, link := range e.links {
+		node := &solved.Cells[link.from]
+		for k := link.placed; k < e.count(link); k++ {
+			if err := ConnectNodes(solved, node, node.GetNeighbor(link.dir), link.dir, false); err != nil {
+				return nil, fmt.Errorf("%w: the SAT engine's bridges do not fit the board: %v", ErrInvariant, err)
+			}
+		}
+	}
+	return solved, nil
+}
+
+// stats reports the SAT solver's work in the fields of Stats
+func (e *satEncoding) stats() Stats {
+	return Stats{
+		Engine:        EngineSAT,
+		NodesExplored: e.sat.decisions,
+		MaxDepth:      e.sat.maxLevel,
+		Iterations:    e.runs,
+		Conflicts:     e.sat.conflicts,
+		Cuts:          e.cuts,
+	}
+}
+
+// solveSAT finds up to limit solutions of the puzzle with the SAT engine, or
+// all of them if limit is 0
+func solveSAT(ctx context.Context, puzzle *Puzzle, limit int) ([]*Puzzle, Stats, error) {
+	start := time.Now()
+	e := encodeSAT(puzzle)
+	solutions := []*Puzzle{}
+	var err error
+	for limit <= 0 || len(solutions) < limit {
+		// Each model may come too quickly for the SAT solver to look at the
+		// context itself
+		if ctx != nil {
+			if err = ctx.Err(); err != nil {
+				break
+			}
+		}
+		var ok bool
+		if ok, err = e.next(ctx); err != nil || !ok {
+			break
+		}
+		var solved *Puzzle
+		if solved, err = e.solution(); err != nil {
+			break
+		}
+		solutions = append(solutions, solved)
+		e.exclude()
+	}
+
+	stats := e.stats()
+	stats.Elapsed = time.Since(start)
+	if err == nil && len(solutions) == 0 {
+		err = fmt.Errorf("%w: no arrangement of bridges meets every clue and joins every island", ErrNoSolution)
+	}
+	return solutions, stats, err
+}
+
+// SolveAllSAT finds up to limit solutions of a parsed puzzle with the SAT
+// engine, or all of them if limit is 0. It never guesses or applies the
+// logical rules, so it is an independent check on what SolveAll finds,
+// including whether a solution is unique.
+func SolveAllSAT(ctx context.Context, puzzle *Puzzle, limit int) ([]*Puzzle, error) {
+	return backend{solveSAT}.SolveAll(ctx, puzzle, Options{}, limit)
+}
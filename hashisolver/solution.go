@@ -0,0 +1,133 @@
+// hashisolver/solution.go
+package hashisolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Solution is a set of bridges solving a puzzle. It is kept apart from the
+// puzzle, so the puzzle keeps only its clues and can be solved more than once
+// or paired with each of several solutions.
+type Solution struct {
+	Bridges []Bridge
+	// Partial is set when the solve stopped short, in which case Bridges are
+	// the ones placed by then
+	Partial bool
+	// Stats is how the solve went. Solutions listed by FindSolutions leave it
+	// empty, as they come from one search.
+	Stats Stats
+}
+
+// NewSolution returns the bridges on a solved board as a solution
+func NewSolution(solved *Puzzle) *Solution {
+	return &Solution{Bridges: solved.Bridges()}
+}
+
+// Clues returns a copy of the puzzle with its clues, rules and metadata but
+// no bridges
+func (p *Puzzle) Clues() *Puzzle {
+	values := make([][]int, p.Rows)
+	for i := range values {
+		values[i] = make([]int, p.Cols)
+		for j := range values[i] {
+			values[i][j] = p.At(j, i).Value()
+		}
+	}
+	clues := NewPuzzle(values, p.Rules)
+	clues.Metadata = p.Metadata
+	return clues
+}
+
+// Apply returns a copy of the puzzle's clues with the solution's bridges
+// built on them. The puzzle itself is left as it is.
+func (p *Puzzle) Apply(solution *Solution) (*Puzzle, error) {
+	board := p.Clues()
+	for _, bridge := range solution.Bridges {
+		from, to := bridge.From, bridge.To
+		if to.Y < from.Y || to.X < from.X {
+			from, to = to, from
+		}
+		if from.X < 0 || from.X >= board.Cols || from.Y < 0 || from.Y >= board.Rows {
+			return nil, fmt.Errorf("bridge from (%d,%d) is off the board", from.X, from.Y)
+		}
+
+		node := board.At(from.X, from.Y)
+		dir := DirectionRight
+		if from.X == to.X {
+			dir = DirectionDown
+		}
+		if neighbor := node.GetNeighbor(dir); node.Value() <= 0 || neighbor == nil || neighbor.XPos() != to.X || neighbor.YPos() != to.Y {
+			return nil, fmt.Errorf("bridge from (%d,%d) to (%d,%d) does not join neighboring islands", from.X, from.Y, to.X, to.Y)
+		}
+		if err := buildBridge(board, node, dir, bridge.Count); err != nil {
+			return nil, fmt.Errorf("bridge from (%d,%d) to (%d,%d): %v", from.X, from.Y, to.X, to.Y, err)
+		}
+	}
+	return board, nil
+}
+
+// FindSolution solves a copy of the puzzle like SolveWithStats and returns
+// the bridges found, leaving the puzzle untouched. The statistics are filled
+// in even when the solve fails.
+func FindSolution(ctx context.Context, puzzle *Puzzle, opts ...Option) (*Solution, error) {
+	result, err := SolveWithStats(ctx, puzzle.Clone(), opts...)
+	solution := &Solution{Partial: result.Partial, Stats: result.Stats}
+	if err == nil || result.Partial {
+		solution.Bridges = result.Puzzle.Bridges()
+	}
+	return solution, err
+}
+
+// FindSolutions finds up to limit solutions of the puzzle with the engine the
+// options pick, or all of them if limit is 0, leaving the puzzle untouched
+func FindSolutions(ctx context.Context, puzzle *Puzzle, limit int, opts ...Option) ([]*Solution, error) {
+	options := NewOptions(opts...)
+	engine, err := LookupEngine(options.Engine)
+	if err != nil {
+		return nil, err
+	}
+	enumerator, ok := engine.(Enumerator)
+	if !ok {
+		return nil, errors.New("the engine cannot list a puzzle's solutions")
+	}
+
+	solved, err := enumerator.SolveAll(ctx, puzzle.Clone(), options, limit)
+	solutions := make([]*Solution, len(solved))
+	for i, board := range solved {
+		solutions[i] = NewSolution(board)
+	}
+	return solutions, err
+}
+
+// SolutionLines renders each row of the puzzle with the solution's bridges
+// drawn on it, as PrintMap would
+func SolutionLines(puzzle *Puzzle, solution *Solution) ([]string, error) {
+	board, err := puzzle.Apply(solution)
+	if err != nil {
+		return nil, err
+	}
+	return MapLines(board), nil
+}
+
+// PrintSolution prints the puzzle with the solution's bridges drawn on it
+func PrintSolution(puzzle *Puzzle, solution *Solution) error {
+	board, err := puzzle.Apply(solution)
+	if err != nil {
+		return err
+	}
+	PrintMap(board)
+	return nil
+}
+
+// RenderSolution writes the puzzle with the solution's bridges on it to w
+// with renderer
+func RenderSolution(w io.Writer, renderer Renderer, puzzle *Puzzle, solution *Solution) error {
+	board, err := puzzle.Apply(solution)
+	if err != nil {
+		return err
+	}
+	return renderer(w, board)
+}
@@ -2,11 +2,10 @@
 package hashisolver
 
 import (
-	"bufio"
+	"context"
 	"errors"
 	"fmt"
 	"io"
-	"strings"
 )
 
 // Direction constants for bridge connections
@@ -17,22 +16,22 @@ const (
 	DirectionRight = 3
 )
 
-// Node represents an island in the puzzle
+// directionNames names each direction, indexed by the constants above
+var directionNames = []string{"up", "down", "left", "right"}
+
+// Node is one cell of a puzzle as it stands in a solve: for an island, its
+// bridges and the directions closed to more of them. What the clues fix, the
+// cell's value and position and the islands it faces, is shared by every copy
+// of the puzzle and read with Value, XPos, YPos and GetNeighbor.
 type Node struct {
-	Value        int
-	XPos         int
-	YPos         int
 	UpBridges    int
 	DownBridges  int
 	LeftBridges  int
 	RightBridges int
 	TotalBridges int
 
-	// Neighbor nodes this one MAY connect to
-	UpNeighbor    *Node
-	DownNeighbor  *Node
-	LeftNeighbor  *Node
-	RightNeighbor *Node
+	// Used when traversing nodes to check for potential islands
+	Visited bool
 
 	// Blocked directions
 	UpBlocked    bool
@@ -41,52 +40,95 @@ type Node struct {
 	RightBlocked bool
 	NumBlocked   int
 
-	// Used when traversing nodes to check for potential islands
-	Visited bool
+	// span is, for a water cell under a bridge, one more than the index of the
+	// island at the bridge's left or top end, so that 0 is no bridge
+	span int32
+
+	// index is the cell's place in the puzzle's cells
+	index int32
+
+	// puzzle is the board the node is a cell of
+	puzzle *Puzzle
 }
 
-// Puzzle represents the entire hashiwokakero puzzle
-type Puzzle struct {
-	Board        [][]*Node
-	Size         int
+// grid is what a puzzle's clues fix. NewPuzzle works it out once and every
+// copy of the puzzle shares it, so nothing may change it afterwards.
+type grid struct {
+	// clues holds each cell's value, 0 for water
+	clues []int
+	// neighbors holds the islands each cell MAY connect to, indexed by
+	// direction, as one more than their index in the cells so that 0 is no
+	// neighbor
+	neighbors [][4]int32
+	// islands holds the index of every island in the cells, in reading
+	// order, and number each cell's place in islands, or -1 for water
+	islands []int32
+	number  []int32
+}
+
+// SolveState is what changes as a puzzle is solved: the state of each cell
+// and how many bridges are certain. Each copy of a puzzle has its own, so a
+// copy costs no more than this.
+type SolveState struct {
+	// Cells holds the board row by row, so (x,y) is Cells[y*Cols+x]
+	Cells        []Node
 	BuiltBridges int
-	FullBridges  int
+
+	// trail, if set, records changes to the cells so a guess can be undone
+	trail *trail
+	// history holds the moves made with AddBridge and RemoveBridge
+	history *History
+	// links, if set, is how the islands are joined, kept up to date as
+	// links are lost
+	links *links
 }
 
-// NewNode creates a new node with the given value and position
-func NewNode(value, x, y int) *Node {
-	return &Node{
-		Value:        value,
-		XPos:         x,
-		YPos:         y,
-		UpBridges:    0,
-		DownBridges:  0,
-		LeftBridges:  0,
-		RightBridges: 0,
-		TotalBridges: 0,
-		UpBlocked:    false,
-		DownBlocked:  false,
-		LeftBlocked:  false,
-		RightBlocked: false,
-		NumBlocked:   0,
-		Visited:      false,
-	}
+// Puzzle represents the entire hashiwokakero puzzle: its clues, shared
+// read-only by every copy, and the state of solving it
+type Puzzle struct {
+	Rows        int
+	Cols        int
+	FullBridges int
+	Rules       RuleSet
+	Metadata    Metadata
+
+	// grid holds the clues and which islands face each other. Its slices are
+	// shared with every copy.
+	grid grid
+
+	SolveState
+}
+
+// At returns the cell in column x of row y
+func (p *Puzzle) At(x, y int) *Node {
+	return &p.Cells[y*p.Cols+x]
+}
+
+// Value returns the clue of the cell, or 0 for water
+func (n *Node) Value() int {
+	return n.puzzle.grid.clues[n.index]
+}
+
+// XPos returns the column of the cell
+func (n *Node) XPos() int {
+	return int(n.index) % n.puzzle.Cols
+}
+
+// YPos returns the row of the cell
+func (n *Node) YPos() int {
+	return int(n.index) / n.puzzle.Cols
 }
 
 // GetNeighbor returns the neighbor in the specified direction
 func (n *Node) GetNeighbor(direction int) *Node {
-	switch direction {
-	case DirectionUp:
-		return n.UpNeighbor
-	case DirectionDown:
-		return n.DownNeighbor
-	case DirectionLeft:
-		return n.LeftNeighbor
-	case DirectionRight:
-		return n.RightNeighbor
-	default:
+	if direction < 0 || direction > DirectionRight {
 		return nil
 	}
+	neighbor := n.puzzle.grid.neighbors[n.index][direction]
+	if neighbor == 0 {
+		return nil
+	}
+	return &n.puzzle.Cells[neighbor-1]
 }
 
 // BridgesInDirection returns the number of bridges in the specified direction
@@ -108,17 +150,10 @@ func (n *Node) BridgesInDirection(direction int) int {
 // NumNeighbors returns the number of neighbors this node has
 func (n *Node) NumNeighbors() int {
 	count := 0
-	if n.UpNeighbor != nil {
-		count++
-	}
-	if n.DownNeighbor != nil {
-		count++
-	}
-	if n.LeftNeighbor != nil {
-		count++
-	}
-	if n.RightNeighbor != nil {
-		count++
+	for dir := DirectionUp; dir <= DirectionRight; dir++ {
+		if n.GetNeighbor(dir) != nil {
+			count++
+		}
 	}
 	return count
 }
@@ -127,78 +162,65 @@ func (n *Node) NumNeighbors() int {
 func (n *Node) RemainingPossibleMoves() int {
 	moves := 2 * n.NumNeighbors()
 
-	if n.UpNeighbor != nil {
-		moves -= n.UpBridges
-		if n.UpNeighbor.Value-n.UpNeighbor.TotalBridges == 1 && n.UpBridges == 0 {
-			moves--
-		} else if n.UpNeighbor.Value-n.UpNeighbor.TotalBridges == 0 && n.UpBridges == 1 {
-			moves--
+	for dir := DirectionUp; dir <= DirectionRight; dir++ {
+		neighbor := n.GetNeighbor(dir)
+		if neighbor == nil {
+			continue
 		}
-	}
-
-	if n.DownNeighbor != nil {
-		moves -= n.DownBridges
-		if n.DownNeighbor.Value-n.DownNeighbor.TotalBridges == 1 && n.DownBridges == 0 {
+		bridges := n.BridgesInDirection(dir)
+		moves -= bridges
+		if neighbor.Value()-neighbor.TotalBridges == 1 && bridges == 0 {
 			moves--
-		} else if n.DownNeighbor.Value-n.DownNeighbor.TotalBridges == 0 && n.DownBridges == 1 {
+		} else if neighbor.Value()-neighbor.TotalBridges == 0 && bridges == 1 {
 			moves--
 		}
 	}
 
-	if n.LeftNeighbor != nil {
-		moves -= n.LeftBridges
-		if n.LeftNeighbor.Value-n.LeftNeighbor.TotalBridges == 1 && n.LeftBridges == 0 {
-			moves--
-		} else if n.LeftNeighbor.Value-n.LeftNeighbor.TotalBridges == 0 && n.LeftBridges == 1 {
-			moves--
-		}
-	}
+	return moves
+}
 
-	if n.RightNeighbor != nil {
-		moves -= n.RightBridges
-		if n.RightNeighbor.Value-n.RightNeighbor.TotalBridges == 1 && n.RightBridges == 0 {
-			moves--
-		} else if n.RightNeighbor.Value-n.RightNeighbor.TotalBridges == 0 && n.RightBridges == 1 {
-			moves--
-		}
+// TotalPossibleMoves calculates how many more bridges this node could still receive
+// across all unblocked directions, given the per-pair bridge limit
+func (n *Node) TotalPossibleMoves(maxBridges int) int {
+	moves := 0
+	for _, dir := range n.UnblockedNodes() {
+		moves += n.DirectionCapacity(dir, maxBridges)
 	}
-
 	return moves
 }
 
-// TotalPossibleMoves calculates the total possible moves (not accounting for nodes with only one possible connection)
-func (n *Node) TotalPossibleMoves() int {
-	moves := 2 * n.NumNeighbors()
-
-	if n.UpNeighbor != nil {
-		moves -= n.UpBridges
-		if n.UpBridges == 1 && n.UpNeighbor.Value == n.UpNeighbor.TotalBridges {
-			moves--
-		}
+// DirectionCapacity returns how many more bridges can be added in the given direction,
+// limited both by the per-pair maximum and by what the neighbor still needs
+func (n *Node) DirectionCapacity(direction int, maxBridges int) int {
+	neighbor := n.GetNeighbor(direction)
+	if neighbor == nil || n.IsBlocked(direction) {
+		return 0
 	}
 
-	if n.DownNeighbor != nil {
-		moves -= n.DownBridges
-		if n.DownBridges == 1 && n.DownNeighbor.Value == n.DownNeighbor.TotalBridges {
-			moves--
-		}
+	capacity := maxBridges - n.BridgesInDirection(direction)
+	if remaining := neighbor.Value() - neighbor.TotalBridges; remaining < capacity {
+		capacity = remaining
 	}
-
-	if n.LeftNeighbor != nil {
-		moves -= n.LeftBridges
-		if n.LeftBridges == 1 && n.LeftNeighbor.Value == n.LeftNeighbor.TotalBridges {
-			moves--
-		}
+	if capacity < 0 {
+		return 0
 	}
+	return capacity
+}
 
-	if n.RightNeighbor != nil {
-		moves -= n.RightBridges
-		if n.RightBridges == 1 && n.RightNeighbor.Value == n.RightNeighbor.TotalBridges {
-			moves--
-		}
+// IsBlocked reports whether no more bridges may be added in the given direction
+func (n *Node) IsBlocked(direction int) bool {
+	switch direction {
+	case DirectionUp:
+		return n.UpBlocked
+	case DirectionDown:
+		return n.DownBlocked
+	case DirectionLeft:
+		return n.LeftBlocked
+	case DirectionRight:
+		return n.RightBlocked
+	default:
+		return true
 	}
-
-	return moves
 }
 
 // UnblockedNode returns the direction of the single unblocked node (assumes only one exists)
@@ -236,6 +258,12 @@ func (n *Node) UnblockedNodes() []int {
 
 // NodeFilled blocks all directions of this node (used when the node is filled with all its bridges)
 func (n *Node) NodeFilled() {
+	for dir := DirectionUp; dir <= DirectionRight; dir++ {
+		if !n.IsBlocked(dir) {
+			n.unlink(dir)
+		}
+	}
+	n.save()
 	n.UpBlocked = true
 	n.DownBlocked = true
 	n.LeftBlocked = true
@@ -243,115 +271,75 @@ func (n *Node) NodeFilled() {
 	n.NumBlocked = 4
 
 	// Also blocks the corresponding directions of neighbor nodes if they aren't already blocked
-	if n.UpNeighbor != nil && !n.UpNeighbor.DownBlocked {
-		n.UpNeighbor.DownBlocked = true
-		n.UpNeighbor.NumBlocked++
-	}
-
-	if n.DownNeighbor != nil && !n.DownNeighbor.UpBlocked {
-		n.DownNeighbor.UpBlocked = true
-		n.DownNeighbor.NumBlocked++
+	for dir := DirectionUp; dir <= DirectionRight; dir++ {
+		if neighbor := n.GetNeighbor(dir); neighbor != nil {
+			neighbor.block(opposite(dir))
+		}
 	}
+}
 
-	if n.LeftNeighbor != nil && !n.LeftNeighbor.RightBlocked {
-		n.LeftNeighbor.RightBlocked = true
-		n.LeftNeighbor.NumBlocked++
+// DirectionBlocked blocks the connection between this node and the neighbor node in the given direction
+func (n *Node) DirectionBlocked(direction int) {
+	n.block(direction)
+	if neighbor := n.GetNeighbor(direction); neighbor != nil {
+		neighbor.block(opposite(direction))
 	}
+}
 
-	if n.RightNeighbor != nil && !n.RightNeighbor.LeftBlocked {
-		n.RightNeighbor.LeftBlocked = true
-		n.RightNeighbor.NumBlocked++
+// block blocks one direction of this node alone, if it isn't blocked already
+func (n *Node) block(direction int) {
+	if n.IsBlocked(direction) {
+		return
 	}
-}
 
-// DirectionBlocked blocks the connection between this node and the neighbor node in the given direction
-func (n *Node) DirectionBlocked(direction int) {
+	n.unlink(direction)
+	n.save()
 	switch direction {
 	case DirectionUp:
-		if !n.UpBlocked {
-			n.UpBlocked = true
-			n.NumBlocked++
-		}
-		if n.UpNeighbor != nil && !n.UpNeighbor.DownBlocked {
-			n.UpNeighbor.DownBlocked = true
-			n.UpNeighbor.NumBlocked++
-		}
-
+		n.UpBlocked = true
 	case DirectionDown:
-		if !n.DownBlocked {
-			n.DownBlocked = true
-			n.NumBlocked++
-		}
-		if n.DownNeighbor != nil && !n.DownNeighbor.UpBlocked {
-			n.DownNeighbor.UpBlocked = true
-			n.DownNeighbor.NumBlocked++
-		}
-
+		n.DownBlocked = true
 	case DirectionLeft:
-		if !n.LeftBlocked {
-			n.LeftBlocked = true
-			n.NumBlocked++
-		}
-		if n.LeftNeighbor != nil && !n.LeftNeighbor.RightBlocked {
-			n.LeftNeighbor.RightBlocked = true
-			n.LeftNeighbor.NumBlocked++
-		}
-
+		n.LeftBlocked = true
 	case DirectionRight:
-		if !n.RightBlocked {
-			n.RightBlocked = true
-			n.NumBlocked++
-		}
-		if n.RightNeighbor != nil && !n.RightNeighbor.LeftBlocked {
-			n.RightNeighbor.LeftBlocked = true
-			n.RightNeighbor.NumBlocked++
-		}
+		n.RightBlocked = true
 	}
+	n.NumBlocked++
 }
 
 // BlockCheck checks whether bridges need to be blocked in any direction
-func (n *Node) BlockCheck() {
+func (n *Node) BlockCheck(maxBridges int) {
 	// If node is filled up with bridges, block all directions
-	if n.Value == n.TotalBridges {
+	if n.Value() == n.TotalBridges {
 		n.NodeFilled()
 	}
 
-	// 2 bridges is maximum in any direction, so block that direction
-	if n.UpBridges == 2 {
-		n.DirectionBlocked(DirectionUp)
-	}
-	if n.UpNeighbor != nil && n.UpNeighbor.TotalBridges == n.UpNeighbor.Value {
-		n.UpNeighbor.NodeFilled()
-	}
-
-	if n.DownBridges == 2 {
-		n.DirectionBlocked(DirectionDown)
-	}
-	if n.DownNeighbor != nil && n.DownNeighbor.TotalBridges == n.DownNeighbor.Value {
-		n.DownNeighbor.NodeFilled()
-	}
-
-	if n.LeftBridges == 2 {
-		n.DirectionBlocked(DirectionLeft)
-	}
-	if n.LeftNeighbor != nil && n.LeftNeighbor.TotalBridges == n.LeftNeighbor.Value {
-		n.LeftNeighbor.NodeFilled()
+	for dir := DirectionUp; dir <= DirectionRight; dir++ {
+		// maxBridges is the maximum in any direction, so block that direction
+		if n.BridgesInDirection(dir) == maxBridges {
+			n.DirectionBlocked(dir)
+		}
+		if neighbor := n.GetNeighbor(dir); neighbor != nil && neighbor.TotalBridges == neighbor.Value() {
+			neighbor.NodeFilled()
+		}
 	}
+}
 
-	if n.RightBridges == 2 {
-		n.DirectionBlocked(DirectionRight)
-	}
-	if n.RightNeighbor != nil && n.RightNeighbor.TotalBridges == n.RightNeighbor.Value {
-		n.RightNeighbor.NodeFilled()
+// ConnectNodes connects two nodes with a bridge in the specified direction.
+// It returns an error wrapping ErrIllegalBridge, and leaves the puzzle as it
+// was, if the bridge would pass through an island or cross another bridge.
+func ConnectNodes(puzzle *Puzzle, node *Node, neighbor *Node, direction int, isSpeculative bool) error {
+	if err := checkPath(puzzle, node, direction); err != nil {
+		return err
 	}
-}
+	first := node.BridgesInDirection(direction) == 0
 
-// ConnectNodes connects two nodes with a bridge in the specified direction
-func ConnectNodes(puzzle *Puzzle, node *Node, neighbor *Node, direction int, isSpeculative bool) {
 	if !isSpeculative {
 		puzzle.BuiltBridges++
 	}
 
+	node.save()
+	neighbor.save()
 	node.TotalBridges++
 	neighbor.TotalBridges++
 
@@ -359,63 +347,35 @@ func ConnectNodes(puzzle *Puzzle, node *Node, neighbor *Node, direction int, isS
 	case DirectionUp:
 		node.UpBridges++
 		neighbor.DownBridges++
-
-		// Mark the bridge in the board
-		distance := node.YPos - neighbor.YPos
-		for i := 1; i < distance; i++ {
-			if node.UpBridges == 1 {
-				puzzle.Board[node.YPos-i][node.XPos].Value = -1 // Vertical single bridge
-			} else {
-				puzzle.Board[node.YPos-i][node.XPos].Value = -2 // Vertical double bridge
-			}
-		}
-
 	case DirectionDown:
 		node.DownBridges++
 		neighbor.UpBridges++
-
-		// Mark the bridge in the board
-		distance := neighbor.YPos - node.YPos
-		for i := 1; i < distance; i++ {
-			if node.DownBridges == 1 {
-				puzzle.Board[node.YPos+i][node.XPos].Value = -1 // Vertical single bridge
-			} else {
-				puzzle.Board[node.YPos+i][node.XPos].Value = -2 // Vertical double bridge
-			}
-		}
-
 	case DirectionLeft:
 		node.LeftBridges++
 		neighbor.RightBridges++
-
-		// Mark the bridge in the board
-		distance := node.XPos - neighbor.XPos
-		for i := 1; i < distance; i++ {
-			if node.LeftBridges == 1 {
-				puzzle.Board[node.YPos][node.XPos-i].Value = -3 // Horizontal single bridge
-			} else {
-				puzzle.Board[node.YPos][node.XPos-i].Value = -4 // Horizontal double bridge
-			}
-		}
-
 	case DirectionRight:
 		node.RightBridges++
 		neighbor.LeftBridges++
+	}
 
-		// Mark the bridge in the board
-		distance := neighbor.XPos - node.XPos
-		for i := 1; i < distance; i++ {
-			if node.RightBridges == 1 {
-				puzzle.Board[node.YPos][node.XPos+i].Value = -3 // Horizontal single bridge
-			} else {
-				puzzle.Board[node.YPos][node.XPos+i].Value = -4 // Horizontal double bridge
-			}
+	// The cells under a new bridge note which bridge it is, so the board can
+	// be drawn and crossings found, and nothing can cross it
+	if first {
+		end := node
+		if direction == DirectionUp || direction == DirectionLeft {
+			end = neighbor
+		}
+		for _, cell := range pathCells(puzzle, node, direction) {
+			cell.save()
+			cell.span = int32(end.YPos()*puzzle.Cols+end.XPos()) + 1
 		}
+		blockCrossings(puzzle, node, direction)
 	}
 
 	// Check for bridge conflicts and node filling
-	node.BlockCheck()
-	neighbor.BlockCheck()
+	node.BlockCheck(puzzle.maxBridges())
+	neighbor.BlockCheck(puzzle.maxBridges())
+	return nil
 }
 
 // BridgeCheck checks for bridges that would block one edge of the node
@@ -423,90 +383,30 @@ func BridgeCheck(node *Node) {
 	// This function implements the bridge checking logic from the C++ implementation
 	// For each direction, if that's the only direction with a possible bridge, connect it
 
-	if node.NumBlocked == 3 && node.Value-node.TotalBridges > 0 {
+	if node.NumBlocked == 3 && node.Value()-node.TotalBridges > 0 {
 		direction := node.UnblockedNode()
 		neighbor := node.GetNeighbor(direction)
 
-		if neighbor != nil && neighbor.Value-neighbor.TotalBridges > 0 {
+		if neighbor != nil && neighbor.Value()-neighbor.TotalBridges > 0 {
 			// This is an obvious move - only one direction is available
 			return
 		}
 	}
 }
 
-// CheckForIsland checks if adding a bridge would create an isolated island
+// CheckForIsland connects node to its neighbor in direction if that link is
+// the only one holding the board together, as leaving it without a bridge
+// would cut some islands off from the rest. It reports whether it placed a
+// bridge. bridgeCount is not used.
 func CheckForIsland(puzzle *Puzzle, node *Node, direction int, bridgeCount int) bool {
-	// Reset visited flags
-	for i := 0; i < puzzle.Size; i++ {
-		for j := 0; j < puzzle.Size; j++ {
-			if puzzle.Board[i][j].Value > 0 {
-				puzzle.Board[i][j].Visited = false
-			}
-		}
+	if node.IsBlocked(direction) || node.BridgesInDirection(direction) > 0 {
+		return false
 	}
-
-	// Temporarily block the direction we're testing
-	oldBlocked := false
-	switch direction {
-	case DirectionUp:
-		oldBlocked = node.UpBlocked
-		node.UpBlocked = true
-	case DirectionDown:
-		oldBlocked = node.DownBlocked
-		node.DownBlocked = true
-	case DirectionLeft:
-		oldBlocked = node.LeftBlocked
-		node.LeftBlocked = true
-	case DirectionRight:
-		oldBlocked = node.RightBlocked
-		node.RightBlocked = true
+	links := puzzle.currentLinks()
+	if !links.connected || !links.isCut(node, direction) {
+		return false
 	}
-
-	// Mark the current node as visited
-	node.Visited = true
-
-	// Start a depth-first search from this node
-	connected := true
-	for i := 0; i < puzzle.Size && connected; i++ {
-		for j := 0; j < puzzle.Size && connected; j++ {
-			if puzzle.Board[i][j].Value > 0 && !puzzle.Board[i][j].Visited {
-				// Found an unvisited node, check if it's reachable
-				connected = CheckNodeString(puzzle.Board[i][j])
-				if !connected {
-					// We found an island, so we must add a bridge in the tested direction
-					// Restore the original blocked state
-					switch direction {
-					case DirectionUp:
-						node.UpBlocked = oldBlocked
-					case DirectionDown:
-						node.DownBlocked = oldBlocked
-					case DirectionLeft:
-						node.LeftBlocked = oldBlocked
-					case DirectionRight:
-						node.RightBlocked = oldBlocked
-					}
-
-					// Add the bridge
-					ConnectNodes(puzzle, node, node.GetNeighbor(direction), direction, false)
-					return true
-				}
-			}
-		}
-	}
-
-	// Restore the original blocked state
-	switch direction {
-	case DirectionUp:
-		node.UpBlocked = oldBlocked
-	case DirectionDown:
-		node.DownBlocked = oldBlocked
-	case DirectionLeft:
-		node.LeftBlocked = oldBlocked
-	case DirectionRight:
-		node.RightBlocked = oldBlocked
-	}
-
-	return false
+	return ConnectNodes(puzzle, node, node.GetNeighbor(direction), direction, false) == nil
 }
 
 // CheckNodeString performs a DFS to mark all nodes that are connected
@@ -517,589 +417,565 @@ func CheckNodeString(node *Node) bool {
 
 	node.Visited = true
 
-	// Check all four directions
-	if !node.UpBlocked && node.UpNeighbor != nil {
-		CheckNodeString(node.UpNeighbor)
-	}
-
-	if !node.DownBlocked && node.DownNeighbor != nil {
-		CheckNodeString(node.DownNeighbor)
-	}
-
-	if !node.LeftBlocked && node.LeftNeighbor != nil {
-		CheckNodeString(node.LeftNeighbor)
-	}
-
-	if !node.RightBlocked && node.RightNeighbor != nil {
-		CheckNodeString(node.RightNeighbor)
+	// Check all four directions, following both placed bridges and open connections
+	for dir := DirectionUp; dir <= DirectionRight; dir++ {
+		if node.BridgesInDirection(dir) > 0 || !node.IsBlocked(dir) {
+			CheckNodeString(node.GetNeighbor(dir))
+		}
 	}
 
 	return true
 }
 
-// Clone creates a deep copy of a puzzle
+// Clone creates a copy of a puzzle to solve apart from it. The copy shares
+// the clues, so only the solve state is copied.
 func (p *Puzzle) Clone() *Puzzle {
 	newPuzzle := &Puzzle{
-		Size:         p.Size,
-		Board:        make([][]*Node, p.Size),
-		BuiltBridges: p.BuiltBridges,
-		FullBridges:  p.FullBridges,
-	}
-
-	// Clone the board
-	for i := 0; i < p.Size; i++ {
-		newPuzzle.Board[i] = make([]*Node, p.Size)
-		for j := 0; j < p.Size; j++ {
-			oldNode := p.Board[i][j]
-			newNode := NewNode(oldNode.Value, oldNode.XPos, oldNode.YPos)
-
-			// Copy node state
-			newNode.UpBridges = oldNode.UpBridges
-			newNode.DownBridges = oldNode.DownBridges
-			newNode.LeftBridges = oldNode.LeftBridges
-			newNode.RightBridges = oldNode.RightBridges
-			newNode.TotalBridges = oldNode.TotalBridges
-
-			newNode.UpBlocked = oldNode.UpBlocked
-			newNode.DownBlocked = oldNode.DownBlocked
-			newNode.LeftBlocked = oldNode.LeftBlocked
-			newNode.RightBlocked = oldNode.RightBlocked
-			newNode.NumBlocked = oldNode.NumBlocked
-
-			newPuzzle.Board[i][j] = newNode
-		}
+		Rows:        p.Rows,
+		Cols:        p.Cols,
+		FullBridges: p.FullBridges,
+		Rules:       p.Rules,
+		Metadata:    p.Metadata,
+		grid:        p.grid,
+		SolveState: SolveState{
+			Cells:        make([]Node, len(p.Cells)),
+			BuiltBridges: p.BuiltBridges,
+			links:        p.links,
+		},
+	}
+
+	// Copying the cells is enough apart from pointing them at their new board
+	copy(newPuzzle.Cells, p.Cells)
+	for i := range newPuzzle.Cells {
+		newPuzzle.Cells[i].puzzle = newPuzzle
+		newPuzzle.Cells[i].Visited = false
 	}
 
-	// Reconnect neighbors
-	for i := 0; i < p.Size; i++ {
-		for j := 0; j < p.Size; j++ {
-			oldNode := p.Board[i][j]
-			newNode := newPuzzle.Board[i][j]
-
-			if oldNode.UpNeighbor != nil {
-				newNode.UpNeighbor = newPuzzle.Board[oldNode.UpNeighbor.YPos][oldNode.UpNeighbor.XPos]
-			}
-
-			if oldNode.DownNeighbor != nil {
-				newNode.DownNeighbor = newPuzzle.Board[oldNode.DownNeighbor.YPos][oldNode.DownNeighbor.XPos]
-			}
-
-			if oldNode.LeftNeighbor != nil {
-				newNode.LeftNeighbor = newPuzzle.Board[oldNode.LeftNeighbor.YPos][oldNode.LeftNeighbor.XPos]
-			}
+	return newPuzzle
+}
 
-			if oldNode.RightNeighbor != nil {
-				newNode.RightNeighbor = newPuzzle.Board[oldNode.RightNeighbor.YPos][oldNode.RightNeighbor.XPos]
-			}
+// PlacedBridges counts every bridge on the board. Unlike BuiltBridges, this
+// includes bridges placed while speculating.
+func (p *Puzzle) PlacedBridges() int {
+	ends := 0
+	for i := range p.Cells {
+		if node := &p.Cells[i]; node.Value() > 0 {
+			ends += node.TotalBridges
 		}
 	}
-
-	return newPuzzle
+	return ends / 2
 }
 
 // IsComplete checks if the puzzle is completely solved
 func (p *Puzzle) IsComplete() bool {
 	// Check if all nodes have their required number of bridges
-	for i := 0; i < p.Size; i++ {
-		for j := 0; j < p.Size; j++ {
-			node := p.Board[i][j]
-			if node.Value > 0 && node.Value != node.TotalBridges {
-				return false
-			}
-		}
-	}
-
-	// Check if all islands are connected
-	var startNode *Node
-
-	// Find the first node
-	for i := 0; i < p.Size && startNode == nil; i++ {
-		for j := 0; j < p.Size && startNode == nil; j++ {
-			if p.Board[i][j].Value > 0 {
-				startNode = p.Board[i][j]
-			}
+	for _, i := range p.grid.islands {
+		if node := &p.Cells[i]; node.Value() != node.TotalBridges {
+			return false
 		}
 	}
 
-	if startNode == nil {
+	if len(p.grid.islands) == 0 {
 		return true // Empty puzzle
 	}
 
 	// Reset visited flags
-	for i := 0; i < p.Size; i++ {
-		for j := 0; j < p.Size; j++ {
-			if p.Board[i][j].Value > 0 {
-				p.Board[i][j].Visited = false
-			}
-		}
+	for _, i := range p.grid.islands {
+		p.Cells[i].Visited = false
 	}
 
 	// Start a DFS from the first node
-	CheckNodeString(startNode)
+	CheckNodeString(&p.Cells[p.grid.islands[0]])
 
 	// Check if all nodes were visited
-	for i := 0; i < p.Size; i++ {
-		for j := 0; j < p.Size; j++ {
-			if p.Board[i][j].Value > 0 && !p.Board[i][j].Visited {
-				return false // Disconnected island
-			}
+	for _, i := range p.grid.islands {
+		if !p.Cells[i].Visited {
+			return false // Disconnected island
 		}
 	}
 
 	return true
 }
 
-// FindCandidateNode finds a node with the most constrained but unresolved connections
+// FindCandidateNode finds a node with the most constrained but unresolved
+// connections, as the HighestRemainingValue strategy chooses it
 func (p *Puzzle) FindCandidateNode() *Node {
-	var bestNode *Node
-	bestScore := -1
+	return HighestRemainingValue().Choose(p)
+}
 
-	for i := 0; i < p.Size; i++ {
-		for j := 0; j < p.Size; j++ {
-			node := p.Board[i][j]
+// AttemptSpeculativeSolve attempts to solve the puzzle using speculative moves and backtracking.
+// If there is no solution, the puzzle is returned as the logical rules left it,
+// without any of the guesses, along with ErrNoSolution.
+func AttemptSpeculativeSolve(puzzle *Puzzle, debug bool) (*Puzzle, error) {
+	return newSolver(context.Background(), NewOptions(WithDebug(debug))).search(puzzle, 0)
+}
 
-			if node.Value <= 0 || node.Value == node.TotalBridges {
-				continue // Skip empty or satisfied nodes
+// search applies the logical rules and then speculates, recording every complete
+// puzzle it reaches. It returns without error once the solver's limit is reached.
+// The guesses being explored are kept on a stack rather than in recursive
+// calls, so how deep the search can go is bounded only by memory.
+func (s *solver) search(puzzle *Puzzle, depth int) (*Puzzle, error) {
+	// Clues that can never be met are reported before any work is done
+	if depth == 0 {
+		if err := checkFeasible(puzzle); err != nil {
+			return puzzle, err
+		}
+	}
+
+	// Guesses are made on the board itself, each a move in the history with
+	// every change since recorded on its trail, so a failed guess can be
+	// taken back
+	if s.history == nil {
+		s.history = &History{}
+	}
+	base := len(s.stack)
+	defer func() {
+		s.stack = s.stack[:base]
+	}()
+
+	for {
+		level := depth + len(s.stack) - base
+		solved, guess, err := s.settle(puzzle, level)
+		if guess != nil && s.parallel(len(guess.counts)) {
+			// With goroutines to spare, the branches are explored at once instead
+			solved, err = s.exploreParallel(puzzle, guess, level)
+			guess = nil
+		}
+		if guess != nil {
+			if puzzle.trail == nil {
+				attach(puzzle, &s.history.trail)
+				defer attach(puzzle, nil)
+			}
+			guess.step = len(s.history.done)
+			s.stack = append(s.stack, guess)
+			err = nil
+		} else if err == nil {
+			return solved, nil
+		}
+
+		// Take the next untried branch, backing out of every guess whose
+		// branches have all failed
+		for {
+			if err != nil {
+				if stop := s.stopping(err); stop != nil {
+					s.unwind(puzzle, base)
+					return puzzle, stop
+				}
 			}
-
-			// Calculate a score based on how constrained this node is
-			remainingBridges := node.Value - node.TotalBridges
-			unblocked := node.UnblockedNodes()
-
-			if len(unblocked) == 0 {
-				continue // Skip fully blocked nodes
+			if len(s.stack) == base {
+				return puzzle, err
 			}
 
-			// Score is higher for nodes with fewer open directions but more remaining bridges
-			score := remainingBridges*10 + (4 - len(unblocked))
+			top := s.stack[len(s.stack)-1]
+			level := depth + len(s.stack) - base
+			if top.next > 0 {
+				s.unwind(puzzle, len(s.stack)-1)
+				s.backtracked(level)
+			}
+			if top.next == len(top.counts) {
+				// Every number of bridges has been tried and none worked. Each
+				// guess has been undone, so the puzzle holds only the bridges
+				// placed before this one was made.
+				s.stack = s.stack[:len(s.stack)-1]
+				err = fmt.Errorf("%w: every number of bridges going %s from the %d at (%d,%d) leads to a contradiction",
+					ErrNoSolution, directionNames[top.dir], top.node.Value(), top.node.XPos(), top.node.YPos())
+				continue
+			}
 
-			if score > bestScore {
-				bestScore = score
-				bestNode = node
+			count := top.counts[top.next]
+			top.next++
+			neighbor := top.node.GetNeighbor(top.dir)
+			s.history.push(puzzle, Move{From: Position{X: top.node.XPos(), Y: top.node.YPos()}, To: Position{X: neighbor.XPos(), Y: neighbor.YPos()},
+				Count: top.node.BridgesInDirection(top.dir) + count, Technique: TechniqueSpeculation, Speculative: true, Depth: level})
+			if err = s.guess(puzzle, top.node, top.dir, count, level-1); err == nil {
+				break
 			}
 		}
 	}
-
-	return bestNode
 }
 
-// AttemptSpeculativeSolve attempts to solve the puzzle using speculative moves and backtracking
-func AttemptSpeculativeSolve(puzzle *Puzzle, debug bool) (*Puzzle, error) {
-	// Try to solve using logic first
-	movesFound := true
-	for movesFound {
-		movesFound = false
-
-		// Look at every node
-		for i := 0; i < puzzle.Size; i++ {
-			for j := 0; j < puzzle.Size; j++ {
-				node := puzzle.Board[i][j]
-
-				// Skip empty spaces or already satisfied nodes
-				if node.Value <= 0 || node.TotalBridges == node.Value {
-					continue
-				}
-
-				// Check for logical errors
-				if node.NumBlocked == 4 && node.TotalBridges < node.Value {
-					if debug {
-						fmt.Println("Logical error - node blocked in all directions but still needs bridges")
-					}
-					return puzzle, errors.New("logical error - node blocked in all directions")
-				}
-
-				// Check for bridges that would block one edge of the node
-				BridgeCheck(node)
-
-				// If 3 directions are blocked, connect to the remaining one
-				if node.NumBlocked == 3 && node.TotalBridges < node.Value {
-					direction := node.UnblockedNode()
-					neighbor := node.GetNeighbor(direction)
-
-					if neighbor != nil {
-						ConnectNodes(puzzle, node, neighbor, direction, false)
-
-						// Make a double bridge if necessary
-						if node.Value == node.TotalBridges+1 {
-							ConnectNodes(puzzle, node, neighbor, direction, false)
-						}
-
-						movesFound = true
-					}
-				}
-
-				// If remaining value equals total possible moves, all bridges must be fully connected
-				if node.Value-node.TotalBridges == node.TotalPossibleMoves() {
-					unblocked := node.UnblockedNodes()
-					for _, dir := range unblocked {
-						neighbor := node.GetNeighbor(dir)
-
-						if neighbor == nil {
-							continue
-						}
-
-						// Don't add a double bridge to a 1 or a node with remaining value of 1
-						if node.BridgesInDirection(dir) == 0 && neighbor.Value-neighbor.TotalBridges > 1 {
-							ConnectNodes(puzzle, node, neighbor, dir, false)
-							ConnectNodes(puzzle, node, neighbor, dir, false)
-						} else {
-							ConnectNodes(puzzle, node, neighbor, dir, false)
-						}
-					}
-					movesFound = true
-				}
-
-				// If remaining value equals remaining possible moves - 1
-				// All edges must have at least one bridge
-				if node.Value-node.TotalBridges == node.TotalPossibleMoves()-1 {
-					unblocked := node.UnblockedNodes()
-					for _, dir := range unblocked {
-						// Check if any bridges already exist in that direction
-						// If not, connect one
-						if node.BridgesInDirection(dir) < 1 {
-							neighbor := node.GetNeighbor(dir)
-							if neighbor != nil {
-								ConnectNodes(puzzle, node, neighbor, dir, false)
-								movesFound = true
-							}
-						}
-					}
-				}
-
-				// Check if adding a bridge in any direction would create an island
-				unblocked := node.UnblockedNodes()
-				for _, dir := range unblocked {
-					if CheckForIsland(puzzle, node, dir, 1) {
-						movesFound = true
-					}
-				}
+// settle applies the logical rules to the position at depth until they stall.
+// It records the puzzle if that completes it, and otherwise returns the guess
+// to make next, or the error that ends the search of this position.
+func (s *solver) settle(puzzle *Puzzle, depth int) (*Puzzle, *decision, error) {
+	s.stats.NodesExplored++
+	if depth > s.stats.MaxDepth {
+		s.stats.MaxDepth = depth
+	}
 
-				// Check the island condition for double bridges
-				if node.NumBlocked == 2 && node.Value-node.TotalBridges == 2 {
-					unblocked := node.UnblockedNodes()
-					if len(unblocked) == 2 { // Make sure we have exactly 2 unblocked directions
-						for k, dir := range unblocked {
-							neighbor := node.GetNeighbor(dir)
-							if neighbor == nil {
-								continue
-							}
-
-							if neighbor.Value >= 2 && neighbor.TotalBridges == 0 {
-								if CheckForIsland(puzzle, node, dir, 2) {
-									movesFound = true
-									// Add a bridge in the other direction
-									var otherDir int
-									if k == 0 {
-										otherDir = unblocked[1]
-									} else {
-										otherDir = unblocked[0]
-									}
-									otherNeighbor := node.GetNeighbor(otherDir)
-									if otherNeighbor != nil {
-										ConnectNodes(puzzle, node, otherNeighbor, otherDir, false)
-									}
-								}
-							}
-						}
-					}
-				}
+	maxBridges := puzzle.maxBridges()
 
-				// If a node has two unblocked edges and one is not enough to satisfy it
-				if node.NumBlocked == 2 && node.Value-node.TotalBridges >= 2 {
-					unblocked := node.UnblockedNodes()
-					if len(unblocked) == 2 { // Make sure we have exactly 2 unblocked directions
-						for k, dir := range unblocked {
-							neighbor := node.GetNeighbor(dir)
-							if neighbor == nil {
-								continue
-							}
-
-							if neighbor.Value-neighbor.TotalBridges == 1 {
-								movesFound = true
-
-								// Connect to the other direction
-								var otherDir int
-								if k == 0 {
-									otherDir = unblocked[1]
-								} else {
-									otherDir = unblocked[0]
-								}
-								otherNeighbor := node.GetNeighbor(otherDir)
-								if otherNeighbor != nil {
-									ConnectNodes(puzzle, node, otherNeighbor, otherDir, false)
-								}
-							}
-						}
-					}
-				}
-			}
+	// Apply the rules until they stall, then probe for more if asked to
+	for {
+		if err := s.propagate(puzzle, depth); err != nil {
+			return puzzle, nil, err
 		}
-
-		if debug && movesFound {
-			fmt.Println("Found moves in this iteration, continuing...")
+		if !s.probing || puzzle.IsComplete() {
+			break
+		}
+		moved, err := s.probe(puzzle, depth)
+		if err != nil {
+			return puzzle, nil, err
+		}
+		if !moved {
+			break
 		}
 	}
 
+	s.observe(puzzle)
+
 	// Check if the puzzle is completely solved using just logic
 	if puzzle.IsComplete() {
-		if debug {
-			fmt.Printf("Solution complete: %d/%d bridges placed\n", puzzle.BuiltBridges, puzzle.FullBridges/2)
-		}
-		return puzzle, nil
+		s.log.Info("solution complete", Field{"placed", puzzle.BuiltBridges}, Field{"total", puzzle.FullBridges / 2}, Field{"depth", depth})
+		s.reportProgress(puzzle, depth, true)
+		solved, err := s.record(puzzle)
+		return solved, nil, err
 	}
 
 	// If we get here, we need to use speculation
-	if debug {
-		fmt.Println("Using speculative solving...")
+	if s.noGuess {
+		s.log.Info("logic stalled", Field{"placed", puzzle.BuiltBridges}, Field{"total", puzzle.FullBridges / 2})
+		return puzzle, nil, ErrGuessRequired
+	}
+
+	// Don't speculate any deeper than the configured limit
+	if s.maxDepth > 0 && depth >= s.maxDepth {
+		s.depthLimited = true
+		return puzzle, nil, ErrDepthLimit
 	}
 
+	s.log.Debug("logic stalled, speculating", Field{"depth", depth})
+
 	// Find a good candidate node for speculation
-	candidateNode := puzzle.FindCandidateNode()
+	candidateNode := s.strategy.Choose(puzzle)
 	if candidateNode == nil {
-		return puzzle, errors.New("no candidate node found for speculation")
+		return puzzle, nil, fmt.Errorf("%w: no candidate node found for speculation", ErrNoSolution)
 	}
 
-	// Try each possible direction
-	unblocked := candidateNode.UnblockedNodes()
-	for _, dir := range unblocked {
-		neighbor := candidateNode.GetNeighbor(dir)
-		if neighbor == nil {
-			continue
-		}
+	// Branch on how many more bridges go in the candidate's first open direction.
+	// Each branch blocks the direction after placing its bridges, so the branches
+	// never overlap and every arrangement is explored exactly once. It also
+	// means no position is ever reached twice: every position below a branch
+	// keeps that branch's bridge count in that direction. A table of positions
+	// already found to fail would never be hit, so there isn't one.
+	dir := candidateNode.UnblockedNode()
 
-		// Try adding a single bridge
-		if debug {
-			fmt.Printf("Trying a single bridge from (%d,%d) in direction %d\n",
-				candidateNode.YPos, candidateNode.XPos, dir)
-		}
+	maxCount := candidateNode.DirectionCapacity(dir, maxBridges)
+	if remaining := candidateNode.Value() - candidateNode.TotalBridges; remaining < maxCount {
+		maxCount = remaining
+	}
 
-		// Create a clone for speculative solving
-		speculativePuzzle := puzzle.Clone()
-		speculativeNode := speculativePuzzle.Board[candidateNode.YPos][candidateNode.XPos]
-		speculativeNeighbor := speculativePuzzle.Board[neighbor.YPos][neighbor.XPos]
+	// Try every bridge count up to the per-pair limit, then no more bridges at all
+	counts := []int{}
+	for count := 1; count <= maxCount; count++ {
+		counts = append(counts, count)
+	}
+	counts = append(counts, 0)
 
-		// Add a single bridge
-		ConnectNodes(speculativePuzzle, speculativeNode, speculativeNeighbor, dir, true)
+	return puzzle, &decision{node: candidateNode, dir: dir, counts: counts}, nil
+}
 
-		// Recursively attempt to solve
-		newPuzzle, err := AttemptSpeculativeSolve(speculativePuzzle, debug)
-		if err == nil && newPuzzle.IsComplete() {
-			return newPuzzle, nil
+// propagate applies the logical rules to the position at depth until they
+// stall, returning an error if they find a contradiction
+func (s *solver) propagate(puzzle *Puzzle, depth int) error {
+	// Apply the rules until they stall. Each round starts by looking at
+	// every island, then only at those near a change until things settle,
+	// as most rules depend on an island and its neighbors alone. The next
+	// round catches what the board-wide isolation rule finds further away,
+	// with the links between islands as they are now.
+	// With maxPasses set, each round is a single plain sweep instead.
+	local := s.maxPasses == 0
+	queue := newWorklist(puzzle)
+	for movesFound := true; movesFound; {
+		if s.maxPasses > 0 && s.stats.Iterations >= s.maxPasses {
+			break
 		}
+		movesFound = false
+		s.stats.Iterations++
+		s.reportProgress(puzzle, depth, false)
+		s.observe(puzzle)
 
-		// If we can add a double bridge, try that too
-		if candidateNode.Value-candidateNode.TotalBridges >= 2 &&
-			neighbor.Value-neighbor.TotalBridges >= 2 {
-
-			if debug {
-				fmt.Printf("Trying a double bridge from (%d,%d) in direction %d\n",
-					candidateNode.YPos, candidateNode.XPos, dir)
-			}
-
-			// Create another clone for double bridge speculation
-			speculativePuzzle2 := puzzle.Clone()
-			speculativeNode2 := speculativePuzzle2.Board[candidateNode.YPos][candidateNode.XPos]
-			speculativeNeighbor2 := speculativePuzzle2.Board[neighbor.YPos][neighbor.XPos]
+		// Stop promptly if the caller has given up
+		if err := s.cancelled(); err != nil {
+			return err
+		}
 
-			// Add two bridges
-			ConnectNodes(speculativePuzzle2, speculativeNode2, speculativeNeighbor2, dir, true)
-			ConnectNodes(speculativePuzzle2, speculativeNode2, speculativeNeighbor2, dir, true)
+		// Islands that can no longer all be joined mean a wrong guess
+		links := puzzle.currentLinks()
+		if !links.connected {
+			s.log.Debug("islands cut off from each other", Field{"depth", depth})
+			return fmt.Errorf("%w: logical error - the islands can no longer all be connected", ErrNoSolution)
+		}
 
-			// Recursively attempt to solve
-			newPuzzle2, err2 := AttemptSpeculativeSolve(speculativePuzzle2, debug)
-			if err2 == nil && newPuzzle2.IsComplete() {
-				return newPuzzle2, nil
+		queue.pushAll(puzzle)
+		for node := queue.pop(); node != nil; node = queue.pop() {
+			moved, err := s.deduce(puzzle, links, node, depth)
+			if err != nil {
+				return err
+			}
+			if moved {
+				movesFound = true
+				if local {
+					queue.pushAround(puzzle, node)
+				}
 			}
 		}
 
-		// Try blocking this direction
-		if debug {
-			fmt.Printf("Trying blocking direction %d from (%d,%d)\n",
-				dir, candidateNode.YPos, candidateNode.XPos)
+		if movesFound {
+			s.log.Debug("found moves in this pass, continuing", Field{"pass", s.stats.Iterations}, Field{"depth", depth})
 		}
+	}
 
-		// Create a clone for blocking speculation
-		speculativePuzzle3 := puzzle.Clone()
-		speculativeNode3 := speculativePuzzle3.Board[candidateNode.YPos][candidateNode.XPos]
+	return nil
+}
 
-		// Block the direction
-		speculativeNode3.DirectionBlocked(dir)
+// guess places count more bridges from node in direction dir and blocks the
+// direction, as one branch of the guess made at depth. If it fails, undoing
+// whatever it changed is left to the caller.
+func (s *solver) guess(puzzle *Puzzle, node *Node, dir, count, depth int) error {
+	s.log.Debug("trying a guess", append(posFields(node.XPos(), node.YPos()),
+		Field{"direction", directionNames[dir]}, Field{"count", count}, Field{"depth", depth + 1})...)
+	s.stats.Branches++
 
-		// Recursively attempt to solve
-		newPuzzle3, err3 := AttemptSpeculativeSolve(speculativePuzzle3, debug)
-		if err3 == nil && newPuzzle3.IsComplete() {
-			return newPuzzle3, nil
+	neighbor := node.GetNeighbor(dir)
+	for k := 0; k < count; k++ {
+		if err := s.connect(puzzle, node, neighbor, dir, TechniqueSpeculation, depth+1); err != nil {
+			return err
 		}
 	}
-
-	// If we've tried all possibilities and none worked, there's no solution
-	return puzzle, errors.New("no solution found with speculation")
+	node.DirectionBlocked(dir)
+	return s.verifyAfter(puzzle, fmt.Sprintf("blocking the guess at (%d,%d)", node.XPos(), node.YPos()))
 }
 
-// Solve attempts to solve the hashiwokakero puzzle from the input reader
-func Solve(input io.Reader, debug bool) (*Puzzle, error) {
-	scanner := bufio.NewScanner(input)
-
-	// Read the puzzle from the input
-	lines := []string{}
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if len(line) > 0 {
-			lines = append(lines, line)
-		}
+// stopping returns the error to give up the whole search with after err ended
+// a branch, or nil to go on to the other branches. The search stops once the
+// caller has given up, once the solver's own state is known to be broken or
+// once it is out of memory.
+func (s *solver) stopping(err error) error {
+	if cancelled := s.cancelled(); cancelled != nil {
+		return cancelled
 	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading input: %v", err)
+	if errors.Is(err, ErrInvariant) || errors.Is(err, ErrMemoryLimit) {
+		return err
 	}
+	return nil
+}
 
-	if len(lines) == 0 {
-		return nil, errors.New("no input provided")
+// unwind undoes the guess at index i of the stack and every one above it,
+// leaving the stack itself as it is
+func (s *solver) unwind(puzzle *Puzzle, i int) {
+	if i >= len(s.stack) {
+		return
 	}
+	s.history.rewind(puzzle, s.stack[i].step)
+}
 
-	// Determine board size - equal to the number of lines
-	boardSize := len(lines)
+// deduce applies every logical rule to one island, reporting whether any of
+// them placed a bridge or blocked a direction. links is the state of the
+// board's connections as of the start of the round.
+func (s *solver) deduce(puzzle *Puzzle, links *links, node *Node, depth int) (bool, error) {
+	maxBridges := puzzle.maxBridges()
+	moved := false
+	// Skip empty spaces or already satisfied nodes
+	if node.Value() <= 0 || node.TotalBridges == node.Value() {
+		return false, nil
+	}
 
-	if debug {
-		fmt.Printf("Board size: %dx%d\n", boardSize, boardSize)
+	// Check for logical errors
+	if node.NumBlocked == 4 && node.TotalBridges < node.Value() {
+		s.log.Debug("island blocked in all directions but still needs bridges", posFields(node.XPos(), node.YPos())...)
+		return moved, fmt.Errorf("%w: logical error - node at (%d,%d) blocked in all directions", ErrNoSolution, node.XPos(), node.YPos())
 	}
 
-	// Initialize the puzzle
-	puzzle := &Puzzle{
-		Size:         boardSize,
-		Board:        make([][]*Node, boardSize),
-		BuiltBridges: 0,
-		FullBridges:  0,
+	if node.Value()-node.TotalBridges > node.TotalPossibleMoves(maxBridges) {
+		s.log.Debug("island needs more bridges than its neighbors can take", posFields(node.XPos(), node.YPos())...)
+		return moved, fmt.Errorf("%w: logical error - node at (%d,%d) cannot reach its value", ErrNoSolution, node.XPos(), node.YPos())
 	}
 
-	// Parse each line of the puzzle
-	for i, line := range lines {
-		puzzle.Board[i] = make([]*Node, boardSize)
+	// Check for bridges that would block one edge of the node
+	BridgeCheck(node)
 
-		for j, char := range line {
-			if j >= boardSize {
-				break
-			}
+	// If 3 directions are blocked, connect to the remaining one
+	if node.NumBlocked == 3 && node.TotalBridges < node.Value() {
+		direction := node.UnblockedNode()
+		neighbor := node.GetNeighbor(direction)
 
-			var value int
-			if char == '.' {
-				value = 0
-			} else if char >= '1' && char <= '9' {
-				value = int(char - '0')
-				puzzle.FullBridges += value
-			} else {
-				// If it's not a number or a dot, assume it's empty space
-				value = 0
+		if neighbor != nil {
+			// Every remaining bridge has to go this way, up to the per-pair limit
+			count := node.Value() - node.TotalBridges
+			if capacity := node.DirectionCapacity(direction, maxBridges); count > capacity {
+				count = capacity
+			}
+			for k := 0; k < count; k++ {
+				if err := s.connect(puzzle, node, neighbor, direction, TechniqueOneDirection, depth); err != nil {
+					return moved, err
+				}
 			}
 
-			puzzle.Board[i][j] = NewNode(value, j, i)
+			moved = true
 		}
 	}
 
-	// Find neighbors for each node
-	for i := 0; i < boardSize; i++ {
-		for j := 0; j < boardSize; j++ {
-			if puzzle.Board[i][j].Value <= 0 {
-				continue
-			}
+	// If remaining value equals total possible moves, all bridges must be fully connected
+	if remaining := node.Value() - node.TotalBridges; remaining > 0 && remaining == node.TotalPossibleMoves(maxBridges) {
+		unblocked := node.UnblockedNodes()
 
-			// Find right neighbor
-			for k := j + 1; k < boardSize; k++ {
-				if puzzle.Board[i][k].Value > 0 {
-					puzzle.Board[i][j].RightNeighbor = puzzle.Board[i][k]
-					break
-				}
-			}
+		// Work out every capacity before connecting, as each bridge changes the neighbors
+		capacities := make([]int, len(unblocked))
+		for k, dir := range unblocked {
+			capacities[k] = node.DirectionCapacity(dir, maxBridges)
+		}
 
-			// Find left neighbor
-			for k := j - 1; k >= 0; k-- {
-				if puzzle.Board[i][k].Value > 0 {
-					puzzle.Board[i][j].LeftNeighbor = puzzle.Board[i][k]
-					break
+		for k, dir := range unblocked {
+			neighbor := node.GetNeighbor(dir)
+			for b := 0; b < capacities[k]; b++ {
+				if err := s.connect(puzzle, node, neighbor, dir, TechniqueValueEqualsCapacity, depth); err != nil {
+					return moved, err
 				}
 			}
+		}
+		moved = true
+	}
 
-			// Find down neighbor
-			for k := i + 1; k < boardSize; k++ {
-				if puzzle.Board[k][j].Value > 0 {
-					puzzle.Board[i][j].DownNeighbor = puzzle.Board[k][j]
-					break
-				}
-			}
+	// If the other directions can't take all the remaining bridges,
+	// the difference must go in this direction
+	if remaining := node.Value() - node.TotalBridges; remaining > 0 && remaining <= node.TotalPossibleMoves(maxBridges) {
+		unblocked := node.UnblockedNodes()
+		total := node.TotalPossibleMoves(maxBridges)
+
+		needed := make([]int, len(unblocked))
+		for k, dir := range unblocked {
+			needed[k] = remaining - (total - node.DirectionCapacity(dir, maxBridges))
+		}
 
-			// Find up neighbor
-			for k := i - 1; k >= 0; k-- {
-				if puzzle.Board[k][j].Value > 0 {
-					puzzle.Board[i][j].UpNeighbor = puzzle.Board[k][j]
-					break
+		for k, dir := range unblocked {
+			neighbor := node.GetNeighbor(dir)
+			for b := 0; b < needed[k]; b++ {
+				if err := s.connect(puzzle, node, neighbor, dir, TechniqueForcedShare, depth); err != nil {
+					return moved, err
 				}
+				moved = true
 			}
 		}
 	}
 
-	// Set up initial blockages
-	for i := 0; i < boardSize; i++ {
-		for j := 0; j < boardSize; j++ {
-			if puzzle.Board[i][j].Value <= 0 {
-				continue
-			}
-
-			// Assign obvious blockages - edge nodes and a 1 connecting to a 1
-			if puzzle.Board[i][j].LeftNeighbor == nil ||
-				(puzzle.Board[i][j].Value == 1 && puzzle.Board[i][j].LeftNeighbor != nil && puzzle.Board[i][j].LeftNeighbor.Value == 1) {
-				puzzle.Board[i][j].LeftBlocked = true
-				puzzle.Board[i][j].NumBlocked++
+	// A link the board can't do without needs at least one bridge, or the
+	// islands on either side of it would be cut off from each other
+	for _, dir := range node.UnblockedNodes() {
+		if node.BridgesInDirection(dir) == 0 && links.isCut(node, dir) && node.DirectionCapacity(dir, maxBridges) > 0 {
+			if err := s.connect(puzzle, node, node.GetNeighbor(dir), dir, TechniqueIsolation, depth); err != nil {
+				return moved, err
 			}
+			moved = true
+		}
+	}
 
-			if puzzle.Board[i][j].RightNeighbor == nil ||
-				(puzzle.Board[i][j].Value == 1 && puzzle.Board[i][j].RightNeighbor != nil && puzzle.Board[i][j].RightNeighbor.Value == 1) {
-				puzzle.Board[i][j].RightBlocked = true
-				puzzle.Board[i][j].NumBlocked++
-			}
+	// If a node has two unblocked edges and one is not enough to satisfy it
+	if node.NumBlocked == 2 && node.Value()-node.TotalBridges >= 2 {
+		unblocked := node.UnblockedNodes()
+		if len(unblocked) == 2 { // Make sure we have exactly 2 unblocked directions
+			for k, dir := range unblocked {
+				neighbor := node.GetNeighbor(dir)
+				if neighbor == nil {
+					continue
+				}
 
-			if puzzle.Board[i][j].UpNeighbor == nil ||
-				(puzzle.Board[i][j].Value == 1 && puzzle.Board[i][j].UpNeighbor != nil && puzzle.Board[i][j].UpNeighbor.Value == 1) {
-				puzzle.Board[i][j].UpBlocked = true
-				puzzle.Board[i][j].NumBlocked++
-			}
+				if neighbor.Value()-neighbor.TotalBridges == 1 {
+					moved = true
 
-			if puzzle.Board[i][j].DownNeighbor == nil ||
-				(puzzle.Board[i][j].Value == 1 && puzzle.Board[i][j].DownNeighbor != nil && puzzle.Board[i][j].DownNeighbor.Value == 1) {
-				puzzle.Board[i][j].DownBlocked = true
-				puzzle.Board[i][j].NumBlocked++
+					// Connect to the other direction
+					var otherDir int
+					if k == 0 {
+						otherDir = unblocked[1]
+					} else {
+						otherDir = unblocked[0]
+					}
+					otherNeighbor := node.GetNeighbor(otherDir)
+					if otherNeighbor != nil {
+						if err := s.connect(puzzle, node, otherNeighbor, otherDir, TechniqueTwoDirections, depth); err != nil {
+							return moved, err
+						}
+					}
+				}
 			}
 		}
 	}
 
-	// Solve the puzzle using the enhanced solver with speculation
-	return AttemptSpeculativeSolve(puzzle, debug)
+	return moved, nil
+}
+
+// SolveLogicOnly applies only the logical rules, without speculating.
+// If they stall before the puzzle is complete, the partial puzzle is returned
+// with ErrGuessRequired, and BuiltBridges shows how far logic alone got.
+func SolveLogicOnly(puzzle *Puzzle, debug bool) (*Puzzle, error) {
+	return SolveWith(context.Background(), puzzle, WithDebug(debug), WithLogicOnly(true))
+}
+
+// SolveWithMaxDepth solves the puzzle but abandons any branch that would need more
+// than maxDepth nested speculative guesses. It also returns the deepest level of
+// speculation reached. If no solution is found and some branch was abandoned,
+// the error is ErrDepthLimit, as a solution may lie beyond the limit.
+func SolveWithMaxDepth(puzzle *Puzzle, maxDepth int, debug bool) (*Puzzle, int, error) {
+	result, err := SolveWithStats(context.Background(), puzzle, WithDebug(debug), WithMaxDepth(maxDepth))
+	return result.Puzzle, result.Stats.MaxDepth, err
+}
+
+// SolveContext solves the puzzle, giving up with ctx.Err() as soon as the
+// context is cancelled or its deadline passes
+func SolveContext(ctx context.Context, puzzle *Puzzle, debug bool) (*Puzzle, error) {
+	return SolveWith(ctx, puzzle, WithDebug(debug))
+}
+
+// Solve attempts to solve the hashiwokakero puzzle from the input reader
+func Solve(input io.Reader, debug bool) (*Puzzle, error) {
+	return SolveReader(context.Background(), input, WithDebug(debug))
+}
+
+// SolveWithRules attempts to solve the puzzle from the input reader under the given rule set
+func SolveWithRules(input io.Reader, debug bool, rules RuleSet) (*Puzzle, error) {
+	return SolveReader(context.Background(), input, WithDebug(debug), WithRuleSet(rules))
+}
+
+// SolvePuzzle solves a puzzle that has already been parsed
+func SolvePuzzle(puzzle *Puzzle, debug bool) (*Puzzle, error) {
+	return SolveWith(context.Background(), puzzle, WithDebug(debug))
 }
 
 // PrintMap prints the solved puzzle to stdout
 func PrintMap(puzzle *Puzzle) {
-	for i := 0; i < puzzle.Size; i++ {
-		for j := 0; j < puzzle.Size; j++ {
-			node := puzzle.Board[i][j]
-
-			switch node.Value {
-			case 0:
-				fmt.Print(" ")
-			case -1:
-				fmt.Print("|") // Vertical single bridge
-			case -2:
-				fmt.Print("\"") // Vertical double bridge
-			case -3:
-				fmt.Print("-") // Horizontal single bridge
-			case -4:
-				fmt.Print("=") // Horizontal double bridge
-			default:
-				if node.Value > 0 {
-					fmt.Print(node.Value)
-				} else {
-					fmt.Print(" ") // Unknown value
-				}
-			}
+	for _, line := range MapLines(puzzle) {
+		fmt.Println(line)
+	}
+}
+
+// MapLines renders each row of the board as it is printed by PrintMap
+func MapLines(puzzle *Puzzle) []string {
+	return DrawMap(puzzle, CharsetASCII)
+}
+
+// cellSymbol returns the character drawn for a board value
+func cellSymbol(value int) string {
+	switch value {
+	case 0:
+		return " "
+	case -1:
+		return "|" // Vertical single bridge
+	case -2:
+		return "\"" // Vertical double bridge
+	case -3:
+		return "-" // Horizontal single bridge
+	case -4:
+		return "=" // Horizontal double bridge
+	case -5:
+		return "#" // Vertical triple bridge
+	case -6:
+		return "E" // Horizontal triple bridge
+	default:
+		if value > 9 {
+			return string(rune('a' + value - 10)) // Same letters as bridgen
+		} else if value > 0 {
+			return fmt.Sprint(value)
 		}
-		fmt.Println()
+		return " " // Unknown value
 	}
 }
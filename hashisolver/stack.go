@@ -0,0 +1,40 @@
+// hashisolver/stack.go
+package hashisolver
+
+// decision is a guess the search is exploring: the numbers of bridges to try
+// from node in direction dir, one branch each
+type decision struct {
+	node   *Node
+	dir    int
+	counts []int
+	// next is the index in counts of the branch to try next
+	next int
+	// step is how many moves the history held before the guess, to rewind a
+	// branch back to
+	step int
+}
+
+// Guess describes one of the guesses a running search is exploring
+type Guess struct {
+	// Island is where the guess was made, and Direction the way its bridges go
+	Island    Position
+	Direction string
+	// Branch is which of the guess's alternatives is being tried, counting
+	// from 1, out of Branches
+	Branch   int
+	Branches int
+}
+
+// guesses describes the stack of guesses being explored, outermost first
+func (s *solver) guesses() []Guess {
+	guesses := make([]Guess, len(s.stack))
+	for i, d := range s.stack {
+		guesses[i] = Guess{
+			Island:    Position{X: d.node.XPos(), Y: d.node.YPos()},
+			Direction: directionNames[d.dir],
+			Branch:    d.next,
+			Branches:  len(d.counts),
+		}
+	}
+	return guesses
+}
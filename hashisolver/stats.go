@@ -0,0 +1,123 @@
+// hashisolver/stats.go
+package hashisolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Stats describes how much work a solve took
+type Stats struct {
+	// Engine names the engine that did the solve
+	Engine string
+	// NodesExplored is the number of search states visited, including the
+	// first, or for the SAT engine the number of decisions made
+	NodesExplored int
+	// Branches is the number of speculative branches tried, or for the DLX
+	// engine the number of rows tried
+	Branches int
+	// MaxDepth is the deepest level of nested speculation reached, or for the
+	// SAT engine the deepest decision level
+	MaxDepth int
+	// Iterations is the number of rounds of the logical rules, each starting
+	// with a pass over the whole board, or for the SAT engine the number of
+	// times the SAT solver was run
+	Iterations int
+	// Probes is the number of links probing tried both ways
+	Probes int
+	// Clones is the number of puzzle copies made, to keep solutions while
+	// the search goes on
+	Clones int
+	// PeakMemory is the most bytes the changes recorded to undo guesses took
+	// at once, estimated
+	PeakMemory int64
+	// Elapsed is the wall time of the solve
+	Elapsed time.Duration
+	// Strategy names the strategy that picked the islands to guess at
+	Strategy string
+	// Conflicts is the number of conflicts the SAT engine learnt a clause from
+	Conflicts int
+	// Cuts is the number of times islands left apart were ruled out: by a
+	// clause the SAT engine added, or by the DLX engine dropping a partial cover
+	Cuts int
+}
+
+// SolveResult is a solved puzzle along with the statistics of its solve
+type SolveResult struct {
+	Puzzle *Puzzle
+	// Partial is set when the solve stopped short of a solution, in which case
+	// Puzzle holds the bridges the logical rules had placed by then, or with
+	// WithAnytime the best board reached if the solve was stopped early
+	Partial bool
+	Stats   Stats
+	// Trace is every move made, including failed guesses, if WithTrace was given
+	Trace []Move
+}
+
+// SolveWithStats solves a parsed puzzle like SolveWith and also reports how the
+// solve went. The statistics are filled in even when the solve fails.
+func SolveWithStats(ctx context.Context, puzzle *Puzzle, opts ...Option) (*SolveResult, error) {
+	options := NewOptions(opts...)
+	engine, err := LookupEngine(options.Engine)
+	if err != nil {
+		return &SolveResult{Puzzle: puzzle}, err
+	}
+	return engine.Solve(ctx, puzzle, options)
+}
+
+// run solves the puzzle from the top and times the solve
+func (s *solver) run(puzzle *Puzzle, options Options) (*SolveResult, error) {
+	puzzle = underRules(puzzle, options)
+
+	s.log.Info("solving", Field{"rows", puzzle.Rows}, Field{"cols", puzzle.Cols})
+
+	parent := s.ctx
+	if options.Timeout > 0 {
+		if parent == nil {
+			parent = context.Background()
+		}
+		var cancel context.CancelFunc
+		s.ctx, cancel = context.WithTimeout(parent, options.Timeout)
+		defer cancel()
+	}
+
+	s.stats.Engine = EngineHeuristic
+	s.stats.Strategy = s.strategy.Name()
+	start := time.Now()
+	solved, err := s.solve(puzzle)
+	s.stats.Elapsed = time.Since(start)
+
+	// Running out of our own time is a timeout, while the caller's deadline
+	// is reported as the caller's
+	if options.Timeout > 0 && errors.Is(err, context.DeadlineExceeded) && parent.Err() == nil {
+		s.log.Info("timed out", Field{"placed", solved.BuiltBridges}, Field{"total", solved.FullBridges / 2})
+		err = fmt.Errorf("%w after %v", ErrTimeout, options.Timeout)
+	}
+	if s.anytime != nil && (errors.Is(err, ErrTimeout) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) {
+		if best := s.anytime.Best(); best != nil {
+			solved = best
+		}
+	}
+
+	return &SolveResult{Puzzle: solved, Partial: err != nil && solved != nil, Stats: s.stats, Trace: s.trace}, err
+}
+
+// WriteStats prints the statistics in a short human-readable form
+func WriteStats(w io.Writer, stats Stats) error {
+	if stats.Engine == EngineSAT {
+		_, err := fmt.Fprintf(w, "Engine: %s\nDecisions: %d\nMax decision level: %d\nSAT solver runs: %d\nConflicts: %d\nConnectivity cuts: %d\nElapsed: %v\n",
+			stats.Engine, stats.NodesExplored, stats.MaxDepth, stats.Iterations, stats.Conflicts, stats.Cuts, stats.Elapsed)
+		return err
+	}
+	if stats.Engine == EngineDLX {
+		_, err := fmt.Fprintf(w, "Engine: %s\nNodes explored: %d\nRows tried: %d\nMax depth: %d\nCovers cut off: %d\nElapsed: %v\n",
+			stats.Engine, stats.NodesExplored, stats.Branches, stats.MaxDepth, stats.Cuts, stats.Elapsed)
+		return err
+	}
+	_, err := fmt.Fprintf(w, "Nodes explored: %d\nSpeculative branches: %d\nMax depth: %d\nDeduction passes: %d\nProbes: %d\nClones: %d\nPeak undo memory: %d bytes\nStrategy: %s\nElapsed: %v\n",
+		stats.NodesExplored, stats.Branches, stats.MaxDepth, stats.Iterations, stats.Probes, stats.Clones, stats.PeakMemory, stats.Strategy, stats.Elapsed)
+	return err
+}
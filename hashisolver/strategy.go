@@ -0,0 +1,148 @@
+// hashisolver/strategy.go
+package hashisolver
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Strategy picks the island to guess at when the logical rules stall. The
+// guess is always about the island's first open direction, so a strategy
+// only decides where the search branches, never whether it finds a solution.
+type Strategy interface {
+	// Name identifies the strategy in statistics and on the command line
+	Name() string
+	// Choose returns the island to guess at, or nil if no unfinished island
+	// has an open direction left
+	Choose(puzzle *Puzzle) *Node
+}
+
+// WithStrategy picks the islands to guess at with strategy, rather than
+// HighestRemainingValue
+func WithStrategy(strategy Strategy) Option {
+	return func(o *Options) {
+		o.Strategy = strategy
+	}
+}
+
+// scoredStrategy chooses the open island with the highest score, taking the
+// first in reading order on a tie
+type scoredStrategy struct {
+	name  string
+	score func(node *Node) int
+}
+
+func (s scoredStrategy) Name() string {
+	return s.name
+}
+
+func (s scoredStrategy) Choose(puzzle *Puzzle) *Node {
+	var best *Node
+	bestScore := 0
+	for _, node := range openIslands(puzzle) {
+		if score := s.score(node); best == nil || score > bestScore {
+			best, bestScore = node, score
+		}
+	}
+	return best
+}
+
+// openIslands returns the islands still needing bridges that have a direction
+// open, in reading order
+func openIslands(puzzle *Puzzle) []*Node {
+	islands := []*Node{}
+	for i := range puzzle.Cells {
+		node := &puzzle.Cells[i]
+		if node.Value() <= 0 || node.Value() == node.TotalBridges || node.NumBlocked == 4 {
+			continue
+		}
+		islands = append(islands, node)
+	}
+	return islands
+}
+
+// HighestRemainingValue guesses at the island needing the most bridges,
+// preferring the one with the fewest directions open on a tie. It is the
+// default strategy.
+func HighestRemainingValue() Strategy {
+	return scoredStrategy{name: "highest-remaining", score: func(node *Node) int {
+		return (node.Value()-node.TotalBridges)*10 + node.NumBlocked
+	}}
+}
+
+// MostConstrained guesses at the island with the fewest directions open,
+// preferring the one needing the most bridges on a tie, so the guess has
+// the fewest alternatives
+func MostConstrained() Strategy {
+	return scoredStrategy{name: "most-constrained", score: func(node *Node) int {
+		return node.NumBlocked*100 + node.Value() - node.TotalBridges
+	}}
+}
+
+// MostNeighbors guesses at the island with the most directions open, so the
+// guess settles the most links at once
+func MostNeighbors() Strategy {
+	return scoredStrategy{name: "most-neighbors", score: func(node *Node) int {
+		return (4-node.NumBlocked)*100 + node.Value() - node.TotalBridges
+	}}
+}
+
+// randomStrategy guesses at an open island picked at random
+type randomStrategy struct {
+	seed int64
+	mu   sync.Mutex
+	rng  *rand.Rand
+}
+
+// RandomStrategy guesses at an open island picked at random from a generator
+// seeded with seed, so runs with the same seed make the same guesses. It is
+// safe to share between goroutines, though then the guesses depend on timing.
+func RandomStrategy(seed int64) Strategy {
+	return &randomStrategy{seed: seed, rng: rand.New(rand.NewSource(seed))}
+}
+
+func (r *randomStrategy) Name() string {
+	return fmt.Sprintf("random:%d", r.seed)
+}
+
+func (r *randomStrategy) Choose(puzzle *Puzzle) *Node {
+	islands := openIslands(puzzle)
+	if len(islands) == 0 {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return islands[r.rng.Intn(len(islands))]
+}
+
+// Strategies returns the names LookupStrategy accepts
+func Strategies() []string {
+	return []string{"highest-remaining", "most-constrained", "most-neighbors", "random[:seed]"}
+}
+
+// LookupStrategy returns the strategy with the given name. A random strategy
+// is named random:seed, or just random for seed 1.
+func LookupStrategy(name string) (Strategy, error) {
+	switch name {
+	case "highest-remaining":
+		return HighestRemainingValue(), nil
+	case "most-constrained":
+		return MostConstrained(), nil
+	case "most-neighbors":
+		return MostNeighbors(), nil
+	case "random":
+		return RandomStrategy(1), nil
+	}
+	if strings.HasPrefix(name, "random:") {
+		seed := strings.TrimPrefix(name, "random:")
+		n, err := strconv.ParseInt(seed, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid seed %q for the random strategy", seed)
+		}
+		return RandomStrategy(n), nil
+	}
+	return nil, fmt.Errorf("unknown strategy %q (want one of %s)", name, strings.Join(Strategies(), ", "))
+}
@@ -0,0 +1,71 @@
+// hashisolver/structure.go
+package hashisolver
+
+// Structure is what the layout of a puzzle's clues says about it, worked
+// out without solving it
+type Structure struct {
+	Cols int `json:"cols"`
+	Rows int `json:"rows"`
+	// Islands is the number of islands, and Density the fraction of cells
+	// they take up
+	Islands int     `json:"islands"`
+	Density float64 `json:"density"`
+	// Bridges is the number of bridges the clues call for
+	Bridges int `json:"bridges"`
+	// Clues counts the islands with each clue, indexed by the clue
+	Clues []int `json:"clues"`
+	// AverageNeighbors is the mean number of islands each island faces
+	AverageNeighbors float64 `json:"average_neighbors"`
+	// ForcedOpenings is the number of islands whose clue is too big for them
+	// to leave out any direction, like an island with one neighbor, a 3 in a
+	// corner, a 5 or 6 on an edge or a 7 or 8, with two bridges to a pair.
+	// These give a solver its first bridges.
+	ForcedOpenings int `json:"forced_openings"`
+	// LongestSpan is the most cells apart of any two islands that face each
+	// other, the longest bridge the puzzle could have
+	LongestSpan int `json:"longest_span"`
+}
+
+// Analyze works out the structure of the puzzle's clues. Bridges already on
+// the board are ignored.
+func Analyze(puzzle *Puzzle) Structure {
+	s := Structure{Cols: puzzle.Cols, Rows: puzzle.Rows}
+	maxBridges := puzzle.maxBridges()
+	neighbors, total := 0, 0
+	for y := 0; y < puzzle.Rows; y++ {
+		for x := 0; x < puzzle.Cols; x++ {
+			node := puzzle.At(x, y)
+			value := node.Value()
+			if value <= 0 {
+				continue
+			}
+			s.Islands++
+			total += value
+			for len(s.Clues) <= value {
+				s.Clues = append(s.Clues, 0)
+			}
+			s.Clues[value]++
+
+			faced := node.NumNeighbors()
+			neighbors += faced
+			if faced > 0 && value > (faced-1)*maxBridges {
+				s.ForcedOpenings++
+			}
+			for _, dir := range []int{DirectionRight, DirectionDown} {
+				if neighbor := node.GetNeighbor(dir); neighbor != nil {
+					if span := neighbor.XPos() - x + neighbor.YPos() - y; span > s.LongestSpan {
+						s.LongestSpan = span
+					}
+				}
+			}
+		}
+	}
+	s.Bridges = total / 2
+	if cells := puzzle.Rows * puzzle.Cols; cells > 0 {
+		s.Density = float64(s.Islands) / float64(cells)
+	}
+	if s.Islands > 0 {
+		s.AverageNeighbors = float64(neighbors) / float64(s.Islands)
+	}
+	return s
+}
@@ -0,0 +1,86 @@
+// hashisolver/svg.go
+package hashisolver
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// SVGStyle sets the sizes, in SVG user units, of a board drawn as SVG
+type SVGStyle struct {
+	// CellSize is the width and height of each cell of the board
+	CellSize float64
+	// BridgeWidth is the stroke width of each bridge line, and BridgeGap the
+	// space between the lines of a double or triple bridge
+	BridgeWidth float64
+	BridgeGap   float64
+	// IslandWidth is the stroke width of the circles drawn for islands
+	IslandWidth float64
+}
+
+// DefaultSVGStyle is the style the svg output format uses
+var DefaultSVGStyle = SVGStyle{CellSize: 40, BridgeWidth: 2, BridgeGap: 4, IslandWidth: 2}
+
+// RenderSVG draws the puzzle as an SVG image, each island a circle with its
+// clue in it. If solution is nil the bridges on the puzzle are drawn,
+// otherwise the solution's bridges are drawn on its clues.
+func RenderSVG(puzzle *Puzzle, solution *Solution, style SVGStyle) (string, error) {
+	if solution != nil {
+		board, err := puzzle.Apply(solution)
+		if err != nil {
+			return "", err
+		}
+		puzzle = board
+	}
+
+	cell := style.CellSize
+	radius := cell * 0.4
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%g" height="%g" viewBox="0 0 %g %g">`+"\n",
+		cell*float64(puzzle.Cols), cell*float64(puzzle.Rows), cell*float64(puzzle.Cols), cell*float64(puzzle.Rows))
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="white"/>`+"\n")
+
+	fmt.Fprintf(&b, `<g stroke="black" stroke-width="%g">`+"\n", style.BridgeWidth)
+	for _, bridge := range puzzle.Bridges() {
+		x0, y0 := (float64(bridge.From.X)+0.5)*cell, (float64(bridge.From.Y)+0.5)*cell
+		x1, y1 := (float64(bridge.To.X)+0.5)*cell, (float64(bridge.To.Y)+0.5)*cell
+		for i := 0; i < bridge.Count; i++ {
+			// The lines are spread evenly about the centre line
+			offset := (float64(i) - float64(bridge.Count-1)/2) * (style.BridgeWidth + style.BridgeGap)
+			if y0 == y1 {
+				fmt.Fprintf(&b, `<line x1="%g" y1="%g" x2="%g" y2="%g"/>`+"\n", x0+radius, y0+offset, x1-radius, y1+offset)
+			} else {
+				fmt.Fprintf(&b, `<line x1="%g" y1="%g" x2="%g" y2="%g"/>`+"\n", x0+offset, y0+radius, x1+offset, y1-radius)
+			}
+		}
+	}
+	b.WriteString("</g>\n")
+
+	fmt.Fprintf(&b, `<g font-family="sans-serif" font-size="%g" text-anchor="middle" dominant-baseline="central">`+"\n", cell*0.45)
+	for y := 0; y < puzzle.Rows; y++ {
+		for x := 0; x < puzzle.Cols; x++ {
+			value := puzzle.At(x, y).Value()
+			if value <= 0 {
+				continue
+			}
+			cx, cy := (float64(x)+0.5)*cell, (float64(y)+0.5)*cell
+			fmt.Fprintf(&b, `<circle cx="%g" cy="%g" r="%g" fill="white" stroke="black" stroke-width="%g"/>`+"\n",
+				cx, cy, radius, style.IslandWidth)
+			fmt.Fprintf(&b, `<text x="%g" y="%g">%d</text>`+"\n", cx, cy, value)
+		}
+	}
+	b.WriteString("</g>\n</svg>\n")
+	return b.String(), nil
+}
+
+// WriteSVG writes the puzzle with whatever bridges are on it as SVG in the
+// default style
+func WriteSVG(w io.Writer, puzzle *Puzzle) error {
+	svg, err := RenderSVG(puzzle, nil, DefaultSVGStyle)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, svg)
+	return err
+}
@@ -0,0 +1,78 @@
+// hashisolver/symmetry.go
+package hashisolver
+
+import "fmt"
+
+// Symmetry is a symmetry the generator keeps the island layout in
+type Symmetry int
+
+const (
+	// SymmetryNone places islands freely
+	SymmetryNone Symmetry = iota
+	// SymmetryRotate180 looks the same turned upside down
+	SymmetryRotate180
+	// SymmetryRotate90 looks the same turned a quarter turn, and needs a square board
+	SymmetryRotate90
+	// SymmetryHorizontal mirrors the top half of the board onto the bottom half
+	SymmetryHorizontal
+	// SymmetryVertical mirrors the left half of the board onto the right half
+	SymmetryVertical
+)
+
+func (s Symmetry) String() string {
+	switch s {
+	case SymmetryRotate180:
+		return "180"
+	case SymmetryRotate90:
+		return "90"
+	case SymmetryHorizontal:
+		return "horizontal"
+	case SymmetryVertical:
+		return "vertical"
+	}
+	return "none"
+}
+
+// ParseSymmetry reads a symmetry by name
+func ParseSymmetry(name string) (Symmetry, error) {
+	for s := SymmetryNone; s <= SymmetryVertical; s++ {
+		if s.String() == name {
+			return s, nil
+		}
+	}
+	return SymmetryNone, fmt.Errorf("unknown symmetry %q, expected 180, 90, horizontal, vertical or none", name)
+}
+
+// images returns sp and its distinct images under the symmetry on a rows x cols board
+func (s Symmetry) images(sp span, rows, cols int) []span {
+	images := []span{sp}
+	add := func(r0, c0, r1, c1 int) {
+		if r1 < r0 || c1 < c0 {
+			r0, c0, r1, c1 = r1, c1, r0, c0
+		}
+		image := span{r0, c0, r1, c1}
+		for _, seen := range images {
+			if seen == image {
+				return
+			}
+		}
+		images = append(images, image)
+	}
+
+	switch s {
+	case SymmetryRotate180:
+		add(rows-1-sp.r0, cols-1-sp.c0, rows-1-sp.r1, cols-1-sp.c1)
+	case SymmetryRotate90:
+		// A quarter turn takes (r,c) to (c,n-1-r) on an n x n board
+		r0, c0, r1, c1 := sp.r0, sp.c0, sp.r1, sp.c1
+		for turn := 0; turn < 3; turn++ {
+			r0, c0, r1, c1 = c0, rows-1-r0, c1, rows-1-r1
+			add(r0, c0, r1, c1)
+		}
+	case SymmetryHorizontal:
+		add(rows-1-sp.r0, sp.c0, rows-1-sp.r1, sp.c1)
+	case SymmetryVertical:
+		add(sp.r0, cols-1-sp.c0, sp.r1, cols-1-sp.c1)
+	}
+	return images
+}
@@ -0,0 +1,89 @@
+// hashisolver/task.go
+package hashisolver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// IsTask reports whether s looks like a task string, "WxH:" followed by the
+// board, rather than a board in the text format
+func IsTask(s string) bool {
+	colon := strings.Index(s, ":")
+	if colon < 0 {
+		return false
+	}
+	_, _, err := taskSize(s[:colon])
+	return err == nil
+}
+
+// ParseTask reads a puzzle from a one-line task string, as used by Simon
+// Tatham's Bridges: "WxH:" followed by the cells row by row, where a digit is
+// a clue and a lowercase letter is a run of water ('a' is one cell, 'b' two
+// and so on). Anything between the size and the colon, such as generator
+// parameters, is ignored.
+func ParseTask(s string, rules RuleSet) (*Puzzle, error) {
+	s = strings.TrimSpace(s)
+	colon := strings.Index(s, ":")
+	if colon < 0 {
+		return nil, fmt.Errorf("%w: task %q has no ':' after its size", ErrInvalidPuzzle, s)
+	}
+	cols, rows, err := taskSize(s[:colon])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPuzzle, err)
+	}
+
+	cells := make([]int, 0, rows*cols)
+	for i, char := range s[colon+1:] {
+		switch {
+		case char >= '0' && char <= '9':
+			cells = append(cells, int(char-'0'))
+		case char >= 'a' && char <= 'z':
+			cells = append(cells, make([]int, char-'a'+1)...)
+		default:
+			return nil, fmt.Errorf("%w: unexpected character %q at position %d of the task", ErrInvalidPuzzle, char, colon+2+i)
+		}
+	}
+	if len(cells) != rows*cols {
+		return nil, fmt.Errorf("%w: task describes %d cells, expected %dx%d = %d", ErrInvalidPuzzle, len(cells), cols, rows, rows*cols)
+	}
+
+	values := make([][]int, rows)
+	for i := range values {
+		values[i] = cells[i*cols : (i+1)*cols]
+		for _, value := range values[i] {
+			if value > rules.MaxIslandValue {
+				return nil, fmt.Errorf("%w: clue %d is above the maximum of %d", ErrInvalidPuzzle, value, rules.MaxIslandValue)
+			}
+		}
+	}
+
+	puzzle := NewPuzzle(values, rules)
+	if puzzle.FullBridges == 0 {
+		return nil, fmt.Errorf("%w: task has no islands", ErrInvalidPuzzle)
+	}
+	return puzzle, nil
+}
+
+// taskSize reads the "WxH" at the start of a task's parameters
+func taskSize(params string) (int, int, error) {
+	x := strings.Index(params, "x")
+	if x <= 0 {
+		return 0, 0, fmt.Errorf("task size %q is not WxH", params)
+	}
+	end := x + 1
+	for end < len(params) && params[end] >= '0' && params[end] <= '9' {
+		end++
+	}
+
+	cols, err := strconv.Atoi(params[:x])
+	if err != nil || cols <= 0 {
+		return 0, 0, fmt.Errorf("task size %q is not WxH", params)
+	}
+	rows, err := strconv.Atoi(params[x+1 : end])
+	if err != nil || rows <= 0 {
+		return 0, 0, fmt.Errorf("task size %q is not WxH", params)
+	}
+	return cols, rows, nil
+}
@@ -0,0 +1,158 @@
+// hashisolver/termimage.go
+package hashisolver
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"strings"
+)
+
+// ImageProtocol is a way of showing images inside a terminal
+type ImageProtocol int
+
+const (
+	// ImageSixel sends sixel graphics, which xterm, foot, mlterm and many
+	// others show
+	ImageSixel ImageProtocol = iota
+	// ImageKitty sends a PNG with the kitty graphics protocol, also taken by
+	// WezTerm, Konsole and Ghostty
+	ImageKitty
+	// ImageITerm sends a PNG as an iTerm2 inline file
+	ImageITerm
+)
+
+func (p ImageProtocol) String() string {
+	switch p {
+	case ImageKitty:
+		return "kitty"
+	case ImageITerm:
+		return "iterm"
+	}
+	return "sixel"
+}
+
+// ParseImageProtocol reads an image protocol by name
+func ParseImageProtocol(name string) (ImageProtocol, error) {
+	for p := ImageSixel; p <= ImageITerm; p++ {
+		if p.String() == name {
+			return p, nil
+		}
+	}
+	return ImageSixel, fmt.Errorf("unknown image protocol %q, expected sixel, kitty or iterm", name)
+}
+
+// TerminalImageRenderer returns a renderer drawing the board like WritePNG
+// and sending it as escape sequences a terminal with the protocol shows in
+// place, followed by a newline
+func TerminalImageRenderer(protocol ImageProtocol) Renderer {
+	return func(w io.Writer, puzzle *Puzzle) error {
+		img := DrawBoard(puzzle, DefaultImageStyle, nil)
+		out := bufio.NewWriter(w)
+		var err error
+		switch protocol {
+		case ImageSixel:
+			writeSixel(out, img)
+		case ImageKitty, ImageITerm:
+			err = writeInlinePNG(out, img, protocol)
+		}
+		if err != nil {
+			return err
+		}
+		out.WriteByte('\n')
+		return out.Flush()
+	}
+}
+
+// writeInlinePNG sends the image as a PNG in the kitty or iTerm2 protocol
+func writeInlinePNG(out *bufio.Writer, img image.Image, protocol ImageProtocol) error {
+	var data bytes.Buffer
+	if err := png.Encode(&data, img); err != nil {
+		return err
+	}
+	encoded := base64.StdEncoding.EncodeToString(data.Bytes())
+	if protocol == ImageITerm {
+		fmt.Fprintf(out, "\033]1337;File=inline=1;size=%d:%s\a", data.Len(), encoded)
+		return nil
+	}
+
+	// kitty takes the data in chunks of at most 4096 bytes, m=1 on every
+	// chunk but the last saying more are to come
+	for start := 0; start < len(encoded); start += 4096 {
+		end := start + 4096
+		more := 1
+		if end >= len(encoded) {
+			end, more = len(encoded), 0
+		}
+		if start == 0 {
+			fmt.Fprintf(out, "\033_Gf=100,a=T,m=%d;%s\033\\", more, encoded[start:end])
+		} else {
+			fmt.Fprintf(out, "\033_Gm=%d;%s\033\\", more, encoded[start:end])
+		}
+	}
+	return nil
+}
+
+// writeSixel sends the image as sixel graphics. Each band of six rows is
+// drawn once for every color in it, a character for each column giving the
+// rows of the band in that color, with runs of the same character shortened.
+func writeSixel(out *bufio.Writer, img *image.Paletted) {
+	bounds := img.Bounds()
+	fmt.Fprintf(out, "\033Pq\"1;1;%d;%d", bounds.Dx(), bounds.Dy())
+	for i, c := range img.Palette {
+		r, g, b, _ := c.RGBA()
+		fmt.Fprintf(out, "#%d;2;%d;%d;%d", i, r*100/0xffff, g*100/0xffff, b*100/0xffff)
+	}
+
+	for top := bounds.Min.Y; top < bounds.Max.Y; top += 6 {
+		first := true
+		for ink := range img.Palette {
+			var band strings.Builder
+			used := false
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				bits := 0
+				for row := 0; row < 6 && top+row < bounds.Max.Y; row++ {
+					if img.ColorIndexAt(x, top+row) == uint8(ink) {
+						bits |= 1 << row
+					}
+				}
+				used = used || bits != 0
+				band.WriteByte(byte(63 + bits))
+			}
+			if !used {
+				continue
+			}
+			// $ goes back to the start of the band for the next color
+			if !first {
+				out.WriteByte('$')
+			}
+			first = false
+			fmt.Fprintf(out, "#%d%s", ink, sixelRuns(band.String()))
+		}
+		out.WriteByte('-')
+	}
+	out.WriteString("\033\\")
+}
+
+// sixelRuns shortens runs of four or more of the same sixel character to !
+// and the count
+func sixelRuns(band string) string {
+	var b strings.Builder
+	for i := 0; i < len(band); {
+		j := i
+		for j < len(band) && band[j] == band[i] {
+			j++
+		}
+		if j-i >= 4 {
+			fmt.Fprintf(&b, "!%d%c", j-i, band[i])
+		} else {
+			b.WriteString(band[i:j])
+		}
+		i = j
+	}
+	return b.String()
+}
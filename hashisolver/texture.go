@@ -0,0 +1,75 @@
+// hashisolver/texture.go
+package hashisolver
+
+// textureTolerance is how far a generated puzzle's density and clue mix may
+// be from the requested values
+const textureTolerance = 0.1
+
+// texture describes how a layout looks: the fraction of cells that are
+// islands, and the fractions of clues that are high (6 or more) or low (1 or 2)
+type texture struct {
+	density, high, low float64
+}
+
+// texture measures the layout's islands
+func (l *layout) texture() texture {
+	islands, high, low := 0, 0, 0
+	for r := 0; r < l.rows; r++ {
+		for c := 0; c < l.cols; c++ {
+			switch value := l.islands[r][c]; {
+			case value >= 6:
+				high++
+			case value > 0 && value <= 2:
+				low++
+			}
+			if l.islands[r][c] > 0 {
+				islands++
+			}
+		}
+	}
+	if islands == 0 {
+		return texture{}
+	}
+
+	return texture{
+		density: float64(islands) / float64(l.rows*l.cols),
+		high:    float64(high) / float64(islands),
+		low:     float64(low) / float64(islands),
+	}
+}
+
+// islandTarget is the number of islands to stop growing at, or 0 for no limit
+func (opts GenerateOptions) islandTarget() int {
+	if opts.Density <= 0 {
+		return 0
+	}
+	return int(opts.Density*float64(opts.Rows*opts.Cols) + 0.5)
+}
+
+// fits reports whether t is close enough to the requested density and clue mix
+func (opts GenerateOptions) fits(t texture) bool {
+	near := func(want, got float64) bool {
+		return want <= 0 || (got > want-textureTolerance && got < want+textureTolerance)
+	}
+	return near(opts.Density, t.density) && near(opts.HighClues, t.high) && near(opts.LowClues, t.low)
+}
+
+// steer says which way to mutate bridges to move t toward the requested clue
+// mix: 1 to add planks, -1 to remove them, or 0 if either will do
+func (opts GenerateOptions) steer(t texture) int {
+	need := 0.0
+	if opts.HighClues > 0 {
+		need += opts.HighClues - t.high
+	}
+	if opts.LowClues > 0 {
+		need += t.low - opts.LowClues
+	}
+
+	switch {
+	case need > textureTolerance/2:
+		return 1
+	case need < -textureTolerance/2:
+		return -1
+	}
+	return 0
+}
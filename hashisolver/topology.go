@@ -0,0 +1,62 @@
+// hashisolver/topology.go
+package hashisolver
+
+// Topology is the shape of a generated puzzle's solution
+type Topology int
+
+const (
+	// TopologyAny accepts any solution
+	TopologyAny Topology = iota
+	// TopologyTree asks for a solution with no cycles
+	TopologyTree
+	// TopologyCycles asks for a solution with a given number of cycles
+	TopologyCycles
+)
+
+// cycles counts the independent cycles in the layout's bridges, treating
+// each pair of joined islands as one edge whatever its planks
+func (l *layout) cycles() int {
+	parent := make([]int, l.rows*l.cols)
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	// Each bridge is found from its top or left island, and one that joins
+	// islands already connected closes a cycle
+	cycles := 0
+	for r := 0; r < l.rows; r++ {
+		for c := 0; c < l.cols; c++ {
+			if l.islands[r][c] == 0 {
+				continue
+			}
+			for _, step := range [][2]int{{0, 1}, {1, 0}} {
+				dirn := layoutHorizontal
+				if step[0] == 1 {
+					dirn = layoutVertical
+				}
+				r1, c1 := r+step[0], c+step[1]
+				for r1 < l.rows && c1 < l.cols && l.dirn[r1][c1] == dirn {
+					r1, c1 = r1+step[0], c1+step[1]
+				}
+				if r1 == r+step[0] && c1 == c+step[1] {
+					continue // no bridge this way
+				}
+
+				a, b := find(r*l.cols+c), find(r1*l.cols+c1)
+				if a == b {
+					cycles++
+				} else {
+					parent[a] = b
+				}
+			}
+		}
+	}
+	return cycles
+}
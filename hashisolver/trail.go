@@ -0,0 +1,51 @@
+// hashisolver/trail.go
+package hashisolver
+
+import "unsafe"
+
+// change is a node as it was before the solver changed it
+type change struct {
+	node  *Node
+	saved Node
+}
+
+// changeBytes is roughly what one change on the trail takes
+var changeBytes = int64(unsafe.Sizeof(change{}))
+
+// trail records the nodes changed while speculating, so that a failed guess
+// can be undone in place rather than made on a copy of the board
+type trail struct {
+	changes []change
+}
+
+// attach makes every cell of the puzzle record its changes on t, or stop
+// recording them if t is nil
+func attach(puzzle *Puzzle, t *trail) {
+	puzzle.trail = t
+}
+
+// save records the node as it is now, before it is changed, if it belongs to
+// a board being speculated on
+func (n *Node) save() {
+	if n.puzzle != nil && n.puzzle.trail != nil {
+		t := n.puzzle.trail
+		t.changes = append(t.changes, change{node: n, saved: *n})
+	}
+}
+
+// mark returns the point on the trail to undo back to
+func (t *trail) mark() int {
+	return len(t.changes)
+}
+
+// undo puts back every node changed since mark, latest change first. Links
+// lost since mark come back, so the board's links are worked out again.
+func (t *trail) undo(mark int) {
+	if mark < len(t.changes) {
+		t.changes[mark].node.puzzle.links = nil
+	}
+	for i := len(t.changes) - 1; i >= mark; i-- {
+		*t.changes[i].node = t.changes[i].saved
+	}
+	t.changes = t.changes[:mark]
+}
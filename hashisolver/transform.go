@@ -0,0 +1,194 @@
+// hashisolver/transform.go
+package hashisolver
+
+import "fmt"
+
+// Transform is one of the eight ways of turning and flipping a board that
+// keep it a grid: the rotations and reflections of a square
+type Transform int
+
+const (
+	// TransformIdentity leaves the board as it is
+	TransformIdentity Transform = iota
+	// TransformRotate90 turns the board a quarter clockwise
+	TransformRotate90
+	// TransformRotate180 turns the board half way round
+	TransformRotate180
+	// TransformRotate270 turns the board a quarter anticlockwise
+	TransformRotate270
+	// TransformMirrorH flips the board left to right
+	TransformMirrorH
+	// TransformMirrorV flips the board top to bottom
+	TransformMirrorV
+	// TransformTranspose flips the board over its main diagonal, so rows
+	// become columns
+	TransformTranspose
+	// TransformAntiTranspose flips the board over its other diagonal
+	TransformAntiTranspose
+)
+
+// Transforms lists every transform, the identity first
+var Transforms = []Transform{
+	TransformIdentity, TransformRotate90, TransformRotate180, TransformRotate270,
+	TransformMirrorH, TransformMirrorV, TransformTranspose, TransformAntiTranspose,
+}
+
+func (t Transform) String() string {
+	switch t {
+	case TransformIdentity:
+		return "identity"
+	case TransformRotate90:
+		return "rotate90"
+	case TransformRotate180:
+		return "rotate180"
+	case TransformRotate270:
+		return "rotate270"
+	case TransformMirrorH:
+		return "mirror-h"
+	case TransformMirrorV:
+		return "mirror-v"
+	case TransformTranspose:
+		return "transpose"
+	case TransformAntiTranspose:
+		return "anti-transpose"
+	}
+	return fmt.Sprintf("Transform(%d)", int(t))
+}
+
+// Size returns the columns and rows of a board of the given size once
+// transformed
+func (t Transform) Size(cols, rows int) (int, int) {
+	switch t {
+	case TransformRotate90, TransformRotate270, TransformTranspose, TransformAntiTranspose:
+		return rows, cols
+	}
+	return cols, rows
+}
+
+// Position returns where the cell at pos on a board of the given size ends
+// up once the board is transformed
+func (t Transform) Position(pos Position, cols, rows int) Position {
+	x, y := pos.X, pos.Y
+	switch t {
+	case TransformRotate90:
+		return Position{X: rows - 1 - y, Y: x}
+	case TransformRotate180:
+		return Position{X: cols - 1 - x, Y: rows - 1 - y}
+	case TransformRotate270:
+		return Position{X: y, Y: cols - 1 - x}
+	case TransformMirrorH:
+		return Position{X: cols - 1 - x, Y: y}
+	case TransformMirrorV:
+		return Position{X: x, Y: rows - 1 - y}
+	case TransformTranspose:
+		return Position{X: y, Y: x}
+	case TransformAntiTranspose:
+		return Position{X: rows - 1 - y, Y: cols - 1 - x}
+	}
+	return pos
+}
+
+// Transform returns a copy of the puzzle turned or flipped, with its clues,
+// rules, metadata and bridges moved along, and its islands linked to their
+// new neighbors. Blocked directions and the move history are not kept.
+func (p *Puzzle) Transform(t Transform) *Puzzle {
+	clues := NewPuzzle(transformedClues(p, t), p.Rules)
+	clues.Metadata = p.Metadata
+	board, err := clues.Apply(NewSolution(p).Transform(t, p.Cols, p.Rows))
+	if err != nil {
+		// The bridges of a board still join neighbors without crossing once
+		// the whole board is moved the same way
+		panic(fmt.Sprintf("hashisolver: %v bridges don't fit: %v", t, err))
+	}
+	return board
+}
+
+// Rotate90 returns a copy of the puzzle turned a quarter clockwise, as by
+// Transform
+func (p *Puzzle) Rotate90() *Puzzle {
+	return p.Transform(TransformRotate90)
+}
+
+// Rotate180 returns a copy of the puzzle turned half way round, as by
+// Transform
+func (p *Puzzle) Rotate180() *Puzzle {
+	return p.Transform(TransformRotate180)
+}
+
+// MirrorH returns a copy of the puzzle flipped left to right, as by Transform
+func (p *Puzzle) MirrorH() *Puzzle {
+	return p.Transform(TransformMirrorH)
+}
+
+// MirrorV returns a copy of the puzzle flipped top to bottom, as by Transform
+func (p *Puzzle) MirrorV() *Puzzle {
+	return p.Transform(TransformMirrorV)
+}
+
+// Transpose returns a copy of the puzzle with its rows as columns, as by
+// Transform
+func (p *Puzzle) Transpose() *Puzzle {
+	return p.Transform(TransformTranspose)
+}
+
+// Transform returns the solution's bridges moved the way t moves a board of
+// the given size, each listed from its left or top end, so that it solves
+// the puzzle transformed the same way
+func (s *Solution) Transform(t Transform, cols, rows int) *Solution {
+	moved := &Solution{Bridges: make([]Bridge, len(s.Bridges)), Partial: s.Partial, Stats: s.Stats}
+	for i, b := range s.Bridges {
+		from, to := t.Position(b.From, cols, rows), t.Position(b.To, cols, rows)
+		if to.Y < from.Y || to.X < from.X {
+			from, to = to, from
+		}
+		moved.Bridges[i] = Bridge{From: from, To: to, Count: b.Count}
+	}
+	return moved
+}
+
+// Rotate90 returns the solution turned a quarter clockwise on a board of the
+// given size, as by Transform
+func (s *Solution) Rotate90(cols, rows int) *Solution {
+	return s.Transform(TransformRotate90, cols, rows)
+}
+
+// Rotate180 returns the solution turned half way round on a board of the
+// given size, as by Transform
+func (s *Solution) Rotate180(cols, rows int) *Solution {
+	return s.Transform(TransformRotate180, cols, rows)
+}
+
+// MirrorH returns the solution flipped left to right on a board of the given
+// size, as by Transform
+func (s *Solution) MirrorH(cols, rows int) *Solution {
+	return s.Transform(TransformMirrorH, cols, rows)
+}
+
+// MirrorV returns the solution flipped top to bottom on a board of the given
+// size, as by Transform
+func (s *Solution) MirrorV(cols, rows int) *Solution {
+	return s.Transform(TransformMirrorV, cols, rows)
+}
+
+// Transpose returns the solution with rows as columns on a board of the
+// given size, as by Transform
+func (s *Solution) Transpose(cols, rows int) *Solution {
+	return s.Transform(TransformTranspose, cols, rows)
+}
+
+// transformedClues returns the puzzle's clues, row by row, as they are once
+// the board is transformed
+func transformedClues(p *Puzzle, t Transform) [][]int {
+	cols, rows := t.Size(p.Cols, p.Rows)
+	values := make([][]int, rows)
+	for y := range values {
+		values[y] = make([]int, cols)
+	}
+	for y := 0; y < p.Rows; y++ {
+		for x := 0; x < p.Cols; x++ {
+			pos := t.Position(Position{X: x, Y: y}, p.Cols, p.Rows)
+			values[pos.Y][pos.X] = p.At(x, y).Value()
+		}
+	}
+	return values
+}
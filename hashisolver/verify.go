@@ -0,0 +1,143 @@
+// hashisolver/verify.go
+package hashisolver
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Violation is one way a set of bridges breaks the rules
+type Violation struct {
+	// At is the island or bridge end the violation is found at
+	At  Position
+	Msg string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("(%d,%d): %s", v.At.X, v.At.Y, v.Msg)
+}
+
+// Verify checks the bridges on a solved board against the clues of the puzzle,
+// returning every violation found, or none if the solution is valid
+func Verify(puzzle, solution *Puzzle) []Violation {
+	return VerifyBridges(puzzle, ToJSON(solution).Bridges)
+}
+
+// VerifyBridges checks a list of bridges against the clues of the puzzle. Every
+// bridge must join two islands in a straight line without passing through an
+// island or crossing another bridge, no pair may have more bridges than the
+// rules allow, every island must have exactly its value in bridges, and all
+// the islands must be connected. It doesn't use the solver, so it can be
+// trusted to check the solver's answers.
+func VerifyBridges(puzzle *Puzzle, bridges []BridgeJSON) []Violation {
+	violations := []Violation{}
+	report := func(at Position, format string, args ...interface{}) {
+		violations = append(violations, Violation{At: at, Msg: fmt.Sprintf(format, args...)})
+	}
+
+	island := func(pos Position) bool {
+		return pos.Y >= 0 && pos.Y < puzzle.Rows && pos.X >= 0 && pos.X < puzzle.Cols && puzzle.At(pos.X, pos.Y).Value() > 0
+	}
+	index := func(pos Position) int {
+		return pos.Y*puzzle.Cols + pos.X
+	}
+
+	// occupied remembers which bridge runs through each cell, to find crossings
+	occupied := map[Position]BridgeJSON{}
+	pairs := map[[2]Position]bool{}
+	totals := map[Position]int{}
+	parent := make([]int, puzzle.Rows*puzzle.Cols)
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	for _, bridge := range bridges {
+		from, to := bridge.From, bridge.To
+		// List each pair top or left end first
+		if to.Y < from.Y || to.X < from.X {
+			from, to = to, from
+		}
+
+		switch {
+		case !island(from):
+			report(from, "bridge to (%d,%d) doesn't start at an island", to.X, to.Y)
+			continue
+		case !island(to):
+			report(to, "bridge from (%d,%d) doesn't end at an island", from.X, from.Y)
+			continue
+		case from == to || (from.X != to.X && from.Y != to.Y):
+			report(from, "bridge to (%d,%d) isn't a straight line", to.X, to.Y)
+			continue
+		case pairs[[2]Position{from, to}]:
+			report(from, "bridge to (%d,%d) is listed twice", to.X, to.Y)
+			continue
+		}
+		pairs[[2]Position{from, to}] = true
+
+		if bridge.Count < 1 || bridge.Count > puzzle.maxBridges() {
+			report(from, "%d bridges to (%d,%d), the rules allow 1 to %d", bridge.Count, to.X, to.Y, puzzle.maxBridges())
+		}
+
+		// Walk the cells between the ends
+		dx, dy := 0, 1
+		if from.Y == to.Y {
+			dx, dy = 1, 0
+		}
+		for pos := (Position{X: from.X + dx, Y: from.Y + dy}); pos != to; pos = (Position{X: pos.X + dx, Y: pos.Y + dy}) {
+			if island(pos) {
+				report(from, "bridge to (%d,%d) passes through the island at (%d,%d)", to.X, to.Y, pos.X, pos.Y)
+				break
+			}
+			if other, ok := occupied[pos]; ok {
+				report(from, "bridge to (%d,%d) crosses the bridge from (%d,%d) to (%d,%d) at (%d,%d)",
+					to.X, to.Y, other.From.X, other.From.Y, other.To.X, other.To.Y, pos.X, pos.Y)
+				break
+			}
+			occupied[pos] = BridgeJSON{From: from, To: to, Count: bridge.Count}
+		}
+
+		totals[from] += bridge.Count
+		totals[to] += bridge.Count
+		if a, b := find(index(from)), find(index(to)); a != b {
+			parent[a] = b
+		}
+	}
+
+	// Check the clues, and collect one island from each connected group
+	groups := map[int]Position{}
+	for i := 0; i < puzzle.Rows; i++ {
+		for j := 0; j < puzzle.Cols; j++ {
+			pos := Position{X: j, Y: i}
+			if !island(pos) {
+				continue
+			}
+			if value := puzzle.At(j, i).Value(); totals[pos] != value {
+				report(pos, "island has %d bridges, its clue is %d", totals[pos], value)
+			}
+			if _, ok := groups[find(index(pos))]; !ok {
+				groups[find(index(pos))] = pos
+			}
+		}
+	}
+
+	if len(groups) > 1 {
+		// Report every group but the one holding the first island, in board order
+		firsts := make([]Position, 0, len(groups))
+		for _, pos := range groups {
+			firsts = append(firsts, pos)
+		}
+		sort.Slice(firsts, func(i, j int) bool { return index(firsts[i]) < index(firsts[j]) })
+		for _, pos := range firsts[1:] {
+			report(pos, "island is not connected to the island at (%d,%d)", firsts[0].X, firsts[0].Y)
+		}
+	}
+
+	return violations
+}
@@ -0,0 +1,67 @@
+// hashisolver/worklist.go
+package hashisolver
+
+// worklist is a queue of islands for the logical rules to look at, holding
+// each island at most once
+type worklist struct {
+	nodes []*Node
+	head  int
+	// queued marks the queued islands, by cell index
+	queued []bool
+}
+
+// newWorklist returns an empty worklist for the puzzle's board
+func newWorklist(puzzle *Puzzle) *worklist {
+	return &worklist{queued: make([]bool, len(puzzle.Cells))}
+}
+
+// push queues an island that still needs bridges, unless it is already queued
+func (w *worklist) push(node *Node) {
+	if node == nil || node.Value() <= 0 || node.TotalBridges == node.Value() || w.queued[node.index] {
+		return
+	}
+	w.queued[node.index] = true
+	w.nodes = append(w.nodes, node)
+}
+
+// pop returns the next island in the order they were queued, or nil once the
+// worklist is empty
+func (w *worklist) pop() *Node {
+	if w.head == len(w.nodes) {
+		w.nodes, w.head = w.nodes[:0], 0
+		return nil
+	}
+	node := w.nodes[w.head]
+	w.head++
+	w.queued[node.index] = false
+	return node
+}
+
+// pushAll queues every island on the board, row by row
+func (w *worklist) pushAll(puzzle *Puzzle) {
+	for _, i := range puzzle.grid.islands {
+		w.push(&puzzle.Cells[i])
+	}
+}
+
+// pushAround queues the islands a change at node can affect: the node, its
+// neighbors and theirs, whose blocked directions follow its bridges, and the
+// islands whose bridges would cross the node's
+func (w *worklist) pushAround(puzzle *Puzzle, node *Node) {
+	w.push(node)
+	for dir := DirectionUp; dir <= DirectionRight; dir++ {
+		neighbor := node.GetNeighbor(dir)
+		if neighbor == nil {
+			continue
+		}
+		w.push(neighbor)
+		for next := DirectionUp; next <= DirectionRight; next++ {
+			w.push(neighbor.GetNeighbor(next))
+		}
+		for _, cell := range pathCells(puzzle, node, dir) {
+			before, after := islandsAcross(puzzle, cell, vertical(dir))
+			w.push(before)
+			w.push(after)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+// hashisolver/write.go
+package hashisolver
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// WritePuzzle writes the puzzle's clues in the compact text format that Parse
+// reads, with '.' for water and its metadata as a header. Any bridges are left out.
+func WritePuzzle(w io.Writer, puzzle *Puzzle) error {
+	out := bufio.NewWriter(w)
+
+	m := puzzle.Metadata
+	for _, field := range []struct{ key, value string }{
+		{"title", m.Title},
+		{"author", m.Author},
+		{"difficulty", m.Difficulty},
+		{"source", m.Source},
+	} {
+		if field.value != "" {
+			fmt.Fprintf(out, "%s: %s\n", field.key, field.value)
+		}
+	}
+
+	keys := make([]string, 0, len(m.Extra))
+	for key := range m.Extra {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(out, "%s: %s\n", key, m.Extra[key])
+	}
+
+	for i := 0; i < puzzle.Rows; i++ {
+		for j := 0; j < puzzle.Cols; j++ {
+			if value := puzzle.At(j, i).Value(); value > 0 {
+				out.WriteString(cellSymbol(value))
+			} else {
+				out.WriteByte('.')
+			}
+		}
+		out.WriteByte('\n')
+	}
+
+	return out.Flush()
+}
+
+// WriteBridges writes the bridges placed on the puzzle as a bridge list, one
+// line of "x,y x,y count" for each pair of joined islands, top or left end first
+func WriteBridges(w io.Writer, puzzle *Puzzle) error {
+	out := bufio.NewWriter(w)
+	for _, bridge := range puzzle.Bridges() {
+		fmt.Fprintf(out, "%d,%d %d,%d %d\n", bridge.From.X, bridge.From.Y, bridge.To.X, bridge.To.Y, bridge.Count)
+	}
+	return out.Flush()
+}
+
+// ParseBridges reads a bridge list as written by WriteBridges, with either
+// end of a bridge written as x,y or as a label like A1. Blank lines and lines
+// starting with # are skipped.
+func ParseBridges(input io.Reader) ([]BridgeJSON, error) {
+	bridges := []BridgeJSON{}
+	scanner := bufio.NewScanner(input)
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		b, ok := parseBridgeLine(line)
+		if !ok {
+			return nil, &ParseError{Line: number, Msg: fmt.Sprintf("expected \"x,y x,y count\", found %q", line)}
+		}
+		bridges = append(bridges, b)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading input: %v", err)
+	}
+	return bridges, nil
+}
+
+// parseBridgeLine reads one line of a bridge list, reporting whether it is
+// well formed
+func parseBridgeLine(line string) (BridgeJSON, bool) {
+	var b BridgeJSON
+	fields := strings.Fields(line)
+	if len(fields) != 3 {
+		return b, false
+	}
+	var err1, err2, err3 error
+	b.From, err1 = ParsePosition(fields[0])
+	b.To, err2 = ParsePosition(fields[1])
+	b.Count, err3 = strconv.Atoi(fields[2])
+	return b, err1 == nil && err2 == nil && err3 == nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"hashi/hashisolver"
+)
+
+// runHint suggests the next bridge for a partly solved board
+func runHint(args []string) {
+	var input inputFlags
+	var labels bool
+
+	fs := flag.NewFlagSet("hint", flag.ExitOnError)
+	input.register(fs)
+	fs.BoolVar(&labels, "labels", false, "Name islands by their labels, like A1, rather than as (x,y)")
+	parseFlags(fs, args)
+	input.useArgs(fs)
+
+	puzzle := input.readPuzzle()
+
+	hint, err := puzzle.Hint()
+	if errors.Is(err, hashisolver.ErrAlreadySolved) {
+		fmt.Println("The puzzle is already solved")
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error finding a hint: %v\n", err)
+		os.Exit(1)
+	}
+
+	move := hint.Move
+	from, to := move.From.String(), move.To.String()
+	if labels {
+		from, to = move.From.Label(), move.To.Label()
+	}
+	fmt.Printf("Add a bridge between %s and %s, making %d\n", from, to, move.Count)
+	fmt.Println(hint.Explanation)
+}
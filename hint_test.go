@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"hashi/hashisolver"
+)
+
+// TestHint tests hints on fresh, partly solved and finished boards
+func TestHint(t *testing.T) {
+	p, err := hashisolver.Parse(strings.NewReader("1.3.1\n.....\n..1..\n"), hashisolver.DefaultRuleSet)
+	if err != nil {
+		t.Fatalf("Failed to parse puzzle: %v", err)
+	}
+
+	hint, err := p.Hint()
+	if err != nil {
+		t.Fatalf("Failed to get a hint: %v", err)
+	}
+	if !hint.Forced || hint.Explanation == "" {
+		t.Errorf("Expected a forced move with an explanation, got %+v", hint)
+	}
+	if p.PlacedBridges() != 0 {
+		t.Errorf("Expected the hint to leave the puzzle untouched")
+	}
+
+	// With the 1s already joined to the 3, the last bridge is the only move left
+	p, err = hashisolver.Parse(strings.NewReader("1-3-1\n.....\n..1..\n"), hashisolver.DefaultRuleSet)
+	if err != nil {
+		t.Fatalf("Failed to parse partly solved puzzle: %v", err)
+	}
+	hint, err = p.Hint()
+	if err != nil {
+		t.Fatalf("Failed to get a hint: %v", err)
+	}
+	want := hashisolver.Move{From: hashisolver.Position{X: 2, Y: 0}, To: hashisolver.Position{X: 2, Y: 2}, Count: 1}
+	if hint.Move.From != want.From || hint.Move.To != want.To || hint.Move.Count != want.Count {
+		t.Errorf("Expected a bridge from (2,0) down to (2,2), got %v", hint.Move)
+	}
+
+	// Once logic has placed what it can, the cycle can be closed two ways and nothing is forced
+	p, _ = hashisolver.Parse(strings.NewReader("2--3\n...|\n1..2\n"), hashisolver.DefaultRuleSet)
+	hint, err = p.Hint()
+	if err != nil {
+		t.Fatalf("Failed to get a hint: %v", err)
+	}
+	if hint.Forced {
+		t.Errorf("Expected an unforced hint for an ambiguous board, got %+v", hint)
+	}
+
+	p, _ = hashisolver.Parse(strings.NewReader("1-3-1\n..|..\n..1..\n"), hashisolver.DefaultRuleSet)
+	if _, err := p.Hint(); !errors.Is(err, hashisolver.ErrAlreadySolved) {
+		t.Errorf("Expected ErrAlreadySolved for a finished board, got %v", err)
+	}
+}
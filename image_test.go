@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+
+	"hashi/hashisolver"
+)
+
+// TestGIF tests drawing a board as a still GIF and a solve as an animated
+// one, with a frame for each move and the latest bridge in red
+func TestGIF(t *testing.T) {
+	puzzle, err := hashisolver.Parse(strings.NewReader("2..3..2.\n........\n2..4..3.\n........\n.2.4.1..\n1...1.3.\n.1......\n2..3..2.\n"), hashisolver.DefaultRuleSet)
+	if err != nil {
+		t.Fatalf("Failed to parse puzzle: %v", err)
+	}
+
+	renderer, err := hashisolver.LookupRenderer("gif")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := renderer(&buf, puzzle); err != nil {
+		t.Fatal(err)
+	}
+	still, err := gif.Decode(&buf)
+	if err != nil {
+		t.Fatalf("Failed to decode the still GIF: %v", err)
+	}
+	if size := still.Bounds().Size(); size.X != 8*hashisolver.DefaultImageStyle.CellSize || size.Y != size.X {
+		t.Errorf("Expected an 8x8 board of %d pixel cells, got %v", hashisolver.DefaultImageStyle.CellSize, size)
+	}
+
+	_, replay, err := recordSolve(context.Background(), puzzle, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to solve puzzle: %v", err)
+	}
+	buf.Reset()
+	if err := hashisolver.WriteReplayGIF(&buf, replay, hashisolver.DefaultImageStyle, 150*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	anim, err := gif.DecodeAll(&buf)
+	if err != nil {
+		t.Fatalf("Failed to decode the animated GIF: %v", err)
+	}
+	if len(anim.Image) != len(replay.Moves)+1 {
+		t.Fatalf("Expected a frame for the start and each of %d moves, got %d", len(replay.Moves), len(anim.Image))
+	}
+	if anim.Delay[0] != 15 || anim.Delay[len(anim.Delay)-1] <= 15 {
+		t.Errorf("Expected frames of 15 hundredths with the last held longer, got %v", anim.Delay)
+	}
+
+	// Only frames after a bridge was placed have red in them
+	for i, frame := range anim.Image {
+		red := false
+		for _, index := range frame.Pix {
+			if r, g, _, _ := frame.Palette[index].RGBA(); r > 0x8000 && g < 0x8000 {
+				red = true
+				break
+			}
+		}
+		if want := i > 0 && !replay.Moves[i-1].Backtrack; red != want {
+			t.Errorf("Frame %d has a red bridge: %v, expected %v", i, red, want)
+		}
+	}
+}
+
+// TestPNG tests drawing a board as a PNG, with its clues written in the
+// islands in the built in font
+func TestPNG(t *testing.T) {
+	puzzle, err := hashisolver.Solve(strings.NewReader("2.2\n...\n"), false)
+	if err != nil {
+		t.Fatalf("Failed to solve puzzle: %v", err)
+	}
+	renderer, err := hashisolver.LookupRenderer("png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := renderer(&buf, puzzle); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("Failed to decode the PNG: %v", err)
+	}
+	cell := hashisolver.DefaultImageStyle.CellSize
+	if size := img.Bounds().Size(); size.X != 3*cell || size.Y != 2*cell {
+		t.Fatalf("Expected a 3x2 board of %d pixel cells, got %v", cell, size)
+	}
+
+	// The middle of a 2 is its diagonal stroke, the centre of the board
+	// between the islands is on the double bridge, and the empty row is blank
+	black := color.RGBAModel.Convert(color.Black)
+	inked := func(x0, y0, x1, y1 int) bool {
+		for y := y0; y < y1; y++ {
+			for x := x0; x < x1; x++ {
+				if color.RGBAModel.Convert(img.At(x, y)) == black {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	if !inked(cell/2-2, cell/2-2, cell/2+2, cell/2+2) {
+		t.Error("Expected the clue drawn in the middle of the island")
+	}
+	if !inked(3*cell/2, cell/2-4, 3*cell/2+1, cell/2+4) {
+		t.Error("Expected the bridge drawn across the middle cell")
+	}
+	if inked(0, cell, 3*cell, 2*cell) {
+		t.Error("Expected nothing drawn on the row of water")
+	}
+}
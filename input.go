@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"hashi/hashisolver"
+)
+
+// inputFlags are the flags every subcommand uses to read its puzzle
+type inputFlags struct {
+	inputFile  string
+	format     string
+	maxBridges int
+	strict     bool
+	sample     string
+	// text is a puzzle given on the command line instead of in a file
+	text string
+}
+
+// register adds the input flags to fs
+func (f *inputFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.inputFile, "input", "", "Input puzzle file (use - for stdin)")
+	f.registerFormat(fs)
+	fs.StringVar(&f.sample, "sample", "", "Use a built in sample puzzle instead of -input: easy, medium, hard or a sample name")
+}
+
+// registerFormat adds only the flags for how puzzles are read to fs, for
+// subcommands that take their puzzle files as arguments
+func (f *inputFlags) registerFormat(fs *flag.FlagSet) {
+	fs.StringVar(&f.format, "format", "text", "Input format: text (auto-detects spacing), compact, spaced, csv or json")
+	fs.BoolVar(&f.strict, "strict", true, "Reject ragged rows, unknown characters and boards without islands")
+	fs.IntVar(&f.maxBridges, "max-bridges", 2, "Maximum bridges between a pair of islands (3 for bridgen puzzles)")
+}
+
+// useArgs takes a puzzle given as the subcommand's only positional argument,
+// exiting with a message if there are more or it clashes with -input or -sample
+func (f *inputFlags) useArgs(fs *flag.FlagSet) {
+	switch {
+	case fs.NArg() == 0:
+		return
+	case fs.NArg() > 1:
+		fmt.Fprintf(os.Stderr, "Error: expected at most one puzzle argument, found %d\n", fs.NArg())
+		os.Exit(2)
+	case f.inputFile != "" || f.sample != "":
+		fmt.Fprintln(os.Stderr, "Error: give the puzzle as an argument or with -input or -sample, not both")
+		os.Exit(2)
+	}
+	f.text = fs.Arg(0)
+}
+
+// rules returns the rule set selected by -max-bridges
+func (f *inputFlags) rules() hashisolver.RuleSet {
+	if f.maxBridges == hashisolver.DefaultRuleSet.MaxBridgesPerPair {
+		return hashisolver.DefaultRuleSet
+	}
+	return hashisolver.RuleSetForMaxBridges(f.maxBridges)
+}
+
+// readPuzzle reads the puzzle named by the flags, exiting with a message if it
+// can't or if the input holds several puzzles
+func (f *inputFlags) readPuzzle() *hashisolver.Puzzle {
+	puzzles := f.readPuzzles()
+	if len(puzzles) > 1 {
+		fmt.Fprintf(os.Stderr, "Error: the input holds %d puzzles, but only solve reads more than one\n", len(puzzles))
+		os.Exit(1)
+	}
+	return puzzles[0]
+}
+
+// readPuzzles reads every puzzle named by the flags, exiting with a message if
+// it can't. A text stream may hold several puzzles separated by empty lines
+// or "---".
+func (f *inputFlags) readPuzzles() []*hashisolver.Puzzle {
+	var puzzles []*hashisolver.Puzzle
+	var err error
+	switch {
+	case f.text != "":
+		puzzles, err = f.parseArg(f.text)
+	case f.sample != "":
+		var puzzle *hashisolver.Puzzle
+		puzzle, err = f.loadSample(f.sample)
+		puzzles = []*hashisolver.Puzzle{puzzle}
+	default:
+		puzzles, err = f.loadPuzzles(f.inputFile)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error %v\n", err)
+		os.Exit(1)
+	}
+	return puzzles
+}
+
+// loadPuzzles reads the puzzles in the named file, or stdin for "" or "-".
+// Gzipped files are decompressed and every puzzle file in an archive is read,
+// and only the text formats are split into several puzzles.
+func (f *inputFlags) loadPuzzles(name string) ([]*hashisolver.Puzzle, error) {
+	if name == "" || name == "-" {
+		if f.format == "csv" || f.format == "json" {
+			puzzle, err := f.parsePuzzle(os.Stdin)
+			if err != nil {
+				return nil, err
+			}
+			return []*hashisolver.Puzzle{puzzle}, nil
+		}
+		return f.splitPuzzles(os.Stdin)
+	}
+
+	members, err := readMembers(name)
+	if err != nil {
+		return nil, err
+	}
+
+	puzzles := []*hashisolver.Puzzle{}
+	for _, m := range members {
+		found, err := f.memberPuzzles(m)
+		if err != nil && isArchive(name) {
+			return nil, fmt.Errorf("in %s: %v", m.name, err)
+		}
+		if err != nil {
+			return nil, err
+		}
+		puzzles = append(puzzles, found...)
+	}
+	return puzzles, nil
+}
+
+// forMember returns the flags to read a puzzle file with, switching to the
+// JSON format for .json files
+func (f *inputFlags) forMember(m member) *inputFlags {
+	if strings.HasSuffix(m.base, ".json") {
+		read := *f
+		read.format = "json"
+		return &read
+	}
+	return f
+}
+
+// memberPuzzles reads the puzzles in one puzzle file
+func (f *inputFlags) memberPuzzles(m member) ([]*hashisolver.Puzzle, error) {
+	read := f.forMember(m)
+	if read.format == "csv" || read.format == "json" {
+		puzzle, err := read.parsePuzzle(bytes.NewReader(m.data))
+		if err != nil {
+			return nil, err
+		}
+		return []*hashisolver.Puzzle{puzzle}, nil
+	}
+	return read.splitPuzzles(bytes.NewReader(m.data))
+}
+
+// parseArg reads the puzzles given on the command line, either as a task
+// string like "3x2:2a2c" or in the input format with "\n" between rows
+func (f *inputFlags) parseArg(text string) ([]*hashisolver.Puzzle, error) {
+	if hashisolver.IsTask(text) {
+		puzzle, err := hashisolver.ParseTask(text, f.rules())
+		if err != nil {
+			return nil, fmt.Errorf("reading puzzle: %v", err)
+		}
+		return []*hashisolver.Puzzle{puzzle}, nil
+	}
+
+	text = strings.ReplaceAll(text, `\n`, "\n")
+	if f.format == "csv" || f.format == "json" {
+		puzzle, err := f.parsePuzzle(strings.NewReader(text))
+		if err != nil {
+			return nil, err
+		}
+		return []*hashisolver.Puzzle{puzzle}, nil
+	}
+	return f.splitPuzzles(strings.NewReader(text))
+}
+
+// splitPuzzles reads one or more puzzles in a text format from reader
+func (f *inputFlags) splitPuzzles(reader io.Reader) ([]*hashisolver.Puzzle, error) {
+	texts, err := hashisolver.SplitPuzzles(reader)
+	if err != nil {
+		return nil, fmt.Errorf("reading puzzle: %v", err)
+	}
+
+	puzzles := make([]*hashisolver.Puzzle, len(texts))
+	for i, text := range texts {
+		puzzles[i], err = f.parsePuzzle(strings.NewReader(text))
+		if err != nil && len(texts) > 1 {
+			return nil, fmt.Errorf("in puzzle %d: %v", i+1, err)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return puzzles, nil
+}
+
+// loadPuzzle reads the puzzle in the named file, or stdin for "" or "-",
+// in the format selected by the flags. Gzipped files are decompressed.
+func (f *inputFlags) loadPuzzle(name string) (*hashisolver.Puzzle, error) {
+	if name == "" || name == "-" {
+		return f.parsePuzzle(os.Stdin)
+	}
+
+	members, err := readMembers(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(members) != 1 {
+		return nil, fmt.Errorf("%s holds %d puzzle files, expected one", name, len(members))
+	}
+	return f.forMember(members[0]).parsePuzzle(bytes.NewReader(members[0].data))
+}
+
+// loadSample reads the built in sample puzzle with the given name or difficulty
+func (f *inputFlags) loadSample(name string) (*hashisolver.Puzzle, error) {
+	sample, err := hashisolver.FindSample(name)
+	if err != nil {
+		return nil, err
+	}
+	return f.parsePuzzle(strings.NewReader(sample.Text))
+}
+
+// parsePuzzle reads a puzzle in the format selected by the flags
+func (f *inputFlags) parsePuzzle(reader io.Reader) (*hashisolver.Puzzle, error) {
+	rules := f.rules()
+
+	var puzzle *hashisolver.Puzzle
+	var err error
+	opts := hashisolver.ParseOptions{Strict: f.strict}
+	switch f.format {
+	case "text":
+		puzzle, err = hashisolver.ParseText(reader, rules, opts)
+	case "compact":
+		opts.Layout = hashisolver.LayoutCompact
+		puzzle, err = hashisolver.ParseText(reader, rules, opts)
+	case "spaced":
+		opts.Layout = hashisolver.LayoutSpaced
+		puzzle, err = hashisolver.ParseText(reader, rules, opts)
+	case "csv":
+		puzzle, err = hashisolver.ParseCSV(reader, rules, opts)
+	case "json":
+		puzzle, err = hashisolver.ParseJSON(reader, rules)
+	default:
+		return nil, fmt.Errorf("unknown input format: %s", f.format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading puzzle: %v", err)
+	}
+
+	return puzzle, nil
+}
@@ -0,0 +1,424 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"hashi/hashisolver"
+)
+
+// TestSolveLogicOnly tests that logic-only solving stops with a partial board when it stalls
+func TestSolveLogicOnly(t *testing.T) {
+	// Every bridge here is forced, so no guessing is needed
+	p, err := hashisolver.Parse(strings.NewReader("1.3.1\n.....\n..1..\n"), hashisolver.DefaultRuleSet)
+	if err != nil {
+		t.Fatalf("Failed to parse puzzle: %v", err)
+	}
+	solved, err := hashisolver.SolveLogicOnly(p, false)
+	if err != nil {
+		t.Fatalf("Expected logic alone to solve the puzzle, got %v", err)
+	}
+	if !solved.IsComplete() {
+		t.Errorf("Expected a complete solution")
+	}
+
+	// The cycle can be closed two ways, so logic has to stop part way
+	p, err = hashisolver.Parse(strings.NewReader("2..3\n....\n1..2\n"), hashisolver.DefaultRuleSet)
+	if err != nil {
+		t.Fatalf("Failed to parse puzzle: %v", err)
+	}
+	partial, err := hashisolver.SolveLogicOnly(p, false)
+	if !errors.Is(err, hashisolver.ErrGuessRequired) {
+		t.Fatalf("Expected ErrGuessRequired, got %v", err)
+	}
+	if partial.IsComplete() || partial.BuiltBridges == 0 {
+		t.Errorf("Expected a partial board with some bridges, got %d placed", partial.BuiltBridges)
+	}
+}
+
+// TestSolveWithMaxDepth tests that speculation stops at the depth limit and reports the depth reached
+func TestSolveWithMaxDepth(t *testing.T) {
+	puzzle := "3.5...4\n...2.4.\n.......\n4.5..5.\n.......\n...2.4.\n3.5.3.3\n"
+
+	parse := func() *hashisolver.Puzzle {
+		p, err := hashisolver.Parse(strings.NewReader(puzzle), hashisolver.DefaultRuleSet)
+		if err != nil {
+			t.Fatalf("Failed to parse puzzle: %v", err)
+		}
+		return p
+	}
+
+	// This puzzle needs two nested guesses
+	_, depth, err := hashisolver.SolveWithMaxDepth(parse(), 1, false)
+	if !errors.Is(err, hashisolver.ErrDepthLimit) {
+		t.Errorf("Expected ErrDepthLimit with a limit of 1, got %v", err)
+	}
+	if depth != 1 {
+		t.Errorf("Expected to reach depth 1, got %d", depth)
+	}
+
+	solved, depth, err := hashisolver.SolveWithMaxDepth(parse(), 2, false)
+	if err != nil {
+		t.Fatalf("Expected a solution with a limit of 2, got %v", err)
+	}
+	if !solved.IsComplete() || depth != 2 {
+		t.Errorf("Expected a complete solution at depth 2, got depth %d", depth)
+	}
+}
+
+// TestSolveContext tests that a cancelled context stops the solver with the context's error
+func TestSolveContext(t *testing.T) {
+	p, err := hashisolver.Parse(strings.NewReader("2..3\n....\n1..2\n"), hashisolver.DefaultRuleSet)
+	if err != nil {
+		t.Fatalf("Failed to parse puzzle: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := hashisolver.SolveContext(ctx, p, false); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+
+	solved, err := hashisolver.SolveContext(context.Background(), p, false)
+	if err != nil || !solved.IsComplete() {
+		t.Errorf("Expected a solution with a live context, got %v", err)
+	}
+}
+
+// TestSolveWithOptions tests that functional options configure the solver like the older entry points
+func TestSolveWithOptions(t *testing.T) {
+	p, err := hashisolver.SolveReader(context.Background(), strings.NewReader("3.3\n...\n...\n"),
+		hashisolver.WithRuleSet(hashisolver.TripleBridgeRuleSet))
+	if err != nil {
+		t.Fatalf("Failed to solve with a triple bridge rule set: %v", err)
+	}
+	if p.At(0, 0).RightBridges != 3 {
+		t.Errorf("Expected 3 bridges between the islands, got %d", p.At(0, 0).RightBridges)
+	}
+
+	puzzle, err := hashisolver.Parse(strings.NewReader("2..3\n....\n1..2\n"), hashisolver.DefaultRuleSet)
+	if err != nil {
+		t.Fatalf("Failed to parse puzzle: %v", err)
+	}
+	if _, err := hashisolver.SolveWith(context.Background(), puzzle, hashisolver.WithLogicOnly(true)); !errors.Is(err, hashisolver.ErrGuessRequired) {
+		t.Errorf("Expected ErrGuessRequired with logic only, got %v", err)
+	}
+
+	// Later options override earlier ones
+	opts := hashisolver.NewOptions(hashisolver.WithMaxDepth(3), hashisolver.WithMaxDepth(0), hashisolver.WithDebug(true))
+	if opts.MaxDepth != 0 || !opts.Debug {
+		t.Errorf("Unexpected options: %+v", opts)
+	}
+}
+
+// TestSolveWithStats tests that the solve statistics reflect the work done
+func TestSolveWithStats(t *testing.T) {
+	puzzle, err := hashisolver.Parse(strings.NewReader("2..3\n....\n1..2\n"), hashisolver.DefaultRuleSet)
+	if err != nil {
+		t.Fatalf("Failed to parse puzzle: %v", err)
+	}
+
+	result, err := hashisolver.SolveWithStats(context.Background(), puzzle)
+	if err != nil {
+		t.Fatalf("Failed to solve puzzle: %v", err)
+	}
+
+	stats := result.Stats
+	if !result.Puzzle.IsComplete() {
+		t.Errorf("Expected a complete solution")
+	}
+	// The cycle needs one guess, so there is at least one branch and one level of depth
+	if stats.Branches < 1 || stats.MaxDepth < 1 || stats.Clones != stats.Branches {
+		t.Errorf("Unexpected speculation statistics: %+v", stats)
+	}
+	if stats.NodesExplored != stats.Branches+1 || stats.Iterations < stats.NodesExplored {
+		t.Errorf("Unexpected search statistics: %+v", stats)
+	}
+}
+
+// TestSolveWithProgress tests that the progress callback sees the finished board
+// and the guess that led to it
+func TestSolveWithProgress(t *testing.T) {
+	puzzle, err := hashisolver.Parse(strings.NewReader("2..3\n....\n1..2\n"), hashisolver.DefaultRuleSet)
+	if err != nil {
+		t.Fatalf("Failed to parse puzzle: %v", err)
+	}
+
+	var last hashisolver.Progress
+	calls := 0
+	report := func(p hashisolver.Progress) {
+		calls++
+		last = p
+	}
+
+	if _, err := hashisolver.SolveWith(context.Background(), puzzle, hashisolver.WithProgress(report, time.Nanosecond)); err != nil {
+		t.Fatalf("Failed to solve puzzle: %v", err)
+	}
+	if calls == 0 {
+		t.Fatalf("Expected the progress callback to be called")
+	}
+	if last.TotalBridges != 4 || last.BuiltBridges != last.TotalBridges || last.Depth != 1 {
+		t.Errorf("Expected the final progress to show a finished board, got %+v", last)
+	}
+	if len(last.Guesses) != 1 || last.Guesses[0].Branch < 1 || last.Guesses[0].Branch > last.Guesses[0].Branches {
+		t.Errorf("Expected the final progress to show the one guess made, got %+v", last.Guesses)
+	}
+}
+
+// TestSolveWithVerify tests that the invariant checks pass on sound solves and
+// catch bookkeeping that has been broken
+func TestSolveWithVerify(t *testing.T) {
+	for _, sample := range hashisolver.Samples() {
+		puzzle, err := sample.Puzzle()
+		if err != nil {
+			t.Fatalf("Failed to parse sample %s: %v", sample.Name, err)
+		}
+		if _, err := hashisolver.SolveWith(context.Background(), puzzle, hashisolver.WithVerify(true)); err != nil {
+			t.Errorf("Failed to solve sample %s with checks on: %v", sample.Name, err)
+		}
+	}
+
+	puzzle, err := hashisolver.Parse(strings.NewReader("2..3\n....\n1..2\n"), hashisolver.DefaultRuleSet)
+	if err != nil {
+		t.Fatalf("Failed to parse puzzle: %v", err)
+	}
+	puzzle.At(0, 2).NumBlocked = 0
+	_, err = hashisolver.SolveWith(context.Background(), puzzle, hashisolver.WithVerify(true))
+	if !errors.Is(err, hashisolver.ErrInvariant) || !strings.Contains(err.Error(), "island at (0,2) has NumBlocked") {
+		t.Errorf("Expected the broken NumBlocked to be caught, got %v", err)
+	}
+}
+
+// TestSolveWithLogger tests that solver messages go to the given logger,
+// filtered by its level
+func TestSolveWithLogger(t *testing.T) {
+	var debug, info strings.Builder
+	for _, l := range []struct {
+		w     *strings.Builder
+		level hashisolver.Level
+	}{{&debug, hashisolver.LevelDebug}, {&info, hashisolver.LevelInfo}} {
+		_, err := hashisolver.SolveReader(context.Background(), strings.NewReader("2..3\n....\n1..2\n"),
+			hashisolver.WithLogger(hashisolver.NewTextLogger(l.w, l.level)))
+		if err != nil {
+			t.Fatalf("Failed to solve puzzle: %v", err)
+		}
+	}
+
+	if !strings.Contains(debug.String(), `level=debug msg="placed bridge" from=(0,0) to=(3,0) direction=right technique="forced share"`) {
+		t.Errorf("Expected the first bridge in the debug log, got:\n%s", debug.String())
+	}
+	if strings.Contains(info.String(), "level=debug") || !strings.Contains(info.String(), `msg="solution complete"`) {
+		t.Errorf("Expected only info messages at the info level, got:\n%s", info.String())
+	}
+}
+
+// TestSolveWithTimeout tests that a solve out of time stops with the partial
+// board, and that the caller's own cancellation is not reported as a timeout
+func TestSolveWithTimeout(t *testing.T) {
+	parse := func() *hashisolver.Puzzle {
+		puzzle, err := hashisolver.Parse(strings.NewReader("2..3\n....\n1..2\n"), hashisolver.DefaultRuleSet)
+		if err != nil {
+			t.Fatalf("Failed to parse puzzle: %v", err)
+		}
+		return puzzle
+	}
+
+	result, err := hashisolver.SolveWithStats(context.Background(), parse(), hashisolver.WithTimeout(time.Nanosecond))
+	if !errors.Is(err, hashisolver.ErrTimeout) {
+		t.Fatalf("Expected ErrTimeout, got %v", err)
+	}
+	if !result.Partial || result.Puzzle == nil || result.Puzzle.IsComplete() {
+		t.Errorf("Expected an incomplete partial board after the timeout")
+	}
+
+	result, err = hashisolver.SolveWithStats(context.Background(), parse(), hashisolver.WithTimeout(time.Minute))
+	if err != nil || result.Partial || !result.Puzzle.IsComplete() {
+		t.Errorf("Expected a solution within the timeout, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := hashisolver.SolveWith(ctx, parse(), hashisolver.WithTimeout(time.Minute)); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+// TestSolveUnsolvablePartial tests that a puzzle refuted by speculation comes
+// back as the logical rules left it, without any of the failed guesses
+func TestSolveUnsolvablePartial(t *testing.T) {
+	const input = "4..5..2.\n....2..2\n4.1.....\n.1.4...4\n3...3.1.\n.....2.6\n3..2....\n.1..4..4\n"
+	parse := func() *hashisolver.Puzzle {
+		puzzle, err := hashisolver.Parse(strings.NewReader(input), hashisolver.DefaultRuleSet)
+		if err != nil {
+			t.Fatalf("Failed to parse puzzle: %v", err)
+		}
+		return puzzle
+	}
+
+	result, err := hashisolver.SolveWithStats(context.Background(), parse())
+	if !errors.Is(err, hashisolver.ErrNoSolution) || !strings.Contains(err.Error(), "going down from the 2 at (4,1)") {
+		t.Fatalf("Expected ErrNoSolution naming the island guessed at, got %v", err)
+	}
+	if !result.Partial || result.Stats.Branches == 0 {
+		t.Fatalf("Expected a partial board after speculating, got partial %v after %d branches", result.Partial, result.Stats.Branches)
+	}
+
+	logic, err := hashisolver.SolveLogicOnly(parse(), false)
+	if !errors.Is(err, hashisolver.ErrGuessRequired) {
+		t.Fatalf("Expected logic alone to stall, got %v", err)
+	}
+	got, want := strings.Join(hashisolver.MapLines(result.Puzzle), "\n"), strings.Join(hashisolver.MapLines(logic), "\n")
+	if got != want || result.Puzzle.BuiltBridges != logic.BuiltBridges {
+		t.Errorf("Expected the board logic alone reaches:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+// TestSolveWithAnytime tests that the best board reached is kept, guesses and all
+func TestSolveWithAnytime(t *testing.T) {
+	var anytime hashisolver.Anytime
+	if anytime.Best() != nil {
+		t.Fatalf("Expected no board before solving")
+	}
+
+	// The puzzle from TestSolveUnsolvablePartial, where logic alone places 21 bridges
+	puzzle, err := hashisolver.Parse(strings.NewReader("4..5..2.\n....2..2\n4.1.....\n.1.4...4\n3...3.1.\n.....2.6\n3..2....\n.1..4..4\n"),
+		hashisolver.DefaultRuleSet)
+	if err != nil {
+		t.Fatalf("Failed to parse puzzle: %v", err)
+	}
+	if _, err := hashisolver.SolveWith(context.Background(), puzzle, hashisolver.WithAnytime(&anytime)); !errors.Is(err, hashisolver.ErrNoSolution) {
+		t.Fatalf("Expected ErrNoSolution, got %v", err)
+	}
+	if best := anytime.Best(); best == nil || best.PlacedBridges() <= 21 {
+		t.Errorf("Expected a guess to have got further than logic alone")
+	}
+
+	var solved hashisolver.Anytime
+	if _, err := hashisolver.SolveReader(context.Background(), strings.NewReader("2..3\n....\n1..2\n"), hashisolver.WithAnytime(&solved)); err != nil {
+		t.Fatalf("Failed to solve puzzle: %v", err)
+	}
+	if best := solved.Best(); best == nil || !best.IsComplete() {
+		t.Errorf("Expected the solution to be the best board")
+	}
+}
+
+// TestSolveWithMaxMemory tests that speculation stops at the memory budget
+func TestSolveWithMaxMemory(t *testing.T) {
+	for _, sample := range hashisolver.Samples() {
+		puzzle, err := sample.Puzzle()
+		if err != nil {
+			t.Fatalf("Failed to parse sample %s: %v", sample.Name, err)
+		}
+		result, err := hashisolver.SolveWithStats(context.Background(), puzzle.Clone(), hashisolver.WithMaxMemory(1<<30))
+		if err != nil {
+			t.Fatalf("Failed to solve sample %s within the budget: %v", sample.Name, err)
+		}
+		if result.Stats.Branches == 0 {
+			continue
+		}
+
+		if result.Stats.PeakMemory <= 0 {
+			t.Errorf("Expected the peak memory of sample %s to be counted", sample.Name)
+		}
+		if _, err := hashisolver.SolveWith(context.Background(), puzzle, hashisolver.WithMaxMemory(result.Stats.PeakMemory-1)); !errors.Is(err, hashisolver.ErrMemoryLimit) {
+			t.Errorf("Expected ErrMemoryLimit for sample %s just under its peak, got %v", sample.Name, err)
+		}
+	}
+}
+
+// TestSolveWithParallelism tests that exploring guesses at once still finds
+// valid solutions, and still proves a puzzle has none
+func TestSolveWithParallelism(t *testing.T) {
+	for _, sample := range hashisolver.Samples() {
+		puzzle, err := sample.Puzzle()
+		if err != nil {
+			t.Fatalf("Failed to parse sample %s: %v", sample.Name, err)
+		}
+		result, err := hashisolver.SolveWithStats(context.Background(), puzzle.Clone(), hashisolver.WithParallelism(4))
+		if err != nil {
+			t.Fatalf("Failed to solve sample %s in parallel: %v", sample.Name, err)
+		}
+		if violations := hashisolver.Verify(puzzle, result.Puzzle); len(violations) > 0 || result.Partial {
+			t.Errorf("Expected a valid solution of sample %s, got %v", sample.Name, violations)
+		}
+	}
+
+	puzzle, err := hashisolver.Parse(strings.NewReader("4..5..2.\n....2..2\n4.1.....\n.1.4...4\n3...3.1.\n.....2.6\n3..2....\n.1..4..4\n"), hashisolver.DefaultRuleSet)
+	if err != nil {
+		t.Fatalf("Failed to parse puzzle: %v", err)
+	}
+	result, err := hashisolver.SolveWithStats(context.Background(), puzzle, hashisolver.WithParallelism(4))
+	if !errors.Is(err, hashisolver.ErrNoSolution) || !result.Partial {
+		t.Fatalf("Expected ErrNoSolution and a partial board, got %v", err)
+	}
+	if result.Puzzle.BuiltBridges != result.Puzzle.PlacedBridges() {
+		t.Errorf("Expected the partial board to hold no guesses, got %d bridges of which %d were guessed",
+			result.Puzzle.PlacedBridges(), result.Puzzle.PlacedBridges()-result.Puzzle.BuiltBridges)
+	}
+}
+
+// TestSolveWithProbing tests that probing finds valid solutions with fewer
+// guesses, and names itself in the trace
+func TestSolveWithProbing(t *testing.T) {
+	probed := 0
+	for _, sample := range hashisolver.Samples() {
+		puzzle, err := sample.Puzzle()
+		if err != nil {
+			t.Fatalf("Failed to parse sample %s: %v", sample.Name, err)
+		}
+		plain, err := hashisolver.SolveWithStats(context.Background(), puzzle.Clone())
+		if err != nil {
+			t.Fatalf("Failed to solve sample %s: %v", sample.Name, err)
+		}
+		result, err := hashisolver.SolveWithStats(context.Background(), puzzle.Clone(), hashisolver.WithProbing(true), hashisolver.WithTrace(true))
+		if err != nil {
+			t.Fatalf("Failed to solve sample %s with probing: %v", sample.Name, err)
+		}
+		if violations := hashisolver.Verify(puzzle, result.Puzzle); len(violations) > 0 {
+			t.Errorf("Expected a valid solution of sample %s, got %v", sample.Name, violations)
+		}
+		if result.Stats.Branches > plain.Stats.Branches {
+			t.Errorf("Expected probing to need no more guesses on sample %s, got %d against %d",
+				sample.Name, result.Stats.Branches, plain.Stats.Branches)
+		}
+
+		for _, move := range result.Trace {
+			if move.Technique == hashisolver.TechniqueProbing {
+				probed++
+				break
+			}
+		}
+	}
+	if probed == 0 {
+		t.Errorf("Expected probing to place a bridge in some sample")
+	}
+}
+
+// TestCheckForIsland tests that a link the board can't do without gets a
+// bridge, and one on a loop doesn't
+func TestCheckForIsland(t *testing.T) {
+	for _, tc := range []struct {
+		input string
+		want  bool
+	}{
+		{"1.2.1\n", true},
+		{"2.2\n...\n2.2\n", false},
+		{"1.3.2\n.....\n..2.2\n", true},
+	} {
+		puzzle, err := hashisolver.Parse(strings.NewReader(tc.input), hashisolver.DefaultRuleSet)
+		if err != nil {
+			t.Fatalf("Failed to parse %q: %v", tc.input, err)
+		}
+		node := puzzle.At(0, 0)
+		if got := hashisolver.CheckForIsland(puzzle, node, hashisolver.DirectionRight, 1); got != tc.want {
+			t.Errorf("Expected %v for %q, got %v", tc.want, tc.input, got)
+		}
+		if tc.want && node.RightBridges != 1 {
+			t.Errorf("Expected a bridge from (0,0) in %q, got %d", tc.input, node.RightBridges)
+		}
+	}
+}
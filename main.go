@@ -1,41 +1,452 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
+	"strings"
+	"time"
 
 	"hashi/hashisolver"
 )
 
+// commands maps each subcommand to the function that runs it with the remaining arguments
+var commands = map[string]func(args []string){
+	"solve":         runSolve,
+	"hint":          runHint,
+	"explain":       runExplain,
+	"estimate":      runEstimate,
+	"gen":           runGen,
+	"verify-corpus": runVerifyCorpus,
+	"validate":      runValidate,
+	"bench":         runBench,
+	"edit":          runEdit,
+	"play":          runPlay,
+	"replay":        runReplay,
+	"export":        runExport,
+	"book":          runBook,
+	"diff":          runDiff,
+	"diff-puzzle":   runDiffPuzzle,
+	"stats":         runStats,
+	"techniques":    runTechniques,
+}
+
 func main() {
-	var inputFile string
+	// Without a subcommand the arguments are for solve
+	args := os.Args[1:]
+	command := "solve"
+	if len(args) > 0 {
+		if _, ok := commands[args[0]]; ok {
+			command = args[0]
+			args = args[1:]
+		}
+	}
+
+	commands[command](args)
+}
+
+// runSolve solves a puzzle and prints the solution
+func runSolve(args []string) {
+	var input inputFlags
+	var output outputFlags
 	var debug bool
+	var jsonOutput bool
+	var countOnly bool
+	var maxSolutions int
+	var noGuess bool
+	var maxDepth int
+	var showStats bool
+	var showTrace bool
+	var verify bool
+	var inputDir, outputDir string
+	var jobs int
+	var parallel int
+	var probe bool
+	var strategyName string
+	var engineName string
+	var crossWith crossCheckFlag
+	var timeout time.Duration
+	var anytime bool
+	var maxMemory byteSize
+	var profile profileFlags
+	var recordFile string
+	var animate bool
+	var delay time.Duration
+	var outputFile string
+	var legend bool
 
-	flag.StringVar(&inputFile, "input", "", "Input puzzle file (use - for stdin)")
-	flag.BoolVar(&debug, "debug", false, "Enable debug output")
-	flag.Parse()
+	fs := flag.NewFlagSet("solve", flag.ExitOnError)
+	input.register(fs)
+	output.register(fs, "text")
+	profile.register(fs)
+	fs.BoolVar(&debug, "debug", false, "Enable debug output")
+	fs.BoolVar(&countOnly, "count", false, "Print the number of solutions instead of solving")
+	fs.IntVar(&maxSolutions, "solutions", 1, "Print up to this many solutions (0 for all of them)")
+	fs.BoolVar(&noGuess, "no-guess", false, "Only use logical deductions; exit with the partial board if guessing is needed")
+	fs.IntVar(&maxDepth, "max-depth", 0, "Abandon speculation nested deeper than this (0 for no limit)")
+	fs.StringVar(&engineName, "engine", hashisolver.EngineHeuristic, "Engine to solve with: "+strings.Join(hashisolver.Engines(), ", ")+"; sat checks for solutions without the logical rules")
+	fs.Var(&crossWith, "cross-check", "Also solve each puzzle with this engine, or the SAT engine if none is named, and fail if the two disagree on its solutions")
+	fs.StringVar(&strategyName, "strategy", "highest-remaining", "How to pick the island to guess at: "+strings.Join(hashisolver.Strategies(), ", "))
+	fs.BoolVar(&probe, "probe", false, "When the rules stall, try both ways of each undecided link and keep what they agree on before guessing")
+	fs.BoolVar(&showStats, "stats", false, "Print solver statistics to stderr")
+	fs.BoolVar(&showTrace, "trace", false, "Print each deduction and guess, with the technique used, to stderr")
+	fs.StringVar(&recordFile, "record", "", "Write every move of the solve, with when it was made, to this file for hashi replay")
+	fs.BoolVar(&animate, "animate", false, "Redraw the board on stderr after each move of the solve, with the latest bridge in yellow")
+	fs.DurationVar(&delay, "delay", 200*time.Millisecond, "With -animate or -output gif, how long to show each move for")
+	fs.StringVar(&outputFile, "o", "", "Write the solutions to this file instead of stdout")
+	fs.BoolVar(&legend, "legend", false, "Under each text board, explain its bridge symbols and sum up the islands, bridges, techniques used and solve time")
+	fs.BoolVar(&verify, "verify", false, "Check the solver's internal state after every move and abort with a dump if it is inconsistent")
+	fs.BoolVar(&jsonOutput, "json", false, "Print the solution and puzzle metadata as JSON (same as -output json)")
+	fs.StringVar(&inputDir, "input-dir", "", "Solve every .txt and .json puzzle in this directory, writing each solution beside it")
+	fs.StringVar(&outputDir, "output-dir", "", "With -input-dir, write the solutions here instead")
+	fs.DurationVar(&timeout, "timeout", 0, "Give up on each puzzle after this long and print the partial board, or the solutions found so far (0 for no limit)")
+	fs.Var(&maxMemory, "max-memory", "Give up on a puzzle once its speculative boards would take more than this, like 512MB (0 for no limit)")
+	fs.BoolVar(&anytime, "anytime", false, "When stopped by -timeout or Ctrl-C, print the board with the most bridges reached, guesses included")
+	fs.IntVar(&jobs, "j", 1, "Solve up to this many puzzles at once from a directory or stream; output stays in order")
+	fs.IntVar(&parallel, "parallel", 1, "Explore the guesses of each solve in up to this many goroutines at once")
+	parseFlags(fs, args)
+	input.useArgs(fs)
 
-	var reader io.Reader
-	if inputFile == "" || inputFile == "-" {
-		reader = os.Stdin
-	} else {
-		file, err := os.Open(inputFile)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error opening file: %v\n", err)
+	if jsonOutput {
+		output.format = "json"
+	}
+	// A file gets what would go down a pipe
+	if outputFile != "" && output.charset.name == "auto" {
+		output.charset.name = "ascii"
+	}
+	if outputFile != "" && output.color == "auto" {
+		output.color = "never"
+	}
+	output.renderer()
+	// Coloring bridges by how they were placed needs the moves of the solve
+	colorMoves := strings.ToLower(output.format) == "text" && output.colorText(os.Stdout)
+	if legend && strings.ToLower(output.format) != "text" {
+		fmt.Fprintln(os.Stderr, "Error: -legend goes under the text output format")
+		os.Exit(2)
+	}
+
+	strategy, err := hashisolver.LookupStrategy(strategyName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if _, err := hashisolver.LookupEngine(engineName); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	checkWith := crossWith.against(engineName)
+	if checkWith != "" {
+		if _, err := hashisolver.LookupEngine(checkWith); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
+	}
+
+	solveOpts := []hashisolver.Option{
+		hashisolver.WithDebug(debug),
+		hashisolver.WithLogicOnly(noGuess),
+		hashisolver.WithMaxDepth(maxDepth),
+		hashisolver.WithTrace(showTrace),
+		hashisolver.WithVerify(verify),
+		hashisolver.WithTimeout(timeout),
+		hashisolver.WithMaxMemory(int64(maxMemory)),
+		hashisolver.WithParallelism(parallel),
+		hashisolver.WithProbing(probe),
+		hashisolver.WithStrategy(strategy),
+		hashisolver.WithEngine(engineName),
+	}
+
+	// single is set when each puzzle gets one solve that can be stopped early,
+	// rather than an enumeration of its solutions
+	single := !countOnly && (maxSolutions == 1 || noGuess || maxDepth > 0)
+	if (recordFile != "" || animate || output.format == "gif") && (!single || inputDir != "") {
+		fmt.Fprintln(os.Stderr, "Error: -record, -animate and -output gif need a single solve of one puzzle")
+		os.Exit(2)
+	}
+
+	// Ctrl-C stops a solve cleanly, so the board reached or the solutions
+	// found so far can be printed instead of the process dying with no
+	// output. A second Ctrl-C exits at once.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	// The profiles cover the solving, and are finished before any exit
+	stopProfile := profile.start()
+	defer stopProfile()
+	exit := func(code int) {
+		stopProfile()
+		os.Exit(code)
+	}
+
+	if inputDir != "" {
+		if outputFile != "" {
+			fmt.Fprintln(os.Stderr, "Error: -o can't be used with -input-dir, which writes a file for each puzzle")
+			exit(2)
+		}
+		if countOnly || maxSolutions != 1 || checkWith != "" || showStats || showTrace || legend || anytime {
+			fmt.Fprintln(os.Stderr, "Error: -count, -solutions, -cross-check, -stats, -trace, -legend and -anytime can't be used with -input-dir, which solves each puzzle once")
+			exit(2)
+		}
+		failed, err := solveDir(ctx, os.Stdout, inputDir, outputDir, &input, &output, solveOpts, jobs)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error %v\n", err)
+			exit(1)
+		}
+		if ctx.Err() != nil {
+			exit(130)
+		}
+		if failed > 0 {
+			exit(1)
+		}
+		return
+	}
+
+	puzzles := input.readPuzzles()
+	if (recordFile != "" || animate || output.format == "gif") && len(puzzles) > 1 {
+		fmt.Fprintln(os.Stderr, "Error: -record, -animate and -output gif need a single solve of one puzzle")
+		exit(2)
+	}
+
+	// Show a progress bar for long solves, unless it would mix with debug output,
+	// end up in a redirected log, be drawn over by -animate or have several
+	// solves to follow at once
+	var bar progressBar
+	if isTerminal(os.Stderr) && !debug && !animate && (jobs <= 1 || len(puzzles) == 1) {
+		solveOpts = append(solveOpts, hashisolver.WithProgress(bar.update, 0))
+	}
+
+	// solve solves one puzzle and prints its solutions to out, separated by
+	// blank lines except in JSON, where each is a value of its own, and its
+	// messages to errOut
+	solve := func(puzzle *hashisolver.Puzzle, label string, out, errOut io.Writer) error {
+		// Puzzles not yet started when interrupted are left alone
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		opts := solveOpts
+		if anytime {
+			opts = append(opts[:len(opts):len(opts)], hashisolver.WithAnytime(&hashisolver.Anytime{}))
+		}
+		if checkWith != "" {
+			err := crossCheck(ctx, [2]string{engineName, checkWith}, puzzle, opts)
+			bar.clear()
+			if errors.Is(err, errCrossCheck) {
+				fmt.Fprintf(errOut, "*** %s: %v ***\n", label, err)
+				return err
+			}
+			if err != nil {
+				fmt.Fprintf(errOut, "Error cross-checking %s: %v\n", label, err)
+				return err
+			}
+		}
+
+		// The heuristic engine counts without keeping every solution. A count
+		// that stopped short prints how far it got, under a banner saying why.
+		if countOnly {
+			var count int
+			var err error
+			if engineName == hashisolver.EngineHeuristic {
+				count, err = hashisolver.CountSolutionsContext(ctx, puzzle, 0, opts...)
+			} else {
+				var solutions []*hashisolver.Solution
+				solutions, err = hashisolver.FindSolutions(ctx, puzzle, 0, opts...)
+				count = len(solutions)
+			}
+			bar.clear()
+			if banner := stoppedBanner(err, label, timeout); banner != "" {
+				fmt.Fprintf(errOut, "*** %s; %s were counted before stopping ***\n", banner, plural(count, "solution"))
+				fmt.Fprintln(out, count)
+				return err
+			}
+			if err != nil && !errors.Is(err, hashisolver.ErrNoSolution) {
+				fmt.Fprintf(errOut, "Error solving %s: %v\n", label, err)
+				return err
+			}
+			fmt.Fprintln(out, count)
+			return nil
+		}
+
+		clues := puzzle
+		solutions := []*hashisolver.Solution{}
+		var moves map[[2]hashisolver.Position]hashisolver.Move
+		// made and elapsed are what the legend's summary says of a single solve
+		var made []hashisolver.Move
+		var elapsed time.Duration
+		var err error
+		if single {
+			var result *hashisolver.SolveResult
+			var replay *hashisolver.Replay
+			if recordFile != "" || animate || output.format == "gif" || colorMoves || legend {
+				var onMove func(*hashisolver.Replay)
+				if animate {
+					onMove = func(replay *hashisolver.Replay) {
+						animateMove(os.Stderr, replay, output.charset.charset(os.Stderr), delay)
+					}
+				}
+				result, replay, err = recordSolve(ctx, puzzle, opts, onMove)
+				if recordFile != "" {
+					if err := saveReplay(recordFile, replay); err != nil {
+						fmt.Fprintf(errOut, "Error recording %s: %v\n", label, err)
+					}
+				}
+			} else {
+				result, err = hashisolver.SolveWithStats(ctx, puzzle, opts...)
+			}
+			bar.clear()
+			puzzle = result.Puzzle
+			if replay != nil {
+				moves = lastMoves(replay)
+				made = replay.Made(len(replay.Moves))
+			}
+			elapsed = result.Stats.Elapsed
+
+			// A GIF shows every move of the solve rather than the board it
+			// ended on
+			writeBoard := func(board *hashisolver.Puzzle) {
+				if output.format != "gif" {
+					output.write(out, board, moves)
+					return
+				}
+				if err := hashisolver.WriteReplayGIF(out, replay, hashisolver.DefaultImageStyle, delay); err != nil {
+					fmt.Fprintf(errOut, "Error writing output: %v\n", err)
+				}
+			}
+			if maxDepth > 0 {
+				fmt.Fprintf(errOut, "Speculation depth reached: %d\n", result.Stats.MaxDepth)
+			}
+			if showTrace {
+				hashisolver.WriteTrace(errOut, result.Trace)
+			}
+			if errors.Is(err, hashisolver.ErrGuessRequired) {
+				if showStats {
+					hashisolver.WriteStats(errOut, result.Stats)
+				}
+				fmt.Fprintf(errOut, "Logic alone placed %d of %d bridges; guessing is required\n",
+					puzzle.BuiltBridges, puzzle.FullBridges/2)
+				writeBoard(puzzle)
+				return err
+			}
+
+			// A solve that stopped short prints the board as far as the
+			// logical rules got, under a banner saying why
+			interrupted := errors.Is(err, context.Canceled)
+			banner := stoppedBanner(err, label, timeout)
+			switch {
+			case banner != "":
+			case errors.Is(err, hashisolver.ErrMemoryLimit):
+				banner = fmt.Sprintf("OUT OF MEMORY: %s failed, %v", label, err)
+			case errors.Is(err, hashisolver.ErrNoSolution) && result.Partial:
+				banner = fmt.Sprintf("NO SOLUTION: %s failed, %v", label, err)
+			}
+			if banner != "" {
+				fmt.Fprintf(errOut, "*** %s; %d of %d bridges were placed before stopping and the board below is partial ***\n",
+					banner, puzzle.BuiltBridges, puzzle.FullBridges/2)
+			}
+			if showStats || interrupted {
+				hashisolver.WriteStats(errOut, result.Stats)
+			}
+			if banner != "" {
+				writeBoard(puzzle)
+				return err
+			}
+			if output.format == "gif" && err == nil {
+				writeBoard(puzzle)
+				return nil
+			}
+			solutions = append(solutions, hashisolver.NewSolution(puzzle))
+		} else {
+			solutions, err = hashisolver.FindSolutions(ctx, puzzle, maxSolutions, opts...)
+			bar.clear()
+			// An enumeration that stopped short prints the solutions found
+			// so far, under a banner saying why
+			if banner := stoppedBanner(err, label, timeout); banner != "" {
+				fmt.Fprintf(errOut, "*** %s; %s were found before stopping ***\n", banner, plural(len(solutions), "solution"))
+			}
+		}
+		if err != nil && stoppedBanner(err, label, timeout) == "" {
+			fmt.Fprintf(errOut, "Error solving %s: %v\n", label, err)
+			return err
+		}
+
+		for i, solution := range solutions {
+			if i > 0 && output.format != "json" {
+				fmt.Fprintln(out)
+			}
+			output.writeSolution(out, clues, solution, moves)
+			if legend {
+				board, err := clues.Apply(solution)
+				if err == nil {
+					err = hashisolver.WriteLegend(out, board, output.charset.charset(os.Stdout), hashisolver.Summarize(board, made, elapsed))
+				}
+				if err != nil {
+					fmt.Fprintf(errOut, "Error writing legend: %v\n", err)
+					return err
+				}
+			}
+		}
+		return err
+	}
+
+	stdout := io.Writer(os.Stdout)
+	if outputFile != "" {
+		file, err := os.Create(outputFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
 		defer file.Close()
-		reader = file
+		stdout = file
 	}
 
-	puzzle, err := hashisolver.Solve(reader, debug)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error solving puzzle: %v\n", err)
-		os.Exit(1)
+	// A stream of several puzzles is solved in order, with the output for
+	// each separated like the input. Each puzzle's output is held back until
+	// those before it have been printed.
+	outs := make([]bytes.Buffer, len(puzzles))
+	errOuts := make([]bytes.Buffer, len(puzzles))
+	errs := make([]error, len(puzzles))
+	failed := false
+	forEachOrdered(len(puzzles), jobs, func(i int) {
+		label := "puzzle"
+		if len(puzzles) > 1 {
+			label = fmt.Sprintf("puzzle %d", i+1)
+		}
+		errs[i] = solve(puzzles[i], label, &outs[i], &errOuts[i])
+	}, func(i int) {
+		if i > 0 && output.format != "json" && !countOnly && outs[i].Len() > 0 {
+			fmt.Fprintln(stdout)
+		}
+		os.Stderr.Write(errOuts[i].Bytes())
+		stdout.Write(outs[i].Bytes())
+		if errs[i] != nil {
+			failed = true
+		}
+	})
+	if ctx.Err() != nil {
+		exit(130)
+	}
+	if failed {
+		exit(1)
 	}
+}
 
-	// Print the solution
-	hashisolver.PrintMap(puzzle)
-} 
\ No newline at end of file
+// stoppedBanner returns the banner for a solve of the puzzle labelled label
+// that err stopped short, either by Ctrl-C or by running out of time, or ""
+// if err didn't stop it
+func stoppedBanner(err error, label string, timeout time.Duration) string {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "INTERRUPTED: stopped solving " + label
+	case errors.Is(err, hashisolver.ErrTimeout):
+		return fmt.Sprintf("INCOMPLETE: %s timed out after %v", label, timeout)
+	}
+	return ""
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"hashi/hashisolver"
+)
+
+// TestSolveStream tests that replaying the streamed moves, undoing failed guesses,
+// rebuilds the solution
+func TestSolveStream(t *testing.T) {
+	// One of the guesses on this board fails, so the stream includes a backtrack
+	puzzle, err := hashisolver.Parse(strings.NewReader("2..3..2.\n........\n2..4..3.\n........\n.2.4.1..\n1...1.3.\n.1......\n2..3..2.\n"), hashisolver.DefaultRuleSet)
+	if err != nil {
+		t.Fatalf("Failed to parse puzzle: %v", err)
+	}
+
+	moves := make(chan hashisolver.Move)
+	done := make(chan struct{})
+	var result *hashisolver.SolveResult
+	go func() {
+		result, err = hashisolver.SolveStream(context.Background(), puzzle, moves)
+		close(done)
+	}()
+
+	var replay []hashisolver.Move
+	guesses, backtracks := 0, 0
+	for move := range moves {
+		if move.Backtrack {
+			backtracks++
+			for len(replay) > 0 && replay[len(replay)-1].Depth >= move.Depth {
+				replay = replay[:len(replay)-1]
+			}
+			continue
+		}
+		if move.Technique == hashisolver.TechniqueSpeculation {
+			guesses++
+		}
+		if move.Speculative != (move.Depth > 0) {
+			t.Errorf("Move %+v is marked speculative at the wrong depth", move)
+		}
+		replay = append(replay, move)
+	}
+	<-done
+
+	if err != nil {
+		t.Fatalf("Failed to solve puzzle: %v", err)
+	}
+	if guesses == 0 || backtracks == 0 {
+		t.Errorf("Expected the stream to include guesses and backtracking, got %d and %d", guesses, backtracks)
+	}
+
+	// The last move on each pair gives its final bridge count
+	final := map[[2]hashisolver.Position]int{}
+	for _, move := range replay {
+		pair := [2]hashisolver.Position{move.From, move.To}
+		if move.To.Y < move.From.Y || move.To.X < move.From.X {
+			pair = [2]hashisolver.Position{move.To, move.From}
+		}
+		final[pair] = move.Count
+	}
+
+	counts := map[hashisolver.Position]int{}
+	for pair, count := range final {
+		counts[pair[0]] += count
+		counts[pair[1]] += count
+	}
+
+	for pos, total := range counts {
+		if want := result.Puzzle.At(pos.X, pos.Y).Value(); total != want {
+			t.Errorf("Replayed island at (%d,%d) has %d bridges, expected %d", pos.X, pos.Y, total, want)
+		}
+	}
+	if len(replay) != result.Puzzle.FullBridges/2 {
+		t.Errorf("Expected %d bridges after replaying, got %d", result.Puzzle.FullBridges/2, len(replay))
+	}
+}
+
+// TestSolveTrace tests that the trace names the technique behind each move
+func TestSolveTrace(t *testing.T) {
+	puzzle := "2..3..2.\n........\n2..4..3.\n........\n.2.4.1..\n1...1.3.\n.1......\n2..3..2.\n"
+
+	p, err := hashisolver.Parse(strings.NewReader(puzzle), hashisolver.DefaultRuleSet)
+	if err != nil {
+		t.Fatalf("Failed to parse puzzle: %v", err)
+	}
+	result, err := hashisolver.SolveWithStats(context.Background(), p, hashisolver.WithTrace(true))
+	if err != nil {
+		t.Fatalf("Failed to solve puzzle: %v", err)
+	}
+
+	techniques := map[hashisolver.Technique]int{}
+	backtracks := 0
+	for _, move := range result.Trace {
+		if move.Backtrack {
+			backtracks++
+			continue
+		}
+		techniques[move.Technique]++
+	}
+	if techniques[hashisolver.TechniqueOneDirection] == 0 || techniques[hashisolver.TechniqueSpeculation] == 0 || backtracks == 0 {
+		t.Errorf("Expected deductions, guesses and a backtrack in the trace, got %v and %d backtracks", techniques, backtracks)
+	}
+
+	first := result.Trace[0].String()
+	if !strings.HasPrefix(first, "forced share: (1,4) to (3,4)") {
+		t.Errorf("Unexpected description of the first move: %q", first)
+	}
+
+	// Without the option no trace is kept
+	p, _ = hashisolver.Parse(strings.NewReader(puzzle), hashisolver.DefaultRuleSet)
+	if result, _ := hashisolver.SolveWithStats(context.Background(), p); result.Trace != nil {
+		t.Errorf("Expected no trace by default, got %d moves", len(result.Trace))
+	}
+}
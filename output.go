@@ -0,0 +1,222 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"hashi/hashisolver"
+)
+
+// outputFlags are the flags every subcommand that prints a board uses to pick
+// its output format
+type outputFlags struct {
+	format string
+	// charset, color, labels and large draw the text format, for stdout
+	charset charsetFlag
+	color   string
+	labels  bool
+	large   bool
+	// protocol is how the terminal-image format is sent
+	protocol string
+}
+
+// register adds the output flags to fs, defaulting to the named format
+func (f *outputFlags) register(fs *flag.FlagSet, format string) {
+	fs.StringVar(&f.format, "output", format, "Output format: "+strings.Join(hashisolver.Renderers(), ", "))
+	f.charset.register(fs)
+	fs.BoolVar(&f.labels, "labels", false, "Draw the text format with column letters above and row numbers beside it, naming cells like A1")
+	fs.BoolVar(&f.large, "large", false, "Draw the text format with each cell three characters wide and a line between rows, for dense boards")
+	fs.StringVar(&f.color, "color", "auto", "Draw the text format in color: always, never, or auto for a terminal when NO_COLOR isn't set")
+	fs.StringVar(&f.protocol, "image-protocol", "auto", "Send the terminal-image format as sixel, kitty or iterm, or auto to go by the terminal")
+}
+
+// renderer returns the renderer selected by -output, exiting with a message
+// if there is none by that name
+func (f *outputFlags) renderer() hashisolver.Renderer {
+	return f.solveRenderer(nil)
+}
+
+// solveRenderer returns the renderer selected by -output like renderer. In
+// color, the text format tells apart the bridges the logical rules placed
+// from those placed while guessing, going by moves, the move that left each
+// pair of islands as it is.
+func (f *outputFlags) solveRenderer(moves map[[2]hashisolver.Position]hashisolver.Move) hashisolver.Renderer {
+	if strings.ToLower(f.format) == "text" {
+		charset := f.charset.charset(os.Stdout)
+		color := f.colorText(os.Stdout)
+		if !color && !f.labels && !f.large {
+			return hashisolver.MapRenderer(charset)
+		}
+		return func(w io.Writer, puzzle *hashisolver.Puzzle) error {
+			lines := hashisolver.DrawMap(puzzle, charset)
+			switch {
+			case f.large:
+				var paint func(x, y int, text string) string
+				if color {
+					paint = func(x, y int, text string) string { return paintCell(puzzle, moves, x, y, text) }
+				}
+				lines = hashisolver.LargeMap(lines, paint)
+			case color:
+				lines = coloredMap(puzzle, charset, moves)
+			}
+			switch {
+			case f.labels && f.large:
+				lines = hashisolver.LabelLargeMap(lines, puzzle.Cols)
+			case f.labels:
+				lines = hashisolver.LabelMap(lines, puzzle.Cols)
+			}
+			_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+			return err
+		}
+	}
+	if strings.ToLower(f.format) == "terminal-image" {
+		return hashisolver.TerminalImageRenderer(f.imageProtocol())
+	}
+	renderer, err := hashisolver.LookupRenderer(f.format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return renderer
+}
+
+// colorText reports whether -color asks for the text format in color on out,
+// exiting with a message if it isn't one of the choices
+func (f *outputFlags) colorText(out *os.File) bool {
+	switch f.color {
+	case "always":
+		return true
+	case "never":
+		return false
+	case "auto":
+		return isTerminal(out) && os.Getenv("NO_COLOR") == "" && os.Getenv("TERM") != "dumb"
+	}
+	fmt.Fprintf(os.Stderr, "Error: unknown -color %q (expected auto, always or never)\n", f.color)
+	os.Exit(2)
+	return false
+}
+
+// imageProtocol returns the protocol -image-protocol asks for, exiting with
+// a message if it isn't one of the choices. auto picks kitty's or iTerm2's
+// in the terminals known to take them, and sixel anywhere else.
+func (f *outputFlags) imageProtocol() hashisolver.ImageProtocol {
+	if f.protocol == "auto" {
+		switch {
+		case os.Getenv("KITTY_WINDOW_ID") != "" || os.Getenv("TERM") == "xterm-kitty" || os.Getenv("TERM") == "xterm-ghostty":
+			return hashisolver.ImageKitty
+		case os.Getenv("TERM_PROGRAM") == "iTerm.app" || os.Getenv("TERM_PROGRAM") == "WezTerm":
+			return hashisolver.ImageITerm
+		}
+		return hashisolver.ImageSixel
+	}
+	protocol, err := hashisolver.ParseImageProtocol(f.protocol)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+	return protocol
+}
+
+// write renders the puzzle to w in the selected format, exiting with a
+// message if it can't. moves are as for solveRenderer, and may be nil.
+func (f *outputFlags) write(w io.Writer, puzzle *hashisolver.Puzzle, moves map[[2]hashisolver.Position]hashisolver.Move) {
+	if err := f.solveRenderer(moves)(w, puzzle); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// writeSolution renders the puzzle with the solution's bridges on it to w in
+// the selected format, exiting with a message if it can't
+func (f *outputFlags) writeSolution(w io.Writer, puzzle *hashisolver.Puzzle, solution *hashisolver.Solution, moves map[[2]hashisolver.Position]hashisolver.Move) {
+	if err := hashisolver.RenderSolution(w, f.solveRenderer(moves), puzzle, solution); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// coloredMap draws the board in the charset with each cell colored as
+// cellColor has it
+func coloredMap(puzzle *hashisolver.Puzzle, charset hashisolver.Charset, moves map[[2]hashisolver.Position]hashisolver.Move) []string {
+	lines := []string{}
+	for y, row := range hashisolver.DrawMap(puzzle, charset) {
+		var line strings.Builder
+		for x, symbol := range []rune(row) {
+			line.WriteString(paintCell(puzzle, moves, x, y, string(symbol)))
+		}
+		lines = append(lines, line.String())
+	}
+	return lines
+}
+
+// paintCell colors text drawn for the cell at (x,y). Islands are cyan, or red
+// while they have too few bridges or too many. Bridges are green if the
+// logical rules placed them and yellow if a guess did, going by the move in
+// moves for their pair, and plain if they have none, as when they came with
+// the puzzle.
+func paintCell(puzzle *hashisolver.Puzzle, moves map[[2]hashisolver.Position]hashisolver.Move, x, y int, text string) string {
+	if node := puzzle.At(x, y); node.Value() > 0 {
+		if node.TotalBridges != node.Value() {
+			return colored(text, colorRed)
+		}
+		return colored(text, colorCyan)
+	}
+	bridge, ok := puzzle.BridgeOver(x, y)
+	if !ok {
+		return text
+	}
+	move, ok := moves[[2]hashisolver.Position{bridge.From, bridge.To}]
+	switch {
+	case !ok:
+		return text
+	case move.Speculative:
+		return colored(text, colorYellow)
+	}
+	return colored(text, colorGreen)
+}
+
+// charsetFlag is the -charset flag of every subcommand that draws boards as
+// text
+type charsetFlag struct {
+	name string
+}
+
+// register adds the charset flag to fs
+func (f *charsetFlag) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.name, "charset", "auto", "Characters to draw bridges with: ascii, unicode, or auto for unicode on a terminal that takes UTF-8")
+}
+
+// charset returns the charset selected by -charset for drawing on out,
+// exiting with a message if there is none by that name
+func (f *charsetFlag) charset(out *os.File) hashisolver.Charset {
+	if f.name == "auto" {
+		if isTerminal(out) && utf8Terminal() {
+			return hashisolver.CharsetUnicode
+		}
+		return hashisolver.CharsetASCII
+	}
+	charset, err := hashisolver.ParseCharset(f.name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+	return charset
+}
+
+// utf8Terminal reports whether the terminal can be expected to show UTF-8,
+// from the locale as the C library would pick it and a TERM that isn't dumb
+func utf8Terminal() bool {
+	if os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if locale := os.Getenv(name); locale != "" {
+			locale = strings.ToLower(locale)
+			return strings.Contains(locale, "utf-8") || strings.Contains(locale, "utf8")
+		}
+	}
+	return false
+}
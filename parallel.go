@@ -0,0 +1,43 @@
+package main
+
+import "sync"
+
+// forEachOrdered calls work for each index from 0 to n-1, on up to jobs
+// goroutines at once, and then calls report for each index in order as soon
+// as its work and that of every index before it is done. work must be safe
+// to run concurrently; report is only ever called from the calling goroutine.
+func forEachOrdered(n, jobs int, work func(i int), report func(i int)) {
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	done := make([]chan struct{}, n)
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < jobs && w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				work(i)
+				close(done[i])
+			}
+		}()
+	}
+	go func() {
+		for i := 0; i < n; i++ {
+			next <- i
+		}
+		close(next)
+	}()
+
+	for i := 0; i < n; i++ {
+		<-done[i]
+		report(i)
+	}
+	wg.Wait()
+}
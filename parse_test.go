@@ -0,0 +1,333 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"hashi/hashisolver"
+)
+
+// TestParseCSV tests that CSV puzzles are sized from their records and solve like text puzzles
+func TestParseCSV(t *testing.T) {
+	csv := "2,,,3\n,,,\n1,,,2\n"
+
+	p, err := hashisolver.ParseCSV(strings.NewReader(csv), hashisolver.DefaultRuleSet, hashisolver.ParseOptions{})
+	if err != nil {
+		t.Fatalf("Failed to parse CSV puzzle: %v", err)
+	}
+
+	if p.Rows != 3 || p.Cols != 4 {
+		t.Fatalf("Expected a 3x4 board, got %dx%d", p.Rows, p.Cols)
+	}
+	if p.At(3, 0).Value() != 3 || p.At(0, 2).Value() != 1 {
+		t.Errorf("Islands were not parsed into the right cells")
+	}
+
+	solved, err := hashisolver.SolvePuzzle(p, false)
+	if err != nil {
+		t.Fatalf("Failed to solve CSV puzzle: %v", err)
+	}
+	if !solved.IsComplete() {
+		t.Errorf("Expected a complete solution")
+	}
+}
+
+// TestParseCSVErrors tests that malformed CSV cells are reported
+func TestParseCSVErrors(t *testing.T) {
+	for _, csv := range []string{
+		"2,x,2\n",
+		"2,,9\n",
+		"2,,-1\n",
+	} {
+		if _, err := hashisolver.ParseCSV(strings.NewReader(csv), hashisolver.DefaultRuleSet, hashisolver.ParseOptions{}); err == nil {
+			t.Errorf("Expected an error parsing %q", csv)
+		}
+	}
+}
+
+// TestParseSpacedLayout tests whitespace-separated grids, both detected and forced
+func TestParseSpacedLayout(t *testing.T) {
+	puzzle := ". 3 .\n3 12 3\n. 3 .\n"
+
+	p, err := hashisolver.Parse(strings.NewReader(puzzle), hashisolver.TripleBridgeRuleSet)
+	if err != nil {
+		t.Fatalf("Failed to parse spaced puzzle: %v", err)
+	}
+	if p.Rows != 3 || p.Cols != 3 || p.At(1, 1).Value() != 12 {
+		t.Fatalf("Expected a 3x3 board with 12 in the center, got %dx%d", p.Rows, p.Cols)
+	}
+
+	// A single column board has no internal whitespace, so the layout must be forced
+	p, err = hashisolver.ParseText(strings.NewReader("10\n.\n10\n"), hashisolver.TripleBridgeRuleSet,
+		hashisolver.ParseOptions{Layout: hashisolver.LayoutSpaced})
+	if err != nil {
+		t.Fatalf("Failed to parse forced spaced puzzle: %v", err)
+	}
+	if p.Cols != 1 || p.At(0, 0).Value() != 10 {
+		t.Errorf("Expected a single column with a 10 at the top, got %d columns", p.Cols)
+	}
+}
+
+// TestParseMetadataHeader tests that comments are skipped and header fields reach the JSON output
+func TestParseMetadataHeader(t *testing.T) {
+	puzzle := "# from the regression pack\ntitle: Corner\nDifficulty: easy\nrating: 3\n2.2\n...\n# trailing comment\n"
+
+	p, err := hashisolver.Parse(strings.NewReader(puzzle), hashisolver.DefaultRuleSet)
+	if err != nil {
+		t.Fatalf("Failed to parse puzzle with header: %v", err)
+	}
+
+	if p.Rows != 2 || p.Cols != 3 {
+		t.Fatalf("Expected header and comments to be skipped, got a %dx%d board", p.Rows, p.Cols)
+	}
+	if p.Metadata.Title != "Corner" || p.Metadata.Difficulty != "easy" || p.Metadata.Extra["rating"] != "3" {
+		t.Errorf("Unexpected metadata: %+v", p.Metadata)
+	}
+
+	var buf bytes.Buffer
+	if err := hashisolver.WriteJSON(&buf, p); err != nil {
+		t.Fatalf("Failed to write JSON: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"title": "Corner"`) {
+		t.Errorf("Expected the title in the JSON output, got %s", buf.String())
+	}
+}
+
+// TestParseStrict tests that strict mode reports where malformed input goes wrong
+func TestParseStrict(t *testing.T) {
+	strict := hashisolver.ParseOptions{Strict: true}
+
+	cases := []struct {
+		puzzle string
+		want   string
+	}{
+		{"2.2\n..\n", "line 2, column 3: expected 3 cells, found 2"},
+		{"2.2\n...\n2.2.\n", "line 3, column 4: expected 3 cells, found 4"},
+		{"title: x\n2.2\n.x.\n", "line 3, column 2: unexpected character 'x'"},
+		{"2 . [3\n", "line 1, column 5: unterminated clue \"[3\""},
+		{"...\n...\n", "puzzle has no islands"},
+	}
+
+	for _, c := range cases {
+		_, err := hashisolver.ParseText(strings.NewReader(c.puzzle), hashisolver.DefaultRuleSet, strict)
+		if err == nil || err.Error() != c.want {
+			t.Errorf("Parsing %q: expected error %q, got %v", c.puzzle, c.want, err)
+		}
+	}
+
+	// Lenient parsing pads ragged rows and treats unknown characters as water
+	p, err := hashisolver.ParseText(strings.NewReader("2.2\n.x\n"), hashisolver.DefaultRuleSet, hashisolver.ParseOptions{})
+	if err != nil {
+		t.Fatalf("Lenient parse failed: %v", err)
+	}
+	if p.Cols != 3 || p.At(1, 1).Value() != 0 {
+		t.Errorf("Expected a padded 3 column board with water for 'x'")
+	}
+}
+
+// TestParseDrawnBridges tests reading boards with bridges already drawn on them
+func TestParseDrawnBridges(t *testing.T) {
+	p, err := hashisolver.Parse(strings.NewReader(" 2=3\n   |\n 1-2\n"), hashisolver.DefaultRuleSet)
+	if err != nil {
+		t.Fatalf("Failed to parse drawn board: %v", err)
+	}
+	if p.Cols != 4 || p.At(1, 0).Value() != 2 {
+		t.Fatalf("Expected leading spaces to be kept as water, got %d columns", p.Cols)
+	}
+	if p.At(1, 0).RightBridges != 2 || p.At(3, 0).DownBridges != 1 || p.PlacedBridges() != 4 {
+		t.Errorf("Bridges were not placed from the drawing")
+	}
+
+	// Rows of water are kept, blank or not, and so are the rows of spaces a
+	// drawn board starts or ends with
+	p, err = hashisolver.Parse(strings.NewReader("      \n2 3==2\n\n      \n1    1\n      \n"), hashisolver.DefaultRuleSet)
+	if err != nil {
+		t.Fatalf("Failed to parse drawn board with rows of water: %v", err)
+	}
+	if p.Rows != 6 || p.At(2, 1).Value() != 3 || p.At(5, 4).Value() != 1 || p.At(2, 1).RightBridges != 2 {
+		t.Errorf("Expected the rows of water to be kept, got %d rows", p.Rows)
+	}
+
+	for _, c := range []struct {
+		puzzle string
+		want   string
+	}{
+		{"2-.2\n", "line 1, column 2: bridge does not reach an island"},
+		{"..-2\n", "line 1, column 3: bridge does not start at an island"},
+		{"1=2\n", "line 1, column 2: bridge gives an island more bridges than its value"},
+	} {
+		_, err := hashisolver.Parse(strings.NewReader(c.puzzle), hashisolver.DefaultRuleSet)
+		if err == nil || err.Error() != c.want {
+			t.Errorf("Parsing %q: expected error %q, got %v", c.puzzle, c.want, err)
+		}
+	}
+}
+
+// TestSplitPuzzles tests splitting a stream of puzzles on empty lines and "---"
+func TestSplitPuzzles(t *testing.T) {
+	stream := "# pack\ntitle: first\n\n2.2\n...\n\n---\n1.2.1\n\n\n3.3\n2.?\n"
+	texts, err := hashisolver.SplitPuzzles(strings.NewReader(stream))
+	if err != nil || len(texts) != 3 {
+		t.Fatalf("Expected 3 puzzles, got %d (%v)", len(texts), err)
+	}
+
+	p, err := hashisolver.Parse(strings.NewReader(texts[0]), hashisolver.DefaultRuleSet)
+	if err != nil || p.Metadata.Title != "first" || p.Rows != 2 {
+		t.Errorf("Expected the first puzzle to keep its header, got %+v (%v)", p, err)
+	}
+
+	// Line numbers in errors count from the start of the stream
+	_, err = hashisolver.ParseText(strings.NewReader(texts[2]), hashisolver.DefaultRuleSet, hashisolver.ParseOptions{Strict: true})
+	if err == nil || !strings.Contains(err.Error(), "line 12") {
+		t.Errorf("Expected an error on line 12, got %v", err)
+	}
+
+	// A lone puzzle, with trailing blank lines, is still one puzzle
+	if texts, err := hashisolver.SplitPuzzles(strings.NewReader("2.2\n\n\n")); err != nil || len(texts) != 1 {
+		t.Errorf("Expected 1 puzzle, got %d (%v)", len(texts), err)
+	}
+}
+
+// TestParseTask tests reading puzzles from task strings and command-line arguments
+func TestParseTask(t *testing.T) {
+	p, err := hashisolver.ParseTask("3x2i30m2:2a2c", hashisolver.DefaultRuleSet)
+	if err != nil {
+		t.Fatalf("Failed to parse task: %v", err)
+	}
+	if p.Rows != 2 || p.Cols != 3 || p.At(2, 0).Value() != 2 || p.At(0, 0).GetNeighbor(hashisolver.DirectionRight) != p.At(2, 0) {
+		t.Errorf("Unexpected puzzle from task:\n%s", strings.Join(hashisolver.MapLines(p), "\n"))
+	}
+
+	for _, task := range []string{"3x2:2a2b", "3x2:2a2c?", "3:2a2", "3x2:f"} {
+		if _, err := hashisolver.ParseTask(task, hashisolver.DefaultRuleSet); !errors.Is(err, hashisolver.ErrInvalidPuzzle) {
+			t.Errorf("Expected ErrInvalidPuzzle for %q, got %v", task, err)
+		}
+	}
+	if hashisolver.IsTask("title: 2x2") || !hashisolver.IsTask("7x7m2:a2") {
+		t.Errorf("Task strings were not told apart from text")
+	}
+
+	// On the command line, rows can be separated by an escaped newline
+	input := &inputFlags{format: "text", maxBridges: 2, strict: true}
+	for _, arg := range []string{`2.2\n...\n2.2`, "3x3:2a2c2a2"} {
+		puzzles, err := input.parseArg(arg)
+		if err != nil || len(puzzles) != 1 || puzzles[0].Rows != 3 || puzzles[0].At(2, 2).Value() != 2 {
+			t.Errorf("Failed to read puzzle argument %q: %v", arg, err)
+		}
+	}
+}
+
+// FuzzParse tests that the parsers never panic on arbitrary input, that every puzzle
+// they accept has consistent neighbor and bridge links, and that strict parsing
+// only accepts what lenient parsing reads the same way
+func FuzzParse(f *testing.F) {
+	for _, seed := range []string{
+		"2..3\n....\n1..2\n",
+		" 2=3\n   |\n 1-2\n",
+		". 3 .\n3 12 3\n. 3 .\n",
+		".3.\n3[12]3\n.3.\n",
+		"title: x\n# comment\n2.2\n...\n",
+		"2,,,3\n,,,\n1,,,2\n",
+		"3E5=3\n    |1\n",
+		"2 . [3\n",
+	} {
+		f.Add([]byte(seed))
+	}
+	for _, s := range hashisolver.Samples() {
+		f.Add([]byte(s.Text))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		rules := hashisolver.TripleBridgeRuleSet
+
+		strict, strictErr := hashisolver.ParseText(bytes.NewReader(data), rules, hashisolver.ParseOptions{Strict: true})
+		if strictErr == nil {
+			checkParsedPuzzle(t, strict, rules)
+			if strict.FullBridges == 0 {
+				t.Errorf("Strict parsing accepted a board without islands")
+			}
+
+			lenient, err := hashisolver.ParseText(bytes.NewReader(data), rules, hashisolver.ParseOptions{})
+			if err != nil {
+				t.Fatalf("Strict parsing succeeded but lenient parsing failed: %v", err)
+			}
+			if lenient.Rows != strict.Rows || lenient.Cols != strict.Cols {
+				t.Fatalf("Strict and lenient parsing disagree on the size: %dx%d and %dx%d",
+					strict.Rows, strict.Cols, lenient.Rows, lenient.Cols)
+			}
+			for i := 0; i < strict.Rows; i++ {
+				for j := 0; j < strict.Cols; j++ {
+					if strict.At(j, i).Value() != lenient.At(j, i).Value() {
+						t.Fatalf("Strict and lenient parsing disagree at (%d,%d)", j, i)
+					}
+				}
+			}
+		}
+
+		if lenient, err := hashisolver.ParseText(bytes.NewReader(data), rules, hashisolver.ParseOptions{}); err == nil {
+			checkParsedPuzzle(t, lenient, rules)
+		}
+		if csv, err := hashisolver.ParseCSV(bytes.NewReader(data), rules, hashisolver.ParseOptions{}); err == nil {
+			checkParsedPuzzle(t, csv, rules)
+		}
+	})
+}
+
+// checkParsedPuzzle checks the board shape, that every island links to the nearest
+// island in each direction, and that bridges drawn on the board agree at both ends
+func checkParsedPuzzle(t *testing.T, p *hashisolver.Puzzle, rules hashisolver.RuleSet) {
+	t.Helper()
+
+	if len(p.Cells) != p.Rows*p.Cols {
+		t.Fatalf("Board has %d cells, expected %d", len(p.Cells), p.Rows*p.Cols)
+	}
+	for i := 0; i < p.Rows; i++ {
+		for j := 0; j < p.Cols; j++ {
+			if node := p.At(j, i); node.XPos() != j || node.YPos() != i {
+				t.Fatalf("Cell (%d,%d) is misplaced", j, i)
+			}
+		}
+	}
+
+	// nearest returns the first island from (x,y) stepping by (dx,dy)
+	nearest := func(x, y, dx, dy int) *hashisolver.Node {
+		for x, y = x+dx, y+dy; x >= 0 && x < p.Cols && y >= 0 && y < p.Rows; x, y = x+dx, y+dy {
+			if p.At(x, y).Value() > 0 {
+				return p.At(x, y)
+			}
+		}
+		return nil
+	}
+
+	for i := 0; i < p.Rows; i++ {
+		for j := 0; j < p.Cols; j++ {
+			node := p.At(j, i)
+			if node.Value() <= 0 {
+				continue
+			}
+			if node.Value() > rules.MaxIslandValue {
+				t.Errorf("Island at (%d,%d) has clue %d above the maximum", j, i, node.Value())
+			}
+
+			right, left := node.GetNeighbor(hashisolver.DirectionRight), node.GetNeighbor(hashisolver.DirectionLeft)
+			down, up := node.GetNeighbor(hashisolver.DirectionDown), node.GetNeighbor(hashisolver.DirectionUp)
+			if right != nearest(j, i, 1, 0) || left != nearest(j, i, -1, 0) ||
+				down != nearest(j, i, 0, 1) || up != nearest(j, i, 0, -1) {
+				t.Fatalf("Island at (%d,%d) is not linked to its nearest neighbors", j, i)
+			}
+
+			if node.TotalBridges != node.UpBridges+node.DownBridges+node.LeftBridges+node.RightBridges ||
+				node.TotalBridges > node.Value() {
+				t.Errorf("Island at (%d,%d) has inconsistent bridge counts", j, i)
+			}
+			if node.RightBridges > 0 && (right == nil || right.LeftBridges != node.RightBridges) {
+				t.Errorf("Bridge right of (%d,%d) doesn't match at its other end", j, i)
+			}
+			if node.DownBridges > 0 && (down == nil || down.UpBridges != node.DownBridges) {
+				t.Errorf("Bridge below (%d,%d) doesn't match at its other end", j, i)
+			}
+		}
+	}
+}
@@ -0,0 +1,500 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+
+	"hashi/hashisolver"
+)
+
+// playKeys is the help line shown under the board
+const playKeys = "arrows/wasd move  shift+arrows/WASD or drag from an island add a bridge, cycling back to none  click a bridge to cycle it  h hint  u undo  r redo  q quit"
+
+// runPlay opens a puzzle, or a newly generated one, for solving by hand in
+// the terminal
+func runPlay(args []string) {
+	var input inputFlags
+	var rows, cols int
+	var difficulty string
+	var seed int64
+	var recordFile string
+	var charset charsetFlag
+
+	fs := flag.NewFlagSet("play", flag.ExitOnError)
+	input.register(fs)
+	charset.register(fs)
+	fs.IntVar(&rows, "rows", 7, "Number of rows on a generated board")
+	fs.IntVar(&cols, "cols", 0, "Number of columns on a generated board (defaults to -rows)")
+	fs.StringVar(&difficulty, "difficulty", "easy", "Difficulty band to generate: easy, medium, hard or any")
+	fs.Int64Var(&seed, "seed", 0, "Seed for the generated puzzle (0 picks one from the clock)")
+	fs.StringVar(&recordFile, "record", "", "Write the moves made to this file on quitting, for hashi replay")
+	parseFlags(fs, args)
+	input.useArgs(fs)
+
+	// Without a puzzle to open a new one is generated, rather than reading one
+	// from the terminal
+	var puzzle *hashisolver.Puzzle
+	if input.inputFile != "" || input.sample != "" || input.text != "" {
+		puzzle = input.readPuzzle()
+	} else {
+		if cols <= 0 {
+			cols = rows
+		}
+		band, err := hashisolver.ParseDifficulty(difficulty)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if seed == 0 {
+			seed = time.Now().UnixNano()
+		}
+		generated, err := hashisolver.Generate(hashisolver.GenerateOptions{
+			Rows:       rows,
+			Cols:       cols,
+			Rules:      input.rules(),
+			Difficulty: band,
+			Source:     rand.NewSource(seed),
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error generating puzzle: %v\n", err)
+			os.Exit(1)
+		}
+		puzzle = generated.Puzzle
+	}
+
+	g, err := newGame(puzzle, statsPath())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	g.charset = charset.charset(os.Stdout)
+	if recordFile != "" {
+		defer func() {
+			if err := saveReplay(recordFile, g.replay()); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			}
+		}()
+	}
+
+	term, err := openTerminal()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer term.restore()
+
+	// The board is redrawn every second as well, to keep the clock going
+	events := term.events()
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		term.draw(g.lines())
+		select {
+		case ev, ok := <-events:
+			switch {
+			case !ok:
+				return
+			case ev.mouse != nil:
+				g.click(*ev.mouse)
+			case g.handle(ev.key):
+				return
+			}
+		case <-ticker.C:
+		}
+	}
+}
+
+// game is a puzzle being solved by hand: the board, the island under the
+// cursor, how the game is going and what to say about the last key
+type game struct {
+	puzzle  *hashisolver.Puzzle
+	charset hashisolver.Charset
+	// clues is the puzzle without bridges, for checking a finished board, and
+	// islands the number of islands on it
+	clues   *hashisolver.Puzzle
+	islands int
+	cursor  *hashisolver.Node
+	// pressed is where the mouse button went down, until it comes up again
+	pressed *mouseEvent
+
+	// now is the clock, and started and finished are when the game began and
+	// when the puzzle was first solved
+	now      func() time.Time
+	started  time.Time
+	finished time.Time
+	// statsFile is where personal bests are kept, or "" to keep none
+	statsFile string
+
+	// moves is every move made on the board, with when, for a replay
+	moves []hashisolver.TimedMove
+
+	// solved is set while the board has been checked and found right. hints
+	// counts the hints asked for and mistakes the bridges refused.
+	solved   bool
+	hints    int
+	mistakes int
+	message  string
+}
+
+// newGame starts a game on the puzzle, with the cursor on its first island
+// and the clock running
+func newGame(puzzle *hashisolver.Puzzle, statsFile string) (*game, error) {
+	g := &game{puzzle: puzzle, clues: puzzle.Clues(), now: time.Now, statsFile: statsFile}
+	for y := 0; y < puzzle.Rows; y++ {
+		for x := 0; x < puzzle.Cols; x++ {
+			if node := puzzle.At(x, y); node.Value() > 0 {
+				if g.cursor == nil {
+					g.cursor = node
+				}
+				g.islands++
+			}
+		}
+	}
+	if g.cursor == nil {
+		return nil, errors.New("the puzzle has no islands")
+	}
+	g.started = g.now()
+	g.check()
+	return g, nil
+}
+
+// elapsed returns how long the puzzle took to solve, or has taken so far
+func (g *game) elapsed() time.Duration {
+	end := g.finished
+	if end.IsZero() {
+		end = g.now()
+	}
+	return end.Sub(g.started).Round(time.Second)
+}
+
+// handle acts on a key and reports whether the game should close
+func (g *game) handle(k key) bool {
+	g.message = ""
+	switch k {
+	case keyUp, 'w':
+		g.move(hashisolver.DirectionUp)
+	case keyDown, 's':
+		g.move(hashisolver.DirectionDown)
+	case keyLeft, 'a':
+		g.move(hashisolver.DirectionLeft)
+	case keyRight, 'd':
+		g.move(hashisolver.DirectionRight)
+	case keyShiftUp, 'W':
+		g.bridge(hashisolver.DirectionUp)
+	case keyShiftDown, 'S':
+		g.bridge(hashisolver.DirectionDown)
+	case keyShiftLeft, 'A':
+		g.bridge(hashisolver.DirectionLeft)
+	case keyShiftRight, 'D':
+		g.bridge(hashisolver.DirectionRight)
+	case 'h':
+		g.hint()
+	case 'u':
+		if move, ok := g.puzzle.Undo(); ok {
+			// The replay sees the move taken back as one the other way
+			move.Removed = !move.Removed
+			if move.Removed {
+				move.Count--
+			} else {
+				move.Count++
+			}
+			g.record(move)
+		} else {
+			g.message = "there is nothing to undo"
+		}
+		g.check()
+	case 'r':
+		if move, ok := g.puzzle.Redo(); ok {
+			g.record(move)
+		} else {
+			g.message = "there is nothing to redo"
+		}
+		g.check()
+	case 'q', keyInterrupt:
+		return true
+	}
+	return false
+}
+
+// click acts on the mouse. The button going down on an island puts the
+// cursor there, and dragging from it towards a neighbor before letting go
+// adds a bridge that way like shift and an arrow. Clicking a bridge cycles
+// it the same way.
+func (g *game) click(m mouseEvent) {
+	g.message = ""
+	x, y, ok := boardCell(m, g.puzzle.Rows, g.puzzle.Cols)
+	if !m.release {
+		g.pressed = nil
+		if ok {
+			g.pressed = &m
+			if node := g.puzzle.At(x, y); node.Value() > 0 {
+				g.cursor = node
+			}
+		}
+		return
+	}
+
+	pressed := g.pressed
+	g.pressed = nil
+	if pressed == nil || !ok {
+		return
+	}
+	px, py, _ := boardCell(*pressed, g.puzzle.Rows, g.puzzle.Cols)
+	start := g.puzzle.At(px, py)
+	if px == x && py == y {
+		if bridge, ok := g.puzzle.BridgeOver(x, y); ok {
+			g.cursor = g.puzzle.At(bridge.From.X, bridge.From.Y)
+			if bridge.From.Y == bridge.To.Y {
+				g.bridge(hashisolver.DirectionRight)
+			} else {
+				g.bridge(hashisolver.DirectionDown)
+			}
+		}
+		return
+	}
+	if start.Value() <= 0 {
+		return
+	}
+
+	// The drag goes whichever way it went furthest, cells being two columns wide
+	dx, dy := m.x-pressed.x, m.y-pressed.y
+	switch {
+	case abs(dx) >= 2*abs(dy) && dx > 0:
+		g.bridge(hashisolver.DirectionRight)
+	case abs(dx) >= 2*abs(dy):
+		g.bridge(hashisolver.DirectionLeft)
+	case dy > 0:
+		g.bridge(hashisolver.DirectionDown)
+	default:
+		g.bridge(hashisolver.DirectionUp)
+	}
+}
+
+// abs returns the size of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// move moves the cursor to the island facing it in the direction, if any
+func (g *game) move(dir int) {
+	if neighbor := g.cursor.GetNeighbor(dir); neighbor != nil {
+		g.cursor = neighbor
+	}
+}
+
+// bridge adds a bridge from the island under the cursor in the direction.
+// Once no more fit, the bridges there are cleared instead, so pressing the
+// same key cycles through every count.
+func (g *game) bridge(dir int) {
+	neighbor := g.cursor.GetNeighbor(dir)
+	if neighbor == nil {
+		g.message = "there is no island that way"
+		return
+	}
+	if g.solved {
+		g.message = "the puzzle is already solved: u takes a move back"
+		return
+	}
+
+	from := hashisolver.Position{X: g.cursor.XPos(), Y: g.cursor.YPos()}
+	to := hashisolver.Position{X: neighbor.XPos(), Y: neighbor.YPos()}
+	count := g.cursor.BridgesInDirection(dir)
+	if err := g.puzzle.AddBridge(from, to); err != nil {
+		if count == 0 {
+			g.mistakes++
+			g.message = strings.TrimPrefix(err.Error(), hashisolver.ErrIllegalBridge.Error()+": ")
+			return
+		}
+		for ; count > 0; count-- {
+			g.puzzle.RemoveBridge(from, to)
+			g.recordLast()
+		}
+	} else {
+		g.recordLast()
+	}
+	g.check()
+}
+
+// record keeps a move made on the board for the replay
+func (g *game) record(move hashisolver.Move) {
+	g.moves = append(g.moves, hashisolver.TimedMove{Move: move, At: g.now().Sub(g.started)})
+}
+
+// recordLast keeps the move just made with AddBridge or RemoveBridge
+func (g *game) recordLast() {
+	moves := g.puzzle.History().Moves()
+	g.record(moves[len(moves)-1])
+}
+
+// replay returns the moves made so far as a replay of the game
+func (g *game) replay() *hashisolver.Replay {
+	return &hashisolver.Replay{Source: "play", Puzzle: g.clues, Moves: g.moves}
+}
+
+// hint moves the cursor to the island a hint starts from and shows the
+// bridge to add there and why
+func (g *game) hint() {
+	hint, err := g.puzzle.Hint()
+	switch {
+	case errors.Is(err, hashisolver.ErrAlreadySolved):
+		g.message = "the puzzle is already solved"
+		return
+	case errors.Is(err, hashisolver.ErrNoSolution):
+		g.message = "some of the bridges placed so far are wrong: undo back to where they weren't"
+		return
+	case err != nil:
+		g.message = err.Error()
+		return
+	}
+
+	g.hints++
+	move := hint.Move
+	g.cursor = g.puzzle.At(move.From.X, move.From.Y)
+	g.message = fmt.Sprintf("hint: add a bridge to (%d,%d), making %d. %s", move.To.X, move.To.Y, move.Count, hint.Explanation)
+}
+
+// check sees whether every island has its bridges, and if so has the
+// verifier check the board. The first time it is right the game is scored.
+func (g *game) check() {
+	g.solved = false
+	if 2*g.puzzle.PlacedBridges() != g.puzzle.FullBridges {
+		return
+	}
+	violations := hashisolver.VerifyBridges(g.clues, g.puzzle.Bridges())
+	if len(violations) > 0 {
+		g.message = fmt.Sprintf("not quite: %v", violations[0])
+		return
+	}
+	g.solved = true
+	if !g.finished.IsZero() {
+		g.message = "solved again!"
+		return
+	}
+	g.finished = g.now()
+	g.message = g.finish()
+}
+
+// finish scores the solved game, records it if it is a personal best and
+// says how it went
+func (g *game) finish() string {
+	moves := 0
+	if history := g.puzzle.History(); history != nil {
+		moves = len(history.Moves())
+	}
+	message := fmt.Sprintf("solved in %d moves and %v", moves, g.elapsed())
+	penalties := []string{}
+	if g.hints > 0 {
+		penalties = append(penalties, plural(g.hints, "hint"))
+	}
+	if g.mistakes > 0 {
+		penalties = append(penalties, plural(g.mistakes, "mistake"))
+	}
+	if len(penalties) > 0 {
+		message += " with " + strings.Join(penalties, " and ")
+	}
+
+	points := score(g.islands, g.elapsed(), g.hints, g.mistakes)
+	message += fmt.Sprintf(": %d points", points)
+	if g.statsFile == "" {
+		return message
+	}
+	finish := personalBest{Score: points, Seconds: g.elapsed().Seconds(), Hints: g.hints, Mistakes: g.mistakes,
+		Date: g.finished.Format("2006-01-02")}
+	best, ok, err := recordBest(g.statsFile, g.clues.Hash(), finish)
+	switch {
+	case err != nil:
+		return message + fmt.Sprintf(" (the personal best couldn't be saved: %v)", err)
+	case ok && best.Score >= points:
+		return message + fmt.Sprintf(" (your best is %d)", best.Score)
+	case ok:
+		return message + fmt.Sprintf(", beating your best of %d!", best.Score)
+	}
+	return message + "!"
+}
+
+// plural writes n of a noun, made plural unless n is 1
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// lines draws the board with the cursor on it, then the status bar and help
+func (g *game) lines() []string {
+	lines := boardLines(g.puzzle, g.charset, func(x, y int) bool {
+		return x == g.cursor.XPos() && y == g.cursor.YPos()
+	}, highlight)
+	status := fmt.Sprintf("(%d,%d) %d of %d bridges  %d of %d placed  %v  %s  %s", g.cursor.XPos(), g.cursor.YPos(),
+		g.cursor.TotalBridges, g.cursor.Value(), g.puzzle.PlacedBridges(), g.puzzle.FullBridges/2,
+		g.elapsed(), plural(g.hints, "hint"), plural(g.mistakes, "mistake"))
+	return append(lines, "", status, g.message, playKeys)
+}
+
+// boardLines draws a board for the terminal with satisfied islands in green
+// and over-full ones in red. The cells marked are drawn with mark, along with
+// the gap between two of them on a row.
+func boardLines(puzzle *hashisolver.Puzzle, charset hashisolver.Charset, marked func(x, y int) bool, mark func(string) string) []string {
+	lines := []string{}
+	for y, row := range hashisolver.DrawMap(puzzle, charset) {
+		symbols := []rune(row)
+		var line strings.Builder
+		line.WriteByte(' ')
+		for x, symbol := range symbols {
+			cell := string(symbol)
+			node := puzzle.At(x, y)
+			switch {
+			case node.Value() <= 0 && symbol == ' ':
+				cell = "."
+			case node.Value() > 0 && node.TotalBridges > node.Value():
+				cell = colored(cell, colorRed)
+			case node.Value() > 0 && node.TotalBridges == node.Value():
+				cell = colored(cell, colorGreen)
+			}
+			if marked(x, y) {
+				cell = mark(cell)
+			}
+			line.WriteString(cell)
+
+			// Horizontal bridges carry on through the gap between cells
+			if x+1 < len(symbols) {
+				gap := ' '
+				if strings.ContainsRune(horizontalBridges, symbols[x+1]) {
+					gap = symbols[x+1]
+				} else if strings.ContainsRune(horizontalBridges, symbol) {
+					gap = symbol
+				}
+				if marked(x, y) && marked(x+1, y) {
+					line.WriteString(mark(string(gap)))
+				} else {
+					line.WriteRune(gap)
+				}
+			}
+		}
+		lines = append(lines, line.String())
+	}
+	return lines
+}
+
+// horizontalBridges are the characters horizontal bridges are drawn with in
+// every charset
+const horizontalBridges = "-=E─═≡"
+
+// onBridge returns a function reporting whether a cell is on the straight
+// line from one island to another, the islands included
+func onBridge(from, to hashisolver.Position) func(x, y int) bool {
+	if to.X < from.X || to.Y < from.Y {
+		from, to = to, from
+	}
+	return func(x, y int) bool {
+		return x >= from.X && x <= to.X && y >= from.Y && y <= to.Y
+	}
+}
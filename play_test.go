@@ -0,0 +1,212 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"hashi/hashisolver"
+)
+
+// TestPlay tests moving between islands, building and clearing bridges with
+// the play keys, undoing a move and noticing when the puzzle is solved
+func TestPlay(t *testing.T) {
+	puzzle, err := hashisolver.ParseText(strings.NewReader("1.3\n...\n..2\n"), hashisolver.DefaultRuleSet, hashisolver.ParseOptions{})
+	if err != nil {
+		t.Fatalf("Failed to parse puzzle: %v", err)
+	}
+	g, err := newGame(puzzle, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.now = func() time.Time { return g.started }
+	press := func(keys ...key) {
+		for _, k := range keys {
+			if g.handle(k) {
+				t.Fatalf("Expected %v not to close the game", k)
+			}
+		}
+	}
+	board := func() string { return strings.Join(hashisolver.MapLines(g.puzzle), "\n") }
+
+	press('S')
+	if g.message != "there is no island that way" {
+		t.Errorf("Expected a bridge to nowhere to be refused, got %q", g.message)
+	}
+	press('D', 'D')
+	if board() != "1 3\n   \n  2" {
+		t.Errorf("Expected a bridge to a full island to cycle back to none, got\n%s", board())
+	}
+	press('D', 'd', 'S')
+	if g.solved || !strings.Contains(g.lines()[0], colored("1", colorGreen)) {
+		t.Errorf("Expected the satisfied 1 in green and the puzzle unsolved, got %q", g.lines()[0])
+	}
+	press(keyShiftDown)
+	if !g.solved || g.message != "solved in 5 moves and 0s: 60 points" || board() != "1-3\n  \"\n  2" {
+		t.Errorf("Expected the puzzle solved, got %q and\n%s", g.message, board())
+	}
+	if line := g.lines()[0]; !strings.Contains(line, "--") {
+		t.Errorf("Expected the bridge drawn through the gaps, got %q", line)
+	}
+
+	press('S')
+	if !g.solved || board() != "1-3\n  \"\n  2" {
+		t.Errorf("Expected no more moves once solved, got\n%s", board())
+	}
+	press('u')
+	if g.solved || board() != "1-3\n  |\n  2" {
+		t.Errorf("Expected the last bridge undone, got\n%s", board())
+	}
+	press('r')
+	if !g.solved || g.message != "solved again!" {
+		t.Errorf("Expected the redone move to solve the puzzle again, got %q", g.message)
+	}
+
+	// The replay has the undo as a move of its own
+	replay := g.replay()
+	if len(replay.Moves) != 7 {
+		t.Fatalf("Expected 7 moves in the replay, got %d", len(replay.Moves))
+	}
+	for n, want := range map[int]string{6: "1-3\n  |\n  2", 7: board()} {
+		got, err := replay.Board(n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lines := strings.Join(hashisolver.MapLines(got), "\n"); lines != want {
+			t.Errorf("Expected the board after %d moves of the replay to be\n%s\ngot\n%s", n, want, lines)
+		}
+	}
+	if !g.handle('q') {
+		t.Error("Expected q to close the game")
+	}
+}
+
+// TestPlayHints tests that a hint points at a bridge to add and is counted,
+// and that a board with a wrong bridge on it is owned up to instead
+func TestPlayHints(t *testing.T) {
+	puzzle, err := hashisolver.ParseText(strings.NewReader("2.4\n...\n1.3\n"), hashisolver.DefaultRuleSet, hashisolver.ParseOptions{})
+	if err != nil {
+		t.Fatalf("Failed to parse puzzle: %v", err)
+	}
+	g, err := newGame(puzzle, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.now = func() time.Time { return g.started }
+
+	g.handle('S')
+	g.handle('h')
+	if !strings.Contains(g.message, "wrong") || g.hints != 0 {
+		t.Errorf("Expected the wrong bridge to be pointed out, got %q", g.message)
+	}
+	g.handle('u')
+	g.handle('h')
+	if !strings.HasPrefix(g.message, "hint: add a bridge to ") || g.hints != 1 {
+		t.Errorf("Expected a hint, got %q", g.message)
+	}
+
+	// Following every hint solves the puzzle
+	for i := 0; i < 10 && !g.solved; i++ {
+		hint, err := g.puzzle.Hint()
+		if err != nil {
+			t.Fatalf("Failed to get a hint: %v", err)
+		}
+		if err := g.puzzle.AddBridge(hint.Move.From, hint.Move.To); err != nil {
+			t.Fatalf("Failed to follow the hint %v: %v", hint.Move, err)
+		}
+		g.check()
+	}
+	if !g.solved || g.message != "solved in 5 moves and 0s with 1 hint: 60 points" {
+		t.Errorf("Expected the puzzle solved with the hint counted, got %q", g.message)
+	}
+}
+
+// TestPlayScore tests that mistakes are counted, that a solved game is
+// scored by its time, hints and mistakes, and that only a higher score
+// replaces the personal best kept for the puzzle
+func TestPlayScore(t *testing.T) {
+	stats := filepath.Join(t.TempDir(), "hashi", "stats.json")
+	play := func(took time.Duration, mistakes int) string {
+		puzzle, err := hashisolver.ParseText(strings.NewReader("2.4\n...\n1.3\n"), hashisolver.DefaultRuleSet, hashisolver.ParseOptions{})
+		if err != nil {
+			t.Fatalf("Failed to parse puzzle: %v", err)
+		}
+		g, err := newGame(puzzle, stats)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g.now = func() time.Time { return g.started.Add(took) }
+		g.handle('D')
+		g.handle('D')
+		for i := 0; i < mistakes; i++ {
+			g.handle('S')
+		}
+		if g.mistakes != mistakes {
+			t.Errorf("Expected %d mistakes, got %d", mistakes, g.mistakes)
+		}
+		for _, k := range []key{'d', 'S', 'S', 's', 'A'} {
+			g.handle(k)
+		}
+		if !g.solved {
+			t.Fatalf("Expected the puzzle solved, got %q", g.message)
+		}
+		return g.message
+	}
+
+	if got := play(50*time.Second, 1); got != "solved in 5 moves and 50s with 1 mistake: 65 points!" {
+		t.Errorf("Expected the first game scored, got %q", got)
+	}
+	if got := play(5*time.Minute, 0); got != "solved in 5 moves and 5m0s: 20 points (your best is 65)" {
+		t.Errorf("Expected a slower game not to beat the best, got %q", got)
+	}
+	if got := play(10*time.Second, 0); got != "solved in 5 moves and 10s: 78 points, beating your best of 65!" {
+		t.Errorf("Expected a quicker game to beat the best, got %q", got)
+	}
+	bests, err := loadBests(stats)
+	if err != nil || len(bests) != 1 {
+		t.Fatalf("Expected one personal best kept, got %v, %v", bests, err)
+	}
+	for _, best := range bests {
+		if best.Score != 78 || best.Seconds != 10 {
+			t.Errorf("Expected the quicker game kept, got %+v", best)
+		}
+	}
+}
+
+// TestPlayMouse tests building bridges by dragging from an island and
+// cycling them by clicking
+func TestPlayMouse(t *testing.T) {
+	puzzle, err := hashisolver.ParseText(strings.NewReader("1.3\n...\n..2\n"), hashisolver.DefaultRuleSet, hashisolver.ParseOptions{})
+	if err != nil {
+		t.Fatalf("Failed to parse puzzle: %v", err)
+	}
+	g, err := newGame(puzzle, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	board := func() string { return strings.Join(hashisolver.MapLines(g.puzzle), "\n") }
+	drag := func(x0, y0, x1, y1 int) {
+		g.click(mouseEvent{x: x0, y: y0})
+		g.click(mouseEvent{x: x1, y: y1, release: true})
+	}
+
+	// Cells are drawn at every other column after a column of margin
+	drag(1, 0, 4, 0)
+	if board() != "1-3\n   \n  2" {
+		t.Errorf("Expected a drag to the right to build a bridge, got\n%s", board())
+	}
+	drag(3, 0, 3, 0)
+	if board() != "1 3\n   \n  2" {
+		t.Errorf("Expected clicking a full bridge to clear it, got\n%s", board())
+	}
+	drag(6, 0, 1, 1)
+	if board() != "1-3\n   \n  2" || g.cursor.XPos() != 2 {
+		t.Errorf("Expected a drag from the gap after an island to start from it, got\n%s", board())
+	}
+	drag(5, 0, 5, 2)
+	drag(5, 1, 5, 1)
+	if !g.solved || board() != "1-3\n  \"\n  2" {
+		t.Errorf("Expected the puzzle solved with the mouse, got %q and\n%s", g.message, board())
+	}
+}
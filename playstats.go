@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Points a finished game scores for each island and loses for each hint and
+// mistake, and how many seconds cost a point
+const (
+	pointsPerIsland  = 20
+	pointsPerHint    = 20
+	pointsPerMistake = 5
+	secondsPerPoint  = 5
+)
+
+// score rates a finished game on a puzzle with the given number of islands.
+// It never goes below 0.
+func score(islands int, elapsed time.Duration, hints, mistakes int) int {
+	points := islands*pointsPerIsland - int(elapsed.Seconds())/secondsPerPoint - hints*pointsPerHint - mistakes*pointsPerMistake
+	if points < 0 {
+		return 0
+	}
+	return points
+}
+
+// personalBest is the highest scoring finish of one puzzle
+type personalBest struct {
+	Score    int     `json:"score"`
+	Seconds  float64 `json:"seconds"`
+	Hints    int     `json:"hints"`
+	Mistakes int     `json:"mistakes"`
+	Date     string  `json:"date"`
+}
+
+// statsPath returns the file personal bests are kept in: $HASHI_STATS if set,
+// otherwise stats.json in the hashi data directory
+func statsPath() string {
+	if path := os.Getenv("HASHI_STATS"); path != "" {
+		return path
+	}
+
+	dir := os.Getenv("XDG_DATA_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dir, "hashi", "stats.json")
+}
+
+// loadBests reads the personal bests by the puzzle's Hash, returning none if
+// the file doesn't exist yet
+func loadBests(path string) (map[string]personalBest, error) {
+	bests := map[string]personalBest{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return bests, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &bests); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return bests, nil
+}
+
+// recordBest saves the finish as the puzzle's personal best if it scores
+// higher than the one kept, and returns the best before it, if any
+func recordBest(path, hash string, finish personalBest) (personalBest, bool, error) {
+	if path == "" {
+		return personalBest{}, false, errors.New("there is nowhere to keep personal bests: set $HASHI_STATS")
+	}
+	bests, err := loadBests(path)
+	if err != nil {
+		return personalBest{}, false, err
+	}
+	best, ok := bests[hash]
+	if ok && best.Score >= finish.Score {
+		return best, true, nil
+	}
+
+	bests[hash] = finish
+	data, err := json.MarshalIndent(bests, "", "  ")
+	if err != nil {
+		return best, ok, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return best, ok, err
+	}
+	return best, ok, os.WriteFile(path, append(data, '\n'), 0o644)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+)
+
+// profileFlags are the flags for profiling a run with runtime/pprof and
+// runtime/trace
+type profileFlags struct {
+	cpu, mem, trace string
+}
+
+// register adds the profiling flags to fs. The execution trace flag isn't
+// called -trace, as solve already uses that for the technique trace.
+func (f *profileFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.cpu, "cpuprofile", "", "Write a CPU profile to this file")
+	fs.StringVar(&f.mem, "memprofile", "", "Write a heap profile to this file when done")
+	fs.StringVar(&f.trace, "exectrace", "", "Write a runtime execution trace to this file")
+}
+
+// start begins whichever profiles were asked for, exiting with a message if
+// one can't be started. The returned function finishes them and writes the
+// heap profile, and must be called before exiting.
+func (f *profileFlags) start() func() {
+	var stops []func()
+	stop := func() {
+		for i := len(stops) - 1; i >= 0; i-- {
+			stops[i]()
+		}
+		stops = nil
+	}
+	fail := func(what string, err error) {
+		stop()
+		fmt.Fprintf(os.Stderr, "Error starting %s: %v\n", what, err)
+		os.Exit(1)
+	}
+
+	if f.cpu != "" {
+		file, err := os.Create(f.cpu)
+		if err != nil {
+			fail("CPU profile", err)
+		}
+		if err := pprof.StartCPUProfile(file); err != nil {
+			file.Close()
+			fail("CPU profile", err)
+		}
+		stops = append(stops, func() {
+			pprof.StopCPUProfile()
+			file.Close()
+		})
+	}
+
+	if f.trace != "" {
+		file, err := os.Create(f.trace)
+		if err != nil {
+			fail("execution trace", err)
+		}
+		if err := trace.Start(file); err != nil {
+			file.Close()
+			fail("execution trace", err)
+		}
+		stops = append(stops, func() {
+			trace.Stop()
+			file.Close()
+		})
+	}
+
+	if f.mem != "" {
+		stops = append(stops, func() {
+			if err := writeHeapProfile(f.mem); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing heap profile: %v\n", err)
+			}
+		})
+	}
+
+	return stop
+}
+
+// writeHeapProfile writes the heap profile as of the last garbage collection
+func writeHeapProfile(name string) error {
+	file, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestProfileFlags tests that each profile asked for is written once stopped
+func TestProfileFlags(t *testing.T) {
+	dir := t.TempDir()
+	profile := profileFlags{
+		cpu:   filepath.Join(dir, "cpu.out"),
+		mem:   filepath.Join(dir, "mem.out"),
+		trace: filepath.Join(dir, "trace.out"),
+	}
+
+	stop := profile.start()
+	stop()
+	stop()
+
+	for _, name := range []string{profile.cpu, profile.mem, profile.trace} {
+		if info, err := os.Stat(name); err != nil || info.Size() == 0 {
+			t.Errorf("Expected %s to be written (%v)", filepath.Base(name), err)
+		}
+	}
+}
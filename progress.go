@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"hashi/hashisolver"
+)
+
+// progressBarWidth is the number of characters in the bar itself
+const progressBarWidth = 30
+
+// isTerminal reports whether f is attached to a terminal rather than a pipe or file
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// progressBar draws the solver's progress on a single line of stderr
+type progressBar struct {
+	drawn bool
+}
+
+// update redraws the bar for the latest progress
+func (b *progressBar) update(p hashisolver.Progress) {
+	filled := 0
+	if p.TotalBridges > 0 {
+		filled = p.BuiltBridges * progressBarWidth / p.TotalBridges
+	}
+	if filled > progressBarWidth {
+		filled = progressBarWidth
+	}
+
+	fmt.Fprintf(os.Stderr, "\r\033[K[%s%s] %d/%d bridges, depth %d",
+		strings.Repeat("#", filled), strings.Repeat(".", progressBarWidth-filled),
+		p.BuiltBridges, p.TotalBridges, p.Depth)
+	b.drawn = true
+}
+
+// clear erases the bar, if it was ever drawn, so later output starts on a clean line
+func (b *progressBar) clear() {
+	if b.drawn {
+		fmt.Fprint(os.Stderr, "\r\033[K")
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"hashi/hashisolver"
+)
+
+// TestRenderers tests that output formats are found by name and can be added
+func TestRenderers(t *testing.T) {
+	puzzle, err := hashisolver.Solve(strings.NewReader("2.2\n...\n"), false)
+	if err != nil {
+		t.Fatalf("Failed to solve puzzle: %v", err)
+	}
+
+	for format, want := range map[string]string{
+		"text":    "2=2\n   \n",
+		"puzzle":  "2.2\n...\n",
+		"bridges": "0,0 2,0 2\n",
+		"accessible": "Board of 3 columns and 2 rows with 2 islands and 2 bridges.\n" +
+			"Island at row 1 column 1, value 2, connected right with a double bridge to the island at row 1 column 3.\n" +
+			"Island at row 1 column 3, value 2, connected left with a double bridge to the island at row 1 column 1.\n",
+	} {
+		renderer, err := hashisolver.LookupRenderer(format)
+		if err != nil {
+			t.Fatalf("Failed to look up %s: %v", format, err)
+		}
+		var buf bytes.Buffer
+		if err := renderer(&buf, puzzle); err != nil || buf.String() != want {
+			t.Errorf("Expected %s output %q, got %q (%v)", format, want, buf.String(), err)
+		}
+	}
+
+	if _, err := hashisolver.LookupRenderer("nope"); err == nil || !strings.Contains(err.Error(), "accessible, bridges, dot, gif, graphml, json, png, puzzle, svg, terminal-image, text") {
+		t.Errorf("Expected an error listing the formats, got %v", err)
+	}
+
+	hashisolver.RegisterRenderer("placed", func(w io.Writer, p *hashisolver.Puzzle) error {
+		_, err := io.WriteString(w, "placed\n")
+		return err
+	})
+	if _, err := hashisolver.LookupRenderer("placed"); err != nil {
+		t.Errorf("Failed to look up a registered renderer: %v", err)
+	}
+
+	// Solution files are named with the format's extension
+	hashisolver.RegisterRendererExtension("placed", ".placed")
+	for format, want := range map[string]string{"text": "q.solution.txt", "json": "q.solution.json", "png": "q.solution.png", "svg": "q.solution.svg", "placed": "q.solution.placed"} {
+		if got := solutionName("q.txt", format); got != want {
+			t.Errorf("Expected the %s solution of q.txt to be %s, got %s", format, want, got)
+		}
+	}
+}
+
+// TestCharset tests that boards are drawn with box-drawing characters in the
+// unicode charset, and that auto keeps to ASCII away from a terminal
+func TestCharset(t *testing.T) {
+	puzzle, err := hashisolver.Solve(strings.NewReader("2.3\n...\n..1\n"), false)
+	if err != nil {
+		t.Fatalf("Failed to solve puzzle: %v", err)
+	}
+	if got := strings.Join(hashisolver.DrawMap(puzzle, hashisolver.CharsetUnicode), "/"); got != "2═3/  │/  1" {
+		t.Errorf("Expected the board in box-drawing characters, got %q", got)
+	}
+	if got := strings.Join(hashisolver.MapLines(puzzle), "/"); got != "2=3/  |/  1" {
+		t.Errorf("Expected the board in ASCII, got %q", got)
+	}
+
+	file, err := os.CreateTemp(t.TempDir(), "board")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	for name, want := range map[string]hashisolver.Charset{"auto": hashisolver.CharsetASCII, "unicode": hashisolver.CharsetUnicode} {
+		flag := charsetFlag{name: name}
+		if got := flag.charset(file); got != want {
+			t.Errorf("Expected -charset %s to draw a file in %s, got %s", name, want, got)
+		}
+	}
+}
+
+// TestColor tests that the text format in color tells apart islands that
+// are done from those that aren't, and bridges deduced from those guessed
+func TestColor(t *testing.T) {
+	puzzle, err := hashisolver.Solve(strings.NewReader("2.3\n...\n..1\n"), false)
+	if err != nil {
+		t.Fatalf("Failed to solve puzzle: %v", err)
+	}
+	moves := map[[2]hashisolver.Position]hashisolver.Move{
+		{{X: 0, Y: 0}, {X: 2, Y: 0}}: {Count: 2},
+		{{X: 2, Y: 0}, {X: 2, Y: 2}}: {Count: 1, Speculative: true},
+	}
+	lines := coloredMap(puzzle, hashisolver.CharsetASCII, moves)
+	want := []string{
+		colored("2", colorCyan) + colored("=", colorGreen) + colored("3", colorCyan),
+		"  " + colored("|", colorYellow),
+		"  " + colored("1", colorCyan),
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Expected\n%q\ngot\n%q", want, lines)
+	}
+
+	lines = coloredMap(puzzle.Clues(), hashisolver.CharsetASCII, nil)
+	if lines[0] != colored("2", colorRed)+" "+colored("3", colorRed) {
+		t.Errorf("Expected the islands without bridges in red, got %q", lines[0])
+	}
+
+	output := outputFlags{color: "auto"}
+	t.Setenv("NO_COLOR", "1")
+	if output.colorText(os.Stdout) {
+		t.Error("Expected NO_COLOR to turn off -color auto")
+	}
+	if output.color = "always"; !output.colorText(os.Stdout) {
+		t.Error("Expected -color always to color even with NO_COLOR")
+	}
+}
+
+// TestLargeMap tests that a board drawn large has its bridges stretch across
+// the gaps between cells and rows up to the islands
+func TestLargeMap(t *testing.T) {
+	puzzle, err := hashisolver.Solve(strings.NewReader("2.3\n...\n..1\n"), false)
+	if err != nil {
+		t.Fatalf("Failed to solve puzzle: %v", err)
+	}
+	lines := hashisolver.LargeMap(hashisolver.MapLines(puzzle), nil)
+	want := []string{" 2=====3", "       |", "       |", "       |", "       1"}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Expected\n%s\ngot\n%s", strings.Join(want, "\n"), strings.Join(lines, "\n"))
+	}
+
+	labelled := hashisolver.LabelLargeMap(lines, puzzle.Cols)
+	if labelled[0] != "   A  B  C" || labelled[1] != "1  2=====3" || labelled[2] != "         |" || labelled[5] != "3        1" {
+		t.Errorf("Expected the labels over the middle of each cell and beside each row, got\n%s", strings.Join(labelled, "\n"))
+	}
+}
+
+// TestTerminalImage tests that the terminal-image format frames the board in
+// each protocol's escape sequences, with the PNG whole inside kitty's chunks
+func TestTerminalImage(t *testing.T) {
+	puzzle, err := hashisolver.Solve(strings.NewReader("2.2\n...\n"), false)
+	if err != nil {
+		t.Fatalf("Failed to solve puzzle: %v", err)
+	}
+	var want bytes.Buffer
+	if err := hashisolver.WritePNG(&want, puzzle); err != nil {
+		t.Fatalf("Failed to write PNG: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := hashisolver.TerminalImageRenderer(hashisolver.ImageKitty)(&buf, puzzle); err != nil {
+		t.Fatalf("Failed to write kitty image: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "\033_Gf=100,a=T,m=0;") || !strings.HasSuffix(buf.String(), "\033\\\n") {
+		t.Errorf("Expected a single kitty graphics chunk, got %q", buf.String())
+	}
+	data := strings.TrimSuffix(strings.TrimPrefix(buf.String(), "\033_Gf=100,a=T,m=0;"), "\033\\\n")
+	if png, err := base64.StdEncoding.DecodeString(data); err != nil || !bytes.Equal(png, want.Bytes()) {
+		t.Errorf("Expected the kitty chunk to hold the PNG (%v)", err)
+	}
+
+	buf.Reset()
+	if err := hashisolver.TerminalImageRenderer(hashisolver.ImageSixel)(&buf, puzzle); err != nil {
+		t.Fatalf("Failed to write sixel image: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "\033Pq\"1;1;96;64#0;2;100;100;100") || !strings.HasSuffix(buf.String(), "-\033\\\n") {
+		t.Errorf("Expected a sixel image 96 by 64 on white, got %q", buf.String())
+	}
+	if strings.Count(buf.String(), "-") != 64/6+1 {
+		t.Errorf("Expected a sixel band for every six rows, got %q", buf.String())
+	}
+}
+
+// TestLegend tests that the footer names the bridge symbols up to the most a
+// pair may take and sums up the solve
+func TestLegend(t *testing.T) {
+	puzzle, err := hashisolver.Solve(strings.NewReader("2.3\n...\n..1\n"), false)
+	if err != nil {
+		t.Fatalf("Failed to solve puzzle: %v", err)
+	}
+	moves := []hashisolver.Move{
+		{Technique: hashisolver.TechniqueOneDirection},
+		{Technique: hashisolver.TechniqueForcedShare},
+		{Technique: hashisolver.TechniqueOneDirection},
+	}
+	var buf bytes.Buffer
+	if err := hashisolver.WriteLegend(&buf, puzzle, hashisolver.CharsetUnicode, hashisolver.Summarize(puzzle, moves, 1500*time.Microsecond)); err != nil {
+		t.Fatalf("Failed to write legend: %v", err)
+	}
+	want := "Legend: numbers and letters are islands and their clues; across ─ single bridge, ═ double bridge; down │ single bridge, ║ double bridge\n" +
+		"Summary: 3 islands, 3 of 3 bridges placed, techniques used: only one direction open, forced share, solved in 1.5ms\n"
+	if buf.String() != want {
+		t.Errorf("Expected\n%s\ngot\n%s", want, buf.String())
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"hashi/hashisolver"
+)
+
+// replayKeys is the help line shown under the board
+const replayKeys = "right/n/space next move  left/p previous  g first  G last  q quit"
+
+// runReplay steps through a replay written by solve -record or play -record
+// in the terminal, or writes it as an animated GIF
+func runReplay(args []string) {
+	var maxBridges int
+	var format, outputFile string
+	var delay time.Duration
+	var charset charsetFlag
+
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	fs.IntVar(&maxBridges, "max-bridges", 2, "Maximum bridges between a pair of islands (3 for bridgen puzzles)")
+	fs.StringVar(&format, "output", "terminal", "Output: terminal to step through the moves, or gif to write them as an animation to -o")
+	fs.StringVar(&outputFile, "o", "", "With -output gif, the file to write (defaults to stdout)")
+	fs.DurationVar(&delay, "delay", 200*time.Millisecond, "With -output gif, how long to show each move for")
+	charset.register(fs)
+	parseFlags(fs, args)
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Error: expected the replay file to step through")
+		os.Exit(2)
+	}
+
+	rules := hashisolver.DefaultRuleSet
+	if maxBridges != rules.MaxBridgesPerPair {
+		rules = hashisolver.RuleSetForMaxBridges(maxBridges)
+	}
+	replay, err := loadReplay(fs.Arg(0), rules)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	switch format {
+	case "terminal":
+	case "gif":
+		if err := writeFileOrStdout(outputFile, func(w io.Writer) error {
+			return hashisolver.WriteReplayGIF(w, replay, hashisolver.DefaultImageStyle, delay)
+		}); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown output %q (expected terminal or gif)\n", format)
+		os.Exit(2)
+	}
+
+	term, err := openTerminal()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer term.restore()
+
+	v := &replayViewer{replay: replay, charset: charset.charset(os.Stdout)}
+	events := term.events()
+	for {
+		term.draw(v.lines())
+		ev, ok := <-events
+		if !ok || ev.mouse == nil && v.handle(ev.key) {
+			return
+		}
+	}
+}
+
+// recordSolve solves the puzzle like SolveWithStats, keeping each move made
+// with how long into the solve it came as a replay. If onMove is set it is
+// called with the replay after each move, and the solve waits for it.
+func recordSolve(ctx context.Context, puzzle *hashisolver.Puzzle, opts []hashisolver.Option, onMove func(*hashisolver.Replay)) (*hashisolver.SolveResult, *hashisolver.Replay, error) {
+	replay := &hashisolver.Replay{Source: "solver", Puzzle: puzzle.Clues()}
+	moves := make(chan hashisolver.Move)
+	done := make(chan struct{})
+	start := time.Now()
+	go func() {
+		defer close(done)
+		for move := range moves {
+			replay.Moves = append(replay.Moves, hashisolver.TimedMove{Move: move, At: time.Since(start)})
+			if onMove != nil {
+				onMove(replay)
+			}
+		}
+	}()
+	result, err := hashisolver.SolveStream(ctx, puzzle, moves, opts...)
+	<-done
+	return result, replay, err
+}
+
+// lastMoves returns the move that left each pair of islands as it is at the
+// end of the replay, keyed by the pair with its left or top end first
+func lastMoves(replay *hashisolver.Replay) map[[2]hashisolver.Position]hashisolver.Move {
+	moves := map[[2]hashisolver.Position]hashisolver.Move{}
+	for _, move := range replay.Made(len(replay.Moves)) {
+		pair := [2]hashisolver.Position{move.From, move.To}
+		if move.To.Y < move.From.Y || move.To.X < move.From.X {
+			pair = [2]hashisolver.Position{move.To, move.From}
+		}
+		moves[pair] = move
+	}
+	return moves
+}
+
+// saveReplay writes a replay to a file
+func saveReplay(path string, replay *hashisolver.Replay) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := hashisolver.WriteReplay(file, replay); err != nil {
+		file.Close()
+		return fmt.Errorf("writing %s: %v", path, err)
+	}
+	return file.Close()
+}
+
+// loadReplay reads a replay from a file
+func loadReplay(path string, rules hashisolver.RuleSet) (*hashisolver.Replay, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	replay, err := hashisolver.ReadReplay(file, rules)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return replay, nil
+}
+
+// replayViewer is the position reached in a replay being stepped through
+type replayViewer struct {
+	replay  *hashisolver.Replay
+	charset hashisolver.Charset
+	// shown is the number of moves made on the board shown
+	shown int
+}
+
+// handle acts on a key and reports whether the viewer should close
+func (v *replayViewer) handle(k key) bool {
+	switch k {
+	case keyRight, 'n', ' ', 'l':
+		if v.shown < len(v.replay.Moves) {
+			v.shown++
+		}
+	case keyLeft, 'p', 'h':
+		if v.shown > 0 {
+			v.shown--
+		}
+	case 'g':
+		v.shown = 0
+	case 'G':
+		v.shown = len(v.replay.Moves)
+	case 'q', keyInterrupt:
+		return true
+	}
+	return false
+}
+
+// lines draws the board after the moves shown, with the bridge the last one
+// changed highlighted, then what it was and the help
+func (v *replayViewer) lines() []string {
+	board, err := v.replay.Board(v.shown)
+	if err != nil {
+		return []string{err.Error(), "", replayKeys}
+	}
+	if v.shown == 0 {
+		lines := boardLines(board, v.charset, func(x, y int) bool { return false }, highlight)
+		status := fmt.Sprintf("start of %d moves recorded by %s", len(v.replay.Moves), v.replay.Source)
+		return append(lines, "", status, "", replayKeys)
+	}
+
+	move := v.replay.Moves[v.shown-1]
+	marked := onBridge(move.From, move.To)
+	if move.Backtrack {
+		marked = func(x, y int) bool { return false }
+	}
+	lines := boardLines(board, v.charset, marked, highlight)
+	// The solver's moves come microseconds apart, and play's seconds
+	at := move.At.Round(time.Millisecond)
+	if move.At < 10*time.Millisecond {
+		at = move.At.Round(time.Microsecond)
+	}
+	status := fmt.Sprintf("move %d of %d at %v  %s", v.shown, len(v.replay.Moves), at, move)
+	return append(lines, "", status, move.Reason, replayKeys)
+}
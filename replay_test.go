@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"hashi/hashisolver"
+)
+
+// TestReplay tests that a recorded solve survives being written and read
+// back, and that stepping through it ends on the solution with the failed
+// guess undone
+func TestReplay(t *testing.T) {
+	// One of the guesses on this board fails, so the replay includes a backtrack
+	puzzle, err := hashisolver.Parse(strings.NewReader("2..3..2.\n........\n2..4..3.\n........\n.2.4.1..\n1...1.3.\n.1......\n2..3..2.\n"), hashisolver.DefaultRuleSet)
+	if err != nil {
+		t.Fatalf("Failed to parse puzzle: %v", err)
+	}
+	result, recorded, err := recordSolve(context.Background(), puzzle, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to solve puzzle: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := hashisolver.WriteReplay(&buf, recorded); err != nil {
+		t.Fatal(err)
+	}
+	replay, err := hashisolver.ReadReplay(&buf, hashisolver.DefaultRuleSet)
+	if err != nil {
+		t.Fatalf("Failed to read the replay back: %v", err)
+	}
+	if replay.Source != "solver" || len(replay.Moves) != len(recorded.Moves) {
+		t.Fatalf("Expected %d moves by the solver, read %d by %q", len(recorded.Moves), len(replay.Moves), replay.Source)
+	}
+
+	backtrack := -1
+	for i, move := range replay.Moves {
+		if move.Move != recorded.Moves[i].Move {
+			t.Errorf("Move %d was read back as %+v, expected %+v", i+1, move.Move, recorded.Moves[i].Move)
+		}
+		if i > 0 && move.At < replay.Moves[i-1].At {
+			t.Errorf("Move %d is timed before the one before it", i+1)
+		}
+		if move.Backtrack && backtrack < 0 {
+			backtrack = i
+		}
+	}
+	if backtrack < 0 {
+		t.Fatal("Expected the replay to include a backtrack")
+	}
+
+	// The guess abandoned by the backtrack leaves fewer bridges than before it
+	before, _ := replay.Board(backtrack)
+	after, _ := replay.Board(backtrack + 1)
+	if after.BuiltBridges >= before.BuiltBridges {
+		t.Errorf("Expected the backtrack to take bridges away, went from %d to %d", before.BuiltBridges, after.BuiltBridges)
+	}
+
+	board, err := replay.Board(len(replay.Moves))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Join(hashisolver.MapLines(board), "\n"), strings.Join(hashisolver.MapLines(result.Puzzle), "\n"); got != want {
+		t.Errorf("Expected the replay to end on the solution\n%s\ngot\n%s", want, got)
+	}
+
+	// The viewer steps between the start and the end
+	v := &replayViewer{replay: replay}
+	v.handle(keyLeft)
+	if v.shown != 0 {
+		t.Errorf("Expected stepping back from the start to stay there, at %d", v.shown)
+	}
+	v.handle(keyRight)
+	if lines := v.lines(); !strings.HasPrefix(lines[len(lines)-3], "move 1 of ") || lines[len(lines)-2] != replay.Moves[0].Reason {
+		t.Errorf("Expected the first move and its reason under the board, got %q", lines[len(lines)-3:])
+	}
+	v.handle('G')
+	v.handle('n')
+	if v.shown != len(replay.Moves) {
+		t.Errorf("Expected stepping on from the end to stay there, at %d", v.shown)
+	}
+	if !v.handle('q') {
+		t.Error("Expected q to close the viewer")
+	}
+}
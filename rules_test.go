@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"hashi/hashisolver"
+)
+
+// TestTripleBridgeRuleSet tests that the variant rule set allows three bridges per pair
+func TestTripleBridgeRuleSet(t *testing.T) {
+	puzzle := "3.3\n...\n...\n"
+
+	p, err := hashisolver.SolveWithRules(strings.NewReader(puzzle), false, hashisolver.TripleBridgeRuleSet)
+	if err != nil {
+		t.Fatalf("Failed to solve triple bridge puzzle: %v", err)
+	}
+
+	if p.At(0, 0).RightBridges != 3 {
+		t.Errorf("Expected 3 bridges between the islands, got %d", p.At(0, 0).RightBridges)
+	}
+	if bridge, ok := p.BridgeOver(1, 0); !ok || bridge.Count != 3 || bridge.To != (hashisolver.Position{X: 2, Y: 0}) {
+		t.Errorf("Expected the triple bridge over (1,0), got %+v", bridge)
+	}
+	if line := hashisolver.MapLines(p)[0]; line != "3E3" {
+		t.Errorf("Expected a horizontal triple bridge marker, got %q", line)
+	}
+
+	// The same puzzle has no solution under the classic rules
+	if _, err := hashisolver.Solve(strings.NewReader(puzzle), false); err == nil {
+		t.Errorf("Expected the classic rules to reject a 3-3 pair")
+	}
+}
+
+// TestTwoDigitClues tests the bracketed and hex letter syntax for clues above 9
+func TestTwoDigitClues(t *testing.T) {
+	for _, puzzle := range []string{
+		".3.\n3[12]3\n.3.\n",
+		".3.\n3c3\n.3.\n",
+	} {
+		p, err := hashisolver.SolveWithRules(strings.NewReader(puzzle), false, hashisolver.TripleBridgeRuleSet)
+		if err != nil {
+			t.Fatalf("Failed to solve %q: %v", puzzle, err)
+		}
+
+		center := p.At(1, 1)
+		if center.Value() != 12 || center.TotalBridges != 12 {
+			t.Errorf("Expected the center island to be a satisfied 12, got %d with %d bridges",
+				center.Value(), center.TotalBridges)
+		}
+	}
+
+	// Clues above the rule set's maximum are rejected while parsing
+	if _, err := hashisolver.Solve(strings.NewReader(".3.\n3[12]3\n.3.\n"), false); err == nil {
+		t.Errorf("Expected a clue of 12 to be rejected under the classic rules")
+	}
+}
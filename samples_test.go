@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+
+	"hashi/hashisolver"
+)
+
+// TestSamples tests that every embedded sample parses, has one solution and still rates in its band
+func TestSamples(t *testing.T) {
+	samples := hashisolver.Samples()
+	if len(samples) == 0 {
+		t.Fatalf("Expected embedded samples")
+	}
+
+	for _, s := range samples {
+		p, err := s.Puzzle()
+		if err != nil {
+			t.Errorf("Failed to parse sample %s: %v", s.Name, err)
+			continue
+		}
+		if p.Rows != s.Rows || p.Cols != s.Cols {
+			t.Errorf("Sample %s is %dx%d", s.Name, p.Rows, p.Cols)
+		}
+		if n := hashisolver.CountSolutions(p, 2); n != 1 {
+			t.Errorf("Sample %s has %d solutions", s.Name, n)
+		}
+		if rating, err := hashisolver.Rate(p); err != nil || rating != s.Difficulty {
+			t.Errorf("Sample %s rates as %v (%v), expected %v", s.Name, rating, err, s.Difficulty)
+		}
+	}
+
+	s, err := hashisolver.FindSample("medium")
+	if err != nil || s.Difficulty != hashisolver.DifficultyMedium {
+		t.Errorf("Expected to find a medium sample, got %q (%v)", s.Name, err)
+	}
+	if _, err := hashisolver.FindSample("impossible"); err == nil {
+		t.Errorf("Expected an error for an unknown sample")
+	}
+}
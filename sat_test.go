@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"hashi/hashisolver"
+)
+
+// TestSATEngine tests that the SAT engine solves the samples and reports its
+// own statistics
+func TestSATEngine(t *testing.T) {
+	for _, sample := range hashisolver.Samples() {
+		puzzle, err := sample.Puzzle()
+		if err != nil {
+			t.Fatalf("Failed to parse sample %s: %v", sample.Name, err)
+		}
+		result, err := hashisolver.SolveWithStats(context.Background(), puzzle.Clone(), hashisolver.WithEngine(hashisolver.EngineSAT))
+		if err != nil {
+			t.Fatalf("Failed to solve sample %s with the SAT engine: %v", sample.Name, err)
+		}
+		if violations := hashisolver.Verify(puzzle, result.Puzzle); len(violations) > 0 {
+			t.Errorf("Expected a valid solution of sample %s, got %v", sample.Name, violations)
+		}
+		if result.Stats.Engine != hashisolver.EngineSAT {
+			t.Errorf("Expected the statistics to name the SAT engine, got %q", result.Stats.Engine)
+		}
+	}
+
+	puzzle, err := hashisolver.Parse(strings.NewReader("1 0 2\n"), hashisolver.DefaultRuleSet)
+	if err != nil {
+		t.Fatalf("Failed to parse puzzle: %v", err)
+	}
+	if _, err := hashisolver.SolveWithStats(context.Background(), puzzle, hashisolver.WithEngine(hashisolver.EngineSAT)); !errors.Is(err, hashisolver.ErrNoSolution) {
+		t.Errorf("Expected ErrNoSolution for an unsolvable puzzle, got %v", err)
+	}
+	if _, err := hashisolver.SolveWithStats(context.Background(), puzzle, hashisolver.WithEngine("cp")); err == nil {
+		t.Error("Expected an error for an unknown engine")
+	}
+}
+
+// TestSATAgreesWithSolveAll tests that the SAT engine finds as many solutions
+// as the heuristic engine, islands joined only through cuts included
+func TestSATAgreesWithSolveAll(t *testing.T) {
+	for seed := int64(1); seed <= 20; seed++ {
+		generated, err := hashisolver.Generate(hashisolver.GenerateOptions{
+			Rows:                   8,
+			Cols:                   8,
+			AllowMultipleSolutions: true,
+			Source:                 rand.NewSource(seed),
+		})
+		if err != nil {
+			t.Fatalf("Failed to generate puzzle: %v", err)
+		}
+		puzzle := generated.Puzzle
+		input := strings.Join(hashisolver.MapLines(puzzle), "\n")
+
+		want := hashisolver.CountSolutions(puzzle, 0)
+		solutions, err := hashisolver.SolveAllSAT(context.Background(), puzzle, 0)
+		if err != nil {
+			t.Fatalf("Failed to solve puzzle with the SAT engine: %v\n%s", err, input)
+		}
+		if len(solutions) != want {
+			t.Errorf("Expected %d solutions, the SAT engine found %d\n%s", want, len(solutions), input)
+		}
+		for _, solution := range solutions {
+			if violations := hashisolver.Verify(puzzle, solution); len(violations) > 0 {
+				t.Errorf("Expected a valid solution, got %v\n%s", violations, input)
+			}
+		}
+	}
+}
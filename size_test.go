@@ -0,0 +1,28 @@
+package main
+
+import "testing"
+
+// TestByteSize tests reading sizes with and without units
+func TestByteSize(t *testing.T) {
+	for input, want := range map[string]int64{
+		"0":       0,
+		"4096":    4096,
+		"64k":     64 << 10,
+		"512MB":   512 << 20,
+		"1.5 GiB": 3 << 29,
+		"2G":      2 << 30,
+		"100B":    100,
+	} {
+		var size byteSize
+		if err := size.Set(input); err != nil || int64(size) != want {
+			t.Errorf("Expected %q to be %d bytes, got %d (%v)", input, want, size, err)
+		}
+	}
+
+	for _, input := range []string{"", "MB", "-1K", "12XB"} {
+		var size byteSize
+		if err := size.Set(input); err == nil {
+			t.Errorf("Expected an error for %q", input)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"hashi/hashisolver"
+)
+
+// TestSolution tests that solutions are found without changing the puzzle and
+// draw the same board as the solved puzzle when paired with it
+func TestSolution(t *testing.T) {
+	sample := hashisolver.Samples()[0]
+	puzzle, err := sample.Puzzle()
+	if err != nil {
+		t.Fatalf("Failed to parse sample %s: %v", sample.Name, err)
+	}
+	clues := hashisolver.MapLines(puzzle)
+
+	solution, err := hashisolver.FindSolution(context.Background(), puzzle)
+	if err != nil {
+		t.Fatalf("Failed to solve sample %s: %v", sample.Name, err)
+	}
+	if got := hashisolver.MapLines(puzzle); strings.Join(got, "\n") != strings.Join(clues, "\n") || puzzle.PlacedBridges() != 0 {
+		t.Errorf("Expected the puzzle to keep only its clues, got\n%s", strings.Join(got, "\n"))
+	}
+	if violations := hashisolver.VerifyBridges(puzzle, solution.Bridges); len(violations) > 0 {
+		t.Errorf("Expected a valid solution, got %v", violations)
+	}
+	if solution.Stats.Engine != hashisolver.EngineHeuristic {
+		t.Errorf("Expected the solve's statistics, got %+v", solution.Stats)
+	}
+
+	solved, err := hashisolver.SolvePuzzle(puzzle.Clone(), false)
+	if err != nil {
+		t.Fatalf("Failed to solve sample %s: %v", sample.Name, err)
+	}
+	lines, err := hashisolver.SolutionLines(puzzle, solution)
+	if err != nil || strings.Join(lines, "\n") != strings.Join(hashisolver.MapLines(solved), "\n") {
+		t.Errorf("Expected the solved board, got %v and\n%s", err, strings.Join(lines, "\n"))
+	}
+
+	for _, engine := range []string{hashisolver.EngineHeuristic, hashisolver.EngineSAT, hashisolver.EngineDLX} {
+		solutions, err := hashisolver.FindSolutions(context.Background(), puzzle, 0, hashisolver.WithEngine(engine))
+		if err != nil || len(solutions) != 1 {
+			t.Fatalf("Expected one solution from engine %s, got %d and %v", engine, len(solutions), err)
+		}
+		if got, _ := hashisolver.SolutionLines(puzzle, solutions[0]); strings.Join(got, "\n") != strings.Join(lines, "\n") {
+			t.Errorf("Expected engine %s to find the same solution, got\n%s", engine, strings.Join(got, "\n"))
+		}
+	}
+
+	bad := &hashisolver.Solution{Bridges: []hashisolver.Bridge{{From: hashisolver.Position{X: -1, Y: 0}, To: hashisolver.Position{X: 0, Y: 0}, Count: 1}}}
+	if _, err := puzzle.Apply(bad); err == nil {
+		t.Error("Expected an error applying a bridge off the board")
+	}
+}
+
+// TestClone tests that a copy of a puzzle reads the same clues and islands
+// but is solved apart from the original
+func TestClone(t *testing.T) {
+	puzzle, err := hashisolver.ParseText(strings.NewReader("2.2\n...\n2.2\n"), hashisolver.DefaultRuleSet, hashisolver.ParseOptions{})
+	if err != nil {
+		t.Fatalf("Failed to parse puzzle: %v", err)
+	}
+	clone := puzzle.Clone()
+	node := clone.At(0, 0)
+	if err := hashisolver.ConnectNodes(clone, node, node.GetNeighbor(hashisolver.DirectionRight), hashisolver.DirectionRight, false); err != nil {
+		t.Fatalf("Failed to place a bridge: %v", err)
+	}
+
+	if node.Value() != 2 || node.XPos() != 0 || node.GetNeighbor(hashisolver.DirectionDown) != clone.At(0, 2) {
+		t.Errorf("Expected the copy to have the puzzle's clues and islands")
+	}
+	if clone.At(0, 0).RightBridges != 1 || clone.BuiltBridges != 1 {
+		t.Errorf("Expected a bridge on the copy, got %d", clone.At(0, 0).RightBridges)
+	}
+	if puzzle.At(0, 0).RightBridges != 0 || puzzle.BuiltBridges != 0 || hashisolver.MapLines(puzzle)[0] != "2 2" {
+		t.Errorf("Expected the original to have no bridges, got %q", hashisolver.MapLines(puzzle)[0])
+	}
+}
@@ -2,35 +2,21 @@ package main
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"hashi/hashisolver"
 )
 
-// runBridgenCommand runs the bridgen.c program and returns the generated puzzle
-func runBridgenCommand(rows, cols int) (string, error) {
-	// Compile bridgen.c if not already compiled
-	if _, err := os.Stat("./bridgen"); os.IsNotExist(err) {
-		cmd := exec.Command("gcc", "-o", "bridgen", "c_src/bridgen.c")
-		if err := cmd.Run(); err != nil {
-			return "", fmt.Errorf("failed to compile bridgen: %v", err)
-		}
-	}
-
-	// Run bridgen to generate a puzzle
-	cmd := exec.Command("./bridgen", fmt.Sprintf("%d", rows), fmt.Sprintf("%d", cols))
-	output, err := cmd.Output()
-	if err != nil {
-		return "", fmt.Errorf("failed to run bridgen: %v", err)
-	}
-
-	return string(output), nil
-}
+// errNoBridgecheck is returned when bridgecheck can't be run here, like a
+// binary built for another platform
+var errNoBridgecheck = errors.New("bridgecheck can't be run")
 
 // runBridgecheckCommand runs the bridgecheck.c program to validate a solution
 func runBridgecheckCommand(puzzleFile, solutionFile string) (bool, string, error) {
@@ -46,6 +32,10 @@ func runBridgecheckCommand(puzzleFile, solutionFile string) (bool, string, error
 	cmd := exec.Command("./bridgecheck", puzzleFile)
 	cmd.Stdin, _ = os.Open(solutionFile)
 	output, err := cmd.CombinedOutput()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return false, "", fmt.Errorf("%w: %v", errNoBridgecheck, err)
+	}
 	if err != nil {
 		return false, string(output), fmt.Errorf("bridgecheck failed: %v", err)
 	}
@@ -54,7 +44,35 @@ func runBridgecheckCommand(puzzleFile, solutionFile string) (bool, string, error
 	return bytes.Contains(output, []byte("Valid Solution")), string(output), nil
 }
 
-// TestSolverWithBridgen tests the hashi solver against puzzles generated by bridgen
+// checkSolution validates the solution with bridgecheck, or with Verify where
+// bridgecheck can't be run, failing the test if it is invalid
+func checkSolution(t *testing.T, puzzleFile, solutionFile, puzzleText string, solved *hashisolver.Puzzle) {
+	t.Helper()
+	isValid, output, err := runBridgecheckCommand(puzzleFile, solutionFile)
+	if errors.Is(err, errNoBridgecheck) {
+		t.Logf("Checking with Verify instead: %v", err)
+		puzzle, err := hashisolver.Parse(strings.NewReader(puzzleText), hashisolver.DefaultRuleSet)
+		if err != nil {
+			t.Fatalf("Failed to parse puzzle: %v", err)
+		}
+		if violations := hashisolver.Verify(puzzle, solved); len(violations) > 0 {
+			t.Errorf("Solution is invalid: %v", violations)
+		}
+		return
+	}
+	if err != nil {
+		t.Logf("Failed to validate solution: %v", err)
+		t.Logf("Bridgecheck output: %s", output)
+		t.Fail()
+		return
+	}
+	if !isValid {
+		t.Errorf("Solution is invalid, bridgecheck output: %s", output)
+	}
+}
+
+// TestSolverWithBridgen tests the hashi solver against generated puzzles like
+// bridgen's, checking its solutions with bridgecheck
 func TestSolverWithBridgen(t *testing.T) {
 	// Create temporary directory for test files
 	tempDir, err := ioutil.TempDir("", "hashi_test")
@@ -79,8 +97,8 @@ func TestSolverWithBridgen(t *testing.T) {
 
 	for _, size := range sizes {
 		t.Run(fmt.Sprintf("%dx%d", size.rows, size.cols), func(t *testing.T) {
-			// Generate puzzle using bridgen
-			puzzle, err := runBridgenCommand(size.rows, size.cols)
+			// Generate the puzzle in process, so the test runs anywhere
+			puzzle, err := generatePuzzle(size.rows, size.cols)
 			if err != nil {
 				t.Fatalf("Failed to generate puzzle: %v", err)
 			}
@@ -131,29 +149,15 @@ func TestSolverWithBridgen(t *testing.T) {
 			}
 
 			// Validate solution using bridgecheck
-			isValid, output, err := runBridgecheckCommand(puzzleFile, solutionFile)
-			if err != nil {
-				t.Logf("Failed to validate solution: %v", err)
-				t.Logf("Bridgecheck output: %s", output)
-				t.Fail()
-				return
-			}
-
-			if !isValid {
-				t.Logf("Solution for %dx%d puzzle is invalid", size.rows, size.cols)
-				t.Logf("Bridgecheck output: %s", output)
-				t.Fail()
-			} else {
-				t.Logf("Solution for %dx%d puzzle is valid", size.rows, size.cols)
-			}
+			checkSolution(t, puzzleFile, solutionFile, puzzle, p)
 		})
 	}
 }
 
 // TestSolverWithKnownPuzzles tests the hashi solver against known puzzles
 func TestSolverWithKnownPuzzles(t *testing.T) {
-	// Generate a simple 3x3 puzzle using bridgen
-	puzzle, err := runBridgenCommand(3, 3)
+	// Generate a simple 3x3 puzzle in process
+	puzzle, err := generatePuzzle(3, 3)
 	if err != nil {
 		t.Fatalf("Failed to generate puzzle: %v", err)
 	}
@@ -203,19 +207,5 @@ func TestSolverWithKnownPuzzles(t *testing.T) {
 	}
 
 	// Validate the solution
-	isValid, output, err := runBridgecheckCommand(puzzleFile, solutionFile)
-	if err != nil {
-		t.Logf("Failed to validate solution: %v", err)
-		t.Logf("Bridgecheck output: %s", output)
-		t.Fail()
-		return
-	}
-
-	if !isValid {
-		t.Logf("Solution for simple puzzle is invalid")
-		t.Logf("Bridgecheck output: %s", output)
-		t.Fail()
-	} else {
-		t.Logf("Solution for simple puzzle is valid")
-	}
+	checkSolution(t, puzzleFile, solutionFile, puzzle, p)
 }
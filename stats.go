@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"hashi/hashisolver"
+)
+
+// runStats prints the structure of each puzzle file, without solving it
+func runStats(args []string) {
+	var input inputFlags
+	var jsonOutput bool
+
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s stats [flags] [puzzle files...]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	input.register(fs)
+	fs.BoolVar(&jsonOutput, "json", false, "Print a JSON object for each puzzle, with its file name")
+	parseFlags(fs, args)
+
+	// Without files, the puzzle comes from -input, -sample or stdin
+	files := fs.Args()
+	if len(files) == 0 {
+		name := input.inputFile
+		switch {
+		case input.sample != "":
+			name = input.sample
+		case name == "":
+			name = "-"
+		}
+		printStructure(name, input.readPuzzle(), 0, jsonOutput)
+		return
+	}
+
+	failed := false
+	for i, name := range files {
+		puzzle, err := input.loadPuzzle(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+			failed = true
+			continue
+		}
+		printStructure(name, puzzle, i, jsonOutput)
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// printStructure prints the structure of the i'th puzzle, as JSON or as a
+// list set off from the one before
+func printStructure(name string, puzzle *hashisolver.Puzzle, i int, jsonOutput bool) {
+	structure := hashisolver.Analyze(puzzle)
+	if jsonOutput {
+		data, _ := json.Marshal(struct {
+			File string `json:"file"`
+			hashisolver.Structure
+		}{name, structure})
+		fmt.Println(string(data))
+		return
+	}
+	if i > 0 {
+		fmt.Println()
+	}
+	writeStructure(os.Stdout, name, structure)
+}
+
+// writeStructure writes the structure of a puzzle as a list of what was
+// found, under the puzzle's name
+func writeStructure(w io.Writer, name string, s hashisolver.Structure) {
+	clues := []string{}
+	for clue, count := range s.Clues {
+		if count > 0 {
+			clues = append(clues, fmt.Sprintf("%d:%d", clue, count))
+		}
+	}
+	fmt.Fprintf(w, "%s\n", name)
+	fmt.Fprintf(w, "  size:              %dx%d\n", s.Cols, s.Rows)
+	fmt.Fprintf(w, "  islands:           %d (density %.2f)\n", s.Islands, s.Density)
+	fmt.Fprintf(w, "  bridges:           %d\n", s.Bridges)
+	fmt.Fprintf(w, "  clues:             %s\n", strings.Join(clues, " "))
+	fmt.Fprintf(w, "  average neighbors: %.2f\n", s.AverageNeighbors)
+	fmt.Fprintf(w, "  forced openings:   %d\n", s.ForcedOpenings)
+	fmt.Fprintf(w, "  longest span:      %d\n", s.LongestSpan)
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"hashi/hashisolver"
+)
+
+// TestAnalyze tests the structure found in a puzzle's clues and how it is
+// listed
+func TestAnalyze(t *testing.T) {
+	puzzle, err := hashisolver.Parse(strings.NewReader("3.4.1\n.....\n1.2.1\n.....\n..2..\n"), hashisolver.DefaultRuleSet)
+	if err != nil {
+		t.Fatalf("Failed to parse puzzle: %v", err)
+	}
+	s := hashisolver.Analyze(puzzle)
+	if s.Islands != 7 || s.Bridges != 7 || s.Density != 7.0/25 {
+		t.Errorf("Expected 7 islands, 7 bridges and a density of 0.28, got %+v", s)
+	}
+	// The corner 3, and the 2 at the bottom with one neighbor, must open
+	// every direction they have
+	if s.ForcedOpenings != 2 || s.LongestSpan != 2 || s.AverageNeighbors != 16.0/7 {
+		t.Errorf("Expected 2 forced openings, a longest span of 2 and 16/7 neighbors, got %+v", s)
+	}
+
+	var buf bytes.Buffer
+	writeStructure(&buf, "p.txt", s)
+	if !strings.Contains(buf.String(), "  clues:             1:3 2:2 3:1 4:1\n") {
+		t.Errorf("Expected the clue histogram, got\n%s", buf.String())
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"hashi/hashisolver"
+)
+
+// TestStrategies tests that every strategy solves the samples, and is named
+// in the statistics
+func TestStrategies(t *testing.T) {
+	for _, name := range []string{"highest-remaining", "most-constrained", "most-neighbors", "random", "random:7"} {
+		strategy, err := hashisolver.LookupStrategy(name)
+		if err != nil {
+			t.Fatalf("Failed to look up strategy %s: %v", name, err)
+		}
+		for _, sample := range hashisolver.Samples() {
+			puzzle, err := sample.Puzzle()
+			if err != nil {
+				t.Fatalf("Failed to parse sample %s: %v", sample.Name, err)
+			}
+			result, err := hashisolver.SolveWithStats(context.Background(), puzzle.Clone(), hashisolver.WithStrategy(strategy))
+			if err != nil {
+				t.Fatalf("Failed to solve sample %s with strategy %s: %v", sample.Name, name, err)
+			}
+			if violations := hashisolver.Verify(puzzle, result.Puzzle); len(violations) > 0 {
+				t.Errorf("Expected a valid solution of sample %s with strategy %s, got %v", sample.Name, name, violations)
+			}
+			if !strings.HasPrefix(result.Stats.Strategy, name) {
+				t.Errorf("Expected the statistics to name strategy %s, got %q", name, result.Stats.Strategy)
+			}
+		}
+	}
+
+	for _, name := range []string{"", "fewest", "random:", "random:x"} {
+		if _, err := hashisolver.LookupStrategy(name); err == nil {
+			t.Errorf("Expected an error for strategy %q", name)
+		}
+	}
+}
+
+// TestRandomStrategySeed tests that a random strategy guesses the same way
+// each time for the same seed
+func TestRandomStrategySeed(t *testing.T) {
+	input, err := generatePuzzle(15, 15)
+	if err != nil {
+		t.Fatalf("Failed to generate puzzle: %v", err)
+	}
+
+	solve := func() *hashisolver.SolveResult {
+		puzzle, err := hashisolver.Parse(strings.NewReader(input), hashisolver.DefaultRuleSet)
+		if err != nil {
+			t.Fatalf("Failed to parse puzzle: %v", err)
+		}
+		result, err := hashisolver.SolveWithStats(context.Background(), puzzle, hashisolver.WithStrategy(hashisolver.RandomStrategy(42)))
+		if err != nil {
+			t.Fatalf("Failed to solve puzzle: %v", err)
+		}
+		return result
+	}
+
+	first, second := solve(), solve()
+	if first.Stats.Branches != second.Stats.Branches ||
+		strings.Join(hashisolver.MapLines(first.Puzzle), "\n") != strings.Join(hashisolver.MapLines(second.Puzzle), "\n") {
+		t.Errorf("Expected the same search for the same seed, got %d and %d branches", first.Stats.Branches, second.Stats.Branches)
+	}
+}
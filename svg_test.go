@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"hashi/hashisolver"
+)
+
+// TestSVG tests that a puzzle drawn with a solution as SVG is well formed,
+// with a circle for each island and a line for each bridge
+func TestSVG(t *testing.T) {
+	puzzle, err := hashisolver.Parse(strings.NewReader("2.4\n...\n1.3\n"), hashisolver.DefaultRuleSet)
+	if err != nil {
+		t.Fatalf("Failed to parse puzzle: %v", err)
+	}
+	solution, err := hashisolver.FindSolution(context.Background(), puzzle)
+	if err != nil {
+		t.Fatalf("Failed to solve puzzle: %v", err)
+	}
+
+	style := hashisolver.SVGStyle{CellSize: 50, BridgeWidth: 3, BridgeGap: 5, IslandWidth: 1.5}
+	svg, err := hashisolver.RenderSVG(puzzle, solution, style)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg" width="150" height="150"`) {
+		t.Errorf("Expected a 150x150 image, got %q", svg[:strings.Index(svg, "\n")])
+	}
+
+	elements := map[string]int{}
+	decoder := xml.NewDecoder(strings.NewReader(svg))
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("The SVG isn't well formed: %v\n%s", err, svg)
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			elements[start.Name.Local]++
+		}
+	}
+	// The double bridges 2=4 and 4=3 and the single 1-3 make five lines
+	if elements["circle"] != 4 || elements["text"] != 4 || elements["line"] != 5 {
+		t.Errorf("Expected 4 islands and 5 bridge lines, got %v", elements)
+	}
+	if !strings.Contains(svg, `stroke-width="1.5"`) || !strings.Contains(svg, `stroke-width="3"`) {
+		t.Error("Expected the style's stroke widths in the SVG")
+	}
+
+	// The puzzle itself is drawn without bridges
+	svg, _ = hashisolver.RenderSVG(puzzle, nil, style)
+	if strings.Contains(svg, "<line") {
+		t.Errorf("Expected no bridges on the clues, got\n%s", svg)
+	}
+
+	wrong := &hashisolver.Solution{Bridges: []hashisolver.Bridge{{From: hashisolver.Position{X: 0, Y: 0}, To: hashisolver.Position{X: 2, Y: 2}, Count: 1}}}
+	if _, err := hashisolver.RenderSVG(puzzle, wrong, style); err == nil {
+		t.Error("Expected a bridge between islands that don't face each other to be refused")
+	}
+}
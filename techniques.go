@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"hashi/hashisolver"
+)
+
+// techniqueUsage is how often each technique fired while solving one
+// puzzle, or a whole corpus
+type techniqueUsage struct {
+	Puzzle string `json:"puzzle"`
+	// Result is solved, timeout, unsolvable or the error the solve failed with
+	Result string `json:"result"`
+	// NeededSpeculation is set when the logical rules alone couldn't solve
+	// the puzzle, and MaxDepth is the deepest the guesses went
+	NeededSpeculation bool `json:"needed_speculation"`
+	MaxDepth          int  `json:"max_depth"`
+	// Techniques counts the bridges each technique placed, including those
+	// on guesses that were later undone
+	Techniques map[hashisolver.Technique]int `json:"techniques"`
+}
+
+// runTechniques solves a corpus and reports which techniques each puzzle
+// needed, and how often, so a setter can see what skills a pack exercises
+func runTechniques(args []string) {
+	var input inputFlags
+	var timeout time.Duration
+	var probe bool
+	var jsonOutput bool
+
+	fs := flag.NewFlagSet("techniques", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s techniques [flags] [puzzle files, directories or archives...]\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Without any puzzles the built in samples are used.")
+		fs.PrintDefaults()
+	}
+	input.register(fs)
+	fs.DurationVar(&timeout, "timeout", 10*time.Second, "Give up on a puzzle after this long (0 for no limit)")
+	fs.BoolVar(&probe, "probe", false, "Try probing before guessing, as solve -probe does")
+	fs.BoolVar(&jsonOutput, "json", false, "Print the report as JSON instead of CSV")
+	parseFlags(fs, args)
+
+	corpus, err := benchCorpus(&input, fs.Args())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error %v\n", err)
+		os.Exit(1)
+	}
+
+	usage := []techniqueUsage{}
+	for _, p := range corpus {
+		usage = append(usage, countTechniques(p, timeout, probe))
+	}
+	if jsonOutput {
+		err = writeTechniquesJSON(os.Stdout, usage)
+	} else {
+		err = writeTechniquesCSV(os.Stdout, usage)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// countTechniques solves the puzzle with its trace recorded and counts the
+// bridges each technique placed
+func countTechniques(p benchPuzzle, timeout time.Duration, probe bool) techniqueUsage {
+	usage := techniqueUsage{Puzzle: p.name, Result: "solved", Techniques: map[hashisolver.Technique]int{}}
+	result, err := hashisolver.SolveWithStats(context.Background(), p.puzzle.Clone(),
+		hashisolver.WithTrace(true), hashisolver.WithTimeout(timeout), hashisolver.WithProbing(probe))
+	switch {
+	case errors.Is(err, hashisolver.ErrTimeout):
+		usage.Result = "timeout"
+	case errors.Is(err, hashisolver.ErrNoSolution):
+		usage.Result = "unsolvable"
+	case err != nil:
+		usage.Result = err.Error()
+	}
+	for _, move := range result.Trace {
+		if !move.Backtrack && !move.Removed {
+			usage.Techniques[move.Technique]++
+		}
+	}
+	usage.MaxDepth = result.Stats.MaxDepth
+	usage.NeededSpeculation = usage.Techniques[hashisolver.TechniqueSpeculation] > 0
+	return usage
+}
+
+// totalTechniques adds up the usage of every puzzle, with the number that
+// needed speculation as the total's Result
+func totalTechniques(usage []techniqueUsage) techniqueUsage {
+	total := techniqueUsage{Puzzle: "total", Techniques: map[hashisolver.Technique]int{}}
+	speculated := 0
+	for _, u := range usage {
+		for technique, count := range u.Techniques {
+			total.Techniques[technique] += count
+		}
+		if u.NeededSpeculation {
+			speculated++
+		}
+		if u.MaxDepth > total.MaxDepth {
+			total.MaxDepth = u.MaxDepth
+		}
+	}
+	total.NeededSpeculation = speculated > 0
+	total.Result = fmt.Sprintf("%d of %d needed speculation", speculated, len(usage))
+	return total
+}
+
+// writeTechniquesCSV writes a row for each puzzle, with a column for each
+// technique, and a last row of totals
+func writeTechniquesCSV(w io.Writer, usage []techniqueUsage) error {
+	out := csv.NewWriter(w)
+	header := []string{"puzzle", "result", "needed_speculation", "max_depth"}
+	for _, technique := range hashisolver.Techniques {
+		header = append(header, string(technique))
+	}
+	out.Write(header)
+	for _, u := range append(usage, totalTechniques(usage)) {
+		row := []string{u.Puzzle, u.Result, strconv.FormatBool(u.NeededSpeculation), strconv.Itoa(u.MaxDepth)}
+		for _, technique := range hashisolver.Techniques {
+			row = append(row, strconv.Itoa(u.Techniques[technique]))
+		}
+		out.Write(row)
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// writeTechniquesJSON writes the usage of each puzzle and the totals as JSON
+func writeTechniquesJSON(w io.Writer, usage []techniqueUsage) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(struct {
+		Puzzles []techniqueUsage `json:"puzzles"`
+		Total   techniqueUsage   `json:"total"`
+	}{usage, totalTechniques(usage)})
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"hashi/hashisolver"
+)
+
+// TestTechniques tests that technique counts are gathered per puzzle and
+// totalled, with the puzzles that needed guessing picked out
+func TestTechniques(t *testing.T) {
+	input := &inputFlags{format: "text", maxBridges: 2, strict: true}
+	corpus, err := benchCorpus(input, nil)
+	if err != nil {
+		t.Fatalf("Failed to read the samples: %v", err)
+	}
+	usage := []techniqueUsage{}
+	for _, p := range corpus {
+		if p.name == "easy-7x7" || p.name == "hard-8x8" {
+			usage = append(usage, countTechniques(p, time.Minute, false))
+		}
+	}
+	if len(usage) != 2 || usage[0].NeededSpeculation || !usage[1].NeededSpeculation || usage[1].MaxDepth == 0 {
+		t.Fatalf("Expected only the hard sample to need speculation, got %+v", usage)
+	}
+	if usage[0].Techniques[hashisolver.TechniqueOneDirection] == 0 {
+		t.Errorf("Expected the easy sample to open with single directions, got %v", usage[0].Techniques)
+	}
+
+	var buf bytes.Buffer
+	if err := writeTechniquesCSV(&buf, usage); err != nil {
+		t.Fatalf("Failed to write CSV: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "puzzle,result,needed_speculation,max_depth,only one direction open,") ||
+		!strings.HasPrefix(lines[3], "total,1 of 2 needed speculation,true,") {
+		t.Errorf("Expected a header, a row for each puzzle and a total, got\n%s", buf.String())
+	}
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"unicode/utf8"
+)
+
+// key is a key read from the terminal: either the character typed or one of
+// the special keys below, which are negative so they can't clash with one
+type key rune
+
+const (
+	keyUp key = -1 - iota
+	keyDown
+	keyLeft
+	keyRight
+	keyShiftUp
+	keyShiftDown
+	keyShiftLeft
+	keyShiftRight
+	keyEnter
+	keyBackspace
+	keyDelete
+	keyEscape
+	// keyInterrupt is Ctrl-C, which arrives as a key in raw mode
+	keyInterrupt
+)
+
+// escapeKeys maps the escape sequences terminals send for special keys
+var escapeKeys = map[string]key{
+	"\x1b[A": keyUp, "\x1b[B": keyDown, "\x1b[C": keyRight, "\x1b[D": keyLeft,
+	"\x1bOA": keyUp, "\x1bOB": keyDown, "\x1bOC": keyRight, "\x1bOD": keyLeft,
+	"\x1b[1;2A": keyShiftUp, "\x1b[1;2B": keyShiftDown, "\x1b[1;2C": keyShiftRight, "\x1b[1;2D": keyShiftLeft,
+	"\x1b[3~": keyDelete,
+}
+
+// mouseEvent is the left mouse button pressed or released over a cell of the
+// screen, counted from 0 at the top left
+type mouseEvent struct {
+	x, y    int
+	release bool
+}
+
+// event is a key read from the terminal, or a mouse event if mouse is set
+type event struct {
+	key   key
+	mouse *mouseEvent
+}
+
+// decodeEvents splits what was read from the terminal into keys and mouse
+// events. An escape sequence that isn't known is read as a lone Escape, and
+// mouse events other than the left button going down or up are dropped.
+func decodeEvents(input []byte) []event {
+	events := []event{}
+	for len(input) > 0 {
+		if m, size, ok := decodeMouse(input); ok {
+			if m != nil {
+				events = append(events, event{mouse: m})
+			}
+			input = input[size:]
+			continue
+		}
+
+		if input[0] == '\x1b' {
+			matched := false
+			for sequence, k := range escapeKeys {
+				if strings.HasPrefix(string(input), sequence) {
+					events = append(events, event{key: k})
+					input = input[len(sequence):]
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				events = append(events, event{key: keyEscape})
+				input = input[1:]
+			}
+			continue
+		}
+
+		switch input[0] {
+		case '\r', '\n':
+			events = append(events, event{key: keyEnter})
+		case 0x7f, '\b':
+			events = append(events, event{key: keyBackspace})
+		case 0x03:
+			events = append(events, event{key: keyInterrupt})
+		default:
+			r, size := utf8.DecodeRune(input)
+			events = append(events, event{key: key(r)})
+			input = input[size:]
+			continue
+		}
+		input = input[1:]
+	}
+	return events
+}
+
+// decodeMouse reads a mouse report in the SGR encoding, like "\x1b[<0;12;5M"
+// for the left button going down in column 12 of row 5, from the start of
+// input. It returns how many bytes the report took, and a nil event for one
+// that isn't the left button going down or up.
+func decodeMouse(input []byte) (*mouseEvent, int, bool) {
+	if !strings.HasPrefix(string(input), "\x1b[<") {
+		return nil, 0, false
+	}
+	end := strings.IndexAny(string(input), "Mm")
+	if end < 0 {
+		return nil, 0, false
+	}
+	var button, x, y int
+	if _, err := fmt.Sscanf(string(input[3:end]), "%d;%d;%d", &button, &x, &y); err != nil {
+		return nil, 0, false
+	}
+
+	// The low bits are the button, 32 marks motion and 64 the wheel
+	if button&3 != 0 || button&(32|64) != 0 {
+		return nil, end + 1, true
+	}
+	return &mouseEvent{x: x - 1, y: y - 1, release: input[end] == 'm'}, end + 1, true
+}
+
+// boardCell returns the cell of a board under a mouse event, for a board
+// drawn from the top left with a column of margin and each cell followed by
+// a gap, which counts as the cell before it
+func boardCell(m mouseEvent, rows, cols int) (int, int, bool) {
+	x, y := (m.x-1)/2, m.y
+	if m.x < 1 || x >= cols || y >= rows {
+		return 0, 0, false
+	}
+	return x, y, true
+}
+
+// terminal is the controlling terminal switched to raw mode, so keys arrive
+// as they are pressed, reporting the mouse and drawn on its alternate screen
+type terminal struct {
+	// state is the terminal's settings beforehand, as stty -g saves them
+	state string
+}
+
+// openTerminal switches the terminal to raw mode and the alternate screen,
+// and has it report the mouse. restore must be called to put it back.
+func openTerminal() (*terminal, error) {
+	if !isTerminal(os.Stdin) || !isTerminal(os.Stdout) {
+		return nil, errors.New("this needs a terminal to run in")
+	}
+	state, err := stty("-g")
+	if err != nil {
+		return nil, fmt.Errorf("reading the terminal settings: %v", err)
+	}
+	if _, err := stty("raw", "-echo"); err != nil {
+		return nil, fmt.Errorf("switching the terminal to raw mode: %v", err)
+	}
+	fmt.Print("\033[?1049h\033[?25l\033[?1000h\033[?1006h")
+	return &terminal{state: state}, nil
+}
+
+// restore stops the mouse reports, leaves the alternate screen and puts the
+// terminal's settings back
+func (t *terminal) restore() {
+	fmt.Print("\033[?1006l\033[?1000l\033[?25h\033[?1049l")
+	stty(t.state)
+}
+
+// stty runs stty on the terminal with the arguments and returns its output
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// events sends each key pressed and mouse event on the channel until
+// reading the terminal fails, when the channel is closed
+func (t *terminal) events() <-chan event {
+	events := make(chan event)
+	go func() {
+		defer close(events)
+		buf := make([]byte, 64)
+		for {
+			n, err := os.Stdin.Read(buf)
+			if err != nil {
+				return
+			}
+			for _, e := range decodeEvents(buf[:n]) {
+				events <- e
+			}
+		}
+	}()
+	return events
+}
+
+// draw clears the screen and writes the lines from the top left. Raw mode
+// doesn't return the cursor to the start of the line, so each line does.
+func (t *terminal) draw(lines []string) {
+	fmt.Print("\033[H\033[2J" + strings.Join(lines, "\r\n"))
+}
+
+// highlight shows text in reverse video, for the cursor
+func highlight(text string) string {
+	return "\033[7m" + text + "\033[0m"
+}
+
+// Colors for text drawn with colored
+const (
+	colorRed    = 31
+	colorGreen  = 32
+	colorYellow = 33
+	colorCyan   = 36
+)
+
+// colored shows text in one of the terminal's colors
+func colored(text string, color int) string {
+	return fmt.Sprintf("\033[%dm%s\033[0m", color, text)
+}
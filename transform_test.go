@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"hashi/hashisolver"
+)
+
+// TestTransforms tests that turning and flipping a solved board moves its
+// clues and bridges together, and that a solution moved the same way still
+// solves the moved puzzle
+func TestTransforms(t *testing.T) {
+	solved, err := hashisolver.Solve(strings.NewReader("3.4.1\n.....\n1.2.1\n"), false)
+	if err != nil {
+		t.Fatalf("Failed to solve puzzle: %v", err)
+	}
+	draw := func(p *hashisolver.Puzzle) string {
+		return strings.Join(hashisolver.MapLines(p), "/")
+	}
+
+	for name, test := range map[string]struct {
+		board *hashisolver.Puzzle
+		want  string
+	}{
+		"Rotate90":  {solved.Rotate90(), "1-3/  \"/2-4/| |/1 1"},
+		"Rotate180": {solved.Rotate180(), "1-2 1/  | |/1-4=3"},
+		"MirrorH":   {solved.MirrorH(), "1-4=3/  | |/1-2 1"},
+		"MirrorV":   {solved.MirrorV(), "1 2-1/| |  /3=4-1"},
+		"Transpose": {solved.Transpose(), "3-1/\"  /4-2/| |/1 1"},
+	} {
+		if got := draw(test.board); got != test.want {
+			t.Errorf("Expected %s to draw %q, got %q", name, test.want, got)
+		}
+	}
+
+	turned := solved
+	for i := 0; i < 4; i++ {
+		turned = turned.Rotate90()
+	}
+	if draw(turned) != draw(solved) || draw(solved.MirrorH().MirrorH()) != draw(solved) {
+		t.Errorf("Expected four quarter turns and two flips to give back the board, got %q", draw(turned))
+	}
+
+	solution := hashisolver.NewSolution(solved)
+	for _, tr := range hashisolver.Transforms {
+		puzzle := solved.Clues().Transform(tr)
+		moved := solution.Transform(tr, solved.Cols, solved.Rows)
+		if violations := hashisolver.VerifyBridges(puzzle, moved.Bridges); len(violations) > 0 {
+			t.Errorf("Expected the %v solution to solve the %v puzzle, got %v", tr, tr, violations)
+		}
+		if again, err := hashisolver.FindSolution(context.Background(), puzzle); err != nil || len(hashisolver.DiffSolutions(again.Bridges, moved.Bridges)) > 0 {
+			t.Errorf("Expected the %v puzzle to solve to the moved solution (%v)", tr, err)
+		}
+	}
+}
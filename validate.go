@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"hashi/hashisolver"
+)
+
+// runValidate checks a player's solution against a puzzle
+func runValidate(args []string) {
+	var input inputFlags
+	var solutionFile string
+	var labels bool
+
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	input.register(fs)
+	fs.StringVar(&input.inputFile, "puzzle", "", "Puzzle file the solution is for (the same as -input)")
+	fs.StringVar(&solutionFile, "solution", "", "Solution file: a drawn board using | \" - =, and # E for triple bridges, or a bridge list of x,y x,y count lines, or with labels like A1 for the islands")
+	fs.BoolVar(&labels, "labels", false, "Name islands by their labels, like A1, rather than as (x,y)")
+	parseFlags(fs, args)
+
+	if solutionFile == "" {
+		fmt.Fprintln(os.Stderr, "Error: -solution is required")
+		os.Exit(2)
+	}
+
+	puzzle := input.readPuzzle()
+	bridges, err := readSolution(solutionFile, input.rules())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading solution: %v\n", err)
+		os.Exit(1)
+	}
+
+	violations := hashisolver.VerifyBridges(puzzle, bridges)
+	if len(violations) == 0 {
+		fmt.Println("The solution is valid")
+		return
+	}
+	for _, v := range violations {
+		if labels {
+			fmt.Printf("%s: %s\n", v.At.Label(), v.Msg)
+		} else {
+			fmt.Println(v)
+		}
+	}
+	os.Exit(1)
+}
+
+// readSolution reads the bridges of a solution, either a bridge list or a
+// board with the bridges drawn on it. A bridge list is recognised by the comma
+// in its first line, or by starting with a label when the islands are
+// labelled.
+func readSolution(name string, rules hashisolver.RuleSet) ([]hashisolver.BridgeJSON, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.Contains(line, ",") || labelledBridge(line) {
+			return hashisolver.ParseBridges(bytes.NewReader(data))
+		}
+		break
+	}
+
+	drawn, err := hashisolver.ParseText(bytes.NewReader(data), rules, hashisolver.ParseOptions{Layout: hashisolver.LayoutCompact})
+	if err != nil {
+		return nil, err
+	}
+	return hashisolver.ToJSON(drawn).Bridges, nil
+}
+
+// labelledBridge reports whether a line looks like a bridge between islands
+// named by their labels, rather than a row of a drawn board
+func labelledBridge(line string) bool {
+	fields := strings.Fields(line)
+	if len(fields) != 3 {
+		return false
+	}
+	_, err := hashisolver.ParsePosition(fields[0])
+	return err == nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"hashi/hashisolver"
+)
+
+// TestReadSolution tests that drawn boards and bridge lists, with or without
+// labels, read back as the same valid bridges
+func TestReadSolution(t *testing.T) {
+	g, err := hashisolver.Generate(hashisolver.GenerateOptions{Rows: 7, Cols: 7, Source: rand.NewSource(2547)})
+	if err != nil {
+		t.Fatalf("Failed to generate puzzle: %v", err)
+	}
+
+	var list bytes.Buffer
+	if err := hashisolver.WriteBridges(&list, g.Solution); err != nil {
+		t.Fatalf("Failed to write bridges: %v", err)
+	}
+	var labelled strings.Builder
+	for _, b := range g.Solution.Bridges() {
+		fmt.Fprintf(&labelled, "%s %s %d\n", b.From.Label(), b.To.Label(), b.Count)
+	}
+	dir := t.TempDir()
+	files := map[string]string{
+		"list.txt":     list.String(),
+		"labelled.txt": labelled.String(),
+		"drawn.txt":    strings.Join(hashisolver.MapLines(g.Solution), "\n") + "\n",
+	}
+
+	for name, text := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		bridges, err := readSolution(path, hashisolver.DefaultRuleSet)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", name, err)
+		}
+		if violations := hashisolver.VerifyBridges(g.Puzzle, bridges); len(violations) > 0 {
+			t.Errorf("Expected %s to be a valid solution, got %v", name, violations)
+		}
+	}
+
+	_, err = hashisolver.ParseBridges(strings.NewReader("# bridges\n0,0 2,0 1\n0,0 0,2\n"))
+	if err == nil || err.Error() != `line 3: expected "x,y x,y count", found "0,0 0,2"` {
+		t.Errorf("Expected a malformed bridge line to be reported, got %v", err)
+	}
+}
+
+// TestReadSolutionTripleBridges tests that a board drawn with triple bridges,
+// whose rows may start with #, reads back as the solution it shows, while
+// comments still don't
+func TestReadSolutionTripleBridges(t *testing.T) {
+	input, err := os.ReadFile("puzzle_5x5.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	puzzle, err := hashisolver.Parse(bytes.NewReader(input), hashisolver.TripleBridgeRuleSet)
+	if err != nil {
+		t.Fatalf("Failed to parse puzzle: %v", err)
+	}
+	solved, err := hashisolver.SolvePuzzle(puzzle.Clone(), false)
+	if err != nil {
+		t.Fatalf("Failed to solve puzzle: %v", err)
+	}
+	lines := hashisolver.MapLines(solved)
+	if !strings.HasPrefix(strings.TrimSpace(lines[1]), "#") {
+		t.Fatalf("Expected a row starting with a vertical triple bridge, got:\n%s", strings.Join(lines, "\n"))
+	}
+
+	path := filepath.Join(t.TempDir(), "drawn.txt")
+	text := "# solved\n" + strings.Join(lines, "\n") + "\n# checked by hand\n"
+	if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	bridges, err := readSolution(path, hashisolver.TripleBridgeRuleSet)
+	if err != nil {
+		t.Fatalf("Failed to read the drawn board: %v", err)
+	}
+	if violations := hashisolver.VerifyBridges(puzzle, bridges); len(violations) > 0 {
+		t.Errorf("Expected the drawn board to be a valid solution, got %v", violations)
+	}
+}
+
+// TestReadSolutionRowsOfWater tests that a drawn board with a row of nothing
+// but water reads back with every bridge where it was drawn
+func TestReadSolutionRowsOfWater(t *testing.T) {
+	puzzle, err := hashisolver.Parse(strings.NewReader(".......\n2.3...2\n.......\n.......\n3.4...4\n.......\n1.....3\n"), hashisolver.DefaultRuleSet)
+	if err != nil {
+		t.Fatalf("Failed to parse puzzle: %v", err)
+	}
+	solved, err := hashisolver.SolvePuzzle(puzzle.Clone(), false)
+	if err != nil {
+		t.Fatalf("Failed to solve puzzle: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "drawn.txt")
+	if err := os.WriteFile(path, []byte(strings.Join(hashisolver.MapLines(solved), "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	bridges, err := readSolution(path, hashisolver.DefaultRuleSet)
+	if err != nil {
+		t.Fatalf("Failed to read the drawn board: %v", err)
+	}
+	if violations := hashisolver.VerifyBridges(puzzle, bridges); len(violations) > 0 {
+		t.Errorf("Expected the drawn board to be a valid solution, got %v", violations)
+	}
+}
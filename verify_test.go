@@ -0,0 +1,65 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"hashi/hashisolver"
+)
+
+// TestVerify tests that a solver's answer passes and that broken bridge lists are reported
+func TestVerify(t *testing.T) {
+	puzzle, err := hashisolver.Parse(strings.NewReader("2.2.\n....\n..3.\n3.4.\n"), hashisolver.DefaultRuleSet)
+	if err != nil {
+		t.Fatalf("Failed to parse puzzle: %v", err)
+	}
+	solved, err := hashisolver.SolvePuzzle(puzzle, false)
+	if err != nil {
+		t.Fatalf("Failed to solve puzzle: %v", err)
+	}
+	if violations := hashisolver.Verify(puzzle, solved); len(violations) > 0 {
+		t.Fatalf("Expected the solution to be valid, got %v", violations)
+	}
+
+	bridge := func(x1, y1, x2, y2, count int) hashisolver.BridgeJSON {
+		return hashisolver.BridgeJSON{
+			From:  hashisolver.Position{X: x1, Y: y1},
+			To:    hashisolver.Position{X: x2, Y: y2},
+			Count: count,
+		}
+	}
+
+	cases := []struct {
+		name    string
+		bridges []hashisolver.BridgeJSON
+		want    string
+	}{
+		{"water end", []hashisolver.BridgeJSON{bridge(0, 0, 1, 0, 1)}, "(1,0): bridge from (0,0) doesn't end at an island"},
+		{"diagonal", []hashisolver.BridgeJSON{bridge(0, 0, 2, 2, 1)}, "(0,0): bridge to (2,2) isn't a straight line"},
+		{"through island", []hashisolver.BridgeJSON{bridge(0, 3, 2, 3, 1), bridge(2, 0, 2, 3, 1)},
+			"(2,0): bridge to (2,3) passes through the island at (2,2)"},
+		{"too many", []hashisolver.BridgeJSON{bridge(0, 0, 0, 3, 3)}, "(0,0): 3 bridges to (0,3), the rules allow 1 to 2"},
+		{"clue", []hashisolver.BridgeJSON{bridge(0, 0, 2, 0, 1)}, "(0,0): island has 1 bridges, its clue is 2"},
+		{"disconnected", []hashisolver.BridgeJSON{bridge(0, 0, 2, 0, 2), bridge(0, 3, 2, 3, 2)},
+			"(0,3): island is not connected to the island at (0,0)"},
+	}
+	for _, c := range cases {
+		found := []string{}
+		for _, v := range hashisolver.VerifyBridges(puzzle, c.bridges) {
+			found = append(found, v.String())
+		}
+		if !strings.Contains(strings.Join(found, "\n"), c.want) {
+			t.Errorf("%s: expected %q among %q", c.name, c.want, found)
+		}
+	}
+
+	// A bridge across the middle of the board crosses one running down it
+	cross, err := hashisolver.Parse(strings.NewReader(".1.\n1.1\n.1.\n"), hashisolver.DefaultRuleSet)
+	if err != nil {
+		t.Fatalf("Failed to parse puzzle: %v", err)
+	}
+	violations := hashisolver.VerifyBridges(cross, []hashisolver.BridgeJSON{bridge(0, 1, 2, 1, 1), bridge(1, 0, 1, 2, 1)})
+	if len(violations) == 0 || !strings.Contains(violations[0].Msg, "crosses the bridge from (0,1) to (2,1)") {
+		t.Errorf("Expected a crossing to be reported, got %v", violations)
+	}
+}